@@ -0,0 +1,203 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrAgentNotFound is returned for an unknown agent ID
+	ErrAgentNotFound = errors.New("agent not found")
+	// ErrAgentConflict is returned by DeleteAgent when the API refuses to
+	// delete an agent in its current state, e.g. one still sending events
+	ErrAgentConflict = errors.New("agent conflict")
+)
+
+// AgentStatus is the lifecycle state of a registered agent
+type AgentStatus string
+
+const (
+	AgentActive  AgentStatus = "active"
+	AgentRetired AgentStatus = "retired"
+)
+
+// Agent is a registered agent as returned by the Trusera API
+type Agent struct {
+	ID         string            `json:"agent_id"`
+	Name       string            `json:"name"`
+	Framework  string            `json:"framework"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Status     AgentStatus       `json:"status,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	LastSeenAt time.Time         `json:"last_seen_at"`
+	Runtime    *RuntimeMetadata  `json:"runtime,omitempty"`
+}
+
+// AgentUpdate lists the fields UpdateAgent changes. Nil and empty fields are
+// left as they are.
+type AgentUpdate struct {
+	Name      *string `json:"name,omitempty"`
+	Framework *string `json:"framework,omitempty"`
+	// Labels replaces the agent's labels when not nil
+	Labels map[string]string `json:"labels,omitempty"`
+	Status AgentStatus       `json:"status,omitempty"`
+}
+
+// ListAgentsOptions filters and pages ListAgents. Zero values are omitted.
+type ListAgentsOptions struct {
+	// Name and Framework keep only agents with an exact match
+	Name      string
+	Framework string
+	// Limit caps the page size; the API applies its own default
+	Limit int
+	// Cursor continues from a previous page's NextCursor
+	Cursor string
+}
+
+// AgentList is one page of ListAgents results
+type AgentList struct {
+	Agents []Agent `json:"agents"`
+	// NextCursor is set when more agents are available
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ListAgents returns one page of the organization's registered agents
+func (c *Client) ListAgents(ctx context.Context, opts ListAgentsOptions) (*AgentList, error) {
+	query := url.Values{}
+	if opts.Name != "" {
+		query.Set("name", opts.Name)
+	}
+	if opts.Framework != "" {
+		query.Set("framework", opts.Framework)
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		query.Set("cursor", opts.Cursor)
+	}
+
+	path := "/v1/agents"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var list AgentList
+	if err := c.doJSON(ctx, http.MethodGet, path, nil, &list); err != nil {
+		return nil, fmt.Errorf("failed to list agents: %w", err)
+	}
+	return &list, nil
+}
+
+// AgentPager iterates over all agents matching opts, fetching pages of
+// opts.Limit as needed, starting at opts.Cursor if set
+func (c *Client) AgentPager(opts ListAgentsOptions) *Pager[Agent] {
+	start := opts.Cursor
+	return NewPager(func(ctx context.Context, cursor string) ([]Agent, string, error) {
+		if cursor == "" {
+			cursor = start
+		}
+		opts.Cursor = cursor
+		list, err := c.ListAgents(ctx, opts)
+		if err != nil {
+			return nil, "", err
+		}
+		return list.Agents, list.NextCursor, nil
+	})
+}
+
+// GetAgent returns one registered agent. Errors wrap ErrAgentNotFound if the
+// API does not know id.
+func (c *Client) GetAgent(ctx context.Context, id string) (*Agent, error) {
+	if id == "" {
+		return nil, errors.New("agent ID is required")
+	}
+
+	var agent Agent
+	err := c.doJSON(ctx, http.MethodGet, "/v1/agents/"+url.PathEscape(id), nil, &agent)
+	if apiStatus(err) == http.StatusNotFound {
+		return nil, fmt.Errorf("%w %s: %w", ErrAgentNotFound, id, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent %s: %w", id, err)
+	}
+	return &agent, nil
+}
+
+// UpdateAgent changes the fields set in update and returns the updated agent.
+// Errors wrap ErrAgentNotFound if the API does not know id.
+func (c *Client) UpdateAgent(ctx context.Context, id string, update AgentUpdate) (*Agent, error) {
+	if id == "" {
+		return nil, errors.New("agent ID is required")
+	}
+
+	var agent Agent
+	err := c.doJSON(ctx, http.MethodPatch, "/v1/agents/"+url.PathEscape(id), update, &agent)
+	if apiStatus(err) == http.StatusNotFound {
+		return nil, fmt.Errorf("%w %s: %w", ErrAgentNotFound, id, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update agent %s: %w", id, err)
+	}
+	return &agent, nil
+}
+
+// DeleteAgent removes a registered agent. Errors wrap ErrAgentNotFound if the
+// API does not know id, or ErrAgentConflict if the agent cannot be deleted.
+func (c *Client) DeleteAgent(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("agent ID is required")
+	}
+
+	err := c.doJSON(ctx, http.MethodDelete, "/v1/agents/"+url.PathEscape(id), nil, nil)
+	switch {
+	case apiStatus(err) == http.StatusNotFound:
+		return fmt.Errorf("%w %s: %w", ErrAgentNotFound, id, err)
+	case apiStatus(err) == http.StatusConflict:
+		return fmt.Errorf("%w %s: %w", ErrAgentConflict, id, err)
+	case err != nil:
+		return fmt.Errorf("failed to delete agent %s: %w", id, err)
+	}
+	return nil
+}
+
+// doJSON sends in, if not nil, as the JSON body of a request to the API and
+// decodes the response into out, if not nil
+func (c *Client) doJSON(ctx context.Context, method, path string, in, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
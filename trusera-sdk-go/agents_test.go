@@ -0,0 +1,169 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListAgents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/agents" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		q := r.URL.Query()
+		if q.Get("framework") != "langchain" || q.Get("limit") != "2" || q.Get("cursor") != "c1" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{
+			"agents": [
+				{"agent_id": "a1", "name": "research", "framework": "langchain", "created_at": "2026-01-02T03:04:05Z"},
+				{"agent_id": "a2", "name": "support", "framework": "langchain", "runtime": {"os": "linux"}}
+			],
+			"next_cursor": "c2"
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	list, err := client.ListAgents(context.Background(), ListAgentsOptions{Framework: "langchain", Limit: 2, Cursor: "c1"})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list.Agents) != 2 || list.NextCursor != "c2" {
+		t.Fatalf("unexpected list %+v", list)
+	}
+	if a := list.Agents[0]; a.ID != "a1" || a.Name != "research" || a.CreatedAt.Year() != 2026 {
+		t.Errorf("unexpected agent %+v", a)
+	}
+	if rt := list.Agents[1].Runtime; rt == nil || rt.OS != "linux" {
+		t.Errorf("expected runtime metadata, got %+v", rt)
+	}
+}
+
+func TestGetAgent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/agents/a1":
+			w.Write([]byte(`{"agent_id": "a1", "name": "research", "framework": "custom"}`))
+		case "/v1/agents/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	agent, err := client.GetAgent(context.Background(), "a1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if agent.ID != "a1" || agent.Framework != "custom" {
+		t.Errorf("unexpected agent %+v", agent)
+	}
+
+	if _, err := client.GetAgent(context.Background(), "missing"); !errors.Is(err, ErrAgentNotFound) {
+		t.Errorf("expected ErrAgentNotFound, got %v", err)
+	}
+	if _, err := client.GetAgent(context.Background(), "broken"); err == nil || errors.Is(err, ErrAgentNotFound) {
+		t.Errorf("expected a server error, got %v", err)
+	}
+	if _, err := client.GetAgent(context.Background(), ""); err == nil {
+		t.Error("expected an error for an empty ID")
+	}
+}
+
+func TestUpdateAgent(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.URL.Path != "/v1/agents/a1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"agent_id": "a1", "name": "research-v2", "framework": "custom", "labels": {"team": "ml"}, "status": "retired"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	name := "research-v2"
+	agent, err := client.UpdateAgent(context.Background(), "a1", AgentUpdate{
+		Name:   &name,
+		Labels: map[string]string{"team": "ml"},
+		Status: AgentRetired,
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if agent.Name != name || agent.Status != AgentRetired || agent.Labels["team"] != "ml" {
+		t.Errorf("unexpected agent %+v", agent)
+	}
+
+	if _, ok := got["framework"]; ok {
+		t.Error("expected unset fields to be left out of the request")
+	}
+	if got["name"] != name || got["status"] != "retired" {
+		t.Errorf("unexpected request body %v", got)
+	}
+
+	if _, err := client.UpdateAgent(context.Background(), "missing", AgentUpdate{Status: AgentActive}); !errors.Is(err, ErrAgentNotFound) {
+		t.Errorf("expected ErrAgentNotFound, got %v", err)
+	}
+}
+
+func TestDeleteAgent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/agents/a1":
+			w.WriteHeader(http.StatusNoContent)
+		case "/v1/agents/busy":
+			w.WriteHeader(http.StatusConflict)
+		case "/v1/agents/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.DeleteAgent(ctx, "a1"); err != nil {
+		t.Errorf("delete: %v", err)
+	}
+	if err := client.DeleteAgent(ctx, "missing"); !errors.Is(err, ErrAgentNotFound) {
+		t.Errorf("expected ErrAgentNotFound, got %v", err)
+	}
+	if err := client.DeleteAgent(ctx, "busy"); !errors.Is(err, ErrAgentConflict) {
+		t.Errorf("expected ErrAgentConflict, got %v", err)
+	}
+	err := client.DeleteAgent(ctx, "broken")
+	if err == nil || errors.Is(err, ErrAgentNotFound) || errors.Is(err, ErrAgentConflict) {
+		t.Errorf("expected a server error, got %v", err)
+	}
+}
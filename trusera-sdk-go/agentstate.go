@@ -0,0 +1,109 @@
+package trusera
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// WithAgentStateFile sets where RegisterAgentOnce caches agent IDs. Defaults
+// to trusera/agents.json in the user cache directory.
+func WithAgentStateFile(path string) Option {
+	return func(c *Client) {
+		c.agentStatePath = path
+	}
+}
+
+// agentState is one cached registration in the agent state file
+type agentState struct {
+	Name         string `json:"name"`
+	Framework    string `json:"framework"`
+	AgentID      string `json:"agent_id"`
+	RegisteredAt string `json:"registered_at"`
+}
+
+// agentStateMu serializes updates to state files within the process
+var agentStateMu sync.Mutex
+
+// RegisterAgentOnce is like RegisterAgent but caches the agent ID in a local
+// state file keyed by name and framework, so a restarted process reuses its
+// identity instead of registering a duplicate agent on every boot
+func (c *Client) RegisterAgentOnce(name, framework string) (string, error) {
+	if name == "" {
+		return "", errors.New("agent name is required")
+	}
+
+	path, err := c.agentStateFile()
+	if err != nil {
+		return "", err
+	}
+
+	agentStateMu.Lock()
+	defer agentStateMu.Unlock()
+
+	states, err := readAgentStates(path)
+	if err != nil {
+		return "", err
+	}
+	for _, s := range states {
+		if s.Name == name && s.Framework == framework && s.AgentID != "" {
+			c.mu.Lock()
+			c.agentID = s.AgentID
+			c.mu.Unlock()
+			return s.AgentID, nil
+		}
+	}
+
+	id, err := c.RegisterAgent(name, framework)
+	if err != nil {
+		return "", err
+	}
+
+	states = append(states, agentState{
+		Name:         name,
+		Framework:    framework,
+		AgentID:      id,
+		RegisteredAt: time.Now().UTC().Format(time.RFC3339),
+	})
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return id, fmt.Errorf("failed to marshal agent state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return id, fmt.Errorf("failed to create agent state directory: %w", err)
+	}
+	return id, writeFileAtomic(path, data, 0600)
+}
+
+// agentStateFile returns the configured state file or the default location
+func (c *Client) agentStateFile() (string, error) {
+	if c.agentStatePath != "" {
+		return c.agentStatePath, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("no agent state file configured: %w", err)
+	}
+	return filepath.Join(dir, "trusera", "agents.json"), nil
+}
+
+// readAgentStates loads cached registrations; a missing file is empty
+func readAgentStates(path string) ([]agentState, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent state: %w", err)
+	}
+
+	var states []agentState
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("failed to parse agent state %s: %w", path, err)
+	}
+	return states, nil
+}
@@ -0,0 +1,103 @@
+package trusera
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRegisterAgentOnceReusesID(t *testing.T) {
+	var registrations atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := registrations.Add(1)
+		fmt.Fprintf(w, `{"agent_id":"agent-%d"}`, n)
+	}))
+	defer server.Close()
+
+	state := filepath.Join(t.TempDir(), "state", "agents.json")
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithAgentStateFile(state))
+	first, err := client.RegisterAgentOnce("research", "langchain")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	client.Close()
+
+	// A restarted process finds the cached ID
+	client = NewClient("test-key", WithBaseURL(server.URL), WithAgentStateFile(state))
+	defer client.Close()
+
+	second, err := client.RegisterAgentOnce("research", "langchain")
+	if err != nil {
+		t.Fatalf("register after restart: %v", err)
+	}
+	if second != first || client.agentID != first {
+		t.Errorf("expected cached ID %s, got %s", first, second)
+	}
+
+	// A different framework is a different agent
+	other, err := client.RegisterAgentOnce("research", "crewai")
+	if err != nil {
+		t.Fatalf("register other: %v", err)
+	}
+	if other == first {
+		t.Error("expected a new ID for a different framework")
+	}
+	if n := registrations.Load(); n != 2 {
+		t.Errorf("expected 2 registrations, got %d", n)
+	}
+
+	fi, err := os.Stat(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("expected state file mode 0600, got %v", fi.Mode().Perm())
+	}
+}
+
+func TestRegisterAgentOnceFailureNotCached(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"agent_id":"agent-1"}`))
+	}))
+	defer server.Close()
+
+	state := filepath.Join(t.TempDir(), "agents.json")
+	client := NewClient("test-key", WithBaseURL(server.URL), WithAgentStateFile(state))
+	defer client.Close()
+
+	if _, err := client.RegisterAgentOnce("research", "langchain"); err == nil {
+		t.Fatal("expected registration error")
+	}
+	if _, err := os.Stat(state); !os.IsNotExist(err) {
+		t.Error("expected no state file after a failed registration")
+	}
+
+	fail.Store(false)
+	id, err := client.RegisterAgentOnce("research", "langchain")
+	if err != nil || id != "agent-1" {
+		t.Fatalf("expected agent-1, got %q, %v", id, err)
+	}
+}
+
+func TestRegisterAgentOnceCorruptState(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "agents.json")
+	os.WriteFile(state, []byte("not json"), 0600)
+
+	client := NewClient("test-key", WithBaseURL("http://127.0.0.1:1"), WithFlushRetry(0, 0), WithAgentStateFile(state))
+	defer client.Close()
+
+	if _, err := client.RegisterAgentOnce("research", "langchain"); err == nil {
+		t.Error("expected an error for a corrupt state file")
+	}
+}
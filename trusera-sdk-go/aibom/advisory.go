@@ -0,0 +1,130 @@
+package aibom
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Advisory is a known risk in an AI component, such as a model vulnerable to
+// a published jailbreak, a deprecated provider endpoint or a compromised MCP
+// server, analogous to a CVE for a package
+type Advisory struct {
+	ID       string `json:"id"` // e.g. "AVID-2024-V001"
+	Summary  string `json:"summary"`
+	Severity string `json:"severity,omitempty"` // "low", "medium", "high" or "critical"
+	URL      string `json:"url,omitempty"`
+
+	// Kind limits the advisory to one BOM section: "provider", "model",
+	// "tool", "api", "dataset", "package", "mcp_server" or "prompt". Empty
+	// matches every section.
+	Kind string `json:"kind,omitempty"`
+	// Component is a path.Match pattern for component names, e.g.
+	// "gpt-3.5-turbo*" or "mcp-server-*", compared ignoring case
+	Component string `json:"component"`
+	// Provider, if set, must equal a model's provider
+	Provider string `json:"provider,omitempty"`
+}
+
+// LoadAdvisories reads an advisory feed: a JSON array of advisories or an
+// object with an "advisories" array
+func LoadAdvisories(r io.Reader) ([]Advisory, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var advisories []Advisory
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(data, &advisories)
+	} else {
+		var feed struct {
+			Advisories []Advisory `json:"advisories"`
+		}
+		err = json.Unmarshal(data, &feed)
+		advisories = feed.Advisories
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse advisory feed: %w", err)
+	}
+
+	for _, a := range advisories {
+		if _, err := path.Match(a.Component, ""); err != nil || a.Component == "" {
+			return nil, fmt.Errorf("advisory %s: invalid component pattern %q", a.ID, a.Component)
+		}
+	}
+	return advisories, nil
+}
+
+// FetchAdvisories downloads a feed from url and parses it with LoadAdvisories.
+// A nil client uses http.DefaultClient.
+func FetchAdvisories(ctx context.Context, client *http.Client, url string) ([]Advisory, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch advisories: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to fetch advisories: status %d", resp.StatusCode)
+	}
+	return LoadAdvisories(resp.Body)
+}
+
+// Scan sets Advisories on each component an advisory matches and returns the
+// number of matches. Advisories from an earlier scan are replaced.
+func Scan(bom *BOM, advisories []Advisory) int {
+	matches := 0
+	sections := []struct {
+		kind       string
+		components []Component
+	}{
+		{"provider", bom.Providers},
+		{"model", bom.Models},
+		{"tool", bom.Tools},
+		{"api", bom.APIs},
+		{"dataset", bom.Datasets},
+		{"package", bom.Packages},
+		{"mcp_server", bom.MCPServers},
+		{"prompt", bom.Prompts},
+	}
+
+	for _, s := range sections {
+		for i := range s.components {
+			c := &s.components[i]
+			c.Advisories = nil
+			for _, a := range advisories {
+				if a.matches(s.kind, *c) {
+					c.Advisories = append(c.Advisories, a)
+					matches++
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// matches reports whether the advisory applies to component c of kind
+func (a Advisory) matches(kind string, c Component) bool {
+	if a.Kind != "" && a.Kind != kind {
+		return false
+	}
+	if a.Provider != "" && !strings.EqualFold(a.Provider, c.Provider) {
+		return false
+	}
+	ok, _ := path.Match(strings.ToLower(a.Component), strings.ToLower(c.Name))
+	return ok
+}
@@ -0,0 +1,85 @@
+package aibom
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testFeed = `{"advisories": [
+  {"id": "ADV-1", "summary": "Prompt injection via tool output", "severity": "high", "kind": "tool", "component": "mcp-server-fetch*"},
+  {"id": "ADV-2", "summary": "Deprecated model", "severity": "low", "kind": "model", "component": "gpt-3.5-turbo*", "provider": "openai"},
+  {"id": "ADV-3", "summary": "Unencrypted endpoint", "component": "*.example.com"}
+]}`
+
+func TestScan(t *testing.T) {
+	advisories, err := LoadAdvisories(strings.NewReader(testFeed))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	bom := &BOM{
+		Models: []Component{
+			{Name: "gpt-3.5-turbo-0125", Provider: "openai"},
+			{Name: "gpt-3.5-turbo", Provider: "azure-openai"},
+			{Name: "gpt-4o", Provider: "openai"},
+		},
+		Tools: []Component{{Name: "MCP-Server-Fetch"}, {Name: "web_search"}},
+		APIs:  []Component{{Name: "api.example.com"}},
+	}
+
+	if n := Scan(bom, advisories); n != 3 {
+		t.Errorf("expected 3 matches, got %d", n)
+	}
+	if a := bom.Models[0].Advisories; len(a) != 1 || a[0].ID != "ADV-2" {
+		t.Errorf("unexpected model advisories %+v", a)
+	}
+	if len(bom.Models[1].Advisories) != 0 {
+		t.Error("expected the provider to limit the match")
+	}
+	if a := bom.Tools[0].Advisories; len(a) != 1 || a[0].Severity != "high" {
+		t.Errorf("expected a case-insensitive tool match, got %+v", a)
+	}
+	if a := bom.APIs[0].Advisories; len(a) != 1 || a[0].ID != "ADV-3" {
+		t.Errorf("expected a kindless advisory to match APIs, got %+v", a)
+	}
+
+	// Rescanning replaces earlier results
+	if n := Scan(bom, nil); n != 0 || bom.Tools[0].Advisories != nil {
+		t.Errorf("expected a rescan to clear advisories, got %d", n)
+	}
+}
+
+func TestLoadAdvisories(t *testing.T) {
+	advisories, err := LoadAdvisories(strings.NewReader(`[{"id": "A", "component": "x"}]`))
+	if err != nil || len(advisories) != 1 {
+		t.Errorf("expected a bare array to load, got %v, %v", advisories, err)
+	}
+
+	for _, feed := range []string{`{`, `[{"id": "B", "component": "[x"}]`, `[{"id": "C"}]`} {
+		if _, err := LoadAdvisories(strings.NewReader(feed)); err == nil {
+			t.Errorf("expected an error for %s", feed)
+		}
+	}
+}
+
+func TestFetchAdvisories(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/feed.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(testFeed))
+	}))
+	defer srv.Close()
+
+	advisories, err := FetchAdvisories(context.Background(), nil, srv.URL+"/feed.json")
+	if err != nil || len(advisories) != 3 {
+		t.Errorf("expected 3 advisories, got %v, %v", advisories, err)
+	}
+	if _, err := FetchAdvisories(context.Background(), srv.Client(), srv.URL+"/missing"); err == nil {
+		t.Error("expected an error for a missing feed")
+	}
+}
@@ -0,0 +1,409 @@
+// Package aibom builds an AI Bill of Materials from what an agent actually
+// used at runtime: the LLM providers and models it called, the tools it ran,
+// the external APIs it reached and the datasets it read, along with the AI
+// packages in its source, the MCP servers it is configured to use and the
+// prompt templates it sends.
+//
+// A Builder consumes client events, interceptor decisions and interceptor
+// JSONL logs:
+//
+//	b := aibom.NewBuilder("agent-123")
+//	interceptor, _ := trusera.NewStandaloneInterceptor(trusera.WithOnAllow(b.AddDecision))
+//	...
+//	b.Add(trusera.NewToolCallEvent(call))
+//	json.NewEncoder(os.Stdout).Encode(b.BOM())
+package aibom
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// Component is one dependency seen during a run
+type Component struct {
+	Name string `json:"name"`
+	// Provider is set on models, on packages of a provider's SDK, on
+	// datasets served by a known vector database and, naming the server, on
+	// tools declared by an MCP server
+	Provider  string    `json:"provider,omitempty"`
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	// Ecosystem and Version are set on packages: "go", "pypi" or "npm", and
+	// the version pinned in go.mod or a lockfile
+	Ecosystem string `json:"ecosystem,omitempty"`
+	Version   string `json:"version,omitempty"`
+	// URI and Hash identify datasets declared with RecordDataset or
+	// trusera.NewDatasetEvent. URI is also set on MCP servers and Hash on
+	// prompts.
+	URI  string `json:"uri,omitempty"`
+	Hash string `json:"hash,omitempty"`
+	// Transport is set on MCP servers: "stdio", "http" or "sse"
+	Transport string `json:"transport,omitempty"`
+	// Variables is set on prompt templates declared with RecordPrompt
+	Variables []string `json:"variables,omitempty"`
+	// License is set on models whose license is known, e.g. "apache-2.0"
+	License string `json:"license,omitempty"`
+	// Info is set on models enriched by Hub.Enrich
+	Info *ModelInfo `json:"model_info,omitempty"`
+	// Agents lists the agents using the component in a BOM built by Merge
+	Agents []string `json:"agents,omitempty"`
+	// Advisories lists known risks, set by Scan
+	Advisories []Advisory `json:"advisories,omitempty"`
+}
+
+// BOM lists an agent's runtime dependencies, and the AI packages found in
+// its source, each sorted by name
+type BOM struct {
+	AgentID     string      `json:"agent_id,omitempty"`
+	GeneratedAt time.Time   `json:"generated_at"`
+	Providers   []Component `json:"providers"`
+	Models      []Component `json:"models"`
+	Tools       []Component `json:"tools"`
+	APIs        []Component `json:"apis"`
+	Datasets    []Component `json:"datasets"`
+	Packages    []Component `json:"packages,omitempty"`
+	MCPServers  []Component `json:"mcp_servers,omitempty"`
+	Prompts     []Component `json:"prompts,omitempty"`
+}
+
+// kind is a section of the BOM
+type kind int
+
+const (
+	kindProvider kind = iota
+	kindModel
+	kindTool
+	kindAPI
+	kindDataset
+	kindPackage
+	kindMCPServer
+	kindPrompt
+	numKinds
+)
+
+// Builder accumulates components. It is safe for concurrent use.
+type Builder struct {
+	agentID string
+
+	mu         sync.Mutex
+	components [numKinds]map[string]*Component
+
+	// Graph state: nodes by ID, edge counts, and the node or parent each
+	// tool call, LLM call and span event resolves to
+	nodes  map[string]Node
+	edges  map[edgeKey]int
+	events map[string]string
+
+	// Declared MCP servers by lowercased URL host and by tool name
+	mcpHosts map[string]string
+	mcpTools map[string]string
+
+	// Declared prompt templates' text before their first variable, and when
+	// prefixes of undeclared prompts seen once were first seen
+	promptPrefixes   map[string]string
+	promptCandidates map[string]time.Time
+}
+
+// NewBuilder returns an empty builder for the agent with the given ID
+func NewBuilder(agentID string) *Builder {
+	b := &Builder{
+		agentID: agentID,
+		nodes:   make(map[string]Node),
+		edges:   make(map[edgeKey]int),
+		events:  make(map[string]string),
+
+		mcpHosts: make(map[string]string),
+		mcpTools: make(map[string]string),
+
+		promptPrefixes:   make(map[string]string),
+		promptCandidates: make(map[string]time.Time),
+	}
+	for i := range b.components {
+		b.components[i] = make(map[string]*Component)
+	}
+	return b
+}
+
+// Add records the dependencies an event shows: providers and models from
+// LLM events, tools from tool calls, hosts from API calls and datasets from
+// data access events. Spans only link their children into the graph. Other
+// events are ignored.
+func (b *Builder) Add(e trusera.Event) {
+	at := parseTime(e.Timestamp)
+	from := b.parentRef(e.ParentID)
+
+	switch e.Type {
+	case trusera.EventLLMCall, trusera.EventLLMInvoke:
+		provider, _ := e.Payload["provider"].(string)
+		model, _ := e.Payload["model"].(string)
+		if model == "" {
+			model = e.Name
+		}
+		license, _ := e.Payload["license"].(string)
+		b.addModel(provider, model, license, at)
+		if prompt, _ := e.Payload["prompt_template"].(string); prompt != "" {
+			b.record(kindPrompt, prompt, "", at)
+		}
+		node := newNode(NodeModel, model, provider)
+		b.link(from, node)
+		b.bindEvent(e.ID, node.ID)
+	case trusera.EventToolCall:
+		b.record(kindTool, e.Name, "", at)
+		node := newNode(NodeTool, e.Name, "")
+		b.link(from, node)
+		b.bindEvent(e.ID, node.ID)
+		if server, ok := b.mcpServer("", e.Name); ok {
+			b.record(kindMCPServer, server, "", at)
+			b.link(node.ID, newNode(NodeMCPServer, server, ""))
+		}
+	case trusera.EventAPICall:
+		// The interceptor follows each call with "response" or "error" events
+		if e.Name == "response" || e.Name == "error" {
+			return
+		}
+		raw, _ := e.Payload["url"].(string)
+		var host string
+		if u, err := url.Parse(raw); err == nil {
+			host = u.Hostname()
+		}
+		provider, _ := e.Payload["llm_provider"].(string)
+		model, _ := e.Payload["llm_model"].(string)
+		license, _ := e.Payload["model_license"].(string)
+		b.addRequest(from, host, provider, model, license, at)
+	case trusera.EventDataAccess:
+		uri, _ := e.Payload["uri"].(string)
+		hash, _ := e.Payload["hash"].(string)
+		b.addDataset(from, e.Name, "", uri, hash, at)
+	case trusera.EventSpan:
+		b.bindEvent(e.ID, from)
+	}
+}
+
+// AddDecision records the host of an intercepted request, and its provider
+// and model when the host is a known LLM API. Blocked requests never reached
+// the host and are skipped. It can be registered directly as a
+// trusera.DecisionHook.
+func (b *Builder) AddDecision(ev trusera.DecisionEvent) {
+	if ev.EnforcementAction == "blocked" || ev.Hostname == "" {
+		return
+	}
+	b.addRequest(b.parentRef(""), ev.Hostname, ev.LLMProvider, ev.LLMModel, ev.ModelLicense, ev.Timestamp)
+}
+
+// ReadEventLog records the hosts, LLM providers and models in an interceptor
+// JSONL log, as written by WithLogFile or WithLogSinks, and the prompts in
+// captured request bodies of LLM calls (see AddPrompt). Blocked requests are
+// skipped.
+func (b *Builder) ReadEventLog(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry struct {
+			Timestamp         string `json:"timestamp"`
+			Hostname          string `json:"hostname"`
+			LLMProvider       string `json:"llm_provider"`
+			LLMModel          string `json:"llm_model"`
+			ModelLicense      string `json:"model_license"`
+			EnforcementAction string `json:"enforcement_action"`
+			RequestBody       *struct {
+				Preview string `json:"preview"`
+			} `json:"request_body"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if entry.EnforcementAction == "blocked" || entry.Hostname == "" {
+			continue
+		}
+		at := parseTime(entry.Timestamp)
+		b.addRequest(b.parentRef(""), entry.Hostname, entry.LLMProvider, entry.LLMModel, entry.ModelLicense, at)
+		if entry.LLMProvider != "" && entry.RequestBody != nil {
+			b.addPrompt(bodyPrompt(entry.RequestBody.Preview), at)
+		}
+	}
+	return scanner.Err()
+}
+
+// FromEventLog builds a BOM offline from an interceptor JSONL log, for runs
+// where only historical logs are available. See ReadEventLog.
+func FromEventLog(r io.Reader) (*BOM, error) {
+	b := NewBuilder("")
+	if err := b.ReadEventLog(r); err != nil {
+		return nil, err
+	}
+	return b.BOM(), nil
+}
+
+// Builder can be passed to trusera.WithBOM
+var _ trusera.BOMRecorder = (*Builder)(nil)
+
+// WriteJSON writes the BOM recorded so far as indented JSON
+func (b *Builder) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b.BOM())
+}
+
+// BOM returns the components recorded so far
+func (b *Builder) BOM() *BOM {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return &BOM{
+		AgentID:     b.agentID,
+		GeneratedAt: time.Now().UTC(),
+		Providers:   b.sorted(kindProvider),
+		Models:      b.sorted(kindModel),
+		Tools:       b.sorted(kindTool),
+		APIs:        b.sorted(kindAPI),
+		Datasets:    b.sorted(kindDataset),
+		Packages:    b.sorted(kindPackage),
+		MCPServers:  b.sorted(kindMCPServer),
+		Prompts:     b.sorted(kindPrompt),
+	}
+}
+
+// addRequest records a request to host made by from. Requests to a detected
+// LLM API are linked through the model they named, requests to a declared
+// MCP server through the server, and requests to a hosted vector database
+// also record the index as a dataset.
+func (b *Builder) addRequest(from, host, provider, model, license string, at time.Time) {
+	b.record(kindAPI, host, "", at)
+	service := newNode(NodeService, host, "")
+
+	if server, ok := b.mcpServer(host, ""); ok {
+		b.record(kindMCPServer, server, "", at)
+		node := newNode(NodeMCPServer, server, "")
+		b.link(from, node)
+		b.link(node.ID, service)
+		return
+	}
+
+	if store := retrievalProvider(host); store != "" {
+		b.addDataset(from, host, store, "", "", at)
+	}
+
+	if provider == "" {
+		b.link(from, service)
+		return
+	}
+	b.addModel(provider, model, license, at)
+	if model == "" {
+		b.link(from, service)
+		return
+	}
+	node := newNode(NodeModel, model, provider)
+	b.link(from, node)
+	b.link(node.ID, service)
+}
+
+// addModel records a model and, if known, its provider and license
+func (b *Builder) addModel(provider, model, license string, at time.Time) {
+	if provider != "" {
+		b.record(kindProvider, provider, "", at)
+	}
+	b.record(kindModel, model, provider, at)
+
+	if license != "" {
+		b.update(kindModel, provider+"/"+model, func(c *Component) { c.License = license })
+	}
+}
+
+// update changes a recorded component, if it exists
+func (b *Builder) update(k kind, key string, fn func(*Component)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c, ok := b.components[k][key]; ok {
+		fn(c)
+	}
+}
+
+// declare adds a component known from configuration rather than from use,
+// with a zero count, unless it is already recorded
+func (b *Builder) declare(k kind, key string, c Component, at time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.components[k][key]; !ok {
+		c.FirstSeen, c.LastSeen = at, at
+		b.components[k][key] = &c
+	}
+}
+
+// record counts one use of a component
+func (b *Builder) record(k kind, name, provider string, at time.Time) {
+	key := name
+	if k == kindModel {
+		key = provider + "/" + name
+	}
+	b.recordKey(k, key, Component{Name: name, Provider: provider}, at)
+}
+
+// recordKey counts one use of the component stored under key, creating it
+// from c on first use
+func (b *Builder) recordKey(k kind, key string, c Component, at time.Time) {
+	if c.Name == "" {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	recorded, ok := b.components[k][key]
+	if !ok {
+		c.FirstSeen, c.LastSeen = at, at
+		recorded = &c
+		b.components[k][key] = recorded
+	}
+	recorded.Count++
+	if at.Before(recorded.FirstSeen) {
+		recorded.FirstSeen = at
+	}
+	if at.After(recorded.LastSeen) {
+		recorded.LastSeen = at
+	}
+}
+
+// sorted returns copies of one kind's components in sortComponents order
+func (b *Builder) sorted(k kind) []Component {
+	out := make([]Component, 0, len(b.components[k]))
+	for _, c := range b.components[k] {
+		out = append(out, *c)
+	}
+	sortComponents(out)
+	return out
+}
+
+// sortComponents orders components by name, provider and ecosystem
+func sortComponents(components []Component) {
+	sort.Slice(components, func(i, j int) bool {
+		if components[i].Name != components[j].Name {
+			return components[i].Name < components[j].Name
+		}
+		if components[i].Provider != components[j].Provider {
+			return components[i].Provider < components[j].Provider
+		}
+		return components[i].Ecosystem < components[j].Ecosystem
+	})
+}
+
+// parseTime reads an RFC 3339 event timestamp, falling back to now
+func parseTime(s string) time.Time {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.UTC()
+	}
+	return time.Now().UTC()
+}
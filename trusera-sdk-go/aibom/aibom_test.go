@@ -0,0 +1,180 @@
+package aibom
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+func TestBuilder(t *testing.T) {
+	b := NewBuilder("agent-1")
+
+	llm := trusera.NewLLMCallEvent(trusera.LLMCall{Provider: "openai", Model: "gpt-4o"})
+	llm.Timestamp = "2026-03-01T10:00:00Z"
+	b.Add(llm)
+	llm.Timestamp = "2026-03-01T09:00:00Z"
+	b.Add(llm)
+
+	tool := trusera.NewToolCallEvent(trusera.ToolCall{Name: "web_search"})
+	b.Add(tool)
+
+	api := trusera.NewEvent(trusera.EventAPICall, "GET https://api.stripe.com/v1/charges").
+		WithPayload("url", "https://api.stripe.com/v1/charges")
+	b.Add(api)
+	b.Add(trusera.NewEvent(trusera.EventAPICall, "response").WithPayload("url", "https://api.stripe.com/v1/charges"))
+
+	b.Add(trusera.NewEvent(trusera.EventDataAccess, "customers_db"))
+	b.Add(trusera.NewEvent(trusera.EventHeartbeat, "heartbeat"))
+
+	bom := b.BOM()
+	if bom.AgentID != "agent-1" {
+		t.Errorf("unexpected agent ID %q", bom.AgentID)
+	}
+	if len(bom.Providers) != 1 || bom.Providers[0].Name != "openai" || bom.Providers[0].Count != 2 {
+		t.Errorf("unexpected providers %+v", bom.Providers)
+	}
+	if len(bom.Models) != 1 {
+		t.Fatalf("unexpected models %+v", bom.Models)
+	}
+	m := bom.Models[0]
+	if m.Name != "gpt-4o" || m.Provider != "openai" || m.Count != 2 {
+		t.Errorf("unexpected model %+v", m)
+	}
+	if m.FirstSeen.Hour() != 9 || m.LastSeen.Hour() != 10 {
+		t.Errorf("expected first/last seen at 9:00 and 10:00, got %v and %v", m.FirstSeen, m.LastSeen)
+	}
+	if len(bom.Tools) != 1 || bom.Tools[0].Name != "web_search" {
+		t.Errorf("unexpected tools %+v", bom.Tools)
+	}
+	if len(bom.APIs) != 1 || bom.APIs[0].Name != "api.stripe.com" || bom.APIs[0].Count != 1 {
+		t.Errorf("expected one API call to api.stripe.com, got %+v", bom.APIs)
+	}
+	if len(bom.Datasets) != 1 || bom.Datasets[0].Name != "customers_db" {
+		t.Errorf("unexpected datasets %+v", bom.Datasets)
+	}
+}
+
+func TestBuilderDecisions(t *testing.T) {
+	b := NewBuilder("")
+	now := time.Now()
+	b.AddDecision(trusera.DecisionEvent{Timestamp: now, Hostname: "api.github.com", EnforcementAction: "allowed"})
+	b.AddDecision(trusera.DecisionEvent{Timestamp: now, Hostname: "api.github.com", EnforcementAction: "warned"})
+	b.AddDecision(trusera.DecisionEvent{Timestamp: now, Hostname: "evil.example.com", EnforcementAction: "blocked"})
+
+	apis := b.BOM().APIs
+	if len(apis) != 1 || apis[0].Name != "api.github.com" || apis[0].Count != 2 {
+		t.Errorf("expected only the reached host, got %+v", apis)
+	}
+}
+
+func TestRuntimeExportBOM(t *testing.T) {
+	t.Setenv("TRUSERA_API_KEY", "")
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	rt, err := trusera.Start("", "", trusera.WithBOM(NewBuilder("agent-1")))
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	resp, err := rt.HTTPClient().Get(backend.URL + "/v1/data")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	// Close waits for the decision hooks feeding the builder
+	if err := rt.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := rt.ExportBOM(&buf); err != nil {
+		t.Fatalf("ExportBOM failed: %v", err)
+	}
+	var bom BOM
+	if err := json.Unmarshal(buf.Bytes(), &bom); err != nil {
+		t.Fatalf("invalid BOM JSON: %v", err)
+	}
+	if bom.AgentID != "agent-1" || len(bom.APIs) != 1 || bom.APIs[0].Name != "127.0.0.1" {
+		t.Errorf("unexpected BOM %+v", bom)
+	}
+
+	if err := rt.UploadBOM(context.Background()); err == nil {
+		t.Error("expected upload to fail without an event client")
+	}
+}
+
+func TestBuilderDetectedModels(t *testing.T) {
+	b := NewBuilder("")
+	now := time.Now()
+	b.AddDecision(trusera.DecisionEvent{Timestamp: now, Hostname: "api.openai.com", LLMProvider: "openai", LLMModel: "gpt-4o", EnforcementAction: "allowed"})
+	b.AddDecision(trusera.DecisionEvent{Timestamp: now, Hostname: "localhost", LLMProvider: "ollama", EnforcementAction: "allowed"})
+	b.AddDecision(trusera.DecisionEvent{Timestamp: now, Hostname: "localhost", LLMProvider: "ollama", LLMModel: "llama3.1", ModelLicense: "llama3.1", EnforcementAction: "allowed"})
+	b.Add(trusera.NewEvent(trusera.EventAPICall, "POST https://api.anthropic.com/v1/messages").
+		WithPayload("url", "https://api.anthropic.com/v1/messages").
+		WithPayload("llm_provider", "anthropic").
+		WithPayload("llm_model", "claude-3-5-haiku-latest"))
+
+	bom := b.BOM()
+	if len(bom.Providers) != 3 {
+		t.Errorf("expected anthropic, ollama and openai providers, got %+v", bom.Providers)
+	}
+	if len(bom.Models) != 3 || bom.Models[0].Name != "claude-3-5-haiku-latest" || bom.Models[1].Provider != "openai" {
+		t.Errorf("unexpected models %+v", bom.Models)
+	}
+	if bom.Models[2].License != "llama3.1" || bom.Models[0].License != "" {
+		t.Errorf("expected only the llama license to be recorded, got %+v", bom.Models)
+	}
+}
+
+func TestReadEventLog(t *testing.T) {
+	log := `{"timestamp":"2026-03-01T10:00:00Z","hostname":"api.openai.com","enforcement_action":"allowed"}
+
+{"timestamp":"2026-03-01T11:00:00Z","hostname":"api.openai.com","llm_provider":"openai","llm_model":"gpt-4o","enforcement_action":"logged"}
+{"timestamp":"2026-03-01T12:00:00Z","hostname":"pastebin.com","enforcement_action":"blocked"}
+`
+	b := NewBuilder("")
+	if err := b.ReadEventLog(strings.NewReader(log)); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	apis := b.BOM().APIs
+	if len(apis) != 1 || apis[0].Count != 2 || apis[0].LastSeen.Hour() != 11 {
+		t.Errorf("unexpected APIs %+v", apis)
+	}
+	if models := b.BOM().Models; len(models) != 1 || models[0].Name != "gpt-4o" || models[0].Provider != "openai" {
+		t.Errorf("unexpected models %+v", models)
+	}
+
+	if err := b.ReadEventLog(strings.NewReader("not json\n")); err == nil {
+		t.Error("expected an error for a malformed line")
+	}
+}
+
+func TestFromEventLog(t *testing.T) {
+	log := `{"timestamp":"2026-03-01T10:00:00Z","hostname":"api.anthropic.com","llm_provider":"anthropic","llm_model":"claude-3-5-haiku-latest","enforcement_action":"allowed"}
+{"timestamp":"2026-03-01T10:01:00Z","hostname":"api.github.com","enforcement_action":"warned"}
+`
+	bom, err := FromEventLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("from log: %v", err)
+	}
+	if len(bom.APIs) != 2 || len(bom.Models) != 1 || len(bom.Providers) != 1 {
+		t.Errorf("unexpected BOM %+v", bom)
+	}
+
+	if _, err := FromEventLog(strings.NewReader("{")); err == nil {
+		t.Error("expected an error for a malformed log")
+	}
+}
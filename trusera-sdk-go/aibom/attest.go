@@ -0,0 +1,164 @@
+package aibom
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// PredicateType identifies an AI-BOM predicate in an in-toto statement.
+	// For keyless signing, pass it to "cosign attest --type" with the BOM
+	// JSON as the predicate.
+	PredicateType = "https://trusera.ai/aibom/v1"
+
+	// StatementType is the in-toto statement version produced by NewStatement
+	StatementType = "https://in-toto.io/Statement/v1"
+
+	// PayloadType is the DSSE payload type of in-toto statements
+	PayloadType = "application/vnd.in-toto+json"
+)
+
+// ErrInvalidSignature is returned by Verify when no signature matches the key
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Subject is an artifact a statement is about, such as the agent binary or
+// container image, identified by digest
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// FileSubject returns a subject for the file at path, named by its base name
+// and identified by its SHA-256 digest
+func FileSubject(path string) (Subject, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Subject{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return Subject{}, fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return Subject{
+		Name:   filepath.Base(path),
+		Digest: map[string]string{"sha256": hex.EncodeToString(h.Sum(nil))},
+	}, nil
+}
+
+// Statement is an in-toto statement whose predicate is a BOM
+type Statement struct {
+	Type          string    `json:"_type"`
+	Subject       []Subject `json:"subject"`
+	PredicateType string    `json:"predicateType"`
+	Predicate     *BOM      `json:"predicate"`
+}
+
+// NewStatement wraps bom in an in-toto statement about subjects
+func NewStatement(bom *BOM, subjects ...Subject) *Statement {
+	return &Statement{
+		Type:          StatementType,
+		Subject:       subjects,
+		PredicateType: PredicateType,
+		Predicate:     bom,
+	}
+}
+
+// Envelope is a DSSE envelope, the format "cosign verify-attestation" and
+// "cosign verify-blob-attestation" read
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     []byte      `json:"payload"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+// Signature is one signature over an envelope's payload
+type Signature struct {
+	KeyID string `json:"keyid"`
+	Sig   []byte `json:"sig"`
+}
+
+// Sign encodes stmt and signs it with signer, which may hold an ECDSA, RSA
+// or Ed25519 key. ECDSA P-256 keys match cosign's key-based signing. keyID
+// is recorded with the signature and may be empty.
+func Sign(stmt *Statement, signer crypto.Signer, keyID string) (*Envelope, error) {
+	payload, err := json.Marshal(stmt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal statement: %w", err)
+	}
+
+	msg := pae(PayloadType, payload)
+	var sig []byte
+	if _, ok := signer.Public().(ed25519.PublicKey); ok {
+		sig, err = signer.Sign(rand.Reader, msg, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(msg)
+		sig, err = signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign statement: %w", err)
+	}
+
+	return &Envelope{
+		PayloadType: PayloadType,
+		Payload:     payload,
+		Signatures:  []Signature{{KeyID: keyID, Sig: sig}},
+	}, nil
+}
+
+// Verify checks that one of env's signatures was made by pub and returns the
+// signed statement
+func Verify(env *Envelope, pub crypto.PublicKey) (*Statement, error) {
+	if env.PayloadType != PayloadType {
+		return nil, fmt.Errorf("unexpected payload type %q", env.PayloadType)
+	}
+
+	msg := pae(env.PayloadType, env.Payload)
+	digest := sha256.Sum256(msg)
+
+	verified := false
+	for _, s := range env.Signatures {
+		switch key := pub.(type) {
+		case *ecdsa.PublicKey:
+			verified = ecdsa.VerifyASN1(key, digest[:], s.Sig)
+		case *rsa.PublicKey:
+			verified = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], s.Sig) == nil
+		case ed25519.PublicKey:
+			verified = ed25519.Verify(key, msg, s.Sig)
+		default:
+			return nil, fmt.Errorf("unsupported public key type %T", pub)
+		}
+		if verified {
+			break
+		}
+	}
+	if !verified {
+		return nil, ErrInvalidSignature
+	}
+
+	var stmt Statement
+	if err := json.Unmarshal(env.Payload, &stmt); err != nil {
+		return nil, fmt.Errorf("failed to parse statement: %w", err)
+	}
+	if stmt.PredicateType != PredicateType {
+		return nil, fmt.Errorf("unexpected predicate type %q", stmt.PredicateType)
+	}
+	return &stmt, nil
+}
+
+// pae is the DSSE pre-authentication encoding of a payload
+func pae(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
@@ -0,0 +1,87 @@
+package aibom
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	bom := &BOM{AgentID: "agent-1", APIs: []Component{{Name: "api.openai.com", Count: 2}}}
+	subject := Subject{Name: "agent", Digest: map[string]string{"sha256": "abc123"}}
+
+	for name, key := range map[string]crypto.Signer{"ecdsa": ecKey, "rsa": rsaKey, "ed25519": edKey} {
+		t.Run(name, func(t *testing.T) {
+			env, err := Sign(NewStatement(bom, subject), key, "key-1")
+			if err != nil {
+				t.Fatalf("sign: %v", err)
+			}
+
+			// The envelope must survive a JSON round trip, as it would on disk
+			data, _ := json.Marshal(env)
+			var decoded Envelope
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+
+			stmt, err := Verify(&decoded, key.Public())
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if stmt.Type != StatementType || stmt.Predicate.AgentID != "agent-1" || stmt.Subject[0].Digest["sha256"] != "abc123" {
+				t.Errorf("unexpected statement %+v", stmt)
+			}
+
+			decoded.Payload = append([]byte(nil), decoded.Payload...)
+			decoded.Payload[len(decoded.Payload)-2] ^= 1
+			if _, err := Verify(&decoded, key.Public()); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("expected a tampered payload to fail, got %v", err)
+			}
+		})
+	}
+
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	env, _ := Sign(NewStatement(bom), ecKey, "")
+	if _, err := Verify(env, other.Public()); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected the wrong key to fail, got %v", err)
+	}
+}
+
+func TestPAE(t *testing.T) {
+	// Test vector from the DSSE specification
+	got := string(pae("http://example.com/HelloWorld", []byte("hello world")))
+	want := "DSSEv1 29 http://example.com/HelloWorld 11 hello world"
+	if got != want {
+		t.Errorf("pae = %q, want %q", got, want)
+	}
+}
+
+func TestFileSubject(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.bin")
+	os.WriteFile(path, []byte("hello world"), 0600)
+
+	s, err := FileSubject(path)
+	if err != nil {
+		t.Fatalf("subject: %v", err)
+	}
+	want := "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+	if s.Name != "agent.bin" || s.Digest["sha256"] != want {
+		t.Errorf("unexpected subject %+v", s)
+	}
+
+	if _, err := FileSubject(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
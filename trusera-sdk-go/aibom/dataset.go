@@ -0,0 +1,54 @@
+package aibom
+
+import (
+	"strings"
+	"time"
+)
+
+// retrievalHosts maps hostname suffixes of hosted vector databases to their
+// provider. Requests to them are retrieval from a dataset.
+var retrievalHosts = []struct {
+	suffix   string
+	provider string
+}{
+	{".pinecone.io", "pinecone"},
+	{".weaviate.network", "weaviate"},
+	{".weaviate.cloud", "weaviate"},
+	{".qdrant.io", "qdrant"},
+	{".zillizcloud.com", "zilliz"},
+	{"api.trychroma.com", "chroma"},
+	{".turbopuffer.com", "turbopuffer"},
+	{".vectara.io", "vectara"},
+}
+
+// RecordDataset declares that the agent used a dataset, identified by uri
+// and, when known, a content hash such as "sha256:...". Agents that track
+// events can send trusera.NewDatasetEvent to Add instead.
+func (b *Builder) RecordDataset(name, uri, hash string) {
+	b.addDataset(b.parentRef(""), name, "", uri, hash, time.Now().UTC())
+}
+
+// addDataset records a dataset and links it to from
+func (b *Builder) addDataset(from, name, provider, uri, hash string, at time.Time) {
+	b.record(kindDataset, name, provider, at)
+	b.update(kindDataset, name, func(c *Component) {
+		if uri != "" {
+			c.URI = uri
+		}
+		if hash != "" {
+			c.Hash = hash
+		}
+	})
+	b.link(from, newNode(NodeDataset, name, ""))
+}
+
+// retrievalProvider names the vector database serving host, if it is a known one
+func retrievalProvider(host string) string {
+	host = strings.ToLower(host)
+	for _, h := range retrievalHosts {
+		if strings.HasSuffix(host, h.suffix) || host == strings.TrimPrefix(h.suffix, ".") {
+			return h.provider
+		}
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package aibom
+
+import (
+	"testing"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+func TestRecordDataset(t *testing.T) {
+	b := NewBuilder("agent-1")
+	b.RecordDataset("support-docs", "s3://kb/support/", "")
+	b.RecordDataset("support-docs", "", "sha256:abc")
+	b.Add(trusera.NewDatasetEvent(trusera.Dataset{Name: "pricing", URI: "postgres://db/pricing"}))
+
+	bom := b.BOM()
+	if len(bom.Datasets) != 2 {
+		t.Fatalf("unexpected datasets %+v", bom.Datasets)
+	}
+	if d := bom.Datasets[1]; d.Name != "support-docs" || d.URI != "s3://kb/support/" || d.Hash != "sha256:abc" || d.Count != 2 {
+		t.Errorf("unexpected dataset %+v", d)
+	}
+	if d := bom.Datasets[0]; d.URI != "postgres://db/pricing" {
+		t.Errorf("expected the event URI, got %+v", d)
+	}
+}
+
+func TestRetrievalDetection(t *testing.T) {
+	b := NewBuilder("")
+	b.AddDecision(trusera.DecisionEvent{
+		Timestamp:         time.Now(),
+		Hostname:          "docs-abc123.svc.us-east1-gcp.pinecone.io",
+		EnforcementAction: "allowed",
+	})
+	b.AddDecision(trusera.DecisionEvent{Timestamp: time.Now(), Hostname: "api.github.com", EnforcementAction: "allowed"})
+
+	bom := b.BOM()
+	if len(bom.Datasets) != 1 || bom.Datasets[0].Provider != "pinecone" {
+		t.Errorf("expected the Pinecone index as a dataset, got %+v", bom.Datasets)
+	}
+	if len(bom.APIs) != 2 {
+		t.Errorf("expected both hosts as APIs, got %+v", bom.APIs)
+	}
+
+	tests := map[string]string{
+		"API.TryChroma.com":        "chroma",
+		"x.gcp.cloud.qdrant.io":    "qdrant",
+		"cluster.weaviate.network": "weaviate",
+		"in01-abc.zillizcloud.com": "zilliz",
+		"notpinecone.io":           "",
+		"pinecone.io.evil.example": "",
+		"api.openai.com":           "",
+	}
+	for host, want := range tests {
+		if got := retrievalProvider(host); got != want {
+			t.Errorf("retrievalProvider(%s) = %q, want %q", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package aibom
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Changes lists the components of one BOM section added or removed between runs
+type Changes struct {
+	Added   []Component `json:"added"`
+	Removed []Component `json:"removed"`
+}
+
+// BOMDiff reports the dependencies an agent gained or lost between two BOMs.
+// Components keep the counts and timestamps from the BOM they appear in.
+type BOMDiff struct {
+	Providers  Changes `json:"providers"`
+	Models     Changes `json:"models"`
+	Tools      Changes `json:"tools"`
+	APIs       Changes `json:"apis"`
+	Datasets   Changes `json:"datasets"`
+	Packages   Changes `json:"packages"`
+	MCPServers Changes `json:"mcp_servers"`
+	Prompts    Changes `json:"prompts"`
+}
+
+// Diff compares two BOMs by component name, and by provider and name for
+// models. A nil BOM is treated as empty. Use counts are not compared, so a
+// dependency used more or less often is not a change.
+func Diff(old, new *BOM) *BOMDiff {
+	if old == nil {
+		old = &BOM{}
+	}
+	if new == nil {
+		new = &BOM{}
+	}
+	return &BOMDiff{
+		Providers:  diffComponents(old.Providers, new.Providers),
+		Models:     diffComponents(old.Models, new.Models),
+		Tools:      diffComponents(old.Tools, new.Tools),
+		APIs:       diffComponents(old.APIs, new.APIs),
+		Datasets:   diffComponents(old.Datasets, new.Datasets),
+		Packages:   diffComponents(old.Packages, new.Packages),
+		MCPServers: diffComponents(old.MCPServers, new.MCPServers),
+		Prompts:    diffComponents(old.Prompts, new.Prompts),
+	}
+}
+
+// Empty reports whether the BOMs list the same components
+func (d *BOMDiff) Empty() bool {
+	return !d.HasAdditions() && !d.HasRemovals()
+}
+
+// HasAdditions reports whether the newer BOM has components the older one
+// lacks, such as a new third-party API. CI can fail the build on it.
+func (d *BOMDiff) HasAdditions() bool {
+	for _, c := range d.sections() {
+		if len(c.changes.Added) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRemovals reports whether the older BOM has components the newer one lacks
+func (d *BOMDiff) HasRemovals() bool {
+	for _, c := range d.sections() {
+		if len(c.changes.Removed) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// String renders one line per change, e.g. "+ api api.stripe.com"
+func (d *BOMDiff) String() string {
+	var b strings.Builder
+	for _, s := range d.sections() {
+		for _, c := range s.changes.Added {
+			fmt.Fprintf(&b, "+ %s %s\n", s.name, componentName(c))
+		}
+		for _, c := range s.changes.Removed {
+			fmt.Fprintf(&b, "- %s %s\n", s.name, componentName(c))
+		}
+	}
+	return b.String()
+}
+
+type diffSection struct {
+	name    string
+	changes Changes
+}
+
+// sections lists the changes in BOM order
+func (d *BOMDiff) sections() []diffSection {
+	return []diffSection{
+		{"provider", d.Providers},
+		{"model", d.Models},
+		{"tool", d.Tools},
+		{"api", d.APIs},
+		{"dataset", d.Datasets},
+		{"package", d.Packages},
+		{"mcp_server", d.MCPServers},
+		{"prompt", d.Prompts},
+	}
+}
+
+// diffComponents returns the components only in new and only in old, in the
+// order they are listed
+func diffComponents(old, new []Component) Changes {
+	changes := Changes{Added: []Component{}, Removed: []Component{}}
+
+	oldKeys := make(map[string]bool, len(old))
+	for _, c := range old {
+		oldKeys[componentName(c)] = true
+	}
+	newKeys := make(map[string]bool, len(new))
+	for _, c := range new {
+		newKeys[componentName(c)] = true
+	}
+
+	for _, c := range new {
+		if !oldKeys[componentName(c)] {
+			changes.Added = append(changes.Added, c)
+		}
+	}
+	for _, c := range old {
+		if !newKeys[componentName(c)] {
+			changes.Removed = append(changes.Removed, c)
+		}
+	}
+	return changes
+}
+
+// componentName identifies a component, qualifying packages by ecosystem
+// and models by provider
+func componentName(c Component) string {
+	if c.Ecosystem != "" {
+		return c.Ecosystem + ":" + c.Name
+	}
+	if c.Provider == "" {
+		return c.Name
+	}
+	return c.Provider + "/" + c.Name
+}
@@ -0,0 +1,67 @@
+package aibom
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old := &BOM{
+		Providers: []Component{{Name: "openai", Count: 10}},
+		Models:    []Component{{Name: "gpt-4o", Provider: "openai", Count: 10}},
+		Tools:     []Component{{Name: "web_search"}, {Name: "calculator"}},
+		APIs:      []Component{{Name: "api.openai.com"}},
+	}
+	new := &BOM{
+		Providers: []Component{{Name: "openai", Count: 3}},
+		Models: []Component{
+			{Name: "gpt-4o", Provider: "azure-openai"},
+			{Name: "gpt-4o", Provider: "openai", Count: 1},
+		},
+		Tools: []Component{{Name: "web_search"}},
+		APIs:  []Component{{Name: "api.openai.com"}, {Name: "pastebin.com"}},
+	}
+
+	d := Diff(old, new)
+	if d.Empty() || !d.HasAdditions() || !d.HasRemovals() {
+		t.Fatalf("expected additions and removals, got %+v", d)
+	}
+	if len(d.Providers.Added) != 0 || len(d.Providers.Removed) != 0 {
+		t.Errorf("count changes should not be reported: %+v", d.Providers)
+	}
+	if len(d.Models.Added) != 1 || d.Models.Added[0].Provider != "azure-openai" {
+		t.Errorf("expected the model to be compared per provider, got %+v", d.Models)
+	}
+	if len(d.Tools.Removed) != 1 || d.Tools.Removed[0].Name != "calculator" {
+		t.Errorf("unexpected tool changes %+v", d.Tools)
+	}
+	if len(d.APIs.Added) != 1 || d.APIs.Added[0].Name != "pastebin.com" {
+		t.Errorf("unexpected API changes %+v", d.APIs)
+	}
+
+	want := "+ model azure-openai/gpt-4o\n- tool calculator\n+ api pastebin.com\n"
+	if got := d.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"datasets":{"added":[],"removed":[]}`) {
+		t.Errorf("expected empty sections as empty lists, got %s", data)
+	}
+}
+
+func TestDiffIdentical(t *testing.T) {
+	bom := &BOM{APIs: []Component{{Name: "api.github.com"}}}
+	if d := Diff(bom, bom); !d.Empty() || d.String() != "" {
+		t.Errorf("expected no changes, got %q", d.String())
+	}
+
+	d := Diff(nil, bom)
+	if !d.HasAdditions() || d.HasRemovals() {
+		t.Errorf("expected a nil BOM to diff as empty, got %+v", d)
+	}
+}
@@ -0,0 +1,204 @@
+package aibom
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// NodeKind is the type of a dependency graph node
+type NodeKind string
+
+const (
+	NodeAgent   NodeKind = "agent"
+	NodeTool    NodeKind = "tool"
+	NodeModel   NodeKind = "model"
+	NodeService NodeKind = "service"
+	NodeDataset NodeKind = "dataset"
+
+	NodeMCPServer NodeKind = "mcp_server"
+)
+
+// Node is an agent, tool, model, external service, dataset or MCP server
+type Node struct {
+	ID   string   `json:"id"`
+	Kind NodeKind `json:"kind"`
+	Name string   `json:"name"`
+	// Provider is set on models
+	Provider string `json:"provider,omitempty"`
+}
+
+// Edge records that From used To, Count times
+type Edge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int    `json:"count"`
+}
+
+// Graph shows what an agent depends on at runtime: the tools it ran, the
+// models it or its tools called and the services and datasets they reached.
+// Nodes are sorted by ID and edges by endpoints, so it encodes to stable JSON.
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// edgeKey identifies an edge whose source may still be an event reference
+type edgeKey struct {
+	from string
+	to   string
+}
+
+// eventRefPrefix marks an edge source that names an event rather than a node
+const eventRefPrefix = "event:"
+
+// Graph returns the dependency graph recorded so far. Events are attached to
+// the tool call, LLM call or span named by their ParentID, looking through
+// spans to the nearest tool or model, and to the agent otherwise. Parents may
+// arrive after their children, as spans and tool calls do.
+func (b *Builder) Graph() *Graph {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	agent := b.agentNode()
+	nodes := map[string]Node{agent.ID: agent}
+	for id, n := range b.nodes {
+		nodes[id] = n
+	}
+
+	counts := make(map[edgeKey]int)
+	for k, n := range b.edges {
+		from := b.resolve(k.from, agent.ID)
+		if from == k.to {
+			continue
+		}
+		counts[edgeKey{from, k.to}] += n
+	}
+
+	g := &Graph{Nodes: make([]Node, 0, len(nodes)), Edges: make([]Edge, 0, len(counts))}
+	for _, n := range nodes {
+		g.Nodes = append(g.Nodes, n)
+	}
+	for k, n := range counts {
+		g.Edges = append(g.Edges, Edge{From: k.from, To: k.to, Count: n})
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	return g
+}
+
+// WriteDOT writes the graph in Graphviz DOT format, e.g. for
+// "dot -Tsvg graph.dot > graph.svg"
+func (g *Graph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph aibom {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	for _, n := range g.Nodes {
+		label := n.Name
+		if n.Provider != "" {
+			label = n.Provider + "/" + n.Name
+		}
+		fmt.Fprintf(bw, "  %s [label=%s, shape=%s];\n", dotQuote(n.ID), dotQuote(label), dotShape(n.Kind))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(bw, "  %s -> %s [label=\"%d\"];\n", dotQuote(e.From), dotQuote(e.To), e.Count)
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// dotShape picks a node shape per kind
+func dotShape(k NodeKind) string {
+	switch k {
+	case NodeAgent:
+		return "doubleoctagon"
+	case NodeTool:
+		return "component"
+	case NodeModel:
+		return "ellipse"
+	case NodeDataset:
+		return "cylinder"
+	case NodeMCPServer:
+		return "box3d"
+	default:
+		return "box"
+	}
+}
+
+// dotQuote renders s as a DOT string literal
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// agentNode is the root of the graph
+func (b *Builder) agentNode() Node {
+	name := b.agentID
+	if name == "" {
+		name = "agent"
+	}
+	return Node{ID: "agent:" + b.agentID, Kind: NodeAgent, Name: name}
+}
+
+// newNode returns the node for a component, keying models by provider
+func newNode(k NodeKind, name, provider string) Node {
+	id := string(k) + ":" + name
+	if k == NodeModel {
+		id = string(k) + ":" + provider + "/" + name
+	}
+	return Node{ID: id, Kind: k, Name: name, Provider: provider}
+}
+
+// parentRef is the edge source for an event: its parent event, or the agent
+func (b *Builder) parentRef(parentID string) string {
+	if parentID == "" {
+		return b.agentNode().ID
+	}
+	return eventRefPrefix + parentID
+}
+
+// link records one use of to by from, which is a node ID or an event reference
+func (b *Builder) link(from string, to Node) {
+	if to.Name == "" {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nodes[to.ID] = to
+	b.edges[edgeKey{from, to.ID}]++
+}
+
+// bindEvent makes an event resolve to target, a node ID or another event reference
+func (b *Builder) bindEvent(eventID, target string) {
+	if eventID == "" {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.events[eventID] = target
+}
+
+// resolve follows event references to a node, falling back to the agent
+func (b *Builder) resolve(ref, agent string) string {
+	// The bound guards against cycles in malformed parent links
+	for i := 0; i <= len(b.events); i++ {
+		id, ok := strings.CutPrefix(ref, eventRefPrefix)
+		if !ok {
+			return ref
+		}
+		if ref, ok = b.events[id]; !ok {
+			return agent
+		}
+	}
+	return agent
+}
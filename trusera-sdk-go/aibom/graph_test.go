@@ -0,0 +1,113 @@
+package aibom
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+func TestGraph(t *testing.T) {
+	b := NewBuilder("agent-1")
+
+	// A span wraps a tool call that calls a model. The tool call event is
+	// emitted when the call returns, after the model call it made.
+	span := trusera.NewEvent(trusera.EventSpan, "research")
+	tool := trusera.NewToolCallEvent(trusera.ToolCall{Name: "web_search"}).WithParent(span.ID)
+	llm := trusera.NewLLMCallEvent(trusera.LLMCall{Provider: "openai", Model: "gpt-4o"}).WithParent(tool.ID)
+	b.Add(llm)
+	b.Add(llm)
+	b.Add(tool)
+	b.Add(span)
+
+	b.Add(trusera.NewEvent(trusera.EventAPICall, "GET https://api.stripe.com/v1/charges").
+		WithPayload("url", "https://api.stripe.com/v1/charges"))
+	b.Add(trusera.NewEvent(trusera.EventDataAccess, "customers_db").WithParent("unknown-event"))
+	b.AddDecision(trusera.DecisionEvent{
+		Timestamp:         time.Now(),
+		Hostname:          "localhost",
+		LLMProvider:       "ollama",
+		LLMModel:          "llama3.1",
+		EnforcementAction: "allowed",
+	})
+
+	g := b.Graph()
+
+	wantEdges := []Edge{
+		{"agent:agent-1", "dataset:customers_db", 1},
+		{"agent:agent-1", "model:ollama/llama3.1", 1},
+		{"agent:agent-1", "service:api.stripe.com", 1},
+		{"agent:agent-1", "tool:web_search", 1},
+		{"model:ollama/llama3.1", "service:localhost", 1},
+		{"tool:web_search", "model:openai/gpt-4o", 2},
+	}
+	if len(g.Edges) != len(wantEdges) {
+		t.Fatalf("expected %d edges, got %+v", len(wantEdges), g.Edges)
+	}
+	for i, want := range wantEdges {
+		if g.Edges[i] != want {
+			t.Errorf("edge %d = %+v, want %+v", i, g.Edges[i], want)
+		}
+	}
+
+	if len(g.Nodes) != 7 || g.Nodes[0].Kind != NodeAgent || g.Nodes[0].Name != "agent-1" {
+		t.Errorf("unexpected nodes %+v", g.Nodes)
+	}
+
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded Graph
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Edges) != len(wantEdges) {
+		t.Errorf("graph did not round-trip through JSON: %v", err)
+	}
+}
+
+func TestGraphParentCycle(t *testing.T) {
+	b := NewBuilder("")
+	a := trusera.NewEvent(trusera.EventSpan, "a").WithParent("b")
+	a.ID = "a"
+	s := trusera.NewEvent(trusera.EventSpan, "b").WithParent("a")
+	s.ID = "b"
+	b.Add(a)
+	b.Add(s)
+	b.Add(trusera.NewEvent(trusera.EventDataAccess, "docs").WithParent("a"))
+
+	g := b.Graph()
+	if len(g.Edges) != 1 || g.Edges[0].From != "agent:" {
+		t.Errorf("expected a cycle to fall back to the agent, got %+v", g.Edges)
+	}
+}
+
+func TestGraphWriteDOT(t *testing.T) {
+	g := &Graph{
+		Nodes: []Node{
+			{ID: "agent:a", Kind: NodeAgent, Name: "a"},
+			{ID: `tool:say "hi"`, Kind: NodeTool, Name: `say "hi"`},
+			{ID: "model:openai/gpt-4o", Kind: NodeModel, Name: "gpt-4o", Provider: "openai"},
+		},
+		Edges: []Edge{{From: "agent:a", To: `tool:say "hi"`, Count: 3}},
+	}
+
+	var buf bytes.Buffer
+	if err := g.WriteDOT(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"digraph aibom {",
+		`"agent:a" [label="a", shape=doubleoctagon];`,
+		`"tool:say \"hi\"" [label="say \"hi\"", shape=component];`,
+		`"model:openai/gpt-4o" [label="openai/gpt-4o", shape=ellipse];`,
+		`"agent:a" -> "tool:say \"hi\"" [label="3"];`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package aibom
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+const defaultHubURL = "https://huggingface.co"
+
+var (
+	// ErrModelNotFound is returned by Hub.Lookup for IDs the Hub does not know
+	ErrModelNotFound = errors.New("model not found on the Hub")
+	// ErrNotCached is returned by Hub.Lookup in offline mode for uncached IDs
+	ErrNotCached = errors.New("model metadata not cached")
+)
+
+// ModelInfo is Hugging Face Hub metadata for a model
+type ModelInfo struct {
+	License     string `json:"license,omitempty"`      // e.g. "apache-2.0", "llama3.1"
+	PipelineTag string `json:"pipeline_tag,omitempty"` // e.g. "text-generation"
+	Parameters  int64  `json:"parameters,omitempty"`   // From safetensors metadata, zero when unknown
+	SizeBytes   int64  `json:"size_bytes,omitempty"`   // Storage used by the repository
+	Gated       bool   `json:"gated"`
+}
+
+// Hub looks up model metadata on the Hugging Face Hub. Results, including
+// unknown IDs, are cached in memory and optionally on disk. It is safe for
+// concurrent use.
+type Hub struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+	cacheDir   string
+	cacheTTL   time.Duration
+	offline    bool
+
+	mu    sync.Mutex
+	cache map[string]hubEntry
+}
+
+// hubEntry is a cached lookup; a nil Info records an unknown ID
+type hubEntry struct {
+	FetchedAt time.Time  `json:"fetched_at"`
+	Info      *ModelInfo `json:"info"`
+}
+
+// HubOption configures a Hub
+type HubOption func(*Hub)
+
+// WithHubToken authenticates requests, which gated and private models need
+func WithHubToken(token string) HubOption {
+	return func(h *Hub) {
+		h.token = token
+	}
+}
+
+// WithHubURL points the Hub at a mirror instead of huggingface.co
+func WithHubURL(baseURL string) HubOption {
+	return func(h *Hub) {
+		h.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithHubHTTPClient sets the HTTP client used for lookups
+func WithHubHTTPClient(client *http.Client) HubOption {
+	return func(h *Hub) {
+		h.httpClient = client
+	}
+}
+
+// WithHubCache keeps lookups as JSON files in dir, reusing them for ttl.
+// A zero ttl keeps them forever.
+func WithHubCache(dir string, ttl time.Duration) HubOption {
+	return func(h *Hub) {
+		h.cacheDir = dir
+		h.cacheTTL = ttl
+	}
+}
+
+// WithHubOffline answers lookups from the cache only, never from the network.
+// Expired entries are still used.
+func WithHubOffline() HubOption {
+	return func(h *Hub) {
+		h.offline = true
+	}
+}
+
+// NewHub returns a Hub client
+func NewHub(opts ...HubOption) *Hub {
+	h := &Hub{
+		baseURL:    defaultHubURL,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		cache:      make(map[string]hubEntry),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Enrich sets Info, and License when it is unset, on the BOM's models whose
+// names look like Hub IDs ("org/name"). Models the Hub does not know, or
+// that are not cached in offline mode, are left as they are; other lookup
+// errors are returned together after every model has been tried.
+func (h *Hub) Enrich(ctx context.Context, bom *BOM) error {
+	var errs []error
+	for i := range bom.Models {
+		m := &bom.Models[i]
+		if !isHubModelID(m.Name) {
+			continue
+		}
+		info, err := h.Lookup(ctx, m.Name)
+		switch {
+		case err == nil:
+			m.Info = info
+			if m.License == "" {
+				m.License = info.License
+			}
+		case errors.Is(err, ErrModelNotFound), errors.Is(err, ErrNotCached):
+		default:
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LicenseFunc adapts Lookup for trusera.WithModelLicenses. Lookups run on
+// the request path, so pair it with WithHubCache or WithHubOffline to avoid
+// a Hub round trip on the first request to each model.
+func (h *Hub) LicenseFunc() trusera.ModelLicenseFunc {
+	return func(ctx context.Context, _, model string) string {
+		if !isHubModelID(model) {
+			return ""
+		}
+		info, err := h.Lookup(ctx, model)
+		if err != nil {
+			return ""
+		}
+		return info.License
+	}
+}
+
+// Lookup returns the metadata for a model ID such as "meta-llama/Llama-3.1-8B"
+func (h *Hub) Lookup(ctx context.Context, id string) (*ModelInfo, error) {
+	entry, ok := h.cached(id)
+	if !ok && h.offline {
+		return nil, fmt.Errorf("%w: %s", ErrNotCached, id)
+	}
+	if !ok {
+		info, err := h.fetch(ctx, id)
+		if err != nil && !errors.Is(err, ErrModelNotFound) {
+			return nil, err
+		}
+		entry = hubEntry{FetchedAt: time.Now().UTC(), Info: info}
+		h.store(id, entry)
+	}
+
+	if entry.Info == nil {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotFound, id)
+	}
+	info := *entry.Info
+	return &info, nil
+}
+
+// cached returns a usable cache entry from memory or disk
+func (h *Hub) cached(id string) (hubEntry, bool) {
+	h.mu.Lock()
+	entry, ok := h.cache[id]
+	h.mu.Unlock()
+
+	if !ok && h.cacheDir != "" {
+		data, err := os.ReadFile(h.cachePath(id))
+		ok = err == nil && json.Unmarshal(data, &entry) == nil
+	}
+	if !ok {
+		return hubEntry{}, false
+	}
+	if !h.offline && h.cacheTTL > 0 && time.Since(entry.FetchedAt) > h.cacheTTL {
+		return hubEntry{}, false
+	}
+	return entry, true
+}
+
+// store saves an entry in memory and, best effort, on disk
+func (h *Hub) store(id string, entry hubEntry) {
+	h.mu.Lock()
+	h.cache[id] = entry
+	h.mu.Unlock()
+
+	if h.cacheDir == "" {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil || os.MkdirAll(h.cacheDir, 0700) != nil {
+		return
+	}
+	os.WriteFile(h.cachePath(id), data, 0600)
+}
+
+// cachePath names the cache file for id the way the Hub's own cache does
+func (h *Hub) cachePath(id string) string {
+	return filepath.Join(h.cacheDir, "models--"+strings.ReplaceAll(id, "/", "--")+".json")
+}
+
+// hubModel holds the fields of the Hub's model API used for ModelInfo
+type hubModel struct {
+	PipelineTag string          `json:"pipeline_tag"`
+	Gated       json.RawMessage `json:"gated"` // false, "auto" or "manual"
+	Tags        []string        `json:"tags"`
+	UsedStorage int64           `json:"usedStorage"`
+	CardData    struct {
+		License json.RawMessage `json:"license"` // A string or a list
+	} `json:"cardData"`
+	Safetensors struct {
+		Total int64 `json:"total"`
+	} `json:"safetensors"`
+}
+
+// fetch queries the Hub's model API
+func (h *Hub) fetch(ctx context.Context, id string) (*ModelInfo, error) {
+	u := h.baseURL + "/api/models/" + escapeModelID(id)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrModelNotFound, id)
+	case resp.StatusCode >= 300:
+		return nil, fmt.Errorf("failed to look up %s: Hub returned status %d", id, resp.StatusCode)
+	}
+
+	var m hubModel
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return nil, fmt.Errorf("failed to parse Hub response for %s: %w", id, err)
+	}
+
+	info := &ModelInfo{
+		License:     m.license(),
+		PipelineTag: m.PipelineTag,
+		Parameters:  m.Safetensors.Total,
+		SizeBytes:   m.UsedStorage,
+		Gated:       len(m.Gated) > 0 && string(m.Gated) != "false" && string(m.Gated) != "null",
+	}
+	return info, nil
+}
+
+// license reads the model card license, falling back to a "license:" tag
+func (m hubModel) license() string {
+	var license string
+	if json.Unmarshal(m.CardData.License, &license) == nil && license != "" {
+		return license
+	}
+	var licenses []string
+	if json.Unmarshal(m.CardData.License, &licenses) == nil && len(licenses) > 0 {
+		return licenses[0]
+	}
+	for _, tag := range m.Tags {
+		if l, ok := strings.CutPrefix(tag, "license:"); ok {
+			return l
+		}
+	}
+	return ""
+}
+
+// isHubModelID reports whether name has the "org/name" shape of a Hub ID
+func isHubModelID(name string) bool {
+	org, model, ok := strings.Cut(name, "/")
+	return ok && org != "" && model != "" && !strings.ContainsAny(model, "/ :")
+}
+
+// escapeModelID escapes each part of an "org/name" ID for the URL path
+func escapeModelID(id string) string {
+	org, model, _ := strings.Cut(id, "/")
+	return url.PathEscape(org) + "/" + url.PathEscape(model)
+}
@@ -0,0 +1,149 @@
+package aibom
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestHub serves two models and counts requests
+func newTestHub(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/api/models/meta-llama/Llama-3.1-8B-Instruct":
+			if r.Header.Get("Authorization") != "Bearer hf_test" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"pipeline_tag":"text-generation","gated":"manual","cardData":{"license":"llama3.1"},"safetensors":{"total":8030261248},"usedStorage":16060522496}`))
+		case "/api/models/BAAI/bge-small-en":
+			w.Write([]byte(`{"pipeline_tag":"feature-extraction","gated":false,"tags":["license:mit"]}`))
+		case "/api/models/broken/model":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestHubEnrich(t *testing.T) {
+	srv, requests := newTestHub(t)
+	hub := NewHub(WithHubURL(srv.URL), WithHubToken("hf_test"))
+
+	bom := &BOM{Models: []Component{
+		{Name: "BAAI/bge-small-en"},
+		{Name: "gpt-4o", Provider: "openai"},
+		{Name: "meta-llama/Llama-3.1-8B-Instruct", Provider: "together"},
+		{Name: "someone/unknown"},
+	}}
+	if err := hub.Enrich(context.Background(), bom); err != nil {
+		t.Fatalf("enrich: %v", err)
+	}
+
+	if info := bom.Models[0].Info; info == nil || info.License != "mit" || info.Gated || info.PipelineTag != "feature-extraction" {
+		t.Errorf("unexpected info for bge: %+v", info)
+	}
+	if bom.Models[0].License != "mit" {
+		t.Errorf("expected the license to be copied to the component, got %q", bom.Models[0].License)
+	}
+	if bom.Models[1].Info != nil {
+		t.Error("expected a non-Hub model to be skipped")
+	}
+	info := bom.Models[2].Info
+	if info == nil || info.License != "llama3.1" || !info.Gated || info.Parameters != 8030261248 || info.SizeBytes != 16060522496 {
+		t.Errorf("unexpected info for llama: %+v", info)
+	}
+	if bom.Models[3].Info != nil {
+		t.Error("expected an unknown model to be left alone")
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("expected 3 lookups, got %d", n)
+	}
+
+	// Known and unknown IDs are both cached
+	if err := hub.Enrich(context.Background(), bom); err != nil {
+		t.Fatalf("enrich: %v", err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("expected cached lookups, got %d requests", n)
+	}
+}
+
+func TestHubErrors(t *testing.T) {
+	srv, _ := newTestHub(t)
+	hub := NewHub(WithHubURL(srv.URL))
+
+	if _, err := hub.Lookup(context.Background(), "someone/unknown"); !errors.Is(err, ErrModelNotFound) {
+		t.Errorf("expected ErrModelNotFound, got %v", err)
+	}
+
+	bom := &BOM{Models: []Component{{Name: "broken/model"}}}
+	if err := hub.Enrich(context.Background(), bom); err == nil {
+		t.Error("expected server errors to be reported")
+	}
+}
+
+func TestHubDiskCacheAndOffline(t *testing.T) {
+	srv, requests := newTestHub(t)
+	dir := t.TempDir()
+
+	online := NewHub(WithHubURL(srv.URL), WithHubCache(dir, time.Hour))
+	if _, err := online.Lookup(context.Background(), "BAAI/bge-small-en"); err != nil {
+		t.Fatalf("lookup: %v", err)
+	}
+
+	// A new process reads the disk cache without touching the network
+	offline := NewHub(WithHubURL("http://127.0.0.1:1"), WithHubCache(dir, time.Nanosecond), WithHubOffline())
+	info, err := offline.Lookup(context.Background(), "BAAI/bge-small-en")
+	if err != nil || info.License != "mit" {
+		t.Fatalf("expected the cached entry offline, got %+v, %v", info, err)
+	}
+	if _, err := offline.Lookup(context.Background(), "meta-llama/Llama-3.1-8B-Instruct"); !errors.Is(err, ErrNotCached) {
+		t.Errorf("expected ErrNotCached, got %v", err)
+	}
+
+	// Expired entries are fetched again when online
+	expired := NewHub(WithHubURL(srv.URL), WithHubCache(dir, time.Nanosecond))
+	time.Sleep(time.Millisecond)
+	expired.Lookup(context.Background(), "BAAI/bge-small-en")
+	if n := requests.Load(); n != 2 {
+		t.Errorf("expected the expired entry to be refetched, got %d requests", n)
+	}
+}
+
+func TestIsHubModelID(t *testing.T) {
+	tests := map[string]bool{
+		"meta-llama/Llama-3.1-8B": true,
+		"gpt-4o":                  false,
+		"a/b/c":                   false,
+		"/model":                  false,
+		"org/":                    false,
+		"library/llama3.1:8b":     false,
+	}
+	for id, want := range tests {
+		if got := isHubModelID(id); got != want {
+			t.Errorf("isHubModelID(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestHubLicenseFunc(t *testing.T) {
+	srv, _ := newTestHub(t)
+	license := NewHub(WithHubURL(srv.URL)).LicenseFunc()
+
+	if got := license(context.Background(), "together", "BAAI/bge-small-en"); got != "mit" {
+		t.Errorf("expected mit, got %q", got)
+	}
+	if got := license(context.Background(), "openai", "gpt-4o"); got != "" {
+		t.Errorf("expected no license for a non-Hub model, got %q", got)
+	}
+}
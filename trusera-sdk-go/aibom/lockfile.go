@@ -0,0 +1,256 @@
+package aibom
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// pyPackages lists Python AI packages by normalized PyPI name
+var pyPackages = []aiPackage{
+	{"openai", "openai"},
+	{"anthropic", "anthropic"},
+	{"google-generativeai", "google"},
+	{"google-genai", "google"},
+	{"google-cloud-aiplatform", "vertex-ai"},
+	{"cohere", "cohere"},
+	{"mistralai", "mistral"},
+	{"groq", "groq"},
+	{"ollama", "ollama"},
+	{"transformers", "huggingface"},
+	{"huggingface-hub", "huggingface"},
+	{"sentence-transformers", "huggingface"},
+	{"langchain", ""},
+	{"langchain-", ""},
+	{"langgraph", ""},
+	{"llama-index", ""},
+	{"llama-index-", ""},
+	{"litellm", ""},
+	{"crewai", ""},
+	{"pyautogen", ""},
+	{"autogen-agentchat", ""},
+	{"mcp", ""},
+}
+
+// npmPackages lists JavaScript AI packages by npm name; scopes match every
+// package in them
+var npmPackages = []aiPackage{
+	{"openai", "openai"},
+	{"@anthropic-ai/sdk", "anthropic"},
+	{"@google/generative-ai", "google"},
+	{"@google/genai", "google"},
+	{"@google-cloud/vertexai", "vertex-ai"},
+	{"@aws-sdk/client-bedrock-runtime", "bedrock"},
+	{"cohere-ai", "cohere"},
+	{"@mistralai/mistralai", "mistral"},
+	{"groq-sdk", "groq"},
+	{"ollama", "ollama"},
+	{"@huggingface/inference", "huggingface"},
+	{"@huggingface/transformers", "huggingface"},
+	{"ai", ""},
+	{"@ai-sdk", ""},
+	{"langchain", ""},
+	{"@langchain", ""},
+	{"llamaindex", ""},
+	{"@modelcontextprotocol/sdk", ""},
+}
+
+// isLockfile reports whether a file name is a lockfile ScanSource reads
+func isLockfile(name string) bool {
+	switch {
+	case name == "poetry.lock", name == "package-lock.json":
+		return true
+	default:
+		return strings.HasPrefix(name, "requirements") && strings.HasSuffix(name, ".txt")
+	}
+}
+
+// lockedPackage is a package and version listed in a lockfile
+type lockedPackage struct {
+	name, version string
+}
+
+// scanLockfile records the known AI packages a lockfile lists, once each
+func (b *Builder) scanLockfile(file string, at time.Time) error {
+	var (
+		ecosystem string
+		known     []aiPackage
+		listed    []lockedPackage
+		err       error
+	)
+	switch filepath.Base(file) {
+	case "poetry.lock":
+		ecosystem, known = "pypi", pyPackages
+		listed, err = readPoetryLock(file)
+	case "package-lock.json":
+		ecosystem, known = "npm", npmPackages
+		listed, err = readPackageLock(file)
+	default:
+		ecosystem, known = "pypi", pyPackages
+		listed, err = readRequirements(file)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range listed {
+		name := p.name
+		if ecosystem == "pypi" {
+			name = normalizePyName(name)
+		}
+		pkg, ok := matchPackage(known, name)
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		b.addPackage(ecosystem, name, pkg.provider, p.version, at)
+	}
+	return nil
+}
+
+var (
+	requirementName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*`)
+	pyNameSeparator = regexp.MustCompile(`[-_.]+`)
+)
+
+// normalizePyName normalizes a PyPI name as pip does, e.g. "LangChain_Core"
+// to "langchain-core"
+func normalizePyName(name string) string {
+	return strings.ToLower(pyNameSeparator.ReplaceAllString(name, "-"))
+}
+
+// readRequirements reads a pip requirements file. Versions are only taken
+// from exact "==" pins; options, URLs and editable installs are skipped.
+func readRequirements(file string) ([]lockedPackage, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var listed []lockedPackage
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line, _, _ = strings.Cut(line, ";")
+		line = strings.TrimSpace(line)
+		name := requirementName.FindString(line)
+		if name == "" {
+			continue
+		}
+
+		var version string
+		if _, pin, ok := strings.Cut(line[len(name):], "=="); ok {
+			version, _, _ = strings.Cut(strings.TrimSpace(pin), ",")
+			version = strings.TrimSpace(version)
+		}
+		listed = append(listed, lockedPackage{name, version})
+	}
+	return listed, scanner.Err()
+}
+
+// readPoetryLock reads the name and version of each [[package]] table in a
+// poetry.lock file
+func readPoetryLock(file string) ([]lockedPackage, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		listed    []lockedPackage
+		inPackage bool
+	)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inPackage = line == "[[package]]"
+			if inPackage {
+				listed = append(listed, lockedPackage{})
+			}
+			continue
+		}
+		if !inPackage {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		switch strings.TrimSpace(key) {
+		case "name":
+			listed[len(listed)-1].name = value
+		case "version":
+			listed[len(listed)-1].version = value
+		}
+	}
+	return listed, scanner.Err()
+}
+
+// readPackageLock reads the packages in an npm package-lock.json, from the
+// "packages" map of lockfile versions 2 and 3 or the "dependencies" tree of
+// version 1
+func readPackageLock(file string) ([]lockedPackage, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	type dependency struct {
+		Version      string                `json:"version"`
+		Dependencies map[string]dependency `json:"dependencies"`
+	}
+	var lock struct {
+		Packages     map[string]dependency `json:"packages"`
+		Dependencies map[string]dependency `json:"dependencies"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	// Sort shallow paths first so the hoisted version of a package wins
+	paths := make([]string, 0, len(lock.Packages))
+	for p := range lock.Packages {
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if len(paths[i]) != len(paths[j]) {
+			return len(paths[i]) < len(paths[j])
+		}
+		return paths[i] < paths[j]
+	})
+
+	var listed []lockedPackage
+	for _, p := range paths {
+		i := strings.LastIndex(p, "node_modules/")
+		if i < 0 {
+			// The root project, or a workspace outside node_modules
+			continue
+		}
+		listed = append(listed, lockedPackage{p[i+len("node_modules/"):], lock.Packages[p].Version})
+	}
+	if len(lock.Packages) == 0 {
+		var walk func(map[string]dependency)
+		walk = func(deps map[string]dependency) {
+			for name, dep := range deps {
+				listed = append(listed, lockedPackage{name, dep.Version})
+			}
+			for _, dep := range deps {
+				walk(dep.Dependencies)
+			}
+		}
+		walk(lock.Dependencies)
+	}
+	return listed, nil
+}
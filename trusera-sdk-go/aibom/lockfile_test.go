@@ -0,0 +1,102 @@
+package aibom
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScanSourceLockfiles(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"agent/requirements.txt": `# Agent dependencies
+-r requirements-base.txt
+OpenAI==1.40.2 ; python_version >= "3.9"
+langchain_community>=0.2
+requests==2.32.3
+git+https://github.com/example/tool.git
+`,
+		"agent/requirements-base.txt": "anthropic==0.34.0\n",
+		"worker/poetry.lock": `[[package]]
+name = "transformers"
+version = "4.44.0"
+description = "State-of-the-art Machine Learning for JAX, PyTorch and TensorFlow"
+
+[package.dependencies]
+huggingface-hub = ">=0.23.2,<1.0"
+
+[[package]]
+name = "huggingface-hub"
+version = "0.24.5"
+
+[[package]]
+name = "numpy"
+version = "1.26.4"
+
+[metadata]
+lock-version = "2.0"
+`,
+		"web/package-lock.json": `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "web"},
+    "node_modules/openai": {"version": "4.56.0"},
+    "node_modules/@langchain/openai": {"version": "0.2.7"},
+    "node_modules/@langchain/openai/node_modules/openai": {"version": "4.20.0"},
+    "node_modules/express": {"version": "4.19.2"}
+  }
+}`,
+		"legacy/package-lock.json": `{
+  "lockfileVersion": 1,
+  "dependencies": {
+    "@anthropic-ai/sdk": {"version": "0.20.0"}
+  }
+}`,
+		"web/node_modules/ai/package-lock.json": `{"packages": {"node_modules/ai": {"version": "3.0.0"}}}`,
+	})
+
+	b := NewBuilder("")
+	if err := b.ScanSource(dir, SourceOptions{}); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	bom := b.BOM()
+
+	want := map[string]Component{
+		"pypi:openai":              {Provider: "openai", Version: "1.40.2"},
+		"pypi:anthropic":           {Provider: "anthropic", Version: "0.34.0"},
+		"pypi:langchain-community": {},
+		"pypi:transformers":        {Provider: "huggingface", Version: "4.44.0"},
+		"pypi:huggingface-hub":     {Provider: "huggingface", Version: "0.24.5"},
+		"npm:openai":               {Provider: "openai", Version: "4.56.0"},
+		"npm:@langchain/openai":    {Version: "0.2.7"},
+		"npm:@anthropic-ai/sdk":    {Provider: "anthropic", Version: "0.20.0"},
+	}
+	if len(bom.Packages) != len(want) {
+		t.Fatalf("unexpected packages %+v", bom.Packages)
+	}
+	for _, p := range bom.Packages {
+		w, ok := want[componentName(p)]
+		if !ok || p.Provider != w.Provider || p.Version != w.Version || p.Count != 1 {
+			t.Errorf("package %s = %+v, want %+v", componentName(p), p, w)
+		}
+	}
+	if len(bom.Providers) != 3 {
+		t.Errorf("expected anthropic, huggingface and openai providers, got %+v", bom.Providers)
+	}
+
+	bad := writeTree(t, map[string]string{"package-lock.json": "{"})
+	err := NewBuilder("").ScanSource(bad, SourceOptions{})
+	if err == nil || !strings.Contains(err.Error(), "package-lock.json") {
+		t.Errorf("expected an error naming the lockfile, got %v", err)
+	}
+}
+
+func TestNormalizePyName(t *testing.T) {
+	for in, want := range map[string]string{
+		"LangChain_Core":  "langchain-core",
+		"llama.index":     "llama-index",
+		"huggingface-hub": "huggingface-hub",
+	} {
+		if got := normalizePyName(in); got != want {
+			t.Errorf("normalizePyName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
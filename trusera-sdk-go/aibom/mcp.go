@@ -0,0 +1,153 @@
+package aibom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MCPServer is a Model Context Protocol server an agent is configured to use
+type MCPServer struct {
+	Name      string
+	Transport string // "stdio", "http" or "sse"
+	Command   string // Set for stdio servers
+	Args      []string
+	URL       string // Set for http and sse servers
+	// Tools lists the tools the configuration declares or pre-approves
+	Tools []string
+}
+
+// mcpServerConfig is one server entry in an MCP client configuration
+type mcpServerConfig struct {
+	Type        string   `json:"type"`
+	Transport   string   `json:"transport"`
+	Command     string   `json:"command"`
+	Args        []string `json:"args"`
+	URL         string   `json:"url"`
+	ServerURL   string   `json:"serverUrl"`
+	Tools       []string `json:"tools"`
+	AlwaysAllow []string `json:"alwaysAllow"`
+	AutoApprove []string `json:"autoApprove"`
+}
+
+// LoadMCPConfig reads the servers in an MCP client configuration: a
+// claude_desktop_config.json, Cursor or project .mcp.json style file with an
+// "mcpServers" object, or a VS Code mcp.json with a "servers" object. Tools
+// are taken from "tools" lists and from the pre-approved "alwaysAllow" and
+// "autoApprove" lists some clients use. Environment variables are not read,
+// as they usually hold credentials. Servers are sorted by name.
+func LoadMCPConfig(r io.Reader) ([]MCPServer, error) {
+	var config struct {
+		MCPServers map[string]mcpServerConfig `json:"mcpServers"`
+		Servers    map[string]mcpServerConfig `json:"servers"`
+	}
+	if err := json.NewDecoder(r).Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to parse MCP config: %w", err)
+	}
+
+	entries := config.MCPServers
+	if len(entries) == 0 {
+		entries = config.Servers
+	}
+	servers := make([]MCPServer, 0, len(entries))
+	for name, c := range entries {
+		s := MCPServer{Name: name, Command: c.Command, Args: c.Args, URL: c.URL}
+		if s.URL == "" {
+			s.URL = c.ServerURL
+		}
+		s.Transport = mcpTransport(c.Type, c.Transport, s)
+		for _, list := range [][]string{c.Tools, c.AlwaysAllow, c.AutoApprove} {
+			for _, tool := range list {
+				if !contains(s.Tools, tool) {
+					s.Tools = append(s.Tools, tool)
+				}
+			}
+		}
+		sort.Strings(s.Tools)
+		servers = append(servers, s)
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Name < servers[j].Name })
+	return servers, nil
+}
+
+// mcpTransport normalizes a declared transport, or infers it from the
+// server's URL or command
+func mcpTransport(typ, transport string, s MCPServer) string {
+	if typ == "" {
+		typ = transport
+	}
+	switch strings.ToLower(strings.ReplaceAll(typ, "-", "")) {
+	case "stdio":
+		return "stdio"
+	case "sse":
+		return "sse"
+	case "http", "streamablehttp":
+		return "http"
+	}
+	switch {
+	case s.URL != "" && strings.HasSuffix(strings.TrimRight(s.URL, "/"), "/sse"):
+		return "sse"
+	case s.URL != "":
+		return "http"
+	case s.Command != "":
+		return "stdio"
+	}
+	return ""
+}
+
+// uri identifies where a server runs: its URL, or its command and the first
+// argument that is not a flag, typically the package or script it runs.
+// Further arguments are left out as they may hold credentials.
+func (s MCPServer) uri() string {
+	if s.URL != "" {
+		return s.URL
+	}
+	for _, arg := range s.Args {
+		if !strings.HasPrefix(arg, "-") {
+			return s.Command + " " + arg
+		}
+	}
+	return s.Command
+}
+
+// RecordMCPServer declares an MCP server and its tools. Declared components
+// have a zero count until they are used: requests to the server's host and
+// calls of its tools, recorded afterwards, are counted as uses of the server
+// and linked to it in the graph.
+func (b *Builder) RecordMCPServer(s MCPServer) {
+	if s.Name == "" {
+		return
+	}
+	at := time.Now().UTC()
+	b.declare(kindMCPServer, s.Name, Component{Name: s.Name, Transport: s.Transport, URI: s.uri()}, at)
+	for _, tool := range s.Tools {
+		b.declare(kindTool, tool, Component{Name: tool, Provider: s.Name}, at)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if u, err := url.Parse(s.URL); err == nil && u.Hostname() != "" {
+		b.mcpHosts[strings.ToLower(u.Hostname())] = s.Name
+	}
+	for _, tool := range s.Tools {
+		b.mcpTools[tool] = s.Name
+	}
+}
+
+// mcpServer returns the declared server serving host or providing tool
+func (b *Builder) mcpServer(host, tool string) (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if tool != "" {
+		server, ok := b.mcpTools[tool]
+		return server, ok
+	}
+	server, ok := b.mcpHosts[strings.ToLower(host)]
+	return server, ok
+}
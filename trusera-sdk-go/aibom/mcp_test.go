@@ -0,0 +1,95 @@
+package aibom
+
+import (
+	"strings"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+func TestLoadMCPConfig(t *testing.T) {
+	servers, err := LoadMCPConfig(strings.NewReader(`{
+  "mcpServers": {
+    "filesystem": {
+      "command": "npx",
+      "args": ["-y", "@modelcontextprotocol/server-filesystem", "/Users/me/Desktop"],
+      "env": {"TOKEN": "secret"},
+      "alwaysAllow": ["read_file", "list_directory"]
+    },
+    "search": {"url": "https://mcp.search.example/sse"},
+    "tickets": {"type": "streamable-http", "url": "https://mcp.tickets.example/mcp", "tools": ["create_ticket"], "autoApprove": ["create_ticket"]}
+  }
+}`))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(servers) != 3 {
+		t.Fatalf("expected 3 servers, got %+v", servers)
+	}
+
+	fs := servers[0]
+	if fs.Name != "filesystem" || fs.Transport != "stdio" || fs.uri() != "npx @modelcontextprotocol/server-filesystem" {
+		t.Errorf("unexpected stdio server %+v (uri %q)", fs, fs.uri())
+	}
+	if len(fs.Tools) != 2 || fs.Tools[0] != "list_directory" {
+		t.Errorf("expected sorted pre-approved tools, got %v", fs.Tools)
+	}
+	if servers[1].Transport != "sse" || servers[1].uri() != "https://mcp.search.example/sse" {
+		t.Errorf("unexpected sse server %+v", servers[1])
+	}
+	if servers[2].Transport != "http" || len(servers[2].Tools) != 1 {
+		t.Errorf("unexpected http server %+v", servers[2])
+	}
+
+	vscode, err := LoadMCPConfig(strings.NewReader(`{"servers": {"github": {"type": "http", "url": "https://api.githubcopilot.com/mcp/"}}, "inputs": []}`))
+	if err != nil || len(vscode) != 1 || vscode[0].Name != "github" || vscode[0].Transport != "http" {
+		t.Errorf("unexpected VS Code servers %+v, %v", vscode, err)
+	}
+
+	if _, err := LoadMCPConfig(strings.NewReader(`{"mcpServers": [`)); err == nil {
+		t.Error("expected an error for malformed JSON")
+	}
+}
+
+func TestBuilderMCPServers(t *testing.T) {
+	b := NewBuilder("agent-1")
+	b.RecordMCPServer(MCPServer{
+		Name: "filesystem", Transport: "stdio", Command: "npx",
+		Args: []string{"-y", "@modelcontextprotocol/server-filesystem"}, Tools: []string{"read_file"},
+	})
+	b.RecordMCPServer(MCPServer{Name: "tickets", Transport: "http", URL: "https://MCP.tickets.example/mcp"})
+	b.RecordMCPServer(MCPServer{Name: "unused", Transport: "stdio", Command: "uvx"})
+
+	b.Add(trusera.Event{ID: "t1", Type: trusera.EventToolCall, Name: "read_file"})
+	b.Add(trusera.Event{ID: "t2", Type: trusera.EventToolCall, Name: "read_file"})
+	b.AddDecision(trusera.DecisionEvent{Hostname: "mcp.tickets.example"})
+
+	bom := b.BOM()
+	counts := map[string]int{}
+	for _, s := range bom.MCPServers {
+		counts[s.Name] = s.Count
+	}
+	if counts["filesystem"] != 2 || counts["tickets"] != 1 || counts["unused"] != 0 || len(counts) != 3 {
+		t.Errorf("unexpected server counts %v", counts)
+	}
+	if bom.MCPServers[0].URI != "npx @modelcontextprotocol/server-filesystem" || bom.MCPServers[0].Transport != "stdio" {
+		t.Errorf("unexpected server %+v", bom.MCPServers[0])
+	}
+	if len(bom.Tools) != 1 || bom.Tools[0].Provider != "filesystem" || bom.Tools[0].Count != 2 {
+		t.Errorf("expected the declared tool to count its calls, got %+v", bom.Tools)
+	}
+
+	edges := map[string]int{}
+	for _, e := range b.Graph().Edges {
+		edges[e.From+" -> "+e.To] = e.Count
+	}
+	for edge, want := range map[string]int{
+		"tool:read_file -> mcp_server:filesystem":           2,
+		"agent:agent-1 -> mcp_server:tickets":               1,
+		"mcp_server:tickets -> service:mcp.tickets.example": 1,
+	} {
+		if edges[edge] != want {
+			t.Errorf("edge %s = %d, want %d (edges %v)", edge, edges[edge], want, edges)
+		}
+	}
+}
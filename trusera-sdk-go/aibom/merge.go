@@ -0,0 +1,120 @@
+package aibom
+
+import (
+	"sort"
+	"time"
+)
+
+// Merge unions the components of several BOMs into an org-level BOM. Each
+// component lists the agents that used it in Agents, taken from the source
+// BOMs' AgentID or, for BOMs that were already merged, their Agents. Counts
+// are summed and first- and last-seen times widened. Metadata such as
+// licenses and advisories is kept from the first BOM that has it.
+func Merge(boms ...*BOM) *BOM {
+	merged := &BOM{GeneratedAt: time.Now().UTC()}
+	sections := []struct {
+		dst *[]Component
+		src func(*BOM) []Component
+	}{
+		{&merged.Providers, func(b *BOM) []Component { return b.Providers }},
+		{&merged.Models, func(b *BOM) []Component { return b.Models }},
+		{&merged.Tools, func(b *BOM) []Component { return b.Tools }},
+		{&merged.APIs, func(b *BOM) []Component { return b.APIs }},
+		{&merged.Datasets, func(b *BOM) []Component { return b.Datasets }},
+		{&merged.Packages, func(b *BOM) []Component { return b.Packages }},
+		{&merged.MCPServers, func(b *BOM) []Component { return b.MCPServers }},
+		{&merged.Prompts, func(b *BOM) []Component { return b.Prompts }},
+	}
+
+	for _, s := range sections {
+		byKey := make(map[string]*Component)
+		for _, bom := range boms {
+			if bom == nil {
+				continue
+			}
+			for _, c := range s.src(bom) {
+				key := componentName(c)
+				m, ok := byKey[key]
+				if !ok {
+					m = &Component{Name: c.Name, Provider: c.Provider, Ecosystem: c.Ecosystem}
+					byKey[key] = m
+				}
+				mergeComponent(m, c, bom.AgentID)
+			}
+		}
+
+		*s.dst = make([]Component, 0, len(byKey))
+		for _, c := range byKey {
+			sort.Strings(c.Agents)
+			*s.dst = append(*s.dst, *c)
+		}
+		sortComponents(*s.dst)
+	}
+	return merged
+}
+
+// mergeComponent folds c, used by agentID, into m
+func mergeComponent(m *Component, c Component, agentID string) {
+	m.Count += c.Count
+	if !c.FirstSeen.IsZero() && (m.FirstSeen.IsZero() || c.FirstSeen.Before(m.FirstSeen)) {
+		m.FirstSeen = c.FirstSeen
+	}
+	if c.LastSeen.After(m.LastSeen) {
+		m.LastSeen = c.LastSeen
+	}
+
+	agents := c.Agents
+	if agentID != "" {
+		agents = append(agents[:len(agents):len(agents)], agentID)
+	}
+	for _, a := range agents {
+		if !contains(m.Agents, a) {
+			m.Agents = append(m.Agents, a)
+		}
+	}
+
+	if m.Version == "" {
+		m.Version = c.Version
+	}
+	if m.URI == "" {
+		m.URI = c.URI
+	}
+	if m.Hash == "" {
+		m.Hash = c.Hash
+	}
+	if m.Transport == "" {
+		m.Transport = c.Transport
+	}
+	if m.Variables == nil {
+		m.Variables = c.Variables
+	}
+	if m.License == "" {
+		m.License = c.License
+	}
+	if m.Info == nil {
+		m.Info = c.Info
+	}
+	for _, a := range c.Advisories {
+		if !hasAdvisory(m.Advisories, a.ID) {
+			m.Advisories = append(m.Advisories, a)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAdvisory(list []Advisory, id string) bool {
+	for _, a := range list {
+		if a.ID == id {
+			return true
+		}
+	}
+	return false
+}
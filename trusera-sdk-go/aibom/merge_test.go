@@ -0,0 +1,62 @@
+package aibom
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	t1 := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	t2 := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	research := &BOM{
+		AgentID: "research",
+		Models: []Component{
+			{Name: "gpt-4o", Provider: "openai", Count: 3, FirstSeen: t2, LastSeen: t2},
+			{Name: "llama3.1", Provider: "ollama", Count: 1, License: "llama3.1"},
+		},
+		Tools: []Component{{Name: "web_search", Count: 2}},
+	}
+	support := &BOM{
+		AgentID: "support",
+		Models: []Component{
+			{Name: "gpt-4o", Provider: "openai", Count: 5, FirstSeen: t1, LastSeen: t1,
+				Advisories: []Advisory{{ID: "ADV-1"}}},
+			{Name: "gpt-4o", Provider: "azure-openai", Count: 1},
+		},
+	}
+
+	merged := Merge(research, nil, support)
+	if merged.AgentID != "" {
+		t.Errorf("expected no agent ID on a merged BOM, got %q", merged.AgentID)
+	}
+	if len(merged.Models) != 3 {
+		t.Fatalf("expected models kept apart per provider, got %+v", merged.Models)
+	}
+
+	m := merged.Models[1]
+	if m.Provider != "openai" || m.Count != 8 || !m.FirstSeen.Equal(t1) || !m.LastSeen.Equal(t2) {
+		t.Errorf("unexpected merged model %+v", m)
+	}
+	if len(m.Agents) != 2 || m.Agents[0] != "research" || m.Agents[1] != "support" {
+		t.Errorf("expected both agents as provenance, got %v", m.Agents)
+	}
+	if len(m.Advisories) != 1 {
+		t.Errorf("expected advisories to carry over, got %+v", m.Advisories)
+	}
+	if l := merged.Models[2]; l.License != "llama3.1" || len(l.Agents) != 1 {
+		t.Errorf("unexpected llama model %+v", l)
+	}
+	if len(merged.Tools) != 1 || merged.Tools[0].Agents[0] != "research" {
+		t.Errorf("unexpected tools %+v", merged.Tools)
+	}
+
+	// Merging merged BOMs keeps their provenance
+	again := Merge(merged, &BOM{AgentID: "billing", Models: []Component{{Name: "gpt-4o", Provider: "openai", Count: 1}}})
+	if agents := again.Models[1].Agents; len(agents) != 3 || agents[0] != "billing" {
+		t.Errorf("expected provenance across merges, got %v", agents)
+	}
+	if again.Models[1].Count != 9 {
+		t.Errorf("expected counts to keep adding up, got %d", again.Models[1].Count)
+	}
+}
@@ -0,0 +1,205 @@
+package aibom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// promptPrefixBytes is how much of a prompt identifies its template when
+	// detecting repeated prefixes
+	promptPrefixBytes = 256
+	// minPromptPrefix is the shortest prompt, or template text before the
+	// first variable, that is matched, so short greetings are not templates
+	minPromptPrefix = 20
+	// maxPromptCandidates bounds the prefixes seen once that are remembered
+	maxPromptCandidates = 10000
+)
+
+// PromptTemplate is a prompt an agent fills in and sends to models
+type PromptTemplate struct {
+	Name     string
+	Template string
+	// Hash defaults to the SHA-256 of Template, as "sha256:<hex>"
+	Hash string
+	// Variables defaults to the placeholders in Template: {{name}},
+	// {{ .Name }} or {name}
+	Variables []string
+}
+
+// promptPlaceholder matches Mustache, Jinja and Go template variables, and
+// Python format fields as used by LangChain
+var promptPlaceholder = regexp.MustCompile(`\{\{-?\s*\.?([A-Za-z_][A-Za-z0-9_.]*)\s*-?\}\}|\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// RecordPrompt declares a prompt template, listed under prompts by name with
+// its hash and variables and a zero count. Prompts passed to AddPrompt, or
+// captured in logs read by ReadEventLog, that start with the template's text
+// up to its first variable count as uses. LLM call events naming the
+// template in a "prompt_template" payload field count too.
+func (b *Builder) RecordPrompt(p PromptTemplate) {
+	if p.Name == "" {
+		return
+	}
+	if p.Hash == "" {
+		p.Hash = hashText(p.Template)
+	}
+	if p.Variables == nil {
+		p.Variables = templateVariables(p.Template)
+	}
+	b.declare(kindPrompt, p.Name, Component{Name: p.Name, Hash: p.Hash, Variables: p.Variables}, time.Now().UTC())
+
+	prefix := p.Template
+	if loc := promptPlaceholder.FindStringIndex(prefix); loc != nil {
+		prefix = prefix[:loc[0]]
+	}
+	if len(prefix) < minPromptPrefix {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.promptPrefixes[p.Name] = prefix
+}
+
+// AddPrompt records one use of a prompt by its text. Prompts matching a
+// template declared with RecordPrompt count as uses of it. Other prompts
+// whose first 256 bytes were seen before are listed as detected templates,
+// named "prefix:" and the start of their hash, so prompts are inventoried
+// even when templates are not declared. Only hashes of prompt text are kept.
+func (b *Builder) AddPrompt(text string) {
+	b.addPrompt(text, time.Now().UTC())
+}
+
+// addPrompt matches text against declared templates, then repeated prefixes
+func (b *Builder) addPrompt(text string, at time.Time) {
+	text = strings.TrimSpace(text)
+	if len(text) < minPromptPrefix {
+		return
+	}
+
+	b.mu.Lock()
+	name := ""
+	for n, prefix := range b.promptPrefixes {
+		// The longest matching prefix is the most specific template
+		if strings.HasPrefix(text, prefix) && len(prefix) > len(b.promptPrefixes[name]) {
+			name = n
+		}
+	}
+	b.mu.Unlock()
+	if name != "" {
+		b.record(kindPrompt, name, "", at)
+		return
+	}
+
+	prefix := text
+	if len(prefix) > promptPrefixBytes {
+		prefix = prefix[:promptPrefixBytes]
+	}
+	hash := hashText(prefix)
+	key := "prefix:" + strings.TrimPrefix(hash, "sha256:")[:12]
+
+	b.mu.Lock()
+	if _, ok := b.components[kindPrompt][key]; !ok {
+		first, seen := b.promptCandidates[key]
+		if !seen {
+			if len(b.promptCandidates) < maxPromptCandidates {
+				b.promptCandidates[key] = at
+			}
+			b.mu.Unlock()
+			return
+		}
+		delete(b.promptCandidates, key)
+		b.components[kindPrompt][key] = &Component{Name: key, Hash: hash, Count: 1, FirstSeen: first, LastSeen: first}
+	}
+	b.mu.Unlock()
+	b.record(kindPrompt, key, "", at)
+}
+
+// templateVariables lists the distinct placeholders in a template in order
+func templateVariables(template string) []string {
+	var vars []string
+	for _, m := range promptPlaceholder.FindAllStringSubmatch(template, -1) {
+		v := m[1]
+		if v == "" {
+			v = m[2]
+		}
+		if !contains(vars, v) {
+			vars = append(vars, v)
+		}
+	}
+	return vars
+}
+
+// hashText returns the SHA-256 of s as "sha256:<hex>"
+func hashText(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// promptFields are the request body fields holding prompt text in OpenAI-,
+// Anthropic- and Gemini-style requests
+var promptFields = map[string]bool{"system": true, "prompt": true, "content": true, "text": true, "input": true}
+
+// bodyPrompt returns the first prompt text in a captured request body: the
+// system prompt or first message, which is where templates usually start.
+// Bodies may be truncated previews, so the JSON is read token by token and
+// a prompt cut off by truncation is kept.
+func bodyPrompt(body string) string {
+	dec := json.NewDecoder(strings.NewReader(body))
+	var (
+		key       string
+		expectKey bool
+		objects   []bool // Whether each open container is an object
+	)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if !expectKey && promptFields[key] {
+				return truncatedString(body[dec.InputOffset():])
+			}
+			return ""
+		}
+		switch t := tok.(type) {
+		case json.Delim:
+			if t == '{' || t == '[' {
+				objects = append(objects, t == '{')
+			} else if len(objects) > 0 {
+				objects = objects[:len(objects)-1]
+			}
+		case string:
+			if expectKey {
+				key = t
+				expectKey = false
+				continue
+			}
+			if promptFields[key] && len(strings.TrimSpace(t)) >= minPromptPrefix {
+				return t
+			}
+		}
+		expectKey = len(objects) > 0 && objects[len(objects)-1]
+		key = ""
+	}
+}
+
+// truncatedString decodes the JSON string value starting rest, such as
+// `: "You are a`, whose end was cut off
+func truncatedString(rest string) string {
+	rest = strings.TrimLeft(rest, " \t\r\n:")
+	s, ok := strings.CutPrefix(rest, `"`)
+	if !ok {
+		return ""
+	}
+	// Drop a cut escape sequence, which is at most 6 bytes ("\u00e9")
+	for cut := len(s); cut >= 0 && cut >= len(s)-6; cut-- {
+		var out string
+		if json.Unmarshal([]byte(`"`+s[:cut]+`"`), &out) == nil {
+			return out
+		}
+	}
+	return ""
+}
@@ -0,0 +1,97 @@
+package aibom
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+const supportTemplate = "You are a support agent for {{company}}. Answer using {{ .Docs }} and cite {source}."
+
+func TestRecordPrompt(t *testing.T) {
+	b := NewBuilder("")
+	b.RecordPrompt(PromptTemplate{Name: "support", Template: supportTemplate})
+	b.RecordPrompt(PromptTemplate{Name: "unused", Template: "Summarize {text}", Hash: "sha256:abc"})
+
+	b.AddPrompt("You are a support agent for Acme. Answer using the FAQ and cite page 3.")
+	b.Add(trusera.NewEvent(trusera.EventLLMCall, "gpt-4o").WithPayload("prompt_template", "support"))
+
+	prompts := b.BOM().Prompts
+	if len(prompts) != 2 {
+		t.Fatalf("unexpected prompts %+v", prompts)
+	}
+	support := prompts[0]
+	if support.Name != "support" || support.Count != 2 || support.Hash != hashText(supportTemplate) {
+		t.Errorf("unexpected template %+v", support)
+	}
+	if !reflect.DeepEqual(support.Variables, []string{"company", "Docs", "source"}) {
+		t.Errorf("unexpected variables %v", support.Variables)
+	}
+	if prompts[1].Count != 0 || prompts[1].Hash != "sha256:abc" {
+		t.Errorf("expected the unused template to be declared as given, got %+v", prompts[1])
+	}
+}
+
+func TestAddPromptDetectsRepeatedPrefixes(t *testing.T) {
+	b := NewBuilder("")
+	system := "You are a careful research assistant. " + strings.Repeat("Follow the style guide. ", 20)
+
+	b.AddPrompt(system + "Question one")
+	if len(b.BOM().Prompts) != 0 {
+		t.Fatal("a prompt seen once should not be listed")
+	}
+	b.AddPrompt(system + "Question two")
+	b.AddPrompt(system + "Question three")
+	b.AddPrompt("Hi")
+	b.AddPrompt("A one-off prompt that is long enough to be considered")
+
+	prompts := b.BOM().Prompts
+	if len(prompts) != 1 {
+		t.Fatalf("unexpected prompts %+v", prompts)
+	}
+	p := prompts[0]
+	if !strings.HasPrefix(p.Name, "prefix:") || p.Count != 3 || p.Hash != hashText(system[:promptPrefixBytes]) {
+		t.Errorf("unexpected detected prompt %+v", p)
+	}
+	if strings.Contains(p.Name+p.Hash, "research") {
+		t.Error("prompt text should not be kept")
+	}
+}
+
+func TestReadEventLogPrompts(t *testing.T) {
+	log := `{"hostname":"api.openai.com","llm_provider":"openai","enforcement_action":"allowed","request_body":{"preview":"{\"model\":\"gpt-4o\",\"messages\":[{\"role\":\"system\",\"content\":\"You are a support agent for Acme. Answer using the FAQ\"},{\"role\":\"user\",\"content\":\"hi\"}]}"}}
+{"hostname":"api.anthropic.com","llm_provider":"anthropic","enforcement_action":"allowed","request_body":{"preview":"{\"model\":\"claude-sonnet-4-5\",\"system\":\"You are a support agent for Globex. Answer using the han","truncated":true}}
+{"hostname":"example.com","enforcement_action":"allowed","request_body":{"preview":"{\"content\":\"You are a support agent for Initech. Answer\"}"}}
+`
+	b := NewBuilder("")
+	b.RecordPrompt(PromptTemplate{Name: "support", Template: supportTemplate})
+	if err := b.ReadEventLog(strings.NewReader(log)); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	prompts := b.BOM().Prompts
+	if len(prompts) != 1 || prompts[0].Count != 2 {
+		t.Errorf("expected two LLM calls to use the template, got %+v", prompts)
+	}
+}
+
+func TestBodyPrompt(t *testing.T) {
+	long := "You are a helpful assistant that answers briefly."
+	for _, tt := range []struct {
+		name, body, want string
+	}{
+		{"openai", `{"model":"gpt-4o","messages":[{"role":"system","content":"` + long + `"}]}`, long},
+		{"anthropic blocks", `{"system":[{"type":"text","text":"` + long + `"}],"messages":[]}`, long},
+		{"gemini", `{"contents":[{"role":"user","parts":[{"text":"` + long + `"}]}]}`, long},
+		{"truncated", `{"messages":[{"role":"system","content":"You are a helpful assistant that ans`, "You are a helpful assistant that ans"},
+		{"truncated escape", `{"prompt":"You are a helpful assistant\nthat answers \u00`, "You are a helpful assistant\nthat answers "},
+		{"short values skipped", `{"messages":[{"content":"hi"},{"content":"` + long + `"}]}`, long},
+		{"keys are not prompts", `{"content":{"system":1}}`, ""},
+		{"not json", `prompt=hello`, ""},
+	} {
+		if got := bodyPrompt(tt.body); got != tt.want {
+			t.Errorf("%s: bodyPrompt = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
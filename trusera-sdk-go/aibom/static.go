@@ -0,0 +1,186 @@
+package aibom
+
+import (
+	"bufio"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// aiPackage is a known AI SDK or framework
+type aiPackage struct {
+	path     string // Go path, npm name or normalized PyPI name
+	provider string // Empty for provider-neutral frameworks
+}
+
+// goPackages lists Go AI SDKs by module or package path
+var goPackages = []aiPackage{
+	{"github.com/openai/openai-go", "openai"},
+	{"github.com/sashabaranov/go-openai", "openai"},
+	{"github.com/anthropics/anthropic-sdk-go", "anthropic"},
+	{"github.com/liushuangls/go-anthropic", "anthropic"},
+	{"github.com/aws/aws-sdk-go-v2/service/bedrockruntime", "bedrock"},
+	{"github.com/aws/aws-sdk-go/service/bedrockruntime", "bedrock"},
+	{"github.com/google/generative-ai-go", "google"},
+	{"google.golang.org/genai", "google"},
+	{"cloud.google.com/go/vertexai", "vertex-ai"},
+	{"github.com/cohere-ai/cohere-go", "cohere"},
+	{"github.com/ollama/ollama/api", "ollama"},
+	{"github.com/tmc/langchaingo", ""},
+	{"github.com/firebase/genkit/go", ""},
+	{"github.com/cloudwego/eino", ""},
+	{"github.com/mark3labs/mcp-go", ""},
+	{"github.com/modelcontextprotocol/go-sdk", ""},
+}
+
+// SourceOptions configures ScanSource
+type SourceOptions struct {
+	// Vendor also scans Go code under vendor directories
+	Vendor bool
+}
+
+// ScanSource seeds the BOM from source code under dir before any runtime
+// traffic exists. Go files importing known AI SDKs (openai-go, go-openai,
+// anthropic-sdk-go, langchaingo, the Bedrock runtime and others) are
+// recorded as packages, counted per importing file and versioned from
+// go.mod. Known AI packages pinned in requirements*.txt, poetry.lock and
+// package-lock.json files (openai, anthropic, transformers, langchain and
+// others) are recorded too, counted per lockfile. The packages' providers
+// are recorded as providers. Go test files and testdata are skipped.
+func (b *Builder) ScanSource(dir string, opts SourceOptions) error {
+	at := time.Now().UTC()
+	versions := make(map[string]string)
+	fset := token.NewFileSet()
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if p != dir && skipDir(name, opts) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch {
+		case name == "go.mod":
+			return readGoMod(p, versions)
+		case isLockfile(name):
+			return b.scanLockfile(p, at)
+		case strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go"):
+			f, err := parser.ParseFile(fset, p, nil, parser.ImportsOnly)
+			if err != nil {
+				// Unparsable files, e.g. templates, are not source we can judge
+				return nil
+			}
+			seen := make(map[string]bool)
+			for _, imp := range f.Imports {
+				importPath, _ := strconv.Unquote(imp.Path.Value)
+				if pkg, ok := matchPackage(goPackages, importPath); ok && !seen[pkg.path] {
+					seen[pkg.path] = true
+					b.addPackage("go", pkg.path, pkg.provider, "", at)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan %s: %w", dir, err)
+	}
+
+	for _, pkg := range goPackages {
+		if v := moduleVersion(versions, pkg.path); v != "" {
+			b.update(kindPackage, "go:"+pkg.path, func(c *Component) { c.Version = v })
+		}
+	}
+	return nil
+}
+
+// skipDir reports whether a directory holds no source of the module itself
+func skipDir(name string, opts SourceOptions) bool {
+	switch {
+	case name == "vendor":
+		return !opts.Vendor
+	case name == "testdata", name == "node_modules", name == "venv", name == "site-packages":
+		return true
+	default:
+		return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+	}
+}
+
+// matchPackage finds the known package that importPath is or belongs to.
+// Known paths ending in "-" match a family of packages by prefix.
+func matchPackage(known []aiPackage, importPath string) (aiPackage, bool) {
+	for _, pkg := range known {
+		family := strings.HasSuffix(pkg.path, "-") && strings.HasPrefix(importPath, pkg.path)
+		if family || importPath == pkg.path || strings.HasPrefix(importPath, pkg.path+"/") {
+			return pkg, true
+		}
+	}
+	return aiPackage{}, false
+}
+
+// addPackage records a package of an ecosystem and its provider
+func (b *Builder) addPackage(ecosystem, name, provider, version string, at time.Time) {
+	if provider != "" {
+		b.record(kindProvider, provider, "", at)
+	}
+	key := ecosystem + ":" + name
+	b.recordKey(kindPackage, key, Component{Name: name, Provider: provider, Ecosystem: ecosystem}, at)
+	if version != "" {
+		b.update(kindPackage, key, func(c *Component) { c.Version = version })
+	}
+}
+
+// readGoMod adds the required module versions in a go.mod file to versions
+func readGoMod(file string, versions map[string]string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	inRequire := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case fields[0] == "require" && len(fields) > 1 && fields[1] == "(":
+			inRequire = true
+		case fields[0] == ")":
+			inRequire = false
+		case fields[0] == "require" && len(fields) >= 3:
+			versions[fields[1]] = fields[2]
+		case inRequire && len(fields) >= 2:
+			versions[fields[0]] = fields[1]
+		}
+	}
+	return scanner.Err()
+}
+
+// moduleVersion returns the version of the module providing pkgPath, trying
+// pkgPath itself, its parents and their major version suffixes
+func moduleVersion(versions map[string]string, pkgPath string) string {
+	for p := pkgPath; p != "." && p != "/"; p = path.Dir(p) {
+		if v, ok := versions[p]; ok {
+			return v
+		}
+		for major := 2; major <= 9; major++ {
+			if v, ok := versions[p+"/v"+strconv.Itoa(major)]; ok {
+				return v
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package aibom
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTree creates files under a temporary directory
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestScanSourceGo(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"go.mod": `module example.com/agent
+
+go 1.22
+
+require (
+	github.com/openai/openai-go/v2 v2.1.0 // indirect
+	github.com/tmc/langchaingo v0.1.13
+)
+
+require github.com/aws/aws-sdk-go-v2/service/bedrockruntime v1.20.0
+`,
+		"main.go": `package main
+
+import (
+	"github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/option"
+	"github.com/tmc/langchaingo/llms"
+)
+`,
+		"chat/chat.go": `package chat
+
+import "github.com/openai/openai-go/v2"
+`,
+		"chat/chat_test.go": `package chat
+
+import _ "github.com/anthropics/anthropic-sdk-go"
+`,
+		"bedrock/invoke.go": `package bedrock
+
+import brt "github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+`,
+		"vendor/github.com/x/y/y.go": `package y
+
+import _ "github.com/sashabaranov/go-openai"
+`,
+		"testdata/broken.go": `package broken import`,
+		"templates/gen.go":   `{{ .Package }}`,
+	})
+
+	b := NewBuilder("")
+	if err := b.ScanSource(dir, SourceOptions{}); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	bom := b.BOM()
+
+	want := map[string]Component{
+		"github.com/aws/aws-sdk-go-v2/service/bedrockruntime": {Provider: "bedrock", Version: "v1.20.0", Count: 1},
+		"github.com/openai/openai-go":                         {Provider: "openai", Version: "v2.1.0", Count: 2},
+		"github.com/tmc/langchaingo":                          {Version: "v0.1.13", Count: 1},
+	}
+	if len(bom.Packages) != len(want) {
+		t.Fatalf("unexpected packages %+v", bom.Packages)
+	}
+	for _, p := range bom.Packages {
+		w := want[p.Name]
+		if p.Provider != w.Provider || p.Version != w.Version || p.Count != w.Count {
+			t.Errorf("package %s = %+v, want %+v", p.Name, p, w)
+		}
+	}
+	if len(bom.Providers) != 2 {
+		t.Errorf("expected bedrock and openai providers, got %+v", bom.Providers)
+	}
+
+	b = NewBuilder("")
+	if err := b.ScanSource(dir, SourceOptions{Vendor: true}); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(b.BOM().Packages) != 4 {
+		t.Errorf("expected the vendored SDK to be found, got %+v", b.BOM().Packages)
+	}
+
+	if err := NewBuilder("").ScanSource(filepath.Join(dir, "missing"), SourceOptions{}); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
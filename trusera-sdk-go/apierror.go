@@ -0,0 +1,99 @@
+package trusera
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBody bounds how much of an error response is read
+const maxErrorBody = 64 << 10
+
+// APIError is a 4xx or 5xx response from the Trusera API. Use errors.As to
+// tell auth failures (401, 403) from validation errors (400, 422) and rate
+// limits (429).
+type APIError struct {
+	StatusCode int
+	// Code is a machine-readable error code, e.g. "invalid_event", if the API sent one
+	Code    string
+	Message string
+	// RequestID identifies the request when contacting support
+	RequestID string
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "API returned status %d", e.StatusCode)
+	if e.Code != "" {
+		b.WriteString(": " + e.Code)
+	}
+	if e.Message != "" {
+		b.WriteString(": " + e.Message)
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " (request %s)", e.RequestID)
+	}
+	return b.String()
+}
+
+// newAPIError reads an error response. The body may be {"code", "message"},
+// the same nested under "error", or "error" as a plain string; anything else
+// is kept as the message.
+func newAPIError(resp *http.Response) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-ID"),
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	data = []byte(strings.TrimSpace(string(data)))
+	if len(data) == 0 {
+		return e
+	}
+
+	type errorBody struct {
+		Code      string `json:"code"`
+		Message   string `json:"message"`
+		RequestID string `json:"request_id"`
+	}
+	var body struct {
+		errorBody
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(data, &body) != nil {
+		e.Message = string(data)
+		return e
+	}
+
+	fields := body.errorBody
+	if len(body.Error) > 0 {
+		var nested errorBody
+		var msg string
+		if json.Unmarshal(body.Error, &nested) == nil {
+			fields = nested
+			if fields.RequestID == "" {
+				fields.RequestID = body.RequestID
+			}
+		} else if json.Unmarshal(body.Error, &msg) == nil {
+			fields.Message = msg
+		}
+	}
+
+	e.Code, e.Message = fields.Code, fields.Message
+	if e.RequestID == "" {
+		e.RequestID = fields.RequestID
+	}
+	return e
+}
+
+// apiStatus returns the status code of an *APIError in err's chain, or 0
+func apiStatus(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
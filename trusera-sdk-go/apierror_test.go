@@ -0,0 +1,106 @@
+package trusera
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		header string
+		want   APIError
+	}{
+		{"flat", `{"code":"invalid_event","message":"name is required","request_id":"req-1"}`, "",
+			APIError{StatusCode: 400, Code: "invalid_event", Message: "name is required", RequestID: "req-1"}},
+		{"nested", `{"error":{"code":"unauthorized","message":"bad key"},"request_id":"req-2"}`, "",
+			APIError{StatusCode: 400, Code: "unauthorized", Message: "bad key", RequestID: "req-2"}},
+		{"string", `{"error":"bad key"}`, "req-3",
+			APIError{StatusCode: 400, Message: "bad key", RequestID: "req-3"}},
+		{"plain text", "upstream timeout\n", "",
+			APIError{StatusCode: 400, Message: "upstream timeout"}},
+		{"empty", "", "", APIError{StatusCode: 400}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 400,
+				Header:     make(http.Header),
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}
+			if tt.header != "" {
+				resp.Header.Set("X-Request-ID", tt.header)
+			}
+			if got := newAPIError(resp); *got != tt.want {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+	err := &APIError{StatusCode: 401, Code: "unauthorized", Message: "bad key", RequestID: "req-1"}
+	want := "API returned status 401: unauthorized: bad key (request req-1)"
+	if err.Error() != want {
+		t.Errorf("got %q, want %q", err.Error(), want)
+	}
+}
+
+func TestFlushReturnsAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "req-9")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"code":"unauthorized","message":"invalid API key"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("bad-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	err := client.Flush()
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an *APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusUnauthorized || apiErr.Code != "unauthorized" || apiErr.RequestID != "req-9" {
+		t.Errorf("unexpected error %+v", apiErr)
+	}
+
+	_, err = client.RegisterAgent("agent", "custom")
+	if !errors.As(err, &apiErr) || apiErr.Message != "invalid API key" {
+		t.Errorf("expected an *APIError from RegisterAgent, got %v", err)
+	}
+}
+
+func TestThrottledFlushReturnsAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// Skip waiting out the pause
+	defer client.CloseContext(ctx)
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	err := client.Flush()
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected a 429 *APIError, got %v", err)
+	}
+	if !errors.Is(err, ErrThrottled) {
+		t.Errorf("expected ErrThrottled, got %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const bomsPath = "/v1/boms"
+
+// UploadBOM sends an AI Bill of Materials, usually an *aibom.BOM, to the
+// Trusera API so BOMs can be stored and compared centrally. The upload is
+// tagged with the client's agent ID and the run ID set on ctx with WithRunID,
+// if any. Transient failures are retried like Flush.
+func (c *Client) UploadBOM(ctx context.Context, bom any) error {
+	c.mu.Lock()
+	agentID := c.agentID
+	c.mu.Unlock()
+
+	upload := struct {
+		AgentID string `json:"agent_id"`
+		RunID   string `json:"run_id,omitempty"`
+		BOM     any    `json:"bom"`
+	}{agentID, RunIDFromContext(ctx), bom}
+
+	body, err := json.Marshal(upload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal BOM: %w", err)
+	}
+	if err := c.deliver(ctx, nil, bomsPath, body); err != nil {
+		return fmt.Errorf("failed to upload BOM: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestUploadBOM(t *testing.T) {
+	var got struct {
+		AgentID string         `json:"agent_id"`
+		RunID   string         `json:"run_id"`
+		BOM     map[string]any `json:"bom"`
+	}
+	var path, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithAgentID("agent-1"))
+	defer client.Close()
+
+	bom := map[string]any{"models": []string{"gpt-4o"}}
+	if err := client.UploadBOM(WithRunID(context.Background(), "run-7"), bom); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	if path != "/v1/boms" || auth != "Bearer test-key" {
+		t.Errorf("unexpected request to %s with %q", path, auth)
+	}
+	if got.AgentID != "agent-1" || got.RunID != "run-7" || got.BOM["models"] == nil {
+		t.Errorf("unexpected upload %+v", got)
+	}
+}
+
+func TestUploadBOMRejected(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"invalid_bom","message":"missing models"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	err := client.UploadBOM(context.Background(), map[string]any{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "invalid_bom" {
+		t.Errorf("expected an APIError, got %v", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("expected a rejected upload not to be retried, got %d requests", n)
+	}
+}
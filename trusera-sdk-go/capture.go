@@ -0,0 +1,250 @@
+package trusera
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// WithRequestBodyCapture records outgoing request bodies in the event log.
+// Up to maxBytes are read and hashed with SHA-256; when previewBytes is
+// positive, a truncated preview is logged as well. The body is re-wrapped so
+// the request is forwarded unchanged.
+func WithRequestBodyCapture(maxBytes, previewBytes int) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.requestCapture = bodyCaptureConfig{maxBytes: maxBytes, previewBytes: previewBytes}
+	}
+}
+
+// WithResponseBodyCapture records response bodies in the event log. The body is
+// teed as the caller reads it: up to maxBytes are hashed with SHA-256 and, when
+// previewBytes is positive, a truncated preview is kept. The entry is written
+// once the caller reads the body to EOF or closes it.
+func WithResponseBodyCapture(maxBytes, previewBytes int) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.responseCapture = bodyCaptureConfig{maxBytes: maxBytes, previewBytes: previewBytes}
+	}
+}
+
+// bodyCaptureConfig bounds how much of a body is captured
+type bodyCaptureConfig struct {
+	maxBytes     int
+	previewBytes int
+}
+
+// enabled reports whether capture is configured
+func (c bodyCaptureConfig) enabled() bool {
+	return c.maxBytes > 0
+}
+
+// bodyLog describes a captured body in the JSONL log
+type bodyLog struct {
+	SHA256    string `json:"sha256"`
+	Bytes     int64  `json:"bytes"`
+	Truncated bool   `json:"truncated,omitempty"`
+	Preview   string `json:"preview,omitempty"`
+}
+
+// newBodyLog hashes captured bytes and renders the preview
+func newBodyLog(data []byte, truncated bool, previewBytes int) *bodyLog {
+	sum := sha256.Sum256(data)
+	return &bodyLog{
+		SHA256:    hex.EncodeToString(sum[:]),
+		Bytes:     int64(len(data)),
+		Truncated: truncated,
+		Preview:   bodyPreview(data, previewBytes),
+	}
+}
+
+// bodyPreview returns at most n bytes of data as valid UTF-8
+func bodyPreview(data []byte, n int) string {
+	if n <= 0 || len(data) == 0 {
+		return ""
+	}
+	if len(data) <= n {
+		return strings.ToValidUTF8(string(data), string(utf8.RuneError))
+	}
+
+	// Avoid splitting a multi-byte rune at the cut point
+	cut := n
+	for cut > 0 && !utf8.RuneStart(data[cut]) {
+		cut--
+	}
+	return strings.ToValidUTF8(string(data[:cut]), string(utf8.RuneError)) + "..."
+}
+
+// captureRequestBody reads up to cfg.maxBytes of the request body and returns a
+// clone of req whose body replays the captured bytes followed by the remainder
+func captureRequestBody(req *http.Request, cfg bodyCaptureConfig) (*http.Request, []byte, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil, false
+	}
+
+	orig := req.Body
+	buf, err := io.ReadAll(io.LimitReader(orig, int64(cfg.maxBytes)+1))
+
+	clone := req.Clone(req.Context())
+
+	if err != nil {
+		// Surface the read error to the base transport rather than hiding it
+		clone.Body = &replayBody{Reader: io.MultiReader(bytes.NewReader(buf), errReader{err}), closer: orig}
+		return clone, buf, false
+	}
+
+	if len(buf) > cfg.maxBytes {
+		clone.Body = &replayBody{Reader: io.MultiReader(bytes.NewReader(buf), orig), closer: orig}
+		return clone, buf[:cfg.maxBytes], true
+	}
+
+	// The whole body fits, so it can be replayed for redirects and retries
+	orig.Close()
+	clone.Body = io.NopCloser(bytes.NewReader(buf))
+	clone.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf)), nil
+	}
+	clone.ContentLength = int64(len(buf))
+	return clone, buf, false
+}
+
+// replayBody reads captured bytes before the rest of the original body
+type replayBody struct {
+	io.Reader
+	closer io.Closer
+}
+
+func (b *replayBody) Close() error {
+	return b.closer.Close()
+}
+
+// errReader always fails with err
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// streamLog describes how a streamed response body was delivered
+type streamLog struct {
+	TTFBMs     float64 `json:"ttfb_ms"`     // From request start to the first body byte
+	DurationMs float64 `json:"duration_ms"` // From request start to the end of the stream
+	Bytes      int64   `json:"bytes"`
+}
+
+// isStreamingResponse reports whether resp is delivered incrementally, as
+// with server-sent events from LLM APIs
+func isStreamingResponse(resp *http.Response) bool {
+	mediaType, _, _ := strings.Cut(resp.Header.Get("Content-Type"), ";")
+	switch strings.TrimSpace(strings.ToLower(mediaType)) {
+	case "text/event-stream", "application/x-ndjson", "application/stream+json":
+		return true
+	}
+	return false
+}
+
+// observedBody tees a response body as the caller reads it and invokes onDone
+// exactly once, at EOF or Close, whichever comes first. Content is captured
+// when cfg is enabled; delivery timing is always tracked.
+type observedBody struct {
+	body    io.ReadCloser
+	cfg     bodyCaptureConfig
+	hash    hash.Hash
+	preview []byte
+	hashed  int64
+	over    bool
+	start   time.Time
+	first   time.Time
+	total   int64
+	once    sync.Once
+	onDone  func(*bodyLog, *streamLog)
+}
+
+// newObservedBody wraps body so its content is captured per cfg. start is
+// the time the request began, from which stream timings are measured.
+func newObservedBody(body io.ReadCloser, cfg bodyCaptureConfig, start time.Time, onDone func(*bodyLog, *streamLog)) *observedBody {
+	return &observedBody{
+		body:   body,
+		cfg:    cfg,
+		hash:   sha256.New(),
+		start:  start,
+		onDone: onDone,
+	}
+}
+
+func (b *observedBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if n > 0 {
+		if b.first.IsZero() {
+			b.first = time.Now()
+		}
+		b.total += int64(n)
+		if b.cfg.enabled() {
+			b.observe(p[:n])
+		}
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *observedBody) Close() error {
+	err := b.body.Close()
+	b.finish()
+	return err
+}
+
+// observe hashes and previews the bytes within the capture cap
+func (b *observedBody) observe(chunk []byte) {
+	if remaining := int64(b.cfg.maxBytes) - b.hashed; remaining < int64(len(chunk)) {
+		b.over = true
+		if remaining < 0 {
+			remaining = 0
+		}
+		chunk = chunk[:remaining]
+	}
+
+	b.hash.Write(chunk)
+	b.hashed += int64(len(chunk))
+
+	// Keep one byte beyond the preview so bodyPreview can tell it was cut
+	if room := b.cfg.previewBytes + 1 - len(b.preview); room > 0 && b.cfg.previewBytes > 0 {
+		if room > len(chunk) {
+			room = len(chunk)
+		}
+		b.preview = append(b.preview, chunk[:room]...)
+	}
+}
+
+// finish reports the capture and stream timing once
+func (b *observedBody) finish() {
+	b.once.Do(func() {
+		var body *bodyLog
+		if b.cfg.enabled() {
+			body = &bodyLog{
+				SHA256:    hex.EncodeToString(b.hash.Sum(nil)),
+				Bytes:     b.hashed,
+				Truncated: b.over,
+				Preview:   bodyPreview(b.preview, b.cfg.previewBytes),
+			}
+		}
+
+		stream := &streamLog{
+			DurationMs: float64(time.Since(b.start).Milliseconds()),
+			Bytes:      b.total,
+		}
+		if !b.first.IsZero() {
+			stream.TTFBMs = float64(b.first.Sub(b.start).Milliseconds())
+		}
+
+		b.onDone(body, stream)
+	})
+}
@@ -0,0 +1,350 @@
+package trusera
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// echoServer records the last request body it received
+func echoServer(t *testing.T, received *string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*received = string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestStandaloneRequestBodyCapture(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+
+	si, err := NewStandaloneInterceptor(
+		WithLogFile(logPath),
+		WithRequestBodyCapture(1024, 10),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	var received string
+	backend := echoServer(t, &received)
+
+	payload := `{"model":"gpt-4o","prompt":"hello"}`
+	resp, err := si.WrapClient(&http.Client{}).Post(backend.URL, "application/json", strings.NewReader(payload))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if received != payload {
+		t.Errorf("backend received %q, want %q", received, payload)
+	}
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 1 || entries[0].RequestBody == nil {
+		t.Fatalf("expected captured request body, got %+v", entries)
+	}
+
+	body := entries[0].RequestBody
+	if body.SHA256 != sha256Hex(payload) {
+		t.Errorf("unexpected hash %s", body.SHA256)
+	}
+	if body.Bytes != int64(len(payload)) || body.Truncated {
+		t.Errorf("unexpected size %d truncated=%v", body.Bytes, body.Truncated)
+	}
+	if body.Preview != payload[:10]+"..." {
+		t.Errorf("unexpected preview %q", body.Preview)
+	}
+}
+
+func TestStandaloneRequestBodyCaptureTruncated(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+
+	si, err := NewStandaloneInterceptor(
+		WithLogFile(logPath),
+		WithRequestBodyCapture(8, 0),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	var received string
+	backend := echoServer(t, &received)
+
+	payload := strings.Repeat("abcdefgh", 100)
+	resp, err := si.WrapClient(&http.Client{}).Post(backend.URL, "text/plain", strings.NewReader(payload))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if received != payload {
+		t.Errorf("backend received %d bytes, want %d", len(received), len(payload))
+	}
+
+	body := readLogEntries(t, logPath)[0].RequestBody
+	if body == nil || !body.Truncated || body.Bytes != 8 {
+		t.Fatalf("expected truncated capture of 8 bytes, got %+v", body)
+	}
+	if body.SHA256 != sha256Hex("abcdefgh") {
+		t.Errorf("expected hash of captured prefix, got %s", body.SHA256)
+	}
+	if body.Preview != "" {
+		t.Errorf("expected no preview when previewBytes is 0, got %q", body.Preview)
+	}
+}
+
+func TestStandaloneRequestBodyCaptureBlocked(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "events.jsonl")
+	policyPath := writeTestPolicy(t, tmpDir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "blocked.example.com";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithLogFile(logPath),
+		WithRequestBodyCapture(1024, 100),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	_, err = si.WrapClient(&http.Client{}).Post("https://blocked.example.com/upload", "text/plain", strings.NewReader("exfiltrated"))
+	if err == nil {
+		t.Fatal("expected blocked request")
+	}
+
+	body := readLogEntries(t, logPath)[0].RequestBody
+	if body == nil || body.Preview != "exfiltrated" {
+		t.Errorf("expected blocked payload to be captured, got %+v", body)
+	}
+}
+
+func TestBodyPreviewKeepsRunesIntact(t *testing.T) {
+	got := bodyPreview([]byte("héllo"), 2)
+	if got != "h..." {
+		t.Errorf("expected preview to stop before split rune, got %q", got)
+	}
+
+	if got := bodyPreview([]byte("abc"), 10); got != "abc" {
+		t.Errorf("expected full preview, got %q", got)
+	}
+}
+
+func TestStandaloneResponseBodyCapture(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+
+	si, err := NewStandaloneInterceptor(
+		WithLogFile(logPath),
+		WithResponseBodyCapture(1024, 5),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	payload := `{"choices":[{"text":"hi"}]}`
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, payload)
+	}))
+	defer backend.Close()
+
+	resp, err := si.WrapClient(&http.Client{}).Get(backend.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	// Nothing is logged until the caller has consumed the body
+	if entries := readLogEntries(t, logPath); len(entries) != 0 {
+		t.Fatalf("expected entry to be deferred, got %d entries", len(entries))
+	}
+
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	resp.Body.Close()
+
+	if string(got) != payload {
+		t.Errorf("caller read %q, want %q", got, payload)
+	}
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 1 {
+		t.Fatalf("expected exactly 1 entry after EOF and Close, got %d", len(entries))
+	}
+
+	body := entries[0].ResponseBody
+	if body == nil {
+		t.Fatal("expected captured response body")
+	}
+	if body.SHA256 != sha256Hex(payload) || body.Bytes != int64(len(payload)) || body.Truncated {
+		t.Errorf("unexpected capture %+v", body)
+	}
+	if body.Preview != payload[:5]+"..." {
+		t.Errorf("unexpected preview %q", body.Preview)
+	}
+	if entries[0].Status != http.StatusOK {
+		t.Errorf("expected status 200, got %d", entries[0].Status)
+	}
+}
+
+func TestStandaloneResponseBodyCaptureTruncated(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+
+	si, err := NewStandaloneInterceptor(
+		WithLogFile(logPath),
+		WithResponseBodyCapture(4, 0),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, strings.Repeat("x", 4096))
+	}))
+	defer backend.Close()
+
+	resp, err := si.WrapClient(&http.Client{}).Get(backend.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if len(got) != 4096 {
+		t.Errorf("caller read %d bytes, want 4096", len(got))
+	}
+
+	body := readLogEntries(t, logPath)[0].ResponseBody
+	if body == nil || !body.Truncated || body.Bytes != 4 || body.SHA256 != sha256Hex("xxxx") {
+		t.Errorf("expected truncated 4-byte capture, got %+v", body)
+	}
+}
+
+func TestStandaloneResponseBodyCaptureClosedEarly(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+
+	si, err := NewStandaloneInterceptor(
+		WithLogFile(logPath),
+		WithResponseBodyCapture(1024, 0),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "unread")
+	}))
+	defer backend.Close()
+
+	resp, err := si.WrapClient(&http.Client{}).Get(backend.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	resp.Body.Close()
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry for an unread body, got %d", len(entries))
+	}
+	if entries[0].ResponseBody == nil || entries[0].ResponseBody.Bytes != 0 {
+		t.Errorf("expected empty capture, got %+v", entries[0].ResponseBody)
+	}
+}
+
+func TestStandaloneStreamingResponseStats(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+
+	events := []string{"data: {\"delta\":\"Hel\"}\n\n", "data: {\"delta\":\"lo\"}\n\n", "data: [DONE]\n\n"}
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		for _, ev := range events {
+			io.WriteString(w, ev)
+			w.(http.Flusher).Flush()
+			time.Sleep(20 * time.Millisecond)
+		}
+	}))
+	defer backend.Close()
+
+	si, err := NewStandaloneInterceptor(WithLogFile(logPath))
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	client := si.WrapClient(&http.Client{})
+	resp, err := client.Get(backend.URL + "/v1/chat/completions")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	if entries := readLogEntries(t, logPath); len(entries) != 0 {
+		t.Fatalf("expected the entry to wait for the stream to end, got %d entries", len(entries))
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+
+	stream := entries[0].Stream
+	if stream == nil {
+		t.Fatal("expected stream stats for an event-stream response")
+	}
+	if stream.Bytes != int64(len(body)) || len(body) != len(strings.Join(events, "")) {
+		t.Errorf("expected %d streamed bytes, got %d", len(body), stream.Bytes)
+	}
+	if stream.DurationMs < 40 || stream.TTFBMs > stream.DurationMs {
+		t.Errorf("unexpected stream timings: ttfb %.0fms, duration %.0fms", stream.TTFBMs, stream.DurationMs)
+	}
+	if entries[0].ResponseBody != nil {
+		t.Error("expected no body capture without WithResponseBodyCapture")
+	}
+}
+
+func TestIsStreamingResponse(t *testing.T) {
+	tests := map[string]bool{
+		"text/event-stream":                true,
+		"Text/Event-Stream; charset=utf-8": true,
+		"application/x-ndjson":             true,
+		"application/json":                 false,
+		"":                                 false,
+	}
+
+	for contentType, want := range tests {
+		resp := &http.Response{Header: http.Header{"Content-Type": []string{contentType}}}
+		if got := isStreamingResponse(resp); got != want {
+			t.Errorf("%q: expected %v, got %v", contentType, want, got)
+		}
+	}
+}
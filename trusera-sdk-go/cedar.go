@@ -3,6 +3,8 @@ package trusera
 import (
 	"bufio"
 	"fmt"
+	"net"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -26,36 +28,77 @@ const (
 	OpGreaterThanOrEqual PolicyOperator = ">="
 	OpLessThan           PolicyOperator = "<"
 	OpLessThanOrEqual    PolicyOperator = "<="
+	OpInRange            PolicyOperator = "isInRange"
 )
 
 // PolicyRule represents a parsed Cedar-like policy rule
 type PolicyRule struct {
-	Action   PolicyAction
-	Field    string
-	Operator PolicyOperator
-	Value    any // string, int, or float64
-	Raw      string
+	Action      PolicyAction
+	Field       string
+	Operator    PolicyOperator
+	Value       any // string, int, or float64; a CIDR string for isInRange
+	Raw         string
+	Obligations []string // From @obligation("...") annotations on the rule
 }
 
 // PolicyDecision represents the result of policy evaluation
 type PolicyDecision struct {
-	Decision string   // "Allow" or "Deny"
-	Reasons  []string // Human-readable reasons for the decision
-	Matched  []string // Raw policy rules that matched
+	Decision    string   // "Allow" or "Deny"
+	Reasons     []string // Human-readable reasons for the decision
+	Matched     []string // Raw policy rules that matched
+	Obligations []string // Obligations attached to the matched rules
 }
 
 // RequestContext contains information about an HTTP request for policy evaluation
 type RequestContext struct {
-	URL      string
-	Method   string
-	Hostname string
-	Path     string
+	URL          string
+	Method       string
+	Hostname     string
+	Path         string
+	SecretCount  int    // Credentials found in the request body by secret scanning
+	Port         int    // Destination port, zero when unknown
+	IP           string // Destination IP address, empty when only the hostname is known
+	LLMProvider  string // Set when the destination is a known LLM API, see DetectLLM
+	LLMModel     string
+	ModelLicense string // Set by WithModelLicenses
+	Region       string // Cloud region of a regional LLM endpoint or SigV4-signed request
+}
+
+// NewRequestContext returns the context the interceptor would evaluate for
+// a request to rawURL, with the hostname, path, port, literal IP, LLM
+// provider and region derived from the URL. Fields that depend on the body,
+// such as SecretCount and LLMModel, are left for the caller to set.
+func NewRequestContext(method, rawURL string) (RequestContext, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return RequestContext{}, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return RequestContext{}, fmt.Errorf("URL %q is not absolute", rawURL)
+	}
+	if method == "" {
+		method = "GET"
+	}
+
+	llm, _ := DetectLLM(u, nil)
+	return RequestContext{
+		URL:         u.String(),
+		Method:      strings.ToUpper(method),
+		Hostname:    u.Hostname(),
+		Path:        u.Path,
+		Port:        urlPort(u),
+		IP:          literalIP(u.Hostname()),
+		LLMProvider: llm.Provider,
+		LLMModel:    llm.Model,
+		Region:      llm.Region,
+	}, nil
 }
 
 var (
 	// Match: forbid ( principal, action == Action::"deploy", resource ) when { ... };
+	// Optional annotations such as @obligation("block_secrets") may precede the rule
 	rulePattern = regexp.MustCompile(
-		`(?s)(forbid|permit)\s*\(\s*principal\s*,\s*action\s*==\s*Action::"(\w+)"\s*,\s*resource\s*\)\s*when\s*\{([^}]+)\}\s*;`,
+		`(?s)((?:@\w+\s*\(\s*"[^"]*"\s*\)\s*)*)(forbid|permit)\s*\(\s*principal\s*,\s*action\s*==\s*Action::"(\w+)"\s*,\s*resource\s*\)\s*when\s*\{([^}]+)\}\s*;`,
 	)
 
 	// Match conditions: resource.field operator "value" or resource.field operator value
@@ -63,6 +106,14 @@ var (
 		`resource\.(\w+)\s*(==|!=|>=|>|<=|<)\s*(?:"([^"]+)"|([^;"\s]+))`,
 	)
 
+	// Match Cedar IP range checks: resource.ip.isInRange(ip("10.0.0.0/8"))
+	rangePattern = regexp.MustCompile(
+		`resource\.(\w+)\.isInRange\(\s*ip\(\s*"([^"]+)"\s*\)\s*\)`,
+	)
+
+	// Match annotations: @name("value")
+	annotationPattern = regexp.MustCompile(`@(\w+)\s*\(\s*"([^"]*)"\s*\)`)
+
 	// Match comments
 	commentPattern = regexp.MustCompile(`//[^\n]*`)
 )
@@ -78,14 +129,15 @@ func ParseCedarPolicy(policyText string) ([]PolicyRule, error) {
 	matches := rulePattern.FindAllStringSubmatch(cleaned, -1)
 
 	for _, match := range matches {
-		if len(match) < 4 {
+		if len(match) < 5 {
 			continue
 		}
 
-		action := PolicyAction(match[1])
-		// actionType := match[2] // e.g., "deploy" - not currently used
-		conditionBlock := strings.TrimSpace(match[3])
-		rawRule := strings.TrimSpace(match[0])
+		obligations := parseObligations(match[1])
+		action := PolicyAction(match[2])
+		// actionType := match[3] // e.g., "deploy" - not currently used
+		conditionBlock := strings.TrimSpace(match[4])
+		rawRule := strings.TrimSpace(match[0][len(match[1]):])
 
 		// Parse conditions within the when block
 		scanner := bufio.NewScanner(strings.NewReader(conditionBlock))
@@ -95,6 +147,21 @@ func ParseCedarPolicy(policyText string) ([]PolicyRule, error) {
 				continue
 			}
 
+			if rangeMatches := rangePattern.FindStringSubmatch(line); rangeMatches != nil {
+				if _, _, err := net.ParseCIDR(rangeMatches[2]); err != nil {
+					return nil, fmt.Errorf("invalid IP range %q: %w", rangeMatches[2], err)
+				}
+				rules = append(rules, PolicyRule{
+					Action:      action,
+					Field:       rangeMatches[1],
+					Operator:    OpInRange,
+					Value:       rangeMatches[2],
+					Raw:         rawRule,
+					Obligations: obligations,
+				})
+				continue
+			}
+
 			condMatches := conditionPattern.FindStringSubmatch(line)
 			if len(condMatches) < 3 {
 				continue
@@ -126,11 +193,12 @@ func ParseCedarPolicy(policyText string) ([]PolicyRule, error) {
 			}
 
 			rules = append(rules, PolicyRule{
-				Action:   action,
-				Field:    field,
-				Operator: operator,
-				Value:    value,
-				Raw:      rawRule,
+				Action:      action,
+				Field:       field,
+				Operator:    operator,
+				Value:       value,
+				Raw:         rawRule,
+				Obligations: obligations,
 			})
 		}
 	}
@@ -138,15 +206,29 @@ func ParseCedarPolicy(policyText string) ([]PolicyRule, error) {
 	return rules, nil
 }
 
+// parseObligations extracts @obligation annotation values
+func parseObligations(annotations string) []string {
+	var obligations []string
+	for _, m := range annotationPattern.FindAllStringSubmatch(annotations, -1) {
+		if m[1] == "obligation" {
+			obligations = append(obligations, m[2])
+		}
+	}
+	return obligations
+}
+
 // EvaluatePolicy evaluates a request context against Cedar policy rules
 func EvaluatePolicy(ctx RequestContext, rules []PolicyRule) PolicyDecision {
 	var forbidReasons []string
 	var forbidMatched []string
 	var permitReasons []string
 	var permitMatched []string
+	var obligations []string
 
 	for _, rule := range rules {
 		if matches := evaluateCondition(rule, ctx); matches {
+			obligations = appendUnique(obligations, rule.Obligations...)
+
 			reason := fmt.Sprintf("%s: resource.%s %s %v (actual: %s)",
 				rule.Action, rule.Field, rule.Operator, rule.Value, getFieldValue(ctx, rule.Field))
 
@@ -163,18 +245,20 @@ func EvaluatePolicy(ctx RequestContext, rules []PolicyRule) PolicyDecision {
 	// Cedar semantics: any forbid overrides permit
 	if len(forbidReasons) > 0 {
 		return PolicyDecision{
-			Decision: "Deny",
-			Reasons:  forbidReasons,
-			Matched:  forbidMatched,
+			Decision:    "Deny",
+			Reasons:     forbidReasons,
+			Matched:     forbidMatched,
+			Obligations: obligations,
 		}
 	}
 
 	// If we have explicit permits, allow
 	if len(permitReasons) > 0 {
 		return PolicyDecision{
-			Decision: "Allow",
-			Reasons:  permitReasons,
-			Matched:  permitMatched,
+			Decision:    "Allow",
+			Reasons:     permitReasons,
+			Matched:     permitMatched,
+			Obligations: obligations,
 		}
 	}
 
@@ -186,6 +270,24 @@ func EvaluatePolicy(ctx RequestContext, rules []PolicyRule) PolicyDecision {
 	}
 }
 
+// RuleTrace records how one rule condition evaluated against a request
+type RuleTrace struct {
+	Rule    PolicyRule
+	Actual  string // The request's value for Rule.Field, empty when it has none
+	Matched bool
+}
+
+// ExplainPolicy evaluates a request context like EvaluatePolicy and also
+// returns how each rule condition evaluated, in policy order, for debugging
+// why a request was allowed or denied
+func ExplainPolicy(ctx RequestContext, rules []PolicyRule) (PolicyDecision, []RuleTrace) {
+	trace := make([]RuleTrace, len(rules))
+	for i, rule := range rules {
+		trace[i] = RuleTrace{Rule: rule, Actual: getFieldValue(ctx, rule.Field), Matched: evaluateCondition(rule, ctx)}
+	}
+	return EvaluatePolicy(ctx, rules), trace
+}
+
 // evaluateCondition checks if a rule condition matches the request context
 func evaluateCondition(rule PolicyRule, ctx RequestContext) bool {
 	actual := getFieldValue(ctx, rule.Field)
@@ -193,6 +295,10 @@ func evaluateCondition(rule PolicyRule, ctx RequestContext) bool {
 		return false
 	}
 
+	if rule.Operator == OpInRange {
+		return inRange(actual, rule.Value)
+	}
+
 	// Try numeric comparison if rule value is numeric
 	switch v := rule.Value.(type) {
 	case int:
@@ -227,11 +333,45 @@ func getFieldValue(ctx RequestContext, field string) string {
 		return ctx.Hostname
 	case "path":
 		return ctx.Path
+	case "secret_count":
+		return strconv.Itoa(ctx.SecretCount)
+	case "port":
+		if ctx.Port == 0 {
+			return ""
+		}
+		return strconv.Itoa(ctx.Port)
+	case "ip":
+		return ctx.IP
+	case "llm_provider":
+		return ctx.LLMProvider
+	case "llm_model":
+		return ctx.LLMModel
+	case "model_license":
+		return ctx.ModelLicense
+	case "region":
+		return ctx.Region
 	default:
 		return ""
 	}
 }
 
+// appendUnique appends values not already present in dst
+func appendUnique(dst []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, existing := range dst {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
+
 // compareNumeric performs numeric comparison
 func compareNumeric(actual, target float64, op PolicyOperator) bool {
 	switch op {
@@ -251,6 +391,20 @@ func compareNumeric(actual, target float64, op PolicyOperator) bool {
 	return false
 }
 
+// inRange reports whether actual is an IP address within the CIDR in value
+func inRange(actual string, value any) bool {
+	cidr, ok := value.(string)
+	if !ok {
+		return false
+	}
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(actual)
+	return ip != nil && network.Contains(ip)
+}
+
 // compareString performs string comparison (case-insensitive)
 func compareString(actual, target string, op PolicyOperator) bool {
 	actualLower := strings.ToLower(actual)
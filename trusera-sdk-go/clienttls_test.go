@@ -0,0 +1,149 @@
+package trusera
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeClientCert mints a client certificate signed by ca and writes it and
+// its key as PEM files
+func writeClientCert(t *testing.T, ca *ProxyCA, dir string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := randomSerial()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "agent"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, "client.pem")
+	keyFile = filepath.Join(dir, "client-key.pem")
+	os.WriteFile(certFile, pemEncodeCert(der), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return certFile, keyFile
+}
+
+func TestClientMutualTLS(t *testing.T) {
+	ca, err := NewProxyCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverCert, err := ca.certificateFor("127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	var clientCN string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientCN = r.TLS.PeerCertificates[0].Subject.CommonName
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{*serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	os.WriteFile(caFile, ca.CertPEM(), 0600)
+	certFile, keyFile := writeClientCert(t, ca, dir)
+
+	client := NewClient("test-key",
+		WithBaseURL(server.URL),
+		WithClientCertificate(certFile, keyFile),
+		WithCACertificate(caFile),
+		WithFlushRetry(0, 0),
+	)
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if clientCN != "agent" {
+		t.Errorf("expected client certificate to be presented, got %q", clientCN)
+	}
+
+	// Without the certificate the handshake fails
+	plain := NewClient("test-key", WithBaseURL(server.URL), WithCACertificate(caFile), WithFlushRetry(0, 0))
+	defer plain.Close()
+	plain.Track(NewEvent(EventToolCall, "search"))
+	if err := plain.Flush(); err == nil {
+		t.Error("expected flush without a client certificate to fail")
+	}
+}
+
+func TestClientCertificateErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	client := NewClient("test-key", WithClientCertificate(filepath.Join(dir, "missing.pem"), filepath.Join(dir, "missing-key.pem")), WithFlushRetry(0, 0))
+	defer client.Close()
+	if client.Flush() == nil {
+		t.Error("expected missing certificate to be reported by Flush")
+	}
+
+	custom := NewClient("test-key",
+		WithTransport(&countingTransport{base: http.DefaultTransport}),
+		WithCACertificate(filepath.Join(dir, "ca.pem")),
+		WithFlushRetry(0, 0),
+	)
+	defer custom.Close()
+	if custom.setupErr == nil {
+		t.Error("expected an error for a non-*http.Transport")
+	}
+}
+
+func TestClientTLSDoesNotModifyCallerClient(t *testing.T) {
+	ca, err := NewProxyCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(caFile, ca.CertPEM(), 0600)
+
+	hc := &http.Client{Transport: &http.Transport{}}
+	client := NewClient("test-key", WithHTTPClient(hc), WithCACertificate(caFile), WithFlushRetry(0, 0))
+	defer client.Close()
+
+	if client.setupErr != nil {
+		t.Fatalf("unexpected setup error: %v", client.setupErr)
+	}
+	if cfg := hc.Transport.(*http.Transport).TLSClientConfig; cfg != nil && cfg.RootCAs != nil {
+		t.Error("expected the caller's transport to be left untouched")
+	}
+}
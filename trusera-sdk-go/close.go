@@ -0,0 +1,93 @@
+package trusera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DroppedError is returned by CloseContext when events could not be delivered,
+// either because a destination failed or because ctx expired while draining
+type DroppedError struct {
+	Dropped int
+	Err     error
+}
+
+func (e *DroppedError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%d events dropped", e.Dropped)
+	}
+	return fmt.Sprintf("%d events dropped: %v", e.Dropped, e.Err)
+}
+
+func (e *DroppedError) Unwrap() error {
+	return e.Err
+}
+
+// DrainingSink is a LogSink that delivers events asynchronously. CloseContext
+// delivers queued events until ctx is done and reports how many were lost.
+type DrainingSink interface {
+	LogSink
+	CloseContext(ctx context.Context) (dropped int, err error)
+}
+
+// CloseContext is like Close but gives up waiting for decision hooks and
+// asynchronous sinks once ctx is done. Events that never reached a sink,
+// including those rejected by a full sink queue, are reported as a *DroppedError.
+func (si *StandaloneInterceptor) CloseContext(ctx context.Context) error {
+	si.stopExceptions()
+	si.stopSignals()
+
+	var errs []error
+
+	if si.control != nil {
+		errs = append(errs, si.control.close(ctx))
+		si.control = nil
+	}
+
+	si.hooks.close()
+	if !waitContext(ctx, &si.hooks.wg) {
+		errs = append(errs, fmt.Errorf("decision hooks still running: %w", ctx.Err()))
+	}
+
+	if si.statsd != nil {
+		errs = append(errs, si.statsd.close())
+	}
+
+	si.logMu.Lock()
+	defer si.logMu.Unlock()
+
+	dropped := int(si.droppedEvents.Load())
+	for _, sink := range si.sinks {
+		if ds, ok := sink.(DrainingSink); ok {
+			n, err := ds.CloseContext(ctx)
+			dropped += n
+			errs = append(errs, err)
+			continue
+		}
+		errs = append(errs, sink.Close())
+	}
+	si.sinks = nil
+
+	if dropped > 0 {
+		return &DroppedError{Dropped: dropped, Err: errors.Join(errs...)}
+	}
+	return errors.Join(errs...)
+}
+
+// waitContext waits for wg until ctx is done, reporting whether wg finished
+func waitContext(ctx context.Context, wg *sync.WaitGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
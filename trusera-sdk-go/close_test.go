@@ -0,0 +1,127 @@
+package trusera
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// failingSink rejects every event
+type failingSink struct{}
+
+func (failingSink) WriteEvent([]byte) error { return errors.New("disk full") }
+func (failingSink) Close() error            { return nil }
+
+func TestStandaloneInterceptorCloseReportsDroppedEvents(t *testing.T) {
+	si, err := NewStandaloneInterceptor(WithLogSinks(failingSink{}))
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get("https://api.example.com/")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	var dropped *DroppedError
+	if err := si.Close(); !errors.As(err, &dropped) || dropped.Dropped != 2 {
+		t.Errorf("expected 2 dropped events, got %v", err)
+	}
+}
+
+func TestStandaloneInterceptorCloseContextAbandonsSlowSink(t *testing.T) {
+	release := make(chan struct{})
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer collector.Close()
+	defer close(release)
+
+	si, err := NewStandaloneInterceptor(WithLogSinks(NewHTTPSink(collector.URL)))
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get("https://api.example.com/")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = si.CloseContext(ctx)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("CloseContext took %v", elapsed)
+	}
+
+	var dropped *DroppedError
+	if !errors.As(err, &dropped) || dropped.Dropped != 3 {
+		t.Errorf("expected 3 dropped events, got %v", err)
+	}
+}
+
+func TestClientCloseContext(t *testing.T) {
+	release := make(chan struct{})
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer api.Close()
+	defer close(release)
+
+	client := NewClient("test-key", WithBaseURL(api.URL))
+	client.Track(NewEvent(EventToolCall, "search"))
+	client.Track(NewEvent(EventToolCall, "fetch"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var dropped *DroppedError
+	err := client.CloseContext(ctx)
+	if !errors.As(err, &dropped) || dropped.Dropped != 2 {
+		t.Fatalf("expected 2 dropped events, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline error, got %v", err)
+	}
+
+	// Closing again is a no-op
+	if err := client.Close(); err != nil {
+		t.Errorf("second close failed: %v", err)
+	}
+}
+
+func TestClientCloseContextDelivers(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+
+	client := NewClient("test-key", WithBaseURL(api.URL))
+	client.Track(NewEvent(EventToolCall, "search"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.CloseContext(ctx); err != nil {
+		t.Errorf("expected clean close, got %v", err)
+	}
+}
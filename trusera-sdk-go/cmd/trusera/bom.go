@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/aibom"
+)
+
+func runBOM(args []string, stdout, stderr io.Writer) error {
+	return subcommand("bom", args, stdout, stderr, []command{
+		{"generate", "Build a BOM from interceptor JSONL logs", runBOMGenerate},
+		{"merge", "Merge BOMs from several agents", runBOMMerge},
+	})
+}
+
+// runBOMGenerate replays interceptor logs, or stdin when neither logs nor a
+// source directory are given, and writes the BOM or its dependency graph
+func runBOMGenerate(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("bom generate", stderr)
+	agentID := fs.String("agent", "", "agent ID to record in the BOM")
+	format := fs.String("format", "json", "output format: json, graph-json or dot")
+	output := fs.String("o", "", "write to this file instead of stdout")
+	enrich := fs.Bool("hf", false, "enrich models with Hugging Face Hub metadata (token from HF_TOKEN)")
+	hfCache := fs.String("hf-cache", "", "cache Hub metadata in this directory")
+	hfOffline := fs.Bool("hf-offline", false, "use only cached Hub metadata")
+	advisories := fs.String("advisories", "", "annotate components from this advisory feed (file or URL)")
+	source := fs.String("source", "", "also scan source code in this directory for AI packages")
+	vendor := fs.Bool("vendor", false, "include vendored code in the source scan")
+	var mcpConfigs []string
+	fs.Func("mcp", "record the servers in this MCP client config (repeatable)", func(path string) error {
+		mcpConfigs = append(mcpConfigs, path)
+		return nil
+	})
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: trusera bom generate [flags] [log.jsonl ...]")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	switch *format {
+	case "json", "graph-json", "dot":
+	default:
+		fmt.Fprintf(stderr, "unknown format %q\n", *format)
+		return errUsage
+	}
+
+	b := aibom.NewBuilder(*agentID)
+	// Servers are declared first so logged traffic to them is attributed
+	for _, path := range mcpConfigs {
+		if err := recordMCPConfig(b, path); err != nil {
+			return err
+		}
+	}
+	if *source != "" {
+		if err := b.ScanSource(*source, aibom.SourceOptions{Vendor: *vendor}); err != nil {
+			return err
+		}
+	}
+	if fs.NArg() == 0 && *source == "" {
+		if err := b.ReadEventLog(os.Stdin); err != nil {
+			return fmt.Errorf("stdin: %w", err)
+		}
+	}
+	for _, path := range fs.Args() {
+		if err := readLog(b, path); err != nil {
+			return err
+		}
+	}
+
+	bom := b.BOM()
+	if *enrich || *hfOffline {
+		opts := []aibom.HubOption{aibom.WithHubToken(os.Getenv("HF_TOKEN"))}
+		if *hfCache != "" {
+			opts = append(opts, aibom.WithHubCache(*hfCache, 7*24*time.Hour))
+		}
+		if *hfOffline {
+			opts = append(opts, aibom.WithHubOffline())
+		}
+		if err := aibom.NewHub(opts...).Enrich(context.Background(), bom); err != nil {
+			// Enrichment is best effort; the BOM is still useful without it
+			fmt.Fprintf(stderr, "warning: %v\n", err)
+		}
+	}
+
+	if *advisories != "" {
+		feed, err := loadAdvisories(*advisories)
+		if err != nil {
+			return err
+		}
+		if n := aibom.Scan(bom, feed); n > 0 {
+			fmt.Fprintf(stderr, "%d advisories match BOM components\n", n)
+		}
+	}
+
+	return writeOutput(*output, stdout, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		switch *format {
+		case "graph-json":
+			return enc.Encode(b.Graph())
+		case "dot":
+			return b.Graph().WriteDOT(w)
+		default:
+			return enc.Encode(bom)
+		}
+	})
+}
+
+// readLog adds one log file to b
+func readLog(b *aibom.Builder, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := b.ReadEventLog(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// recordMCPConfig declares the servers in one MCP client config file
+func recordMCPConfig(b *aibom.Builder, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	servers, err := aibom.LoadMCPConfig(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, s := range servers {
+		b.RecordMCPServer(s)
+	}
+	return nil
+}
+
+// loadAdvisories reads an advisory feed from a file or an http(s) URL
+func loadAdvisories(source string) ([]aibom.Advisory, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return aibom.FetchAdvisories(context.Background(), nil, source)
+	}
+
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return aibom.LoadAdvisories(f)
+}
+
+// runBOMMerge merges BOM files into an org-level BOM
+func runBOMMerge(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("bom merge", stderr)
+	output := fs.String("o", "", "write to this file instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: trusera bom merge [flags] bom.json ...")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	var boms []*aibom.BOM
+	for _, path := range fs.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var bom aibom.BOM
+		if err := json.Unmarshal(data, &bom); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		boms = append(boms, &bom)
+	}
+
+	return writeOutput(*output, stdout, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(aibom.Merge(boms...))
+	})
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/aibom"
+)
+
+const testLog = `{"timestamp":"2026-03-01T10:00:00Z","hostname":"api.openai.com","llm_provider":"openai","llm_model":"gpt-4o","enforcement_action":"allowed"}
+{"timestamp":"2026-03-01T10:01:00Z","hostname":"pastebin.com","enforcement_action":"blocked"}
+`
+
+func writeTestLog(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	return path
+}
+
+func TestBOMGenerate(t *testing.T) {
+	logPath := writeTestLog(t, testLog)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"bom", "generate", "-agent", "agent-1", logPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+
+	var bom aibom.BOM
+	if err := json.Unmarshal(stdout.Bytes(), &bom); err != nil {
+		t.Fatalf("output is not a BOM: %v\n%s", err, stdout.String())
+	}
+	if bom.AgentID != "agent-1" || len(bom.APIs) != 1 || len(bom.Models) != 1 {
+		t.Errorf("unexpected BOM %+v", bom)
+	}
+}
+
+func TestBOMGenerateFormats(t *testing.T) {
+	logPath := writeTestLog(t, testLog)
+	out := filepath.Join(t.TempDir(), "graph.dot")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"bom", "generate", "-format", "dot", "-o", out, logPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	data, _ := os.ReadFile(out)
+	if !strings.HasPrefix(string(data), "digraph") || stdout.Len() != 0 {
+		t.Errorf("expected DOT in the output file only, got %q", data)
+	}
+
+	if code := run([]string{"bom", "generate", "-format", "yaml", logPath}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit 2 for an unknown format, got %d", code)
+	}
+}
+
+func TestBOMGenerateErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"bom", "generate", writeTestLog(t, "not json\n")}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit 1 for a malformed log, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "events.jsonl") {
+		t.Errorf("expected the file name in the error, got %q", stderr.String())
+	}
+
+	if code := run([]string{"bom", "generate", filepath.Join(t.TempDir(), "missing")}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit 1 for a missing file, got %d", code)
+	}
+}
+
+func TestBOMGenerateHubOffline(t *testing.T) {
+	logPath := writeTestLog(t, `{"timestamp":"2026-03-01T10:00:00Z","hostname":"localhost","llm_provider":"ollama","llm_model":"qwen/qwen2.5","enforcement_action":"allowed"}
+`)
+
+	// Nothing is cached, so the model is left as it is and nothing is fetched
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"bom", "generate", "-hf-offline", "-hf-cache", t.TempDir(), logPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	if stderr.Len() != 0 || strings.Contains(stdout.String(), "model_info") {
+		t.Errorf("expected an unenriched BOM without warnings, got %q / %q", stdout.String(), stderr.String())
+	}
+}
+
+func TestBOMGenerateAdvisories(t *testing.T) {
+	logPath := writeTestLog(t, testLog)
+	feed := filepath.Join(t.TempDir(), "feed.json")
+	os.WriteFile(feed, []byte(`[{"id": "ADV-1", "summary": "Deprecated", "kind": "model", "component": "gpt-4o"}]`), 0600)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"bom", "generate", "-advisories", feed, logPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"ADV-1"`) || !strings.Contains(stderr.String(), "1 advisories") {
+		t.Errorf("expected the model to be annotated, got %s / %s", stdout.String(), stderr.String())
+	}
+}
+
+func TestBOMMerge(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.json")
+	b := filepath.Join(dir, "b.json")
+	os.WriteFile(a, []byte(`{"agent_id":"a","apis":[{"name":"api.github.com","count":1}]}`), 0600)
+	os.WriteFile(b, []byte(`{"agent_id":"b","apis":[{"name":"api.github.com","count":2}]}`), 0600)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"bom", "merge", a, b}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	var merged aibom.BOM
+	if err := json.Unmarshal(stdout.Bytes(), &merged); err != nil {
+		t.Fatalf("output is not a BOM: %v", err)
+	}
+	if len(merged.APIs) != 1 || merged.APIs[0].Count != 3 || len(merged.APIs[0].Agents) != 2 {
+		t.Errorf("unexpected merged BOM %+v", merged)
+	}
+
+	if code := run([]string{"bom", "merge"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit 2 without files, got %d", code)
+	}
+}
+
+func TestBOMGenerateSource(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nimport _ \"github.com/anthropics/anthropic-sdk-go\"\n"), 0600)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"bom", "generate", "-source", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	var bom aibom.BOM
+	json.Unmarshal(stdout.Bytes(), &bom)
+	if len(bom.Packages) != 1 || bom.Packages[0].Provider != "anthropic" {
+		t.Errorf("unexpected packages %+v", bom.Packages)
+	}
+}
+
+func TestBOMGenerateMCP(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "mcp.json")
+	os.WriteFile(config, []byte(`{"mcpServers": {"tickets": {"url": "https://mcp.tickets.example/mcp"}}}`), 0600)
+	log := filepath.Join(dir, "run.jsonl")
+	os.WriteFile(log, []byte(`{"hostname":"mcp.tickets.example","enforcement_action":"allowed"}`+"\n"), 0600)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"bom", "generate", "-mcp", config, log}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	var bom aibom.BOM
+	json.Unmarshal(stdout.Bytes(), &bom)
+	if len(bom.MCPServers) != 1 || bom.MCPServers[0].Count != 1 || bom.MCPServers[0].Transport != "http" {
+		t.Errorf("unexpected MCP servers %+v", bom.MCPServers)
+	}
+
+	if code := run([]string{"bom", "generate", "-mcp", filepath.Join(dir, "missing.json"), log}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit 1 for a missing config, got %d", code)
+	}
+}
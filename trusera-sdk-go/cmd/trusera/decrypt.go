@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// logKeyEnv holds the log encryption key when no key file is given
+const logKeyEnv = "TRUSERA_LOG_KEY"
+
+// runDecryptLog writes encrypted event logs out as plain JSONL
+func runDecryptLog(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("decrypt-log", stderr)
+	keyFile := fs.String("key-file", "", "file holding the hex or base64 key (default $"+logKeyEnv+")")
+	output := fs.String("o", "", "write to this file instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: trusera decrypt-log [flags] events.jsonl ...")
+		fmt.Fprintln(stderr, "\nDecrypts logs written with WithLogEncryption or proxy -log-key-file. Plain lines are copied as they are.")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	key, err := loadLogKey(*keyFile)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(*output, stdout, func(w io.Writer) error {
+		for _, path := range fs.Args() {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			err = trusera.DecryptLog(w, f, key)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		return nil
+	})
+}
+
+// loadLogKey reads the log encryption key from path, or from the
+// environment when path is empty
+func loadLogKey(path string) ([]byte, error) {
+	if path == "" {
+		value := os.Getenv(logKeyEnv)
+		if value == "" {
+			return nil, errors.New("no log key: pass -key-file or set " + logKeyEnv)
+		}
+		return trusera.ParseLogKey(value)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return trusera.ParseLogKey(string(data))
+}
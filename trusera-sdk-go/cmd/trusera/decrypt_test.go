@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+func TestDecryptLog(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{7}, 32)
+	var encrypted bytes.Buffer
+	sink, err := trusera.NewEncryptedSink(trusera.NewWriterSink(&encrypted), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.WriteEvent([]byte(`{"hostname":"api.example.com"}` + "\n"))
+	logPath := writeFile(t, dir, "events.jsonl", encrypted.String())
+	out := filepath.Join(dir, "plain.jsonl")
+
+	t.Setenv(logKeyEnv, strings.Repeat("07", 32))
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"decrypt-log", "-o", out, logPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	if data, _ := os.ReadFile(out); string(data) != `{"hostname":"api.example.com"}`+"\n" {
+		t.Errorf("unexpected output %q", data)
+	}
+
+	// The wrong key names the file and line
+	t.Setenv(logKeyEnv, strings.Repeat("08", 32))
+	stderr.Reset()
+	if code := run([]string{"decrypt-log", logPath}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit 1 for the wrong key, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "events.jsonl: line 1: log line failed authentication") {
+		t.Errorf("unexpected stderr %q", stderr.String())
+	}
+
+	t.Setenv(logKeyEnv, "")
+	stderr.Reset()
+	if code := run([]string{"decrypt-log", logPath}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "no log key") {
+		t.Errorf("expected a missing key error, got %d %q", code, stderr.String())
+	}
+	if code := run([]string{"decrypt-log"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit 2 without files, got %d", code)
+	}
+}
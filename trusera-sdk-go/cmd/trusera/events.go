@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// followInterval is how often a followed log is checked for new entries
+var followInterval = 250 * time.Millisecond
+
+func runEvents(args []string, stdout, stderr io.Writer) error {
+	return subcommand("events", args, stdout, stderr, []command{
+		{"tail", "Print an interceptor JSONL log, optionally following it", runEventsTail},
+	})
+}
+
+// logEntry holds the interceptor log fields shown by tail
+type logEntry struct {
+	Timestamp         string  `json:"timestamp"`
+	Method            string  `json:"method"`
+	URL               string  `json:"url"`
+	Hostname          string  `json:"hostname"`
+	Path              string  `json:"path"`
+	Status            int     `json:"status"`
+	DurationMs        float64 `json:"duration_ms"`
+	PolicyDecision    string  `json:"policy_decision"`
+	EnforcementAction string  `json:"enforcement_action"`
+	Reasons           string  `json:"reasons"`
+}
+
+// runEventsTail prints the last entries of a log and, with -f, new ones as
+// they are written, until interrupted
+func runEventsTail(args []string, stdout, stderr io.Writer) error {
+	return tail("events tail", false, args, stdout, stderr)
+}
+
+// runTail is events tail following by default, for watching a live log
+func runTail(args []string, stdout, stderr io.Writer) error {
+	return tail("tail", true, args, stdout, stderr)
+}
+
+// tail implements events tail and tail, which differ in whether -f is set
+// by default
+func tail(name string, followDefault bool, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet(name, stderr)
+	lines := fs.Int("n", 10, "print the last n matching entries first, -1 for all")
+	follow := fs.Bool("f", followDefault, "keep printing entries as they are appended")
+	format := fs.String("format", "compact", "output format: compact, table or json")
+	colorMode := fs.String("color", "auto", "colorize decisions: auto, always or never")
+	var filter entryFilter
+	fs.StringVar(&filter.decision, "decision", "", "only show entries with this policy decision, allow or deny")
+	fs.StringVar(&filter.action, "action", "", "only show entries with this enforcement action, such as blocked")
+	fs.StringVar(&filter.host, "host", "", "only show requests to this host or its subdomains")
+	fs.StringVar(&filter.method, "method", "", "only show requests with this HTTP method")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: trusera %s [flags] events.jsonl\n", name)
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	p := &entryPrinter{w: stdout, format: *format}
+	switch *format {
+	case "compact", "table", "json":
+	default:
+		fmt.Fprintf(stderr, "unknown format %q\n", *format)
+		return errUsage
+	}
+	switch *colorMode {
+	case "auto":
+		p.color = isTerminal(stdout) && os.Getenv("NO_COLOR") == ""
+	case "always":
+		p.color = true
+	case "never":
+	default:
+		fmt.Fprintf(stderr, "unknown color mode %q\n", *colorMode)
+		return errUsage
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	show := func(line []byte) {
+		if filter.match(line) {
+			p.print(line)
+		}
+	}
+	offset, err := printLast(f, *lines, filter.match, p.print)
+	if err != nil || !*follow {
+		return err
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	return followLog(ctx, fs.Arg(0), f, offset, show)
+}
+
+// entryFilter selects log entries by decision, enforcement action, host and
+// method. Empty fields match everything.
+type entryFilter struct {
+	decision, action, host, method string
+}
+
+// match reports whether a log line passes the filter. Lines that are not
+// entries only pass when no filter is set.
+func (f entryFilter) match(line []byte) bool {
+	if f == (entryFilter{}) {
+		return true
+	}
+	var e logEntry
+	if err := json.Unmarshal(line, &e); err != nil {
+		return false
+	}
+	host := e.Hostname
+	if host == "" {
+		if u, err := url.Parse(e.URL); err == nil {
+			host = u.Hostname()
+		}
+	}
+	switch {
+	case f.decision != "" && !strings.EqualFold(f.decision, e.PolicyDecision):
+		return false
+	case f.action != "" && !strings.EqualFold(f.action, e.EnforcementAction):
+		return false
+	case f.method != "" && !strings.EqualFold(f.method, e.Method):
+		return false
+	case f.host != "" && !strings.EqualFold(f.host, host) && !strings.HasSuffix(strings.ToLower(host), "."+strings.ToLower(f.host)):
+		return false
+	}
+	return true
+}
+
+// printLast prints the last n complete lines of f that keep accepts, or all
+// of them when n is negative, and returns the offset after the last complete
+// line
+func printLast(f *os.File, n int, keep func([]byte) bool, show func([]byte)) (int64, error) {
+	var (
+		last   [][]byte
+		offset int64
+	)
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		offset += int64(len(line))
+		if n == 0 || !keep(line) {
+			continue
+		}
+		last = append(last, line)
+		if n > 0 && len(last) > n {
+			last = last[1:]
+		}
+	}
+	for _, line := range last {
+		show(line)
+	}
+	return offset, nil
+}
+
+// followLog prints lines appended to f after offset until ctx is done. A
+// file that shrinks was truncated and is read again from the start, and a
+// file replaced by rotation is reopened.
+func followLog(ctx context.Context, path string, f *os.File, offset int64, show func([]byte)) error {
+	// f may be replaced by a reopened file, which is closed here
+	defer func() { f.Close() }()
+
+	var partial []byte
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if info, err := os.Stat(path); err == nil {
+			if current, err := f.Stat(); err == nil && !os.SameFile(info, current) {
+				if reopened, err := os.Open(path); err == nil {
+					f.Close()
+					f, offset, partial = reopened, 0, nil
+				}
+			} else if info.Size() < offset {
+				offset, partial = 0, nil
+			}
+		}
+
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return err
+		}
+		offset += int64(len(data))
+
+		partial = append(partial, data...)
+		for {
+			i := bytes.IndexByte(partial, '\n')
+			if i < 0 {
+				break
+			}
+			show(partial[:i+1])
+			partial = partial[i+1:]
+		}
+	}
+}
+
+// ANSI escapes for decisions
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorBold   = "\033[1;31m"
+	colorYellow = "\033[33m"
+	colorGreen  = "\033[32m"
+	colorDim    = "\033[2m"
+)
+
+// actionColors colors entries by enforcement action
+var actionColors = map[string]string{
+	"allowed":   colorGreen,
+	"logged":    colorYellow,
+	"warned":    colorYellow,
+	"blocked":   colorBold,
+	"timed_out": colorRed,
+}
+
+// entryPrinter renders log lines in one of the tail formats
+type entryPrinter struct {
+	w       io.Writer
+	format  string
+	color   bool
+	started bool
+}
+
+// paint wraps s in code when color is on
+func (p *entryPrinter) paint(code, s string) string {
+	if !p.color || code == "" {
+		return s
+	}
+	return code + s + colorReset
+}
+
+// print renders one log line, or writes it as is if it is not an entry
+func (p *entryPrinter) print(line []byte) {
+	if p.format == "json" {
+		p.w.Write(line)
+		return
+	}
+	var e logEntry
+	if err := json.Unmarshal(line, &e); err != nil {
+		fmt.Fprint(p.w, string(line))
+		return
+	}
+
+	ts := "-"
+	if t, err := time.Parse(time.RFC3339Nano, e.Timestamp); err == nil {
+		ts = t.Local().Format("15:04:05.000")
+	}
+	status := "-"
+	if e.Status != 0 {
+		status = fmt.Sprint(e.Status)
+	}
+	code := actionColors[e.EnforcementAction]
+
+	if p.format == "table" {
+		if !p.started {
+			p.started = true
+			fmt.Fprintf(p.w, "%-12s  %-8s  %-9s  %-7s  %6s  %8s  %s\n", "TIME", "DECISION", "ACTION", "METHOD", "STATUS", "DURATION", "URL")
+		}
+		// Pad before painting so escapes do not upset the alignment
+		fmt.Fprintf(p.w, "%-12s  %s  %s  %-7s  %6s  %6.0fms  %s\n",
+			ts, p.paint(code, fmt.Sprintf("%-8s", strings.ToUpper(e.PolicyDecision))), p.paint(code, fmt.Sprintf("%-9s", e.EnforcementAction)),
+			e.Method, status, e.DurationMs, e.URL)
+		return
+	}
+
+	fmt.Fprintf(p.w, "%s %s %s %-6s %s %s %.0fms\n",
+		ts, p.paint(code, fmt.Sprintf("%-5s", strings.ToUpper(e.PolicyDecision))), p.paint(code, fmt.Sprintf("%-9s", e.EnforcementAction)),
+		e.Method, e.URL, status, e.DurationMs)
+	if e.Reasons != "" {
+		fmt.Fprintf(p.w, "    %s\n", p.paint(colorDim, e.Reasons))
+	}
+}
+
+// isTerminal reports whether w is a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
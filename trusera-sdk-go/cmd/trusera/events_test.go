@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const tailLog = `{"timestamp":"2026-03-01T10:00:00Z","method":"GET","url":"https://api.example.com/a","status":200,"duration_ms":12,"policy_decision":"Allow","enforcement_action":"allowed"}
+{"timestamp":"2026-03-01T10:00:01Z","method":"DELETE","url":"https://api.example.com/b","duration_ms":1,"policy_decision":"Deny","enforcement_action":"blocked","reasons":"forbid: resource.method == DELETE (actual: DELETE)"}
+`
+
+// syncBuffer is a bytes.Buffer safe for a command writing from another goroutine
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// cancelableSignals makes commands that run until interrupted stop when the
+// returned function is called
+func cancelableSignals(t *testing.T) context.CancelFunc {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	saved := signalContext
+	signalContext = func() (context.Context, context.CancelFunc) { return ctx, func() {} }
+	t.Cleanup(func() {
+		cancel()
+		signalContext = saved
+	})
+	return cancel
+}
+
+// waitFor polls until cond holds or fails the test after a few seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestEventsTail(t *testing.T) {
+	path := writeTestLog(t, tailLog)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"events", "tail", "-n", "1", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if strings.Contains(out, "example.com/a") || !strings.Contains(out, "DENY  blocked   DELETE https://api.example.com/b - 1ms") {
+		t.Errorf("unexpected output %q", out)
+	}
+	if !strings.Contains(out, "    forbid: resource.method == DELETE") {
+		t.Errorf("expected the reasons to be printed, got %q", out)
+	}
+
+	stdout.Reset()
+	run([]string{"events", "tail", "-n", "-1", path}, &stdout, &stderr)
+	if strings.Count(stdout.String(), "https://api.example.com/") != 2 {
+		t.Errorf("expected every entry with -n -1, got %q", stdout.String())
+	}
+}
+
+func TestEventsTailFollow(t *testing.T) {
+	followInterval = 10 * time.Millisecond
+	defer func() { followInterval = 250 * time.Millisecond }()
+	stop := cancelableSignals(t)
+	path := writeTestLog(t, tailLog)
+
+	var stdout, stderr syncBuffer
+	done := make(chan int)
+	go func() { done <- run([]string{"events", "tail", "-f", "-n", "0", path}, &stdout, &stderr) }()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"method":"POST","url":"https://api.example.com/c",`)
+	f.Sync()
+	time.Sleep(50 * time.Millisecond)
+	f.WriteString(`"policy_decision":"Allow","enforcement_action":"allowed"}` + "\n")
+	f.Close()
+	waitFor(t, "the appended entry", func() bool { return strings.Contains(stdout.String(), "https://api.example.com/c") })
+
+	// Truncation starts over from the beginning
+	os.WriteFile(path, []byte(`{"method":"PUT","url":"https://api.example.com/d"}`+"\n"), 0600)
+	waitFor(t, "the rewritten entry", func() bool { return strings.Contains(stdout.String(), "https://api.example.com/d") })
+
+	stop()
+	if code := <-done; code != 0 {
+		t.Errorf("exit %d: %s", code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), "example.com/a") {
+		t.Errorf("expected -n 0 to skip existing entries, got %q", stdout.String())
+	}
+}
+
+func TestTailFilters(t *testing.T) {
+	path := writeTestLog(t, tailLog+`{"method":"GET","url":"https://other.test/c","hostname":"other.test","policy_decision":"Deny","enforcement_action":"warned"}
+not json
+`)
+
+	tests := []struct {
+		args       []string
+		want, miss []string
+	}{
+		{[]string{"-decision", "deny"}, []string{"example.com/b", "other.test/c"}, []string{"example.com/a", "not json"}},
+		{[]string{"--host=example.com"}, []string{"example.com/a", "example.com/b"}, []string{"other.test/c"}},
+		{[]string{"-action", "warned", "-method", "get"}, []string{"other.test/c"}, []string{"example.com/"}},
+		{nil, []string{"example.com/a", "other.test/c", "not json"}, nil},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		args := append(append([]string{"events", "tail", "-n", "-1"}, tt.args...), path)
+		if code := run(args, &stdout, &stderr); code != 0 {
+			t.Fatalf("%q: exit %d: %s", tt.args, code, stderr.String())
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(stdout.String(), want) {
+				t.Errorf("%q: missing %s in %q", tt.args, want, stdout.String())
+			}
+		}
+		for _, miss := range tt.miss {
+			if strings.Contains(stdout.String(), miss) {
+				t.Errorf("%q: unexpected %s in %q", tt.args, miss, stdout.String())
+			}
+		}
+	}
+
+	// -n counts matching entries
+	var stdout, stderr bytes.Buffer
+	run([]string{"events", "tail", "-n", "1", "-decision", "allow", path}, &stdout, &stderr)
+	if !strings.Contains(stdout.String(), "example.com/a") {
+		t.Errorf("expected the last allowed entry, got %q", stdout.String())
+	}
+}
+
+func TestTailFormats(t *testing.T) {
+	path := writeTestLog(t, tailLog)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"events", "tail", "-format", "table", "-color", "always", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "TIME          DECISION  ACTION") {
+		t.Fatalf("expected a header and two rows, got %q", stdout.String())
+	}
+	if !strings.Contains(lines[2], "\033[1;31mDENY    \033[0m") || strings.Contains(stdout.String(), "forbid:") {
+		t.Errorf("unexpected row %q", lines[2])
+	}
+
+	stdout.Reset()
+	run([]string{"events", "tail", "-format", "json", "-decision", "deny", path}, &stdout, &stderr)
+	if got := stdout.String(); got != strings.SplitAfter(tailLog, "\n")[1] {
+		t.Errorf("expected the raw denied line, got %q", got)
+	}
+
+	// Output to a buffer is not a terminal, so auto leaves color off
+	stdout.Reset()
+	run([]string{"events", "tail", path}, &stdout, &stderr)
+	if strings.Contains(stdout.String(), "\033[") {
+		t.Errorf("unexpected escapes in %q", stdout.String())
+	}
+
+	if code := run([]string{"tail", "-format", "xml", path}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected usage error for an unknown format, got %d", code)
+	}
+}
+
+func TestTailFollowsByDefault(t *testing.T) {
+	followInterval = 10 * time.Millisecond
+	defer func() { followInterval = 250 * time.Millisecond }()
+	stop := cancelableSignals(t)
+	path := writeTestLog(t, tailLog)
+
+	var stdout, stderr syncBuffer
+	done := make(chan int)
+	go func() { done <- run([]string{"tail", "-decision", "deny", path}, &stdout, &stderr) }()
+	waitFor(t, "the existing denied entry", func() bool { return strings.Contains(stdout.String(), "example.com/b") })
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"method":"GET","url":"https://api.example.com/allowed","policy_decision":"Allow"}` + "\n")
+	f.WriteString(`{"method":"PUT","url":"https://api.example.com/denied","policy_decision":"Deny"}` + "\n")
+	f.Close()
+	waitFor(t, "the appended denied entry", func() bool { return strings.Contains(stdout.String(), "example.com/denied") })
+
+	stop()
+	if code := <-done; code != 0 {
+		t.Errorf("exit %d: %s", code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), "example.com/allowed") {
+		t.Errorf("expected allowed entries to be filtered, got %q", stdout.String())
+	}
+}
@@ -0,0 +1,149 @@
+// Command trusera exposes the SDK to operators and CI without writing Go.
+//
+// Usage:
+//
+//	trusera policy validate policy.cedar ...
+//	trusera policy test -policy policy.cedar cases/ cases.yaml ...
+//	trusera events tail [-f] [-n 10] events.jsonl
+//	trusera tail [-decision deny] [-host api.example.com] [-format table] events.jsonl
+//	trusera bom generate [flags] [log.jsonl ...]
+//	trusera bom merge [-o org.json] bom.json ...
+//	trusera proxy [-policy policy.cedar] [-listen 127.0.0.1:8080]
+//	trusera replay -policy policy.cedar [-enforcement block] events.jsonl ...
+//	trusera simulate -policy policy.cedar -method DELETE -url https://api.example.com/x
+//	trusera decrypt-log -key-file log.key events.jsonl ...
+//	trusera verify-log -pub signing.pub events.jsonl ...
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// errUsage marks errors caused by bad arguments, which exit with status 2
+var errUsage = errors.New("usage")
+
+// signalContext returns a context canceled on interrupt or termination, for
+// commands that run until stopped. Tests replace it.
+var signalContext = func() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// command is one subcommand, run with the arguments after its name
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) error
+}
+
+func commands() []command {
+	return []command{
+		{"policy", "Validate and test Cedar policies", runPolicy},
+		{"events", "Inspect interceptor event logs", runEvents},
+		{"tail", "Follow an event log with filters (events tail -f)", runTail},
+		{"bom", "Build AI bills of materials", runBOM},
+		{"proxy", "Run the enforcing forward proxy", runProxy},
+		{"replay", "Evaluate logged requests against a new policy", runReplay},
+		{"simulate", "Explain the policy decision for one request", runSimulate},
+		{"decrypt-log", "Decrypt an encrypted event log", runDecryptLog},
+		{"verify-log", "Verify the signatures of a signed event log", runVerifyLog},
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run dispatches to a subcommand and returns the exit status
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		usage(stderr)
+		return 2
+	}
+
+	for _, cmd := range commands() {
+		if cmd.name != args[0] {
+			continue
+		}
+		err := cmd.run(args[1:], stdout, stderr)
+		switch {
+		case err == nil:
+			return 0
+		case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+			return 2
+		default:
+			fmt.Fprintf(stderr, "trusera %s: %v\n", cmd.name, err)
+			return 1
+		}
+	}
+
+	fmt.Fprintf(stderr, "trusera: unknown command %q\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: trusera <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-12s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// subcommand dispatches args to one of subs, printing usage for unknown names
+func subcommand(name string, args []string, stdout, stderr io.Writer, subs []command) error {
+	if len(args) > 0 {
+		for _, sub := range subs {
+			if sub.name == args[0] {
+				return sub.run(args[1:], stdout, stderr)
+			}
+		}
+		fmt.Fprintf(stderr, "trusera %s: unknown command %q\n", name, args[0])
+	}
+
+	fmt.Fprintf(stderr, "Usage: trusera %s <command> [arguments]\n\nCommands:\n", name)
+	for _, sub := range subs {
+		fmt.Fprintf(stderr, "  %-10s %s\n", sub.name, sub.summary)
+	}
+	return errUsage
+}
+
+// newFlagSet returns a flag set that reports errors instead of exiting
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("trusera "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// parseFlags parses args, turning flag errors into errUsage since the flag
+// set has already printed them
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
+
+// writeOutput runs write against stdout, or against path when it is set
+func writeOutput(path string, stdout io.Writer, write func(io.Writer) error) error {
+	if path == "" {
+		return write(stdout)
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunUsage(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "Usage: trusera <command>"},
+		{[]string{"nope"}, `unknown command "nope"`},
+		{[]string{"bom"}, "Usage: trusera bom <command>"},
+		{[]string{"bom", "nope"}, `unknown command "nope"`},
+		{[]string{"bom", "generate", "-bogus"}, "flag provided but not defined"},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run(tt.args, &stdout, &stderr); code != 2 {
+			t.Errorf("run(%q) exited %d, want 2", tt.args, code)
+		}
+		if !strings.Contains(stderr.String(), tt.want) {
+			t.Errorf("run(%q) printed %q, want %q", tt.args, stderr.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/internal/yaml"
+)
+
+func runPolicy(args []string, stdout, stderr io.Writer) error {
+	return subcommand("policy", args, stdout, stderr, []command{
+		{"validate", "Check Cedar policy files for mistakes", runPolicyValidate},
+		{"test", "Check policy decisions against expected ones", runPolicyTest},
+	})
+}
+
+// runPolicyValidate reports every problem in each policy file
+func runPolicyValidate(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("policy validate", stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: trusera policy validate policy.cedar ...")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	invalid := 0
+	for _, path := range fs.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rules, err := trusera.ValidatePolicy(string(data))
+		if err != nil {
+			invalid++
+			for _, line := range strings.Split(err.Error(), "\n") {
+				fmt.Fprintf(stdout, "%s: %s\n", path, line)
+			}
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: ok, %d rules\n", path, len(rules))
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d policy files have problems", invalid, fs.NArg())
+	}
+	return nil
+}
+
+// policyCase is one expected decision. Request fields beyond the method and
+// URL are those the interceptor derives from the body or configuration.
+type policyCase struct {
+	Name    string `json:"name"`
+	Request struct {
+		Method       string `json:"method"`
+		URL          string `json:"url"`
+		SecretCount  int    `json:"secret_count"`
+		IP           string `json:"ip"`
+		LLMProvider  string `json:"llm_provider"`
+		LLMModel     string `json:"llm_model"`
+		ModelLicense string `json:"model_license"`
+		Region       string `json:"region"`
+	} `json:"request"`
+	Expect string `json:"expect"` // "allow" or "deny"
+}
+
+// runPolicyTest evaluates test cases in YAML or JSON files, or directories
+// of them, against a policy and fails if any decision differs from the
+// expected one
+func runPolicyTest(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("policy test", stderr)
+	policyFile := fs.String("policy", "", "Cedar policy file to test (required)")
+	verbose := fs.Bool("v", false, "also list passing cases")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: trusera policy test -policy policy.cedar cases/ cases.yaml ...")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *policyFile == "" || fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	data, err := os.ReadFile(*policyFile)
+	if err != nil {
+		return err
+	}
+	rules, err := trusera.ParseCedarPolicy(string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", *policyFile, err)
+	}
+
+	files, err := caseFiles(fs.Args())
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("no test case files found")
+	}
+
+	passed, failed := 0, 0
+	for _, path := range files {
+		cases, err := loadPolicyCases(path)
+		if err != nil {
+			return err
+		}
+		for i, c := range cases {
+			name := c.Name
+			if name == "" {
+				name = fmt.Sprintf("case %d", i+1)
+			}
+			got, err := evaluateCase(c, rules)
+			if err != nil {
+				return fmt.Errorf("%s: %s: %w", path, name, err)
+			}
+			if strings.EqualFold(got.Decision, c.Expect) {
+				passed++
+				if *verbose {
+					fmt.Fprintf(stdout, "PASS %s: %s\n", path, name)
+				}
+				continue
+			}
+			failed++
+			fmt.Fprintf(stdout, "FAIL %s: %s: expected %s, got %s\n", path, name, strings.ToLower(c.Expect), strings.ToLower(got.Decision))
+			for _, reason := range got.Reasons {
+				fmt.Fprintf(stdout, "    %s\n", reason)
+			}
+		}
+	}
+
+	fmt.Fprintf(stdout, "%d passed, %d failed\n", passed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d cases failed", failed, passed+failed)
+	}
+	return nil
+}
+
+// caseFiles expands directories in paths to the YAML and JSON files in them
+func caseFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			switch filepath.Ext(p) {
+			case ".yaml", ".yml", ".json":
+				if !d.IsDir() {
+					files = append(files, p)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// loadPolicyCases reads the cases in a YAML or JSON file: a list of cases,
+// or a mapping with a "cases" list. Unknown fields are errors, so a
+// misspelled expectation does not pass silently.
+func loadPolicyCases(path string) ([]policyCase, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		v, err := yaml.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if data, err = json.Marshal(v); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var cases []policyCase
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var file struct {
+			Cases []policyCase `json:"cases"`
+		}
+		err = dec.Decode(&file)
+		cases = file.Cases
+	} else {
+		err = dec.Decode(&cases)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cases, nil
+}
+
+// evaluateCase returns the policy decision for a case's request
+func evaluateCase(c policyCase, rules []trusera.PolicyRule) (trusera.PolicyDecision, error) {
+	switch strings.ToLower(c.Expect) {
+	case "allow", "deny":
+	default:
+		return trusera.PolicyDecision{}, errors.New(`expect must be "allow" or "deny"`)
+	}
+
+	ctx, err := trusera.NewRequestContext(c.Request.Method, c.Request.URL)
+	if err != nil {
+		return trusera.PolicyDecision{}, err
+	}
+	ctx.SecretCount = c.Request.SecretCount
+	if c.Request.IP != "" {
+		ctx.IP = c.Request.IP
+	}
+	if c.Request.LLMProvider != "" {
+		ctx.LLMProvider = c.Request.LLMProvider
+	}
+	if c.Request.LLMModel != "" {
+		ctx.LLMModel = c.Request.LLMModel
+	}
+	ctx.ModelLicense = c.Request.ModelLicense
+	if c.Request.Region != "" {
+		ctx.Region = c.Request.Region
+	}
+	return trusera.EvaluatePolicy(ctx, rules), nil
+}
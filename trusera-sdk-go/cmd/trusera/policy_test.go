@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testPolicy = `forbid ( principal, action == Action::"delete", resource )
+when {
+    resource.method == "DELETE";
+};
+`
+
+// writeFile writes content to name in dir and returns its path
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPolicyValidate(t *testing.T) {
+	dir := t.TempDir()
+	good := writeFile(t, dir, "good.cedar", testPolicy)
+	bad := writeFile(t, dir, "bad.cedar", `forbid ( principal, action == Action::"x", resource )
+when {
+    resource.host == "example.com";
+};
+`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"policy", "validate", good}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "ok, 1 rules") {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"policy", "validate", good, bad}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit 1 for an invalid policy, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), bad+": line 3: unknown field resource.host") {
+		t.Errorf("expected the problem with its file and line, got %q", stdout.String())
+	}
+}
+
+func TestPolicyTest(t *testing.T) {
+	dir := t.TempDir()
+	policy := writeFile(t, dir, "policy.cedar", testPolicy)
+	passing := writeFile(t, dir, "pass.json", `[
+  {"name": "deletes are denied", "request": {"method": "DELETE", "url": "https://api.example.com/users/1"}, "expect": "deny"},
+  {"request": {"url": "https://api.example.com/users/1"}, "expect": "allow"}
+]`)
+	failing := writeFile(t, dir, "fail.json", `[
+  {"name": "posts are denied", "request": {"method": "POST", "url": "https://api.example.com/users"}, "expect": "deny"}
+]`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"policy", "test", "-v", "-policy", policy, passing}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "PASS "+passing+": case 2") || !strings.Contains(stdout.String(), "2 passed, 0 failed") {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"policy", "test", "-policy", policy, passing, failing}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit 1 for a failing case, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "FAIL "+failing+": posts are denied: expected deny, got allow") {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+
+	invalid := writeFile(t, dir, "invalid.json", `[{"request": {"url": "https://x"}, "expect": "maybe"}]`)
+	if code := run([]string{"policy", "test", "-policy", policy, invalid}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit 1 for an invalid expectation, got %d", code)
+	}
+	if code := run([]string{"policy", "test", passing}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected usage error without -policy, got %d", code)
+	}
+}
+
+func TestPolicyTestDirectory(t *testing.T) {
+	dir := t.TempDir()
+	policy := writeFile(t, dir, "policy.cedar", testPolicy)
+	cases := filepath.Join(dir, "cases")
+	writeFile(t, cases, "deletes.yaml", `cases:
+  - name: deletes are denied
+    request:
+      method: DELETE
+      url: https://api.example.com/users/1
+    expect: deny
+`)
+	writeFile(t, cases, "nested/reads.yml", `- name: reads are allowed
+  request:
+    url: https://api.openai.com/v1/models
+    llm_provider: openai
+  expect: allow
+`)
+	writeFile(t, cases, "README.md", "not a case file")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"policy", "test", "-policy", policy, cases}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "2 passed, 0 failed") {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+
+	// A misspelled field is an error rather than a case that cannot fail
+	writeFile(t, cases, "typo.yaml", "- request:\n    url: https://x\n  expected: deny\n")
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"policy", "test", "-policy", policy, cases}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit 1 for an unknown field, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), `unknown field "expected"`) {
+		t.Errorf("unexpected stderr %q", stderr.String())
+	}
+
+	if code := run([]string{"policy", "test", "-policy", policy, t.TempDir()}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit 1 for a directory without cases, got %d", code)
+	}
+}
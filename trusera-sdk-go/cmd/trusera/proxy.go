@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// runProxy runs the enforcing forward proxy until interrupted. SIGHUP
+// reloads the policy and reopens log files.
+func runProxy(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("proxy", stderr)
+	policy := fs.String("policy", "", "Cedar policy file to enforce")
+	enforcement := fs.String("enforcement", "", "what to do with denied requests: log, warn or block (default $TRUSERA_ENFORCEMENT, then log)")
+	listen := fs.String("listen", "127.0.0.1:8080", "address to listen on")
+	logFormat := fs.String("log-format", "json", "format of file and stdout logs: json, cef or leef")
+	logKeyFile := fs.String("log-key-file", "", "encrypt log files with the hex or base64 AES key in this file")
+	signingKeyFile := fs.String("log-signing-key", "", "sign events with the PEM Ed25519 private key in this file")
+	var logs []string
+	fs.Func("log", "write the event log to this file, - for stdout, an http(s) URL, journald: or eventlog:<source> (repeatable)", func(dest string) error {
+		logs = append(logs, dest)
+		return nil
+	})
+	var gateways []string
+	fs.Func("llm-gateway", "treat this host[:port] as a LiteLLM or Portkey gateway and attribute its calls to the routed provider (repeatable)", func(host string) error {
+		gateways = append(gateways, host)
+		return nil
+	})
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: trusera proxy [flags]")
+		fmt.Fprintln(stderr, "\nPoint an agent's HTTP_PROXY and HTTPS_PROXY at the listen address.")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	mode := trusera.EnforcementAction(*enforcement)
+	switch mode {
+	case "", trusera.EnforcementLog, trusera.EnforcementWarn, trusera.EnforcementBlock:
+	default:
+		fmt.Fprintf(stderr, "unknown enforcement mode %q\n", *enforcement)
+		return errUsage
+	}
+	switch *logFormat {
+	case "json", "cef", "leef":
+	default:
+		fmt.Fprintf(stderr, "unknown log format %q\n", *logFormat)
+		return errUsage
+	}
+
+	var logKey []byte
+	if *logKeyFile != "" {
+		key, err := loadLogKey(*logKeyFile)
+		if err != nil {
+			return err
+		}
+		logKey = key
+	}
+	var signingKey ed25519.PrivateKey
+	if *signingKeyFile != "" {
+		data, err := os.ReadFile(*signingKeyFile)
+		if err != nil {
+			return err
+		}
+		if signingKey, err = trusera.ParseLogSigningKey(data); err != nil {
+			return err
+		}
+	}
+
+	sinks, err := logSinks(logs, *logFormat, stdout)
+	if err != nil {
+		return err
+	}
+	opts := []trusera.StandaloneOption{trusera.WithLogSinks(sinks...)}
+	if mode != "" {
+		opts = append(opts, trusera.WithEnforcement(mode))
+	}
+	if logKey != nil {
+		opts = append(opts, trusera.WithLogEncryption(logKey))
+	}
+	if signingKey != nil {
+		opts = append(opts, trusera.WithLogSigning(signingKey))
+	}
+	if *policy != "" {
+		opts = append(opts, trusera.WithPolicyFile(*policy))
+	}
+	if len(gateways) > 0 {
+		opts = append(opts, trusera.WithLLMGateways(gateways...))
+	}
+	proxy, err := trusera.NewProxyServer(trusera.WithProxyInterceptorOptions(opts...))
+	if err != nil {
+		for _, sink := range sinks {
+			sink.Close()
+		}
+		return err
+	}
+	proxy.Interceptor().HandleSignals()
+
+	l, err := net.Listen("tcp", *listen)
+	if err != nil {
+		proxy.Close()
+		return err
+	}
+	fmt.Fprintf(stderr, "trusera proxy listening on %s, enforcement %s\n", l.Addr(), mode)
+
+	ctx, stop := signalContext()
+	defer stop()
+	served := make(chan error, 1)
+	go func() { served <- proxy.Serve(l) }()
+
+	select {
+	case err := <-served:
+		proxy.Close()
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err = proxy.Shutdown(shutdownCtx)
+	if serveErr := <-served; !errors.Is(serveErr, http.ErrServerClosed) {
+		err = errors.Join(err, serveErr)
+	}
+	return err
+}
+
+// logSinks opens a sink per log destination. Files and stdout are written
+// in format; HTTP sinks always post JSONL, and journald and the Event Log
+// get structured entries.
+func logSinks(dests []string, format string, stdout io.Writer) ([]trusera.LogSink, error) {
+	var sinks []trusera.LogSink
+	for _, dest := range dests {
+		var sink trusera.LogSink
+		var err error
+		structured := false
+		switch {
+		case dest == "-":
+			sink = trusera.NewWriterSink(stdout)
+		case strings.HasPrefix(dest, "http://"), strings.HasPrefix(dest, "https://"):
+			sinks = append(sinks, trusera.NewHTTPSink(dest))
+			continue
+		case dest == "journald:":
+			sink, err = trusera.NewJournaldSink()
+			structured = true
+		case strings.HasPrefix(dest, "eventlog:"):
+			sink, err = trusera.NewEventLogSink(strings.TrimPrefix(dest, "eventlog:"))
+			structured = true
+		default:
+			sink, err = trusera.NewFileSink(dest)
+		}
+		if err != nil {
+			for _, s := range sinks {
+				s.Close()
+			}
+			return nil, err
+		}
+
+		switch {
+		case structured:
+		case format == "cef":
+			sink = trusera.NewCEFSink(sink)
+		case format == "leef":
+			sink = trusera.NewLEEFSink(sink)
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// startProxy runs "trusera proxy" with args on a free port and returns a
+// client using it and a function that stops it and returns the exit status
+func startProxy(t *testing.T, args ...string) (*http.Client, func() int) {
+	t.Helper()
+	stop := cancelableSignals(t)
+
+	var stdout, stderr syncBuffer
+	done := make(chan int)
+	go func() {
+		done <- run(append([]string{"proxy", "-listen", "127.0.0.1:0"}, args...), &stdout, &stderr)
+	}()
+
+	listening := regexp.MustCompile(`listening on (\S+),`)
+	waitFor(t, "the proxy to listen", func() bool { return listening.MatchString(stderr.String()) })
+	proxyURL, _ := url.Parse("http://" + listening.FindStringSubmatch(stderr.String())[1])
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+	return client, func() int {
+		stop()
+		code := <-done
+		if code != 0 {
+			t.Logf("proxy stderr: %s", stderr.String())
+		}
+		return code
+	}
+}
+
+// proxyStatus sends a request through client and returns the status code
+func proxyStatus(t *testing.T, client *http.Client, method, url string) int {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("proxied %s: %v", method, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer upstream.Close()
+	policy := writeFile(t, t.TempDir(), "policy.cedar", testPolicy)
+
+	client, stop := startProxy(t, "-policy", policy)
+	if code := proxyStatus(t, client, http.MethodGet, upstream.URL); code != http.StatusNoContent {
+		t.Errorf("expected the GET to pass, got %d", code)
+	}
+	// The default log mode records the denied DELETE but lets it through
+	if code := proxyStatus(t, client, http.MethodDelete, upstream.URL); code != http.StatusNoContent {
+		t.Errorf("expected the DELETE to be logged only, got %d", code)
+	}
+	if code := stop(); code != 0 {
+		t.Errorf("exit %d", code)
+	}
+}
+
+func TestProxyBlock(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer upstream.Close()
+	dir := t.TempDir()
+	policy := writeFile(t, dir, "policy.cedar", testPolicy)
+	logPath := filepath.Join(dir, "events.jsonl")
+
+	client, stop := startProxy(t, "-policy", policy, "-enforcement", "block", "-log", logPath)
+	if code := proxyStatus(t, client, http.MethodDelete, upstream.URL); code != http.StatusForbidden {
+		t.Errorf("expected the DELETE to be blocked, got %d", code)
+	}
+	if code := stop(); code != 0 {
+		t.Errorf("exit %d", code)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("log not written: %v", err)
+	}
+	if !strings.Contains(string(data), `"enforcement_action":"blocked"`) {
+		t.Errorf("expected the blocked request in the log, got %s", data)
+	}
+}
+
+func TestProxyEnv(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer upstream.Close()
+	t.Setenv("TRUSERA_POLICY_FILE", writeFile(t, t.TempDir(), "policy.cedar", testPolicy))
+	t.Setenv("TRUSERA_ENFORCEMENT", "block")
+
+	client, stop := startProxy(t)
+	if code := proxyStatus(t, client, http.MethodDelete, upstream.URL); code != http.StatusForbidden {
+		t.Errorf("expected the environment to enable blocking, got %d", code)
+	}
+	if code := stop(); code != 0 {
+		t.Errorf("exit %d", code)
+	}
+
+	// The flag wins over the environment
+	client, stop = startProxy(t, "-enforcement", "log")
+	if code := proxyStatus(t, client, http.MethodDelete, upstream.URL); code != http.StatusNoContent {
+		t.Errorf("expected -enforcement log to override the environment, got %d", code)
+	}
+	if code := stop(); code != 0 {
+		t.Errorf("exit %d", code)
+	}
+}
+
+func TestProxyLogFormat(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer upstream.Close()
+	dir := t.TempDir()
+	policy := writeFile(t, dir, "policy.cedar", testPolicy)
+	logPath := filepath.Join(dir, "events.cef")
+
+	client, stop := startProxy(t, "-policy", policy, "-enforcement", "block", "-log", logPath, "-log-format", "cef")
+	proxyStatus(t, client, http.MethodDelete, upstream.URL)
+	if code := stop(); code != 0 {
+		t.Errorf("exit %d", code)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("log not written: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "CEF:0|Trusera|trusera-sdk-go|") || !strings.Contains(string(data), "|blocked|Request blocked by policy|8|") {
+		t.Errorf("expected a CEF line for the blocked request, got %s", data)
+	}
+}
+
+func TestProxyLogEncryption(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer upstream.Close()
+	dir := t.TempDir()
+	keyFile := writeFile(t, dir, "log.key", strings.Repeat("ab", 32)+"\n")
+	logPath := filepath.Join(dir, "events.jsonl")
+
+	client, stop := startProxy(t, "-log", logPath, "-log-key-file", keyFile)
+	proxyStatus(t, client, http.MethodGet, upstream.URL)
+	if code := stop(); code != 0 {
+		t.Errorf("exit %d", code)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("log not written: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "trusera-enc:v1:") {
+		t.Fatalf("expected an encrypted log, got %s", data)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"decrypt-log", "-key-file", keyFile, logPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("decrypt-log exit %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"enforcement_action":"allowed"`) {
+		t.Errorf("unexpected decrypted log %s", stdout.String())
+	}
+}
+
+func TestProxyLogSigning(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer upstream.Close()
+	dir := t.TempDir()
+	keyFile := writeFile(t, dir, "log.key", strings.Repeat("ab", 32)+"\n")
+	signingKey, pubFile := writeSigningKeys(t, dir, 1)
+	logPath := filepath.Join(dir, "events.jsonl")
+
+	client, stop := startProxy(t, "-log", logPath, "-log-key-file", keyFile, "-log-signing-key", signingKey)
+	proxyStatus(t, client, http.MethodGet, upstream.URL)
+	proxyStatus(t, client, http.MethodPost, upstream.URL)
+	if code := stop(); code != 0 {
+		t.Errorf("exit %d", code)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"verify-log", "-pub", pubFile, "-key-file", keyFile, logPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("verify-log exit %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "events.jsonl: 2 entries verified") {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+
+	// A different key is named in the error
+	otherDir := t.TempDir()
+	_, otherPub := writeSigningKeys(t, otherDir, 2)
+	stderr.Reset()
+	if code := run([]string{"verify-log", "-pub", otherPub, "-key-file", keyFile, logPath}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit 1 for the wrong key, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "line 1: signed by key") {
+		t.Errorf("unexpected stderr %q", stderr.String())
+	}
+}
+
+func TestProxyUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"proxy", "-enforcement", "deny"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit 2 for an unknown mode, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), `unknown enforcement mode "deny"`) {
+		t.Errorf("unexpected stderr %q", stderr.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"proxy", "-log-format", "syslog"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit 2 for an unknown log format, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), `unknown log format "syslog"`) {
+		t.Errorf("unexpected stderr %q", stderr.String())
+	}
+
+	if runtime.GOOS != "windows" {
+		if code := run([]string{"proxy", "-log", "eventlog:trusera"}, &stdout, &stderr); code != 1 {
+			t.Errorf("expected exit 1 without the Event Log, got %d", code)
+		}
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing", "events.jsonl")
+	if code := run([]string{"proxy", "-log", missing}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit 1 for an unwritable log, got %d", code)
+	}
+}
+
+func TestProxyLLMGateway(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer upstream.Close()
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+	gateway := strings.TrimPrefix(upstream.URL, "http://")
+
+	client, stop := startProxy(t, "-llm-gateway", gateway, "-log", logPath)
+	resp, err := client.Post(upstream.URL+"/v1/chat/completions", "application/json", strings.NewReader(`{"model":"anthropic/claude-3-5-haiku-latest"}`))
+	if err != nil {
+		t.Fatalf("proxied POST: %v", err)
+	}
+	resp.Body.Close()
+	if code := stop(); code != 0 {
+		t.Errorf("exit %d", code)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("log not written: %v", err)
+	}
+	for _, want := range []string{`"llm_provider":"anthropic"`, `"llm_model":"claude-3-5-haiku-latest"`, `"llm_gateway":"litellm"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %s in the log, got %s", want, data)
+		}
+	}
+}
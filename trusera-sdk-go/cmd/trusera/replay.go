@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// replayEntry holds the interceptor log fields a policy decision depends on
+type replayEntry struct {
+	logEntry
+	IP              string   `json:"ip"`
+	LLMProvider     string   `json:"llm_provider"`
+	LLMModel        string   `json:"llm_model"`
+	ModelLicense    string   `json:"model_license"`
+	Region          string   `json:"region"`
+	SecretsDetected []string `json:"secrets_detected"`
+}
+
+// replayActions orders enforcement actions in the summary
+var replayActions = []string{"allowed", "logged", "warned", "blocked"}
+
+// runReplay evaluates the requests in event logs against a policy and
+// reports those whose enforcement would change
+func runReplay(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("replay", stderr)
+	policyFile := fs.String("policy", "", "Cedar policy file to evaluate (required)")
+	enforcement := fs.String("enforcement", "block", "enforcement mode to assume: log, warn or block")
+	verbose := fs.Bool("v", false, "also list requests whose enforcement is unchanged")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: trusera replay -policy policy.cedar [flags] events.jsonl ...")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *policyFile == "" || fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+	mode := trusera.EnforcementAction(*enforcement)
+	switch mode {
+	case trusera.EnforcementLog, trusera.EnforcementWarn, trusera.EnforcementBlock:
+	default:
+		fmt.Fprintf(stderr, "unknown enforcement mode %q\n", *enforcement)
+		return errUsage
+	}
+
+	data, err := os.ReadFile(*policyFile)
+	if err != nil {
+		return err
+	}
+	rules, err := trusera.ParseCedarPolicy(string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", *policyFile, err)
+	}
+
+	r := &replayer{rules: rules, mode: mode, verbose: *verbose, w: stdout, before: map[string]int{}, after: map[string]int{}}
+	for _, path := range fs.Args() {
+		if err := r.replayFile(path); err != nil {
+			return err
+		}
+	}
+	r.summary()
+	return nil
+}
+
+// replayer accumulates the outcome of replaying log entries
+type replayer struct {
+	rules   []trusera.PolicyRule
+	mode    trusera.EnforcementAction
+	verbose bool
+	w       io.Writer
+
+	total, changed, skipped int
+	before, after           map[string]int
+}
+
+// replayFile replays every entry in one log, printing changed ones
+func (r *replayer) replayFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for num := 1; scanner.Scan(); num++ {
+		var e replayEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.URL == "" {
+			r.skipped++
+			continue
+		}
+		ctx, err := replayContext(e)
+		if err != nil {
+			r.skipped++
+			continue
+		}
+
+		decision := trusera.EvaluatePolicy(ctx, r.rules)
+		now := enforcementFor(decision, r.mode)
+		r.total++
+		r.before[e.EnforcementAction]++
+		r.after[now]++
+
+		if now == e.EnforcementAction {
+			if r.verbose {
+				fmt.Fprintf(r.w, "  %s:%d: %s %s: %s\n", path, num, ctx.Method, ctx.URL, now)
+			}
+			continue
+		}
+		r.changed++
+		fmt.Fprintf(r.w, "~ %s:%d: %s %s: %s -> %s\n", path, num, ctx.Method, ctx.URL, e.EnforcementAction, now)
+		if decision.Decision == "Deny" {
+			for _, reason := range decision.Reasons {
+				fmt.Fprintf(r.w, "    %s\n", reason)
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// summary prints how many requests changed and the count of each action
+// before and after
+func (r *replayer) summary() {
+	fmt.Fprintf(r.w, "%d requests replayed, %d changed", r.total, r.changed)
+	if r.skipped > 0 {
+		fmt.Fprintf(r.w, ", %d lines skipped", r.skipped)
+	}
+	fmt.Fprintln(r.w)
+
+	// Actions only seen in the log, such as timed_out, follow in name order
+	var extra []string
+	for action := range r.before {
+		if !slices.Contains(replayActions, action) {
+			extra = append(extra, action)
+		}
+	}
+	slices.Sort(extra)
+	for _, action := range append(slices.Clone(replayActions), extra...) {
+		if r.before[action] == 0 && r.after[action] == 0 {
+			continue
+		}
+		fmt.Fprintf(r.w, "  %-9s %6d -> %d\n", action, r.before[action], r.after[action])
+	}
+}
+
+// replayContext rebuilds the request context the interceptor evaluated for
+// a logged request
+func replayContext(e replayEntry) (trusera.RequestContext, error) {
+	ctx, err := trusera.NewRequestContext(e.Method, e.URL)
+	if err != nil {
+		return ctx, err
+	}
+	// The log records what the interceptor resolved and read from the body
+	if e.IP != "" {
+		ctx.IP = e.IP
+	}
+	if e.LLMProvider != "" {
+		ctx.LLMProvider = e.LLMProvider
+	}
+	if e.LLMModel != "" {
+		ctx.LLMModel = e.LLMModel
+	}
+	ctx.ModelLicense = e.ModelLicense
+	if e.Region != "" {
+		ctx.Region = e.Region
+	}
+	ctx.SecretCount = len(e.SecretsDetected)
+	return ctx, nil
+}
+
+// enforcementFor returns the action the interceptor would log for a
+// decision in mode
+func enforcementFor(decision trusera.PolicyDecision, mode trusera.EnforcementAction) string {
+	if decision.Decision != "Deny" {
+		return "allowed"
+	}
+	switch mode {
+	case trusera.EnforcementBlock:
+		return "blocked"
+	case trusera.EnforcementWarn:
+		return "warned"
+	default:
+		return "logged"
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	dir := t.TempDir()
+	policy := writeFile(t, dir, "policy.cedar", testPolicy+`
+forbid ( principal, action == Action::"secrets", resource )
+when {
+    resource.secret_count > 0;
+};
+`)
+	log := writeFile(t, dir, "events.jsonl", `{"method":"GET","url":"https://api.example.com/users","policy_decision":"Allow","enforcement_action":"allowed"}
+{"method":"DELETE","url":"https://api.example.com/users/1","policy_decision":"Allow","enforcement_action":"allowed"}
+not json
+{"method":"POST","url":"https://api.example.com/users","policy_decision":"Allow","enforcement_action":"allowed","secrets_detected":["aws_access_key"]}
+{"method":"DELETE","url":"https://api.example.com/users/2","policy_decision":"Deny","enforcement_action":"blocked"}
+`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"replay", "-policy", policy, log}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{
+		"~ " + log + ":2: DELETE https://api.example.com/users/1: allowed -> blocked",
+		"    forbid: resource.method == DELETE (actual: DELETE)",
+		"~ " + log + ":4: POST https://api.example.com/users: allowed -> blocked",
+		"4 requests replayed, 2 changed, 1 lines skipped",
+		"  allowed        3 -> 1",
+		"  blocked        1 -> 3",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, ":1:") || strings.Contains(out, ":5:") {
+		t.Errorf("unchanged requests listed without -v:\n%s", out)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"replay", "-policy", policy, "-enforcement", "warn", "-v", log}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "  "+log+":1: GET https://api.example.com/users: allowed") ||
+		!strings.Contains(stdout.String(), ":5: DELETE https://api.example.com/users/2: blocked -> warned") {
+		t.Errorf("unexpected output\n%s", stdout.String())
+	}
+
+	if code := run([]string{"replay", log}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected usage error without -policy, got %d", code)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// runSimulate evaluates one hypothetical request against a policy and
+// explains the decision rule by rule
+func runSimulate(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("simulate", stderr)
+	policyFile := fs.String("policy", "", "Cedar policy file to evaluate (required)")
+	method := fs.String("method", "GET", "request method")
+	rawURL := fs.String("url", "", "absolute request URL (required)")
+	secrets := fs.Int("secret-count", 0, "credentials secret scanning would find in the body")
+	ip := fs.String("ip", "", "destination IP address, when the host is not a literal IP")
+	provider := fs.String("llm-provider", "", "LLM provider, when not detected from the URL")
+	model := fs.String("llm-model", "", "LLM model, as read from the request body")
+	license := fs.String("model-license", "", "license of the model, as configured with WithModelLicenses")
+	region := fs.String("region", "", "cloud region, when not derived from the URL or a SigV4 signature")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: trusera simulate -policy policy.cedar -url URL [flags]")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *policyFile == "" || *rawURL == "" || fs.NArg() != 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	data, err := os.ReadFile(*policyFile)
+	if err != nil {
+		return err
+	}
+	rules, err := trusera.ParseCedarPolicy(string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", *policyFile, err)
+	}
+	ctx, err := trusera.NewRequestContext(*method, *rawURL)
+	if err != nil {
+		return err
+	}
+	ctx.SecretCount = *secrets
+	if *ip != "" {
+		ctx.IP = *ip
+	}
+	if *provider != "" {
+		ctx.LLMProvider = *provider
+	}
+	if *model != "" {
+		ctx.LLMModel = *model
+	}
+	ctx.ModelLicense = *license
+	if *region != "" {
+		ctx.Region = *region
+	}
+
+	decision, trace := trusera.ExplainPolicy(ctx, rules)
+	printSimulation(stdout, ctx, decision, trace)
+	return nil
+}
+
+// printSimulation writes the request as evaluated, the decision with its
+// matched rules and obligations, and the trace of every condition
+func printSimulation(w io.Writer, ctx trusera.RequestContext, decision trusera.PolicyDecision, trace []trusera.RuleTrace) {
+	if len(decision.Matched) == 0 {
+		fmt.Fprintf(w, "Decision: %s (no matching policy rules)\n", strings.ToUpper(decision.Decision))
+	} else {
+		fmt.Fprintf(w, "Decision: %s\n", strings.ToUpper(decision.Decision))
+	}
+
+	fmt.Fprintln(w, "\nRequest:")
+	fields := []struct{ name, value string }{
+		{"method", ctx.Method},
+		{"url", ctx.URL},
+		{"hostname", ctx.Hostname},
+		{"path", ctx.Path},
+		{"port", fmt.Sprint(ctx.Port)},
+		{"ip", ctx.IP},
+		{"secret_count", fmt.Sprint(ctx.SecretCount)},
+		{"llm_provider", ctx.LLMProvider},
+		{"llm_model", ctx.LLMModel},
+		{"model_license", ctx.ModelLicense},
+		{"region", ctx.Region},
+	}
+	for _, f := range fields {
+		if (f.value != "" && f.value != "0") || f.name == "secret_count" {
+			fmt.Fprintf(w, "  %-14s %s\n", f.name, f.value)
+		}
+	}
+
+	// Several conditions of one rule share its text, so print each once
+	if len(decision.Matched) > 0 {
+		fmt.Fprintln(w, "\nMatched rules:")
+		seen := make(map[string]bool)
+		for _, raw := range decision.Matched {
+			if seen[raw] {
+				continue
+			}
+			seen[raw] = true
+			for _, line := range strings.Split(raw, "\n") {
+				fmt.Fprintf(w, "  %s\n", strings.TrimRight(line, " \t"))
+			}
+		}
+	}
+	if len(decision.Obligations) > 0 {
+		fmt.Fprintf(w, "\nObligations: %s\n", strings.Join(decision.Obligations, ", "))
+	}
+
+	fmt.Fprintln(w, "\nTrace:")
+	if len(trace) == 0 {
+		fmt.Fprintln(w, "  (policy has no rules)")
+	}
+	for _, t := range trace {
+		result := "no match"
+		if t.Matched {
+			result = "MATCH"
+		}
+		actual := t.Actual
+		if actual == "" {
+			actual = "unset"
+		}
+		fmt.Fprintf(w, "  %-8s %s: resource.%s %s %v (actual: %s)\n", result, t.Rule.Action, t.Rule.Field, t.Rule.Operator, t.Rule.Value, actual)
+	}
+}
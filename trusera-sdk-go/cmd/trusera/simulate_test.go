@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSimulate(t *testing.T) {
+	policy := writeFile(t, t.TempDir(), "policy.cedar", `@obligation("notify")
+`+testPolicy+`
+forbid ( principal, action == Action::"secrets", resource )
+when {
+    resource.secret_count > 0;
+};
+`)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"simulate", "-policy", policy, "-method", "delete", "-url", "https://api.openai.com/v1/files/1"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{
+		"Decision: DENY\n",
+		"  llm_provider   openai\n",
+		"Matched rules:\n  forbid ( principal, action == Action::\"delete\", resource )\n  when {\n      resource.method == \"DELETE\";\n  };\n",
+		"Obligations: notify\n",
+		"  MATCH    forbid: resource.method == DELETE (actual: DELETE)\n",
+		"  no match forbid: resource.secret_count > 0 (actual: 0)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+
+	stdout.Reset()
+	if code := run([]string{"simulate", "-policy", policy, "-url", "https://api.example.com/"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "Decision: ALLOW (no matching policy rules)") || strings.Contains(stdout.String(), "Matched rules") {
+		t.Errorf("unexpected output\n%s", stdout.String())
+	}
+
+	// The region comes from a regional endpoint's host
+	stdout.Reset()
+	if code := run([]string{"simulate", "-policy", policy, "-method", "POST", "-url", "https://bedrock-runtime.eu-west-1.amazonaws.com/model/amazon.nova-pro-v1:0/converse"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	if out := stdout.String(); !strings.Contains(out, "  region         eu-west-1\n") || !strings.Contains(out, "  llm_model      amazon.nova-pro-v1:0\n") {
+		t.Errorf("expected the Bedrock region and model in\n%s", out)
+	}
+
+	if code := run([]string{"simulate", "-policy", policy}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected usage error without -url, got %d", code)
+	}
+	if code := run([]string{"simulate", "-policy", policy, "-url", "/relative"}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit 1 for a relative URL, got %d", code)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"fmt"
+	"io"
+	"os"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+)
+
+// runVerifyLog checks the signatures of signed event logs
+func runVerifyLog(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("verify-log", stderr)
+	pubFile := fs.String("pub", "", "PEM Ed25519 public key of the signing key (required)")
+	keyFile := fs.String("key-file", "", "decrypt the logs first with the hex or base64 key in this file (default $"+logKeyEnv+" if set)")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: trusera verify-log -pub signing.pub [flags] events.jsonl ...")
+		fmt.Fprintln(stderr, "\nVerifies logs written with WithLogSigning or proxy -log-signing-key. Every entry must be signed.")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *pubFile == "" || fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	data, err := os.ReadFile(*pubFile)
+	if err != nil {
+		return err
+	}
+	pub, err := trusera.ParseLogPublicKey(data)
+	if err != nil {
+		return err
+	}
+	var key []byte
+	if *keyFile != "" || os.Getenv(logKeyEnv) != "" {
+		if key, err = loadLogKey(*keyFile); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range fs.Args() {
+		n, err := verifyLogFile(path, pub, key)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fmt.Fprintf(stdout, "%s: %d entries verified with key %s\n", path, n, trusera.LogKeyID(pub))
+	}
+	return nil
+}
+
+// verifyLogFile verifies the log at path, decrypting it first when key is set
+func verifyLogFile(path string, pub ed25519.PublicKey, key []byte) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	if key == nil {
+		return trusera.VerifyLog(f, pub)
+	}
+
+	var plain bytes.Buffer
+	if err := trusera.DecryptLog(&plain, f, key); err != nil {
+		return 0, err
+	}
+	return trusera.VerifyLog(&plain, pub)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+// writeSigningKeys writes a PEM Ed25519 key pair to dir and returns the
+// private and public key paths
+func writeSigningKeys(t *testing.T, dir string, seed byte) (string, string) {
+	t.Helper()
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+	priv, _ := x509.MarshalPKCS8PrivateKey(key)
+	pub, _ := x509.MarshalPKIXPublicKey(key.Public())
+	return writeFile(t, dir, "signing.key", string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: priv}))),
+		writeFile(t, dir, "signing.pub", string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub})))
+}
+
+func TestVerifyLogUsage(t *testing.T) {
+	dir := t.TempDir()
+	_, pubFile := writeSigningKeys(t, dir, 1)
+	logPath := writeFile(t, dir, "events.jsonl", `{"hostname":"api.example.com"}`+"\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"verify-log", logPath}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit 2 without -pub, got %d", code)
+	}
+	if code := run([]string{"verify-log", "-pub", pubFile}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit 2 without files, got %d", code)
+	}
+
+	stderr.Reset()
+	if code := run([]string{"verify-log", "-pub", pubFile, logPath}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit 1 for an unsigned log, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "events.jsonl: line 1: entry is not signed") {
+		t.Errorf("unexpected stderr %q", stderr.String())
+	}
+
+	stderr.Reset()
+	if code := run([]string{"verify-log", "-pub", logPath, logPath}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "not PEM encoded") {
+		t.Errorf("expected a bad key error, got %d %q", code, stderr.String())
+	}
+}
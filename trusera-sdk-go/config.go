@@ -0,0 +1,621 @@
+package trusera
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/internal/yaml"
+)
+
+// NewStandaloneInterceptorFromConfig creates an interceptor from a YAML or
+// JSON config file, so deployment settings live outside the code. $VAR and
+// ${VAR} references are expanded from the environment first, which keeps
+// secrets such as webhook URLs out of the file, and relative paths are
+// resolved against the file's directory. Unknown keys are errors. opts are
+// applied after the file, for settings that only exist in code such as hooks
+// and loggers. See STANDALONE.md for the file format.
+func NewStandaloneInterceptorFromConfig(path string, opts ...StandaloneOption) (*StandaloneInterceptor, error) {
+	cfg, err := loadStandaloneConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	cfgOpts, err := cfg.options(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return NewStandaloneInterceptor(append(cfgOpts, opts...)...)
+}
+
+// standaloneConfig is the config file format
+type standaloneConfig struct {
+	Policy                string                `json:"policy"`
+	HostPolicies          []hostPolicyConfig    `json:"host_policies"`
+	Enforcement           EnforcementAction     `json:"enforcement"`
+	Exclude               []string              `json:"exclude"`
+	Include               []string              `json:"include"`
+	LLMGateways           []string              `json:"llm_gateways"`
+	ControlSocket         string                `json:"control_socket"`
+	RequestTimeout        configDuration        `json:"request_timeout"`
+	MaxConcurrentRequests int                   `json:"max_concurrent_requests"`
+	Retry                 *retryConfig          `json:"retry"`
+	SampleRate            *float64              `json:"sample_rate"`
+	DecisionHeaders       bool                  `json:"decision_headers"`
+	BlockResponse         bool                  `json:"block_response"`
+	CircuitBreaker        *circuitBreakerConfig `json:"circuit_breaker"`
+	Capture               captureConfig         `json:"capture"`
+	Redaction             redactionConfig       `json:"redaction"`
+	Log                   logConfig             `json:"log"`
+	Statsd                *statsdConfig         `json:"statsd"`
+	Sentry                *sentryConfig         `json:"sentry"`
+	Slack                 *slackConfig          `json:"slack"`
+	PagerDuty             *pagerDutyConfig      `json:"pagerduty"`
+	Webhooks              []webhookConfig       `json:"webhooks"`
+}
+
+type hostPolicyConfig struct {
+	Pattern string `json:"pattern"`
+	File    string `json:"file"`
+}
+
+type retryConfig struct {
+	Max     int            `json:"max"`
+	Backoff configDuration `json:"backoff"`
+}
+
+type circuitBreakerConfig struct {
+	Threshold int            `json:"threshold"`
+	Window    configDuration `json:"window"`
+	Cooldown  configDuration `json:"cooldown"`
+}
+
+type captureConfig struct {
+	RequestBody  *bodyLimitsConfig `json:"request_body"`
+	ResponseBody *bodyLimitsConfig `json:"response_body"`
+	Headers      bool              `json:"headers"`
+}
+
+type bodyLimitsConfig struct {
+	MaxBytes     int `json:"max_bytes"`
+	PreviewBytes int `json:"preview_bytes"`
+}
+
+type redactionConfig struct {
+	Headers     []string      `json:"headers"`
+	HashHeaders bool          `json:"hash_headers"`
+	PII         detectorNames `json:"pii"`
+	Secrets     detectorNames `json:"secrets"`
+}
+
+type logConfig struct {
+	File              string       `json:"file"`
+	Sinks             []sinkConfig `json:"sinks"`
+	EncryptionKeyFile string       `json:"encryption_key_file"`
+	SigningKeyFile    string       `json:"signing_key_file"`
+}
+
+// sinkConfig is one log sink. Type selects the sink and which other fields
+// apply.
+type sinkConfig struct {
+	Type   string `json:"type"`
+	Format string `json:"format"`
+
+	// file
+	Path string `json:"path"`
+
+	// http and otlp
+	URL           string            `json:"url"`
+	ServiceName   string            `json:"service_name"`
+	Headers       map[string]string `json:"headers"`
+	BatchSize     int               `json:"batch_size"`
+	BatchInterval configDuration    `json:"batch_interval"`
+
+	// rotating
+	Dir             string         `json:"dir"`
+	Interval        configDuration `json:"interval"`
+	MaxBytes        int64          `json:"max_bytes"`
+	MaxSegments     int            `json:"max_segments"`
+	RetentionPeriod configDuration `json:"retention_period"`
+	S3              *s3Config      `json:"s3"`
+
+	// journald
+	Identifier string `json:"identifier"`
+
+	// eventlog
+	Source string `json:"source"`
+}
+
+type s3Config struct {
+	Bucket          string `json:"bucket"`
+	Region          string `json:"region"`
+	Prefix          string `json:"prefix"`
+	Endpoint        string `json:"endpoint"`
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	SessionToken    string `json:"session_token"`
+}
+
+type statsdConfig struct {
+	Addr   string   `json:"addr"`
+	Prefix string   `json:"prefix"`
+	Tags   []string `json:"tags"`
+}
+
+type sentryConfig struct {
+	DSN         string `json:"dsn"`
+	Environment string `json:"environment"`
+	AgentID     string `json:"agent_id"`
+}
+
+type slackConfig struct {
+	WebhookURL     string           `json:"webhook_url"`
+	BlockThreshold *thresholdConfig `json:"block_threshold"`
+	NewHosts       *bool            `json:"new_hosts"`
+	RateLimit      configDuration   `json:"rate_limit"`
+}
+
+type pagerDutyConfig struct {
+	RoutingKey  string           `json:"routing_key"`
+	Severity    string           `json:"severity"`
+	Source      string           `json:"source"`
+	CircuitTrip *bool            `json:"circuit_trip"`
+	BlockRate   *thresholdConfig `json:"block_rate"`
+}
+
+// thresholdConfig is a count of events within a window
+type thresholdConfig struct {
+	Count  int            `json:"count"`
+	Window configDuration `json:"window"`
+}
+
+type webhookConfig struct {
+	URL     string            `json:"url"`
+	Actions []string          `json:"actions"`
+	Secret  string            `json:"secret"`
+	Headers map[string]string `json:"headers"`
+	Retry   *retryConfig      `json:"retry"`
+}
+
+// configDuration is a duration written as a string such as "30s" or "5m"
+type configDuration time.Duration
+
+func (d *configDuration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("durations are strings such as \"30s\", got %s", data)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = configDuration(v)
+	return nil
+}
+
+// detectorNames enables the default detectors with true, or the named ones
+// with a list
+type detectorNames struct {
+	enabled bool
+	names   []string
+}
+
+func (d *detectorNames) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &d.enabled); err == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, &d.names); err != nil {
+		return fmt.Errorf("expected true, false or a list of detector names, got %s", data)
+	}
+	d.enabled = true
+	return nil
+}
+
+// loadStandaloneConfig reads and decodes a config file
+func loadStandaloneConfig(path string) (*standaloneConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data = []byte(os.ExpandEnv(string(data)))
+
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		v, err := yaml.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if data, err = json.Marshal(v); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	var cfg standaloneConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// options converts the config to interceptor options, resolving relative
+// paths against dir. Sinks opened before an error are closed.
+func (c *standaloneConfig) options(dir string) ([]StandaloneOption, error) {
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+
+	var opts []StandaloneOption
+	if c.Policy != "" {
+		opts = append(opts, WithPolicyFile(resolve(c.Policy)))
+	}
+	for i, hp := range c.HostPolicies {
+		if hp.Pattern == "" || hp.File == "" {
+			return nil, fmt.Errorf("host_policies[%d]: pattern and file are required", i)
+		}
+		opts = append(opts, WithHostPolicy(hp.Pattern, resolve(hp.File)))
+	}
+	switch c.Enforcement {
+	case "":
+	case EnforcementLog, EnforcementWarn, EnforcementBlock:
+		opts = append(opts, WithEnforcement(c.Enforcement))
+	default:
+		return nil, fmt.Errorf("unknown enforcement mode %q", c.Enforcement)
+	}
+	if len(c.Exclude) > 0 {
+		opts = append(opts, WithExcludePatterns(c.Exclude...))
+	}
+	if len(c.Include) > 0 {
+		opts = append(opts, WithIncludePatterns(c.Include...))
+	}
+	if len(c.LLMGateways) > 0 {
+		opts = append(opts, WithLLMGateways(c.LLMGateways...))
+	}
+	if c.ControlSocket != "" {
+		opts = append(opts, WithControlSocket(resolve(c.ControlSocket)))
+	}
+	if c.RequestTimeout > 0 {
+		opts = append(opts, WithRequestTimeout(time.Duration(c.RequestTimeout)))
+	}
+	if c.MaxConcurrentRequests > 0 {
+		opts = append(opts, WithMaxConcurrentRequests(c.MaxConcurrentRequests))
+	}
+	if c.Retry != nil {
+		opts = append(opts, WithRetry(c.Retry.Max, time.Duration(c.Retry.Backoff)))
+	}
+	if c.SampleRate != nil {
+		opts = append(opts, WithSampleRate(*c.SampleRate))
+	}
+	if c.DecisionHeaders {
+		opts = append(opts, WithDecisionHeaders(true))
+	}
+	if c.BlockResponse {
+		opts = append(opts, WithBlockResponse(true))
+	}
+	if cb := c.CircuitBreaker; cb != nil {
+		opts = append(opts, WithCircuitBreaker(cb.Threshold, time.Duration(cb.Window), time.Duration(cb.Cooldown)))
+	}
+
+	if body := c.Capture.RequestBody; body != nil {
+		opts = append(opts, WithRequestBodyCapture(body.MaxBytes, body.PreviewBytes))
+	}
+	if body := c.Capture.ResponseBody; body != nil {
+		opts = append(opts, WithResponseBodyCapture(body.MaxBytes, body.PreviewBytes))
+	}
+	if c.Capture.Headers {
+		opts = append(opts, WithCaptureHeaders(true))
+	}
+
+	if len(c.Redaction.Headers) > 0 {
+		opts = append(opts, WithRedactHeaders(c.Redaction.Headers...))
+	}
+	if c.Redaction.HashHeaders {
+		opts = append(opts, WithHashRedactedHeaders(true))
+	}
+	if c.Redaction.PII.enabled {
+		detectors, err := pickDetectors("pii", c.Redaction.PII.names, DefaultPIIDetectors(), func(d PIIDetector) string { return d.Name })
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithPIIRedaction(detectors...))
+	}
+	if c.Redaction.Secrets.enabled {
+		detectors, err := pickDetectors("secrets", c.Redaction.Secrets.names, DefaultSecretDetectors(), func(d SecretDetector) string { return d.Name })
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithSecretScanning(detectors...))
+	}
+
+	notifyOpts, err := c.notifierOptions()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, notifyOpts...)
+
+	logOpts, err := c.Log.options(resolve)
+	if err != nil {
+		return nil, err
+	}
+	return append(opts, logOpts...), nil
+}
+
+// pickDetectors returns the named built-in detectors, or all of them when
+// no names are given
+func pickDetectors[D any](field string, names []string, builtin []D, name func(D) string) ([]D, error) {
+	if len(names) == 0 {
+		return builtin, nil
+	}
+	byName := make(map[string]D, len(builtin))
+	var known []string
+	for _, d := range builtin {
+		byName[name(d)] = d
+		known = append(known, name(d))
+	}
+	sort.Strings(known)
+
+	var picked []D
+	for _, n := range names {
+		d, ok := byName[n]
+		if !ok {
+			return nil, fmt.Errorf("redaction.%s: unknown detector %q (known: %s)", field, n, strings.Join(known, ", "))
+		}
+		picked = append(picked, d)
+	}
+	return picked, nil
+}
+
+// notifierOptions converts the metrics and alerting settings
+func (c *standaloneConfig) notifierOptions() ([]StandaloneOption, error) {
+	var opts []StandaloneOption
+	if s := c.Statsd; s != nil {
+		if s.Addr == "" {
+			return nil, errors.New("statsd: addr is required")
+		}
+		var statsdOpts []StatsdOption
+		if s.Prefix != "" {
+			statsdOpts = append(statsdOpts, WithStatsdPrefix(s.Prefix))
+		}
+		if len(s.Tags) > 0 {
+			statsdOpts = append(statsdOpts, WithStatsdTags(s.Tags...))
+		}
+		opts = append(opts, WithStatsd(s.Addr, statsdOpts...))
+	}
+
+	if s := c.Sentry; s != nil {
+		if s.DSN == "" {
+			return nil, errors.New("sentry: dsn is required")
+		}
+		var sentryOpts []SentryOption
+		if s.Environment != "" {
+			sentryOpts = append(sentryOpts, WithSentryEnvironment(s.Environment))
+		}
+		if s.AgentID != "" {
+			sentryOpts = append(sentryOpts, WithSentryAgentID(s.AgentID))
+		}
+		opts = append(opts, WithSentry(s.DSN, sentryOpts...))
+	}
+
+	if s := c.Slack; s != nil {
+		if s.WebhookURL == "" {
+			return nil, errors.New("slack: webhook_url is required")
+		}
+		var slackOpts []SlackOption
+		if t := s.BlockThreshold; t != nil {
+			slackOpts = append(slackOpts, WithSlackBlockThreshold(t.Count, time.Duration(t.Window)))
+		}
+		if s.NewHosts != nil {
+			slackOpts = append(slackOpts, WithSlackNewHosts(*s.NewHosts))
+		}
+		if s.RateLimit > 0 {
+			slackOpts = append(slackOpts, WithSlackRateLimit(time.Duration(s.RateLimit)))
+		}
+		opts = append(opts, WithSlack(s.WebhookURL, slackOpts...))
+	}
+
+	if p := c.PagerDuty; p != nil {
+		if p.RoutingKey == "" {
+			return nil, errors.New("pagerduty: routing_key is required")
+		}
+		var pdOpts []PagerDutyOption
+		if p.Severity != "" {
+			pdOpts = append(pdOpts, WithPagerDutySeverity(p.Severity))
+		}
+		if p.Source != "" {
+			pdOpts = append(pdOpts, WithPagerDutySource(p.Source))
+		}
+		if p.CircuitTrip != nil {
+			pdOpts = append(pdOpts, WithPagerDutyCircuitTrip(*p.CircuitTrip))
+		}
+		if t := p.BlockRate; t != nil {
+			pdOpts = append(pdOpts, WithPagerDutyBlockRate(t.Count, time.Duration(t.Window)))
+		}
+		opts = append(opts, WithPagerDuty(p.RoutingKey, pdOpts...))
+	}
+
+	for i, w := range c.Webhooks {
+		if w.URL == "" {
+			return nil, fmt.Errorf("webhooks[%d]: url is required", i)
+		}
+		var filter WebhookFilter
+		if len(w.Actions) > 0 {
+			filter = WebhookOnActions(w.Actions...)
+		}
+		var webhookOpts []WebhookOption
+		if w.Secret != "" {
+			webhookOpts = append(webhookOpts, WithWebhookSecret([]byte(w.Secret)))
+		}
+		for _, key := range sortedKeys(w.Headers) {
+			webhookOpts = append(webhookOpts, WithWebhookHeader(key, w.Headers[key]))
+		}
+		if w.Retry != nil {
+			webhookOpts = append(webhookOpts, WithWebhookRetry(w.Retry.Max, time.Duration(w.Retry.Backoff)))
+		}
+		opts = append(opts, WithDecisionWebhook(w.URL, filter, webhookOpts...))
+	}
+	return opts, nil
+}
+
+// options converts the log settings, opening the configured sinks
+func (c *logConfig) options(resolve func(string) string) ([]StandaloneOption, error) {
+	var opts []StandaloneOption
+	if c.File != "" {
+		opts = append(opts, WithLogFile(resolve(c.File)))
+	}
+	if c.EncryptionKeyFile != "" {
+		data, err := os.ReadFile(resolve(c.EncryptionKeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("log.encryption_key_file: %w", err)
+		}
+		key, err := ParseLogKey(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("log.encryption_key_file: %w", err)
+		}
+		opts = append(opts, WithLogEncryption(key))
+	}
+	if c.SigningKeyFile != "" {
+		data, err := os.ReadFile(resolve(c.SigningKeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("log.signing_key_file: %w", err)
+		}
+		key, err := ParseLogSigningKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("log.signing_key_file: %w", err)
+		}
+		opts = append(opts, WithLogSigning(key))
+	}
+
+	var sinks []LogSink
+	for i, sc := range c.Sinks {
+		sink, err := sc.open(resolve)
+		if err != nil {
+			for _, s := range sinks {
+				s.Close()
+			}
+			return nil, fmt.Errorf("log.sinks[%d]: %w", i, err)
+		}
+		sinks = append(sinks, sink)
+	}
+	if len(sinks) > 0 {
+		opts = append(opts, WithLogSinks(sinks...))
+	}
+	return opts, nil
+}
+
+// open creates the sink, wrapped for CEF or LEEF when a format is set
+func (c *sinkConfig) open(resolve func(string) string) (LogSink, error) {
+	switch c.Format {
+	case "", "json", "cef", "leef":
+	default:
+		return nil, fmt.Errorf("unknown format %q", c.Format)
+	}
+
+	var httpOpts []HTTPSinkOption
+	for _, key := range sortedKeys(c.Headers) {
+		httpOpts = append(httpOpts, WithHTTPSinkHeader(key, c.Headers[key]))
+	}
+	if c.BatchSize > 0 || c.BatchInterval > 0 {
+		httpOpts = append(httpOpts, WithHTTPSinkBatch(c.BatchSize, time.Duration(c.BatchInterval)))
+	}
+
+	var (
+		sink LogSink
+		err  error
+	)
+	switch c.Type {
+	case "file":
+		if c.Path == "" {
+			return nil, errors.New("path is required")
+		}
+		sink, err = NewFileSink(resolve(c.Path))
+	case "stdout":
+		sink = NewWriterSink(os.Stdout)
+	case "http":
+		if c.URL == "" {
+			return nil, errors.New("url is required")
+		}
+		sink = NewHTTPSink(c.URL, httpOpts...)
+	case "otlp":
+		if c.URL == "" {
+			return nil, errors.New("url is required")
+		}
+		sink, err = NewOTLPSink(c.URL, c.ServiceName, httpOpts...)
+	case "rotating":
+		if c.Dir == "" {
+			return nil, errors.New("dir is required")
+		}
+		var rotOpts []RotatingSinkOption
+		if c.Interval > 0 {
+			rotOpts = append(rotOpts, WithRotationInterval(time.Duration(c.Interval)))
+		}
+		if c.MaxBytes > 0 {
+			rotOpts = append(rotOpts, WithRotationSize(c.MaxBytes))
+		}
+		if c.MaxSegments > 0 {
+			rotOpts = append(rotOpts, WithLocalRetention(c.MaxSegments))
+		}
+		if c.RetentionPeriod > 0 {
+			rotOpts = append(rotOpts, WithRetentionPeriod(time.Duration(c.RetentionPeriod)))
+		}
+		if s3 := c.S3; s3 != nil {
+			uploader, err := NewS3Uploader(S3Config{
+				Bucket:          s3.Bucket,
+				Region:          s3.Region,
+				Prefix:          s3.Prefix,
+				Endpoint:        s3.Endpoint,
+				AccessKeyID:     s3.AccessKeyID,
+				SecretAccessKey: s3.SecretAccessKey,
+				SessionToken:    s3.SessionToken,
+			})
+			if err != nil {
+				return nil, err
+			}
+			rotOpts = append(rotOpts, WithSegmentUploader(uploader))
+		}
+		sink, err = NewRotatingSink(resolve(c.Dir), rotOpts...)
+	case "journald":
+		var journalOpts []JournaldOption
+		if c.Identifier != "" {
+			journalOpts = append(journalOpts, WithJournaldIdentifier(c.Identifier))
+		}
+		sink, err = NewJournaldSink(journalOpts...)
+	case "eventlog":
+		if c.Source == "" {
+			return nil, errors.New("source is required")
+		}
+		sink, err = NewEventLogSink(c.Source)
+	case "":
+		return nil, errors.New("type is required")
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", c.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.Format {
+	case "cef":
+		sink = NewCEFSink(sink)
+	case "leef":
+		sink = NewLEEFSink(sink)
+	}
+	return sink, nil
+}
+
+// sortedKeys returns the keys of m in order, so options apply predictably
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,160 @@
+package trusera
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewStandaloneInterceptorFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPolicy(t, dir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "blocked.example.com";
+};
+`)
+	os.WriteFile(filepath.Join(dir, "log.key"), []byte(strings.Repeat("42", 32)), 0600)
+	t.Setenv("TRUSERA_TEST_TAG", "team:research")
+
+	configPath := filepath.Join(dir, "trusera.yaml")
+	os.WriteFile(configPath, []byte(`
+# Relative paths are resolved against this file
+policy: policy.cedar
+enforcement: block
+exclude: [telemetry.example.com]
+include:
+  - example.com
+request_timeout: 30s
+retry:
+  max: 2
+  backoff: 100ms
+sample_rate: 0.5
+circuit_breaker:
+  threshold: 5
+  window: 1m
+  cooldown: 5m
+capture:
+  request_body:
+    max_bytes: 4096
+    preview_bytes: 256
+  headers: true
+redaction:
+  headers: [Authorization]
+  hash_headers: true
+  pii: [email]
+  secrets: true
+statsd:
+  addr: 127.0.0.1:8125
+  tags: ["${TRUSERA_TEST_TAG}"]
+log:
+  file: events.jsonl
+  encryption_key_file: log.key
+  sinks:
+    - type: file
+      path: events.cef
+      format: cef
+    - type: rotating
+      dir: segments
+      interval: 1h
+      retention_period: 720h
+`), 0644)
+
+	si, err := NewStandaloneInterceptorFromConfig(configPath, WithSampleRate(1))
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	defer si.Close()
+
+	if si.enforcement != EnforcementBlock || si.requestTimeout != 30*time.Second || si.retry.max != 2 {
+		t.Errorf("unexpected settings: enforcement %s, timeout %v, retry %+v", si.enforcement, si.requestTimeout, si.retry)
+	}
+	// Options passed in code override the file
+	if si.sampleRate != 1 {
+		t.Errorf("expected the sample rate option to win, got %v", si.sampleRate)
+	}
+	if si.circuit == nil || si.circuit.threshold != 5 || !si.captureHeaders || !si.hashRedacted {
+		t.Error("expected the circuit breaker, header capture and hashing to be configured")
+	}
+	if si.redactor == nil || len(si.redactor.detectors) != 1 || si.redactor.detectors[0].Name != "email" {
+		t.Errorf("expected only the email detector, got %+v", si.redactor)
+	}
+	if len(si.secretDetectors) != len(DefaultSecretDetectors()) {
+		t.Errorf("expected the default secret detectors, got %d", len(si.secretDetectors))
+	}
+	if len(si.statsd.tags) != 1 || si.statsd.tags[0] != "team:research" {
+		t.Errorf("expected the tag from the environment, got %v", si.statsd.tags)
+	}
+	if len(si.sinks) != 3 {
+		t.Fatalf("expected 3 sinks, got %d", len(si.sinks))
+	}
+
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	if _, err := client.Get("https://blocked.example.com/"); err == nil {
+		t.Error("expected the policy from the config to block the request")
+	}
+	resp, err := client.Get("https://other.test/")
+	if err != nil {
+		t.Fatalf("expected requests outside the include list to pass: %v", err)
+	}
+	resp.Body.Close()
+	si.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "events.jsonl"))
+	if err != nil || !strings.HasPrefix(string(data), encryptedLinePrefix) || strings.Count(string(data), "\n") != 1 {
+		t.Errorf("expected one encrypted event in the log file, got %q (%v)", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "segments")); err != nil {
+		t.Errorf("expected the segment directory next to the config: %v", err)
+	}
+}
+
+func TestStandaloneConfigJSON(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "trusera.json")
+	os.WriteFile(configPath, []byte(`{"enforcement": "warn", "decision_headers": true, "log": {"sinks": [{"type": "stdout"}]}}`), 0644)
+
+	si, err := NewStandaloneInterceptorFromConfig(configPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	defer si.Close()
+	if si.enforcement != EnforcementWarn || !si.decisionHeaders || len(si.sinks) != 1 {
+		t.Errorf("unexpected settings: enforcement %s, headers %v, %d sinks", si.enforcement, si.decisionHeaders, len(si.sinks))
+	}
+}
+
+func TestStandaloneConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{"unknown key", "enforcment: block", `unknown field "enforcment"`},
+		{"bad mode", "enforcement: strict", `unknown enforcement mode "strict"`},
+		{"bad duration", "request_timeout: 30", `durations are strings`},
+		{"bad detector", "redaction:\n  pii: [email, ssn]", `redaction.pii: unknown detector "ssn" (known: credit_card, email, phone)`},
+		{"bad sink", "log:\n  sinks:\n    - type: kafka", `log.sinks[0]: unknown sink type "kafka"`},
+		{"missing path", "log:\n  sinks:\n    - type: file", `log.sinks[0]: path is required`},
+		{"bad format", "log:\n  sinks:\n    - type: stdout\n      format: syslog", `log.sinks[0]: unknown format "syslog"`},
+		{"missing url", "webhooks:\n  - actions: [blocked]", `webhooks[0]: url is required`},
+		{"bad yaml", "policy: [a", `unterminated flow sequence`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "trusera.yaml")
+			os.WriteFile(configPath, []byte(tt.config), 0644)
+			_, err := NewStandaloneInterceptorFromConfig(configPath)
+			if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), configPath) {
+				t.Errorf("expected an error naming the file and %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if _, err := NewStandaloneInterceptorFromConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
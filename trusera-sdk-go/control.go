@@ -0,0 +1,271 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// InterceptorStats summarizes a running interceptor
+type InterceptorStats struct {
+	Requests       int64             `json:"requests"`
+	Allowed        int64             `json:"allowed"`
+	Warned         int64             `json:"warned"`
+	Logged         int64             `json:"logged"`
+	Blocked        int64             `json:"blocked"`
+	TimedOut       int64             `json:"timed_out"`
+	DroppedEvents  int64             `json:"dropped_events"`
+	Enforcement    EnforcementAction `json:"enforcement"`
+	Rules          int               `json:"rules"`
+	PolicyLoadedAt time.Time         `json:"policy_loaded_at"`
+	CircuitOpen    bool              `json:"circuit_open"`
+}
+
+// decisionCounters tallies recorded events by enforcement action
+type decisionCounters struct {
+	allowed  atomic.Int64
+	warned   atomic.Int64
+	logged   atomic.Int64
+	blocked  atomic.Int64
+	timedOut atomic.Int64
+}
+
+// count records one event
+func (c *decisionCounters) count(action string) {
+	switch action {
+	case "allowed":
+		c.allowed.Add(1)
+	case "warned":
+		c.warned.Add(1)
+	case "logged":
+		c.logged.Add(1)
+	case "blocked":
+		c.blocked.Add(1)
+	case "timed_out":
+		c.timedOut.Add(1)
+	}
+}
+
+// Stats returns request counters and the current policy state
+func (si *StandaloneInterceptor) Stats() InterceptorStats {
+	stats := InterceptorStats{
+		Allowed:       si.counters.allowed.Load(),
+		Warned:        si.counters.warned.Load(),
+		Logged:        si.counters.logged.Load(),
+		Blocked:       si.counters.blocked.Load(),
+		TimedOut:      si.counters.timedOut.Load(),
+		DroppedEvents: si.droppedEvents.Load(),
+		CircuitOpen:   si.CircuitOpen(),
+	}
+	stats.Requests = stats.Allowed + stats.Warned + stats.Logged + stats.Blocked + stats.TimedOut
+
+	si.policyMu.RLock()
+	defer si.policyMu.RUnlock()
+
+	stats.Enforcement = si.enforcement
+	stats.PolicyLoadedAt = si.policyLoadedAt
+	stats.Rules = len(si.rules)
+	for _, hp := range si.hostPolicies {
+		stats.Rules += len(hp.rules)
+	}
+
+	return stats
+}
+
+// ReloadPolicy re-reads the policy file and host policy files. The new rules
+// apply to requests evaluated afterwards; if any file fails to load, the
+// current rules are kept.
+func (si *StandaloneInterceptor) ReloadPolicy() error {
+	rules, hostRules, err := si.loadPolicies()
+	if err != nil {
+		return err
+	}
+	si.setPolicies(rules, hostRules)
+
+	si.logger.Info("policy reloaded", "file", si.policyFile, "rules", len(rules))
+	return nil
+}
+
+// SetEnforcement changes the enforcement mode of a running interceptor
+func (si *StandaloneInterceptor) SetEnforcement(mode EnforcementAction) error {
+	switch mode {
+	case EnforcementLog, EnforcementWarn, EnforcementBlock:
+	default:
+		return fmt.Errorf("invalid enforcement mode %q", mode)
+	}
+
+	si.policyMu.Lock()
+	previous := si.enforcement
+	si.enforcement = mode
+	si.policyMu.Unlock()
+
+	si.logger.Info("enforcement changed", "from", previous, "to", mode)
+	return nil
+}
+
+// WithControlSocket serves a local control API over a Unix socket at path, so
+// operators can adjust a long-running agent without restarting it. Commands
+// are HTTP requests:
+//
+//	curl --unix-socket /run/agent.sock -X POST http://agent/reload-policy
+//	curl --unix-socket /run/agent.sock -X POST http://agent/set-enforcement -d '{"mode":"block"}'
+//	curl --unix-socket /run/agent.sock http://agent/get-stats
+//
+// The socket is created with mode 0600 and removed by Close.
+func WithControlSocket(path string) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.controlSocket = path
+	}
+}
+
+// controlServer serves the control API on a Unix socket
+type controlServer struct {
+	path     string
+	server   *http.Server
+	listener net.Listener
+}
+
+// startControl listens on the control socket, replacing a stale socket file
+func (si *StandaloneInterceptor) startControl() error {
+	if fi, err := os.Lstat(si.controlSocket); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(si.controlSocket)
+	}
+
+	l, err := net.Listen("unix", si.controlSocket)
+	if err != nil {
+		return fmt.Errorf("failed to listen on control socket: %w", err)
+	}
+	if err := os.Chmod(si.controlSocket, 0600); err != nil {
+		l.Close()
+		return fmt.Errorf("failed to secure control socket: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/reload-policy", si.handleReloadPolicy)
+	mux.HandleFunc("/set-enforcement", si.handleSetEnforcement)
+	mux.HandleFunc("/get-stats", si.handleGetStats)
+	mux.HandleFunc("/purge-events", si.handlePurgeEvents)
+
+	c := &controlServer{
+		path:     si.controlSocket,
+		server:   &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second},
+		listener: l,
+	}
+	go c.server.Serve(l)
+
+	si.control = c
+	return nil
+}
+
+// close stops the control server and removes its socket
+func (c *controlServer) close(ctx context.Context) error {
+	err := c.server.Shutdown(ctx)
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		err = c.server.Close()
+	}
+	if rmErr := os.Remove(c.path); rmErr != nil && !errors.Is(rmErr, os.ErrNotExist) {
+		err = errors.Join(err, rmErr)
+	}
+	return err
+}
+
+func (si *StandaloneInterceptor) handleReloadPolicy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeControlError(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return
+	}
+
+	if err := si.ReloadPolicy(); err != nil {
+		si.logger.Warn("policy reload failed", "error", err)
+		writeControlError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeControlJSON(w, map[string]any{"ok": true, "rules": si.Stats().Rules})
+}
+
+func (si *StandaloneInterceptor) handleSetEnforcement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeControlError(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return
+	}
+
+	var body struct {
+		Mode EnforcementAction `json:"mode"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&body); err != nil {
+		writeControlError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if err := si.SetEnforcement(body.Mode); err != nil {
+		writeControlError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeControlJSON(w, map[string]any{"ok": true, "enforcement": body.Mode})
+}
+
+func (si *StandaloneInterceptor) handleGetStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeControlError(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+	writeControlJSON(w, si.Stats())
+}
+
+func (si *StandaloneInterceptor) handlePurgeEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeControlError(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return
+	}
+
+	var body struct {
+		OlderThan string `json:"older_than"`
+		Host      string `json:"host"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&body); err != nil {
+		writeControlError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	var (
+		purged int
+		err    error
+	)
+	switch {
+	case body.OlderThan != "" && body.Host == "":
+		d, parseErr := time.ParseDuration(body.OlderThan)
+		if parseErr != nil {
+			writeControlError(w, http.StatusBadRequest, parseErr)
+			return
+		}
+		purged, err = si.PurgeEvents(d)
+	case body.Host != "" && body.OlderThan == "":
+		purged, err = si.PurgeHostEvents(body.Host)
+	default:
+		writeControlError(w, http.StatusBadRequest, errors.New("give one of older_than or host"))
+		return
+	}
+	if err != nil {
+		si.logger.Warn("event purge failed", "error", err)
+		writeControlError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeControlJSON(w, map[string]any{"ok": true, "purged": purged})
+}
+
+func writeControlJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeControlError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
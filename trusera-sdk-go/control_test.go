@@ -0,0 +1,215 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// controlClient returns an HTTP client that dials the Unix socket at path
+func controlClient(path string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+}
+
+// shortTempDir returns a directory whose paths fit Unix socket length limits
+func shortTempDir(t *testing.T) string {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "trusera")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestControlSocket(t *testing.T) {
+	dir := shortTempDir(t)
+	socket := filepath.Join(dir, "control.sock")
+
+	policyPath := writeTestPolicy(t, dir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "blocked.example.com";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(WithPolicyFile(policyPath), WithControlSocket(socket))
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+
+	if fi, err := os.Stat(socket); err != nil || fi.Mode().Perm() != 0600 {
+		t.Fatalf("expected socket with mode 0600, got %v %v", fi, err)
+	}
+
+	agent := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	ctl := controlClient(socket)
+
+	// Switch to block mode at runtime
+	resp, err := ctl.Post("http://agent/set-enforcement", "application/json", strings.NewReader(`{"mode":"block"}`))
+	if err != nil {
+		t.Fatalf("set-enforcement failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("set-enforcement returned %d", resp.StatusCode)
+	}
+
+	if _, err := agent.Get("https://blocked.example.com/"); err == nil {
+		t.Error("expected request to be blocked after switching to block mode")
+	}
+
+	// Allow everything by reloading an empty policy
+	os.WriteFile(policyPath, []byte(""), 0644)
+	resp, err = ctl.Post("http://agent/reload-policy", "", nil)
+	if err != nil {
+		t.Fatalf("reload-policy failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("reload-policy returned %d", resp.StatusCode)
+	}
+
+	resp, err = agent.Get("https://blocked.example.com/")
+	if err != nil {
+		t.Fatalf("expected request to pass after reload: %v", err)
+	}
+	resp.Body.Close()
+
+	resp, err = ctl.Get("http://agent/get-stats")
+	if err != nil {
+		t.Fatalf("get-stats failed: %v", err)
+	}
+	var stats InterceptorStats
+	json.NewDecoder(resp.Body).Decode(&stats)
+	resp.Body.Close()
+
+	if stats.Requests != 2 || stats.Blocked != 1 || stats.Allowed != 1 {
+		t.Errorf("unexpected counters %+v", stats)
+	}
+	if stats.Enforcement != EnforcementBlock || stats.Rules != 0 {
+		t.Errorf("unexpected policy state %+v", stats)
+	}
+
+	if err := si.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Error("expected socket to be removed on close")
+	}
+}
+
+func TestControlSocketErrors(t *testing.T) {
+	dir := shortTempDir(t)
+	socket := filepath.Join(dir, "control.sock")
+	policyPath := writeTestPolicy(t, dir, "")
+
+	si, err := NewStandaloneInterceptor(WithPolicyFile(policyPath), WithControlSocket(socket))
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	ctl := controlClient(socket)
+
+	resp, err := ctl.Post("http://agent/set-enforcement", "application/json", strings.NewReader(`{"mode":"panic"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid mode, got %d", resp.StatusCode)
+	}
+
+	// A broken policy keeps the current rules
+	os.WriteFile(policyPath, []byte(`
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.ip.isInRange(ip("not-a-cidr"));
+};
+`), 0644)
+	resp, err = ctl.Post("http://agent/reload-policy", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for invalid policy, got %d", resp.StatusCode)
+	}
+
+	resp, err = ctl.Get("http://agent/reload-policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", resp.StatusCode)
+	}
+}
+
+func TestControlSocketPurgeEvents(t *testing.T) {
+	dir := shortTempDir(t)
+	socket := filepath.Join(dir, "control.sock")
+	logPath := filepath.Join(dir, "events.jsonl")
+	log := string(testEvent("crm.example.org", time.Now())) + string(testEvent("api.example.com", time.Now().Add(-48*time.Hour)))
+	os.WriteFile(logPath, []byte(log), 0644)
+
+	si, err := NewStandaloneInterceptor(WithLogFile(logPath), WithControlSocket(socket))
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+	ctl := controlClient(socket)
+
+	for _, tt := range []struct {
+		body   string
+		status int
+		purged int
+	}{
+		{`{"host":"*.example.org"}`, http.StatusOK, 1},
+		{`{"older_than":"24h"}`, http.StatusOK, 1},
+		{`{"older_than":"a while"}`, http.StatusBadRequest, 0},
+		{`{"older_than":"24h","host":"*"}`, http.StatusBadRequest, 0},
+	} {
+		resp, err := ctl.Post("http://agent/purge-events", "application/json", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result struct {
+			Purged int `json:"purged"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status || result.Purged != tt.purged {
+			t.Errorf("%s: expected %d with %d purged, got %d with %d", tt.body, tt.status, tt.purged, resp.StatusCode, result.Purged)
+		}
+	}
+
+	if data, _ := os.ReadFile(logPath); len(data) != 0 {
+		t.Errorf("expected an empty log, got %s", data)
+	}
+}
+
+func TestSetEnforcementValidates(t *testing.T) {
+	si := MustNewStandaloneInterceptor()
+	defer si.Close()
+
+	if err := si.SetEnforcement("strict"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+	if err := si.SetEnforcement(EnforcementWarn); err != nil || si.Stats().Enforcement != EnforcementWarn {
+		t.Errorf("expected warn mode, got %v %s", err, si.Stats().Enforcement)
+	}
+}
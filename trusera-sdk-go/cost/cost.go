@@ -0,0 +1,119 @@
+// Package cost estimates the price of LLM calls from token counts.
+//
+// Prices are list prices in USD per million tokens and drift as providers
+// change them. Override entries with SetPrice, or build a Table of your own
+// for negotiated rates.
+package cost
+
+import (
+	"strings"
+	"sync"
+)
+
+// Price is what a model charges, in USD per million tokens
+type Price struct {
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// Table maps model names to prices. Lookups match the model exactly, or else
+// the longest entry the model starts with, so dated variants such as
+// "gpt-4o-2024-08-06" use the "gpt-4o" price. A "provider/" prefix, as used
+// by gateways, is ignored. A Table is safe for concurrent use.
+type Table struct {
+	mu     sync.RWMutex
+	prices map[string]Price
+}
+
+// NewTable returns a table holding prices
+func NewTable(prices map[string]Price) *Table {
+	t := &Table{prices: make(map[string]Price, len(prices))}
+	for model, p := range prices {
+		t.prices[strings.ToLower(model)] = p
+	}
+	return t
+}
+
+// Set adds or replaces the price of model
+func (t *Table) Set(model string, p Price) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.prices[strings.ToLower(model)] = p
+}
+
+// Lookup returns the price of model
+func (t *Table) Lookup(model string) (Price, bool) {
+	model = strings.ToLower(model)
+	if i := strings.LastIndexByte(model, '/'); i >= 0 {
+		model = model[i+1:]
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if p, ok := t.prices[model]; ok {
+		return p, true
+	}
+	best, found := "", false
+	for name := range t.prices {
+		if len(name) > len(best) && strings.HasPrefix(model, name) {
+			best, found = name, true
+		}
+	}
+	return t.prices[best], found
+}
+
+// Estimate returns the cost in USD of a call to model, reporting false when
+// the model has no price
+func (t *Table) Estimate(model string, inTokens, outTokens int) (float64, bool) {
+	p, ok := t.Lookup(model)
+	if !ok {
+		return 0, false
+	}
+	return (float64(inTokens)*p.InputPerMillion + float64(outTokens)*p.OutputPerMillion) / 1e6, true
+}
+
+// Default holds list prices for common OpenAI, Anthropic and Google models
+var Default = NewTable(map[string]Price{
+	// OpenAI
+	"gpt-4o":        {2.50, 10.00},
+	"gpt-4o-mini":   {0.15, 0.60},
+	"gpt-4.1":       {2.00, 8.00},
+	"gpt-4.1-mini":  {0.40, 1.60},
+	"gpt-4.1-nano":  {0.10, 0.40},
+	"gpt-4-turbo":   {10.00, 30.00},
+	"gpt-4":         {30.00, 60.00},
+	"gpt-3.5-turbo": {0.50, 1.50},
+	"o1":            {15.00, 60.00},
+	"o1-mini":       {1.10, 4.40},
+	"o3":            {2.00, 8.00},
+	"o3-mini":       {1.10, 4.40},
+	"o4-mini":       {1.10, 4.40},
+
+	// Anthropic
+	"claude-opus-4":     {15.00, 75.00},
+	"claude-sonnet-4":   {3.00, 15.00},
+	"claude-haiku-4-5":  {1.00, 5.00},
+	"claude-3-7-sonnet": {3.00, 15.00},
+	"claude-3-5-sonnet": {3.00, 15.00},
+	"claude-3-5-haiku":  {0.80, 4.00},
+	"claude-3-opus":     {15.00, 75.00},
+	"claude-3-haiku":    {0.25, 1.25},
+
+	// Google
+	"gemini-2.5-pro":   {1.25, 10.00},
+	"gemini-2.5-flash": {0.30, 2.50},
+	"gemini-2.0-flash": {0.10, 0.40},
+	"gemini-1.5-pro":   {1.25, 5.00},
+	"gemini-1.5-flash": {0.075, 0.30},
+})
+
+// Estimate returns the cost in USD of a call to model using the Default table
+func Estimate(model string, inTokens, outTokens int) (float64, bool) {
+	return Default.Estimate(model, inTokens, outTokens)
+}
+
+// SetPrice overrides the Default price of model
+func SetPrice(model string, p Price) {
+	Default.Set(model, p)
+}
@@ -0,0 +1,46 @@
+package cost
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		model   string
+		in, out int
+		want    float64
+		ok      bool
+	}{
+		{"gpt-4o", 1_000_000, 1_000_000, 12.50, true},
+		{"gpt-4o-2024-08-06", 1000, 500, 0.0075, true},
+		{"gpt-4o-mini", 1000, 1000, 0.00075, true},
+		{"openai/gpt-4o-mini", 1000, 1000, 0.00075, true},
+		{"Claude-Sonnet-4-5-20250929", 2000, 100, 0.0075, true},
+		{"my-fine-tune", 1000, 1000, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			got, ok := Estimate(tt.model, tt.in, tt.out)
+			if ok != tt.ok || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Estimate(%q) = %v, %v; want %v, %v", tt.model, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestTableOverride(t *testing.T) {
+	table := NewTable(map[string]Price{"gpt-4o": {2.50, 10.00}})
+	table.Set("gpt-4o", Price{InputPerMillion: 1, OutputPerMillion: 2})
+	table.Set("my-fine-tune", Price{InputPerMillion: 4, OutputPerMillion: 8})
+
+	if got, _ := table.Estimate("gpt-4o", 1_000_000, 1_000_000); got != 3 {
+		t.Errorf("expected override to apply, got %v", got)
+	}
+	if got, ok := table.Estimate("my-fine-tune", 500_000, 0); !ok || got != 2 {
+		t.Errorf("expected custom model price, got %v, %v", got, ok)
+	}
+	if _, ok := Default.Lookup("my-fine-tune"); ok {
+		t.Error("expected the Default table to be unaffected")
+	}
+}
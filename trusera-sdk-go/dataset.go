@@ -0,0 +1,22 @@
+package trusera
+
+// Dataset identifies data an agent read, such as a training set, a document
+// collection or a vector index
+type Dataset struct {
+	Name string
+	URI  string // e.g. "s3://corpus/2026-03/" or "pinecone://docs-index"
+	Hash string // Content digest such as "sha256:...", empty when unknown
+}
+
+// NewDatasetEvent returns an EventDataAccess event named after the dataset,
+// with its URI and hash in the payload
+func NewDatasetEvent(d Dataset) Event {
+	e := NewEvent(EventDataAccess, d.Name)
+	if d.URI != "" {
+		e = e.WithPayload("uri", d.URI)
+	}
+	if d.Hash != "" {
+		e = e.WithPayload("hash", d.Hash)
+	}
+	return e
+}
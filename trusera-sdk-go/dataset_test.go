@@ -0,0 +1,17 @@
+package trusera
+
+import "testing"
+
+func TestNewDatasetEvent(t *testing.T) {
+	e := NewDatasetEvent(Dataset{Name: "support-docs", URI: "s3://kb/support/", Hash: "sha256:abc"})
+	if e.Type != EventDataAccess || e.Name != "support-docs" {
+		t.Errorf("unexpected event %+v", e)
+	}
+	if e.Payload["uri"] != "s3://kb/support/" || e.Payload["hash"] != "sha256:abc" {
+		t.Errorf("unexpected payload %+v", e.Payload)
+	}
+
+	if e := NewDatasetEvent(Dataset{Name: "scratch"}); len(e.Payload) != 0 {
+		t.Errorf("expected an empty payload, got %+v", e.Payload)
+	}
+}
@@ -0,0 +1,129 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// WithDeadLetterFile appends batches that could not be delivered after all
+// retries to a JSONL file at path, one line per batch with the error and a
+// timestamp, instead of re-queuing or dropping them. ReplayDeadLetters
+// resubmits them later.
+func WithDeadLetterFile(path string) Option {
+	return func(c *Client) {
+		c.deadLetterPath = path
+	}
+}
+
+// deadLetter is one undeliverable batch in the dead-letter file
+type deadLetter struct {
+	Timestamp string          `json:"timestamp"`
+	Error     string          `json:"error"`
+	AgentID   string          `json:"agent_id"`
+	Events    json.RawMessage `json:"events"`
+}
+
+// writeDeadLetter records a failed batch
+func (c *Client) writeDeadLetter(events any, cause error) error {
+	raw, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter: %w", err)
+	}
+
+	line, err := json.Marshal(deadLetter{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Error:     cause.Error(),
+		AgentID:   c.agentID,
+		Events:    raw,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter: %w", err)
+	}
+
+	c.deadLetterMu.Lock()
+	defer c.deadLetterMu.Unlock()
+
+	f, err := os.OpenFile(c.deadLetterPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open dead-letter file: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write dead letter: %w", err)
+	}
+	return f.Close()
+}
+
+// ReplayDeadLetters resubmits the batches in the dead-letter file set by
+// WithDeadLetterFile and returns how many events were delivered. Delivered
+// batches are removed from the file; the rest stay for a later replay.
+// Replay stops at the first transient failure, since the API is likely
+// still unavailable.
+func (c *Client) ReplayDeadLetters() (int, error) {
+	if c.deadLetterPath == "" {
+		return 0, errors.New("no dead-letter file configured")
+	}
+
+	c.deadLetterMu.Lock()
+	defer c.deadLetterMu.Unlock()
+
+	data, err := os.ReadFile(c.deadLetterPath)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read dead-letter file: %w", err)
+	}
+
+	var remaining bytes.Buffer
+	var lastErr error
+	delivered := 0
+
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var dl deadLetter
+		if isTransient(lastErr) || json.Unmarshal(line, &dl) != nil {
+			remaining.Write(line)
+			remaining.WriteByte('\n')
+			continue
+		}
+
+		body, err := c.batchBody(dl.AgentID, dl.Events)
+		var events []json.RawMessage
+		json.Unmarshal(dl.Events, &events)
+
+		if err == nil {
+			err = c.deliver(context.Background(), nil, eventsPath, body)
+			c.recordDelivery(len(events), err)
+		}
+		if err != nil {
+			lastErr = err
+			remaining.Write(line)
+			remaining.WriteByte('\n')
+			continue
+		}
+		delivered += len(events)
+	}
+
+	if err := writeFileAtomic(c.deadLetterPath, remaining.Bytes(), 0600); err != nil {
+		return delivered, err
+	}
+	return delivered, lastErr
+}
+
+// writeFileAtomic replaces path with data via a temporary file and rename
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	return os.Rename(tmp, path)
+}
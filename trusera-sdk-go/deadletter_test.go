@@ -0,0 +1,110 @@
+package trusera
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDeadLetterAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead.jsonl")
+	collector := &eventCollector{}
+	collector.offline.Store(true)
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	client := NewClient("test-key",
+		WithBaseURL(server.URL),
+		WithAgentID("agent-1"),
+		WithFlushRetry(1, 0),
+		WithDeadLetterFile(path),
+	)
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	if err := client.Flush(); err == nil {
+		t.Fatal("expected flush to fail")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected dead-letter file: %v", err)
+	}
+	var dl deadLetter
+	if err := json.Unmarshal(data, &dl); err != nil {
+		t.Fatalf("invalid dead letter %q: %v", data, err)
+	}
+	if dl.AgentID != "agent-1" || !strings.Contains(dl.Error, "503") || dl.Timestamp == "" {
+		t.Errorf("unexpected dead letter: %+v", dl)
+	}
+
+	client.mu.Lock()
+	if len(client.events) != 0 {
+		t.Errorf("expected dead-lettered events not to be re-queued, got %d", len(client.events))
+	}
+	client.mu.Unlock()
+
+	// Still offline: the batch stays in the file
+	if n, err := client.ReplayDeadLetters(); err == nil || n != 0 {
+		t.Fatalf("expected replay to fail while offline, got %d %v", n, err)
+	}
+
+	collector.offline.Store(false)
+	n, err := client.ReplayDeadLetters()
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 replayed event, got %d %v", n, err)
+	}
+	if got := collector.received(); len(got) != 1 || got[0] != "search" {
+		t.Errorf("expected replayed event, got %v", got)
+	}
+	if data, _ := os.ReadFile(path); len(data) != 0 {
+		t.Errorf("expected empty dead-letter file after replay, got %q", data)
+	}
+}
+
+func TestDeadLetterRejectedDiskSegment(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dead.jsonl")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key",
+		WithBaseURL(server.URL),
+		WithDiskQueue(filepath.Join(dir, "queue"), 0),
+		WithDeadLetterFile(path),
+	)
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	client.Track(NewEvent(EventToolCall, "fetch"))
+
+	// Rejected events are kept in the dead-letter file, not reported as dropped
+	if err := client.Close(); err == nil || strings.Contains(err.Error(), "dropped") {
+		t.Fatalf("expected a rejection error without drops, got %v", err)
+	}
+	if segments, _ := listSegments(filepath.Join(dir, "queue")); len(segments) != 0 {
+		t.Errorf("expected rejected segment to leave the queue, got %v", segments)
+	}
+
+	data, _ := os.ReadFile(path)
+	var dl deadLetter
+	json.Unmarshal(data, &dl)
+	var events []Event
+	if err := json.Unmarshal(dl.Events, &events); err != nil || len(events) != 2 {
+		t.Errorf("expected both events in the dead letter, got %s", dl.Events)
+	}
+}
+
+func TestReplayDeadLettersWithoutFile(t *testing.T) {
+	client := NewClient("test-key", WithFlushRetry(0, 0))
+	defer client.Close()
+
+	if _, err := client.ReplayDeadLetters(); err == nil {
+		t.Error("expected error without a dead-letter file")
+	}
+}
@@ -0,0 +1,124 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	decisionsPath = "/v1/decisions"
+
+	// maxPendingDecisions bounds decisions waiting for the next flush
+	maxPendingDecisions = 10000
+)
+
+// DecisionReport is a policy decision sent to the Trusera API for org-wide
+// violation dashboards. The query string is left out, since it may hold secrets.
+type DecisionReport struct {
+	Timestamp         time.Time `json:"timestamp"`
+	Method            string    `json:"method"`
+	Hostname          string    `json:"hostname"`
+	Path              string    `json:"path"`
+	Decision          string    `json:"decision"`
+	EnforcementAction string    `json:"enforcement_action"`
+	Reasons           []string  `json:"reasons,omitempty"`
+	Matched           []string  `json:"matched,omitempty"`
+	TraceID           string    `json:"trace_id,omitempty"`
+	SessionID         string    `json:"session_id,omitempty"`
+	RunID             string    `json:"run_id,omitempty"`
+}
+
+// NewDecisionReport converts an interceptor decision into a report
+func NewDecisionReport(ev DecisionEvent) DecisionReport {
+	return DecisionReport{
+		Timestamp:         ev.Timestamp.UTC(),
+		Method:            ev.Method,
+		Hostname:          ev.Hostname,
+		Path:              ev.Path,
+		Decision:          ev.Decision,
+		EnforcementAction: ev.EnforcementAction,
+		Reasons:           ev.Reasons,
+		Matched:           ev.Matched,
+		TraceID:           ev.TraceID,
+		SessionID:         ev.SessionID,
+		RunID:             ev.RunID,
+	}
+}
+
+// ReportDecisions sends decisions to the Trusera API, retrying transient
+// failures like Flush
+func (c *Client) ReportDecisions(ctx context.Context, reports []DecisionReport) error {
+	if len(reports) == 0 {
+		return nil
+	}
+	return c.sendDecisions(ctx, nil, reports)
+}
+
+// sendDecisions posts one batch of decisions
+func (c *Client) sendDecisions(ctx context.Context, stop <-chan struct{}, reports []DecisionReport) error {
+	c.mu.Lock()
+	agentID := c.agentID
+	c.mu.Unlock()
+
+	body, err := json.Marshal(map[string]interface{}{
+		"agent_id":  agentID,
+		"decisions": reports,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal decisions: %w", err)
+	}
+	if err := c.deliver(ctx, stop, decisionsPath, body); err != nil {
+		return fmt.Errorf("failed to report %d decisions: %w", len(reports), err)
+	}
+	return nil
+}
+
+// WithDecisionReporting batches blocked and warned decisions into client,
+// which sends them to the Trusera API with each flush. Denials let through in
+// log mode and by decision timeouts count as warned. Transient failures keep
+// the batch for the next flush.
+func WithDecisionReporting(client *Client) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		report := func(ev DecisionEvent) {
+			client.queueDecision(NewDecisionReport(ev))
+		}
+		si.hooks.onBlock = append(si.hooks.onBlock, report)
+		si.hooks.onWarn = append(si.hooks.onWarn, report)
+	}
+}
+
+// queueDecision holds a report for the next flush, dropping the oldest when full
+func (c *Client) queueDecision(r DecisionReport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.decisions = append(c.decisions, r)
+	if over := len(c.decisions) - maxPendingDecisions; over > 0 {
+		c.decisions = append(c.decisions[:0], c.decisions[over:]...)
+	}
+}
+
+// flushDecisions sends decisions queued by WithDecisionReporting
+func (c *Client) flushDecisions(ctx context.Context, stop <-chan struct{}) error {
+	c.mu.Lock()
+	reports := c.decisions
+	c.decisions = nil
+	c.mu.Unlock()
+
+	if len(reports) == 0 {
+		return nil
+	}
+
+	err := c.sendDecisions(ctx, stop, reports)
+	if isTransient(err) {
+		c.mu.Lock()
+		c.decisions = append(reports, c.decisions...)
+		if over := len(c.decisions) - maxPendingDecisions; over > 0 {
+			c.decisions = c.decisions[over:]
+		}
+		c.mu.Unlock()
+	}
+	return err
+}
@@ -0,0 +1,133 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// decisionCollector is a fake decisions API
+type decisionCollector struct {
+	mu      sync.Mutex
+	reports []DecisionReport
+	offline atomic.Bool
+}
+
+func (dc *decisionCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/decisions" {
+		return
+	}
+	if dc.offline.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var payload struct {
+		Decisions []DecisionReport `json:"decisions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	dc.mu.Lock()
+	dc.reports = append(dc.reports, payload.Decisions...)
+	dc.mu.Unlock()
+}
+
+func (dc *decisionCollector) received() []DecisionReport {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	return append([]DecisionReport(nil), dc.reports...)
+}
+
+func TestReportDecisions(t *testing.T) {
+	collector := &decisionCollector{}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	report := NewDecisionReport(DecisionEvent{
+		Timestamp:         time.Now(),
+		Method:            "POST",
+		URL:               "https://api.example.com/upload?token=secret",
+		Hostname:          "api.example.com",
+		Path:              "/upload",
+		Decision:          "Deny",
+		EnforcementAction: "blocked",
+		Matched:           []string{"policy0"},
+	})
+	if err := client.ReportDecisions(context.Background(), []DecisionReport{report}); err != nil {
+		t.Fatalf("report: %v", err)
+	}
+
+	got := collector.received()
+	if len(got) != 1 {
+		t.Fatalf("expected 1 decision, got %d", len(got))
+	}
+	if got[0].Hostname != "api.example.com" || got[0].EnforcementAction != "blocked" || got[0].Matched[0] != "policy0" {
+		t.Errorf("unexpected report %+v", got[0])
+	}
+}
+
+func TestDecisionReporting(t *testing.T) {
+	collector := &decisionCollector{}
+	collector.offline.Store(true)
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithFlushRetry(0, 0))
+	defer client.Close()
+
+	policyPath := writeTestPolicy(t, t.TempDir(), `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "blocked.example.com";
+};
+`)
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithDecisionReporting(client),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer backend.Close()
+
+	httpClient := si.WrapClient(&http.Client{})
+	if _, err := httpClient.Get("https://blocked.example.com/data"); err == nil {
+		t.Fatal("expected blocked request")
+	}
+	resp, err := httpClient.Get(backend.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	si.Close()
+
+	// A transient failure keeps the batch for the next flush
+	if err := client.Flush(); err == nil {
+		t.Fatal("expected flush to fail while the API is down")
+	}
+	collector.offline.Store(false)
+	if err := client.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	got := collector.received()
+	if len(got) != 1 {
+		t.Fatalf("expected only the blocked decision, got %+v", got)
+	}
+	if got[0].Hostname != "blocked.example.com" || got[0].Decision != "Deny" {
+		t.Errorf("unexpected report %+v", got[0])
+	}
+}
@@ -0,0 +1,177 @@
+package trusera
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialer applies Cedar policies to raw network connections such as database
+// drivers, SMTP clients and custom protocols
+type Dialer struct {
+	dialer      *net.Dialer
+	interceptor *StandaloneInterceptor
+}
+
+// WrapDialer returns a Dialer that evaluates hostname, port and IP range
+// policies before connecting and logs a connection event for each dial.
+// Hostnames are resolved first so ip.isInRange rules see the actual
+// destination, and addresses denied in block mode are never dialed.
+func (si *StandaloneInterceptor) WrapDialer(d *net.Dialer) *Dialer {
+	if d == nil {
+		d = &net.Dialer{}
+	}
+	return &Dialer{dialer: d, interceptor: si}
+}
+
+// Dial connects to address on the named network
+func (d *Dialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+// DialContext connects to address on the named network using ctx
+func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+	default:
+		// Unix sockets and other local transports are not egress
+		return d.dialer.DialContext(ctx, network, address)
+	}
+
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, _ := strconv.Atoi(portStr)
+
+	si := d.interceptor
+	t := &standaloneTransport{interceptor: si}
+	target := network + "://" + address
+	if t.shouldExclude(target) {
+		return d.dialer.DialContext(ctx, network, address)
+	}
+
+	startTime := time.Now()
+
+	ips := []string{host}
+	if literalIP(host) == "" {
+		resolver := d.dialer.Resolver
+		if resolver == nil {
+			resolver = net.DefaultResolver
+		}
+		addrs, err := resolver.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		if len(addrs) == 0 {
+			return nil, fmt.Errorf("no addresses for host %s", host)
+		}
+		ips = addrs
+	}
+
+	// Hostname and port rules are evaluated once per dial; only IP rules
+	// depend on the address
+	hostCtx := RequestContext{
+		URL:      target,
+		Method:   strings.ToUpper(network),
+		Hostname: host,
+		Port:     port,
+	}
+	hostRules, ipRules := splitIPRules(si.rulesFor(host))
+	hostDecision := EvaluatePolicy(hostCtx, hostRules)
+	open, circuitOpen := si.openCircuit(startTime)
+
+	// Try resolved addresses in order, skipping those the policy blocks. The
+	// event records the address that was dialed, or the first one blocked.
+	var blocked, failed *eventLog
+	var blockedDecision, failedDecision PolicyDecision
+	var blockReasons []string
+	var lastErr error
+	traceID, spanID := si.traceFromContext(ctx)
+	runID, sessionID := si.groupIDs(ctx)
+
+	for _, ip := range ips {
+		reqCtx := hostCtx
+		reqCtx.IP = literalIP(ip)
+
+		decision := hostDecision
+		if len(ipRules) > 0 {
+			if ipDecision := EvaluatePolicy(reqCtx, ipRules); ipDecision.Decision == "Deny" {
+				if decision.Decision == "Deny" {
+					ipDecision.Reasons = appendUnique(append([]string(nil), decision.Reasons...), ipDecision.Reasons...)
+					ipDecision.Matched = appendUnique(append([]string(nil), decision.Matched...), ipDecision.Matched...)
+				}
+				decision = ipDecision
+			}
+		}
+		decision, exception := si.applyException(reqCtx, decision)
+		if circuitOpen {
+			decision, exception = open, nil
+		}
+		enforcementAction, blockRequest := si.enforce(decision)
+
+		candidate := &eventLog{
+			Method:            reqCtx.Method,
+			URL:               target,
+			Hostname:          host,
+			IP:                reqCtx.IP,
+			TraceID:           traceID,
+			SpanID:            spanID,
+			RunID:             runID,
+			SessionID:         sessionID,
+			PolicyDecision:    decision.Decision,
+			EnforcementAction: enforcementAction,
+			Exception:         exception,
+		}
+		if len(decision.Reasons) > 0 {
+			candidate.Reasons = strings.Join(decision.Reasons, "; ")
+		}
+
+		if blockRequest {
+			if blocked == nil {
+				blocked, blockedDecision = candidate, decision
+			}
+			blockReasons = appendUnique(blockReasons, decision.Reasons...)
+			continue
+		}
+
+		conn, err := d.dialer.DialContext(ctx, network, net.JoinHostPort(ip, portStr))
+		if err != nil {
+			failed, failedDecision, lastErr = candidate, decision, err
+			continue
+		}
+
+		si.countDenial(decision, startTime)
+		t.record(*candidate, decision, startTime, time.Since(startTime))
+		return conn, nil
+	}
+
+	// Report a dial failure in preference to blocked alternatives
+	if lastErr != nil {
+		si.countDenial(failedDecision, startTime)
+		t.record(*failed, failedDecision, startTime, time.Since(startTime))
+		return nil, lastErr
+	}
+
+	if blocked != nil {
+		si.countDenial(blockedDecision, startTime)
+		t.record(*blocked, blockedDecision, startTime, time.Since(startTime))
+	}
+	return nil, fmt.Errorf("connection blocked by Cedar policy: %s", strings.Join(blockReasons, "; "))
+}
+
+// splitIPRules separates rules on resource.ip, which are checked for each
+// resolved address, from the rest
+func splitIPRules(rules []PolicyRule) (hostRules, ipRules []PolicyRule) {
+	for _, rule := range rules {
+		if rule.Field == "ip" {
+			ipRules = append(ipRules, rule)
+		} else {
+			hostRules = append(hostRules, rule)
+		}
+	}
+	return hostRules, ipRules
+}
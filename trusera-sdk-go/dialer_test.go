@@ -0,0 +1,144 @@
+package trusera
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrapDialer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "events.jsonl")
+	policyPath := writeTestPolicy(t, tmpDir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.port == 25;
+    resource.ip.isInRange(ip("10.0.0.0/8"));
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithLogFile(logPath),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	dialer := si.WrapDialer(nil)
+
+	conn, err := dialer.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("allowed dial failed: %v", err)
+	}
+	conn.Close()
+
+	for _, addr := range []string{"127.0.0.1:25", "10.1.2.3:5432"} {
+		_, err := dialer.Dial("tcp", addr)
+		if err == nil || !strings.Contains(err.Error(), "connection blocked by Cedar policy") {
+			t.Errorf("expected dial to %s to be blocked, got %v", addr, err)
+		}
+	}
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 connection events, got %d", len(entries))
+	}
+	if entries[0].Method != "TCP" || entries[0].EnforcementAction != "allowed" || entries[0].IP != "127.0.0.1" {
+		t.Errorf("unexpected allowed entry: %+v", entries[0])
+	}
+	if entries[1].EnforcementAction != "blocked" || !strings.Contains(entries[1].Reasons, "resource.port") {
+		t.Errorf("unexpected port entry: %+v", entries[1])
+	}
+	if entries[2].EnforcementAction != "blocked" || entries[2].IP != "10.1.2.3" {
+		t.Errorf("unexpected range entry: %+v", entries[2])
+	}
+}
+
+func TestWrapDialerEvaluatesHostOncePerDial(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "events.jsonl")
+	policyPath := writeTestPolicy(t, tmpDir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "db.example.com";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithLogFile(logPath),
+		WithCircuitBreaker(2, time.Minute, time.Hour),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	server := startFakeDNS(t, "10.0.0.1", "10.0.0.2")
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "udp", server)
+		},
+	}
+
+	_, err = si.WrapDialer(&net.Dialer{Resolver: resolver}).Dial("tcp", "db.example.com:5432")
+	if err == nil {
+		t.Fatal("expected dial to be blocked")
+	}
+	if n := strings.Count(err.Error(), "resource.hostname"); n != 1 {
+		t.Errorf("expected the forbid reason once, got %d in %q", n, err)
+	}
+	if si.CircuitOpen() {
+		t.Error("expected one denial toward the circuit breaker for one dial")
+	}
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 1 || entries[0].EnforcementAction != "blocked" || entries[0].IP != "10.0.0.1" {
+		t.Errorf("expected one blocked event for the first address, got %+v", entries)
+	}
+}
+
+func TestWrapDialerPassesThroughUnixSockets(t *testing.T) {
+	si, err := NewStandaloneInterceptor(WithEnforcement(EnforcementBlock))
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	sock := filepath.Join(t.TempDir(), "s.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer ln.Close()
+
+	conn, err := si.WrapDialer(&net.Dialer{}).Dial("unix", sock)
+	if err != nil {
+		t.Fatalf("unix dial failed: %v", err)
+	}
+	conn.Close()
+}
@@ -0,0 +1,249 @@
+package trusera
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dnsMethod is the method recorded for DNS lookups in events and policies
+const dnsMethod = "DNS"
+
+// dnsLookupWindow is how long a lookup decision covers further queries for
+// the same name, so the A and AAAA queries and search-domain expansions of
+// one lookup are evaluated and recorded once
+const dnsLookupWindow = time.Second
+
+// Resolver returns a net.Resolver that evaluates hostname policies when names
+// are looked up. In block mode, lookups of forbidden names fail with NXDOMAIN,
+// which also stops libraries that bypass the wrapped http.Client. Use it as
+// net.DefaultResolver or as the Resolver of a net.Dialer.
+func (si *StandaloneInterceptor) Resolver() *net.Resolver {
+	var d net.Dialer
+	return si.newResolver(d.DialContext)
+}
+
+// newResolver returns a Go resolver whose connections to DNS servers are
+// opened with dial and filtered by policy
+func (si *StandaloneInterceptor) newResolver(dial func(ctx context.Context, network, address string) (net.Conn, error)) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			conn, err := dial(ctx, network, address)
+			if err != nil {
+				return nil, err
+			}
+			_, packet := conn.(net.PacketConn)
+			return &dnsConn{Conn: conn, si: si, packet: packet}, nil
+		},
+	}
+}
+
+// dnsConn inspects the queries the Go resolver writes to a DNS server and
+// answers denied ones locally. The wrapper is not a net.PacketConn, so the
+// resolver always frames messages with a two-byte length prefix; the prefix is
+// translated away for UDP servers.
+type dnsConn struct {
+	net.Conn
+	si     *StandaloneInterceptor
+	packet bool
+
+	mu      sync.Mutex
+	pending []byte
+}
+
+func (c *dnsConn) Write(b []byte) (int, error) {
+	if len(b) < 2 {
+		return c.Conn.Write(b)
+	}
+	msg := b[2:]
+
+	name, questionEnd, err := parseDNSQuestion(msg)
+	if err == nil && !c.si.allowLookup(name) {
+		reply := nxdomainReply(msg, questionEnd)
+
+		c.mu.Lock()
+		c.pending = binary.BigEndian.AppendUint16(c.pending, uint16(len(reply)))
+		c.pending = append(c.pending, reply...)
+		c.mu.Unlock()
+		return len(b), nil
+	}
+
+	// Anything we cannot parse is left for the server to reject
+	if c.packet {
+		if _, err := c.Conn.Write(msg); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	return c.Conn.Write(b)
+}
+
+func (c *dnsConn) Read(b []byte) (int, error) {
+	c.mu.Lock()
+	if len(c.pending) == 0 && !c.packet {
+		c.mu.Unlock()
+		return c.Conn.Read(b)
+	}
+	defer c.mu.Unlock()
+
+	// Frame the next datagram like a TCP message
+	if len(c.pending) == 0 {
+		buf := make([]byte, 65535)
+		n, err := c.Conn.Read(buf)
+		if err != nil {
+			return 0, err
+		}
+		c.pending = binary.BigEndian.AppendUint16(c.pending, uint16(n))
+		c.pending = append(c.pending, buf[:n]...)
+	}
+
+	n := copy(b, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+// allowLookup evaluates the policy for a DNS lookup and logs the decision.
+// Queries covered by a recent decision reuse it without logging again.
+func (si *StandaloneInterceptor) allowLookup(name string) bool {
+	startTime := time.Now()
+	t := &standaloneTransport{interceptor: si}
+
+	target := "dns://" + name
+	if t.shouldExclude(target) {
+		return true
+	}
+
+	si.dnsLookups.mu.Lock()
+	if allowed, ok := si.dnsLookups.covered(name, startTime); ok {
+		si.dnsLookups.mu.Unlock()
+		return allowed
+	}
+
+	ctx := RequestContext{
+		URL:      target,
+		Method:   dnsMethod,
+		Hostname: name,
+	}
+
+	decision, exception := si.evaluate(ctx, nil, startTime)
+	enforcementAction, blockRequest := si.enforce(decision)
+	si.dnsLookups.remember(name, !blockRequest, startTime)
+	si.dnsLookups.mu.Unlock()
+
+	entry := eventLog{
+		Method:            dnsMethod,
+		URL:               target,
+		Hostname:          name,
+		PolicyDecision:    decision.Decision,
+		EnforcementAction: enforcementAction,
+		Exception:         exception,
+	}
+	if len(decision.Reasons) > 0 {
+		entry.Reasons = strings.Join(decision.Reasons, "; ")
+	}
+
+	t.record(entry, decision, startTime, time.Since(startTime))
+
+	return !blockRequest
+}
+
+// dnsLookups remembers recent lookup decisions by name. Callers hold mu
+// across covered, evaluation and remember, so concurrent queries of one
+// lookup wait for the first decision.
+type dnsLookups struct {
+	mu     sync.Mutex
+	recent map[string]dnsLookup
+}
+
+type dnsLookup struct {
+	allowed bool
+	expires time.Time
+}
+
+// covered returns the recent decision for name. A denied name also covers
+// its search-domain expansions, which the resolver tries after the NXDOMAIN.
+func (l *dnsLookups) covered(name string, now time.Time) (allowed, ok bool) {
+	if lookup, found := l.recent[name]; found && now.Before(lookup.expires) {
+		return lookup.allowed, true
+	}
+	for denied, lookup := range l.recent {
+		if !lookup.allowed && now.Before(lookup.expires) && strings.HasPrefix(name, denied+".") {
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// remember records the decision for name and forgets expired ones
+func (l *dnsLookups) remember(name string, allowed bool, now time.Time) {
+	if l.recent == nil {
+		l.recent = make(map[string]dnsLookup)
+	}
+	for n, lookup := range l.recent {
+		if !now.Before(lookup.expires) {
+			delete(l.recent, n)
+		}
+	}
+	l.recent[name] = dnsLookup{allowed: allowed, expires: now.Add(dnsLookupWindow)}
+}
+
+var errMalformedDNS = errors.New("malformed DNS message")
+
+// parseDNSQuestion returns the lowercased name of the first question in a DNS
+// query and the offset just past that question
+func parseDNSQuestion(msg []byte) (string, int, error) {
+	const headerLen = 12
+	if len(msg) < headerLen || binary.BigEndian.Uint16(msg[4:6]) == 0 {
+		return "", 0, errMalformedDNS
+	}
+
+	var labels []string
+	off := headerLen
+	for {
+		if off >= len(msg) {
+			return "", 0, errMalformedDNS
+		}
+		n := int(msg[off])
+		off++
+		if n == 0 {
+			break
+		}
+		// Queries never use compression pointers
+		if n > 63 || off+n > len(msg) {
+			return "", 0, errMalformedDNS
+		}
+		labels = append(labels, string(msg[off:off+n]))
+		off += n
+	}
+
+	// QTYPE and QCLASS follow the name
+	off += 4
+	if off > len(msg) || len(labels) == 0 {
+		return "", 0, errMalformedDNS
+	}
+
+	return strings.ToLower(strings.Join(labels, ".")), off, nil
+}
+
+// nxdomainReply builds an NXDOMAIN response echoing the query's header and question
+func nxdomainReply(query []byte, questionEnd int) []byte {
+	reply := make([]byte, questionEnd)
+	copy(reply, query[:questionEnd])
+
+	// QR=1, keep opcode and RD, RA=1, RCODE=3 (NXDOMAIN)
+	reply[2] = 0x80 | (query[2] & 0x79)
+	reply[3] = 0x80 | 0x03
+
+	// One question, no answer, authority or additional records
+	binary.BigEndian.PutUint16(reply[4:6], 1)
+	binary.BigEndian.PutUint16(reply[6:8], 0)
+	binary.BigEndian.PutUint16(reply[8:10], 0)
+	binary.BigEndian.PutUint16(reply[10:12], 0)
+
+	return reply
+}
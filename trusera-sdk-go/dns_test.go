@@ -0,0 +1,214 @@
+package trusera
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// startFakeDNS answers every A query with addrs, 10.0.0.1 by default, and
+// everything else with no records
+func startFakeDNS(t *testing.T, addrs ...string) string {
+	t.Helper()
+
+	if len(addrs) == 0 {
+		addrs = []string{"10.0.0.1"}
+	}
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			query := buf[:n]
+			_, end, err := parseDNSQuestion(query)
+			if err != nil {
+				continue
+			}
+
+			reply := append([]byte(nil), query[:end]...)
+			reply[2] = 0x80 | (query[2] & 0x01)
+			reply[3] = 0x80
+			binary.BigEndian.PutUint16(reply[4:6], 1)
+			binary.BigEndian.PutUint16(reply[8:10], 0)
+			binary.BigEndian.PutUint16(reply[10:12], 0)
+
+			if qtype := binary.BigEndian.Uint16(query[end-4 : end-2]); qtype == 1 {
+				binary.BigEndian.PutUint16(reply[6:8], uint16(len(addrs)))
+				for _, addr := range addrs {
+					// Name pointer to the question, type A, class IN, TTL 60, 4 bytes
+					reply = append(reply, 0xc0, 0x0c, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4)
+					reply = append(reply, net.ParseIP(addr).To4()...)
+				}
+			} else {
+				binary.BigEndian.PutUint16(reply[6:8], 0)
+			}
+
+			pc.WriteTo(reply, addr)
+		}
+	}()
+
+	return pc.LocalAddr().String()
+}
+
+func TestResolverBlocksForbiddenNames(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "events.jsonl")
+	policyPath := writeTestPolicy(t, tmpDir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "exfil.example.com";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithLogFile(logPath),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	server := startFakeDNS(t)
+	resolver := si.newResolver(func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "udp", server)
+	})
+
+	addrs, err := resolver.LookupHost(context.Background(), "api.example.com")
+	if err != nil {
+		t.Fatalf("allowed lookup failed: %v", err)
+	}
+	if len(addrs) != 1 || addrs[0] != "10.0.0.1" {
+		t.Errorf("unexpected addresses: %v", addrs)
+	}
+
+	_, err = resolver.LookupHost(context.Background(), "Exfil.Example.com")
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+		t.Fatalf("expected NXDOMAIN for forbidden name, got %v", err)
+	}
+
+	var blocked int
+	for _, entry := range readLogEntries(t, logPath) {
+		if entry.Method != dnsMethod {
+			t.Errorf("unexpected method %q", entry.Method)
+		}
+		if entry.EnforcementAction == "blocked" {
+			blocked++
+			if entry.Hostname != "exfil.example.com" {
+				t.Errorf("unexpected blocked hostname %q", entry.Hostname)
+			}
+		}
+	}
+	if blocked == 0 {
+		t.Error("expected blocked lookups to be logged")
+	}
+}
+
+func TestResolverRecordsOneEventPerLookup(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "events.jsonl")
+	policyPath := writeTestPolicy(t, tmpDir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "exfil.example.com";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithLogFile(logPath),
+		WithCircuitBreaker(2, time.Minute, time.Hour),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	server := startFakeDNS(t)
+	resolver := si.newResolver(func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "udp", server)
+	})
+
+	if _, err := resolver.LookupHost(context.Background(), "api.example.com"); err != nil {
+		t.Fatalf("allowed lookup failed: %v", err)
+	}
+	if _, err := resolver.LookupHost(context.Background(), "exfil.example.com"); err == nil {
+		t.Fatal("expected forbidden lookup to fail")
+	}
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 2 {
+		t.Fatalf("expected one event per lookup, got %+v", entries)
+	}
+	if entries[0].Hostname != "api.example.com" || entries[1].Hostname != "exfil.example.com" || entries[1].EnforcementAction != "blocked" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+	if si.CircuitOpen() {
+		t.Error("expected one denial toward the circuit breaker for one lookup")
+	}
+}
+
+func TestDNSLookupsCoverSearchExpansions(t *testing.T) {
+	var l dnsLookups
+	now := time.Now()
+	l.remember("exfil.example.com", false, now)
+	l.remember("api.example.com", true, now)
+
+	if allowed, ok := l.covered("exfil.example.com.corp.internal", now); !ok || allowed {
+		t.Error("expected denial to cover search-domain expansions")
+	}
+	if _, ok := l.covered("api.example.com.corp.internal", now); ok {
+		t.Error("expected allowed names not to cover other names")
+	}
+	if _, ok := l.covered("exfil.example.com", now.Add(dnsLookupWindow)); ok {
+		t.Error("expected decisions to expire after the window")
+	}
+}
+
+func TestParseDNSQuestion(t *testing.T) {
+	query := []byte{
+		0x12, 0x34, 0x01, 0x00, 0, 1, 0, 0, 0, 0, 0, 0,
+		3, 'A', 'p', 'i', 7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0,
+		0, 1, 0, 1,
+	}
+
+	name, end, err := parseDNSQuestion(query)
+	if err != nil {
+		t.Fatalf("failed to parse query: %v", err)
+	}
+	if name != "api.example.com" || end != len(query) {
+		t.Errorf("got name %q end %d", name, end)
+	}
+
+	reply := nxdomainReply(query, end)
+	if reply[0] != 0x12 || reply[1] != 0x34 {
+		t.Error("expected reply to echo the query ID")
+	}
+	if reply[2]&0x80 == 0 || reply[3]&0x0f != 3 {
+		t.Errorf("expected NXDOMAIN response flags, got %#x %#x", reply[2], reply[3])
+	}
+
+	for _, bad := range [][]byte{nil, query[:10], query[:20], append(query[:12:12], 64)} {
+		if _, _, err := parseDNSQuestion(bad); err == nil {
+			t.Errorf("expected error for malformed query %v", bad)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package trusera
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// applyEnv sets interceptor defaults from TRUSERA_POLICY_FILE,
+// TRUSERA_ENFORCEMENT, TRUSERA_LOG_FILE and TRUSERA_EXCLUDE. It runs before
+// the options, so explicit values take precedence.
+func (si *StandaloneInterceptor) applyEnv() error {
+	if v := os.Getenv("TRUSERA_POLICY_FILE"); v != "" {
+		si.policyFile = v
+	}
+	if v := os.Getenv("TRUSERA_ENFORCEMENT"); v != "" {
+		switch mode := EnforcementAction(strings.ToLower(strings.TrimSpace(v))); mode {
+		case EnforcementLog, EnforcementWarn, EnforcementBlock:
+			si.enforcement = mode
+		default:
+			return fmt.Errorf("TRUSERA_ENFORCEMENT: unknown enforcement mode %q", v)
+		}
+	}
+	if v := os.Getenv("TRUSERA_LOG_FILE"); v != "" {
+		si.logFile = v
+	}
+	if v := os.Getenv("TRUSERA_EXCLUDE"); v != "" {
+		si.excludePatterns = splitEnvList(v)
+	}
+	return nil
+}
+
+// splitEnvList splits a comma-separated variable, dropping blank entries
+func splitEnvList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
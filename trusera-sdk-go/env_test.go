@@ -0,0 +1,68 @@
+package trusera
+
+import (
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStandaloneInterceptorEnv(t *testing.T) {
+	dir := t.TempDir()
+	policyPath := writeTestPolicy(t, dir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "blocked.example.com";
+};
+`)
+	logPath := filepath.Join(dir, "events.jsonl")
+	t.Setenv("TRUSERA_POLICY_FILE", policyPath)
+	t.Setenv("TRUSERA_ENFORCEMENT", " Block ")
+	t.Setenv("TRUSERA_LOG_FILE", logPath)
+	t.Setenv("TRUSERA_EXCLUDE", "telemetry.example.com, ,localhost")
+
+	si, err := NewStandaloneInterceptor()
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	if si.enforcement != EnforcementBlock || len(si.rules) != 1 {
+		t.Errorf("expected block mode with the policy from the environment, got %s with %d rules", si.enforcement, len(si.rules))
+	}
+	if want := []string{"telemetry.example.com", "localhost"}; !reflect.DeepEqual(si.excludePatterns, want) {
+		t.Errorf("expected exclude patterns %v, got %v", want, si.excludePatterns)
+	}
+
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	if _, err := client.Get("https://blocked.example.com/"); err == nil {
+		t.Error("expected the request to be blocked")
+	}
+	si.Close()
+	if entries := readLogEntries(t, logPath); len(entries) != 1 {
+		t.Errorf("expected 1 entry in the log file, got %d", len(entries))
+	}
+}
+
+func TestStandaloneInterceptorEnvPrecedence(t *testing.T) {
+	t.Setenv("TRUSERA_ENFORCEMENT", "block")
+	t.Setenv("TRUSERA_EXCLUDE", "localhost")
+
+	si, err := NewStandaloneInterceptor(WithEnforcement(EnforcementWarn), WithExcludePatterns("internal.example.com"))
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+	if si.enforcement != EnforcementWarn || len(si.excludePatterns) != 1 || si.excludePatterns[0] != "internal.example.com" {
+		t.Errorf("expected options to override the environment, got %s and %v", si.enforcement, si.excludePatterns)
+	}
+}
+
+func TestStandaloneInterceptorEnvInvalid(t *testing.T) {
+	t.Setenv("TRUSERA_ENFORCEMENT", "strict")
+	_, err := NewStandaloneInterceptor()
+	if err == nil || !strings.Contains(err.Error(), `TRUSERA_ENFORCEMENT: unknown enforcement mode "strict"`) {
+		t.Errorf("expected an error naming the variable, got %v", err)
+	}
+}
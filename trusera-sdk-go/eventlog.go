@@ -0,0 +1,64 @@
+package trusera
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maxEventLogMessage is the longest string ReportEvent accepts, in UTF-16
+// code units, which a UTF-8 string of that many bytes cannot exceed
+const maxEventLogMessage = 31839
+
+// Event Log entry types
+const (
+	eventLogError       = 1
+	eventLogWarning     = 2
+	eventLogInformation = 4
+)
+
+// eventLogEntries maps enforcement actions to Event Log entry types and
+// event IDs. Unknown actions are logged like logged denials.
+var eventLogEntries = map[string]struct {
+	kind uint16
+	id   uint32
+}{
+	"allowed":   {eventLogInformation, 1},
+	"logged":    {eventLogInformation, 2},
+	"warned":    {eventLogWarning, 3},
+	"timed_out": {eventLogWarning, 4},
+	"blocked":   {eventLogError, 5},
+}
+
+// NewEventLogSink writes events to the Windows Event Log under source, so
+// host security tooling collecting the Event Log sees them. Blocked requests
+// are errors, warned and timed out requests warnings, and the rest
+// information, with event IDs 1 (allowed) to 5 (blocked). The message is a
+// summary line followed by the JSON event, truncated to the Event Log's
+// limit of about 32K characters. Register the source first, e.g. with
+// PowerShell's New-EventLog, or Event Viewer shows the message with a
+// missing description notice. It fails on platforms other than Windows.
+func NewEventLogSink(source string) (LogSink, error) {
+	s := &eventLogSink{source: source}
+	if err := s.open(); err != nil {
+		return nil, fmt.Errorf("failed to open event source %q: %w", source, err)
+	}
+	return s, nil
+}
+
+func (s *eventLogSink) WriteEvent(data []byte) error {
+	var e eventLog
+	if err := json.Unmarshal(data, &e); err != nil {
+		return fmt.Errorf("invalid event: %w", err)
+	}
+	entry, ok := eventLogEntries[e.EnforcementAction]
+	if !ok {
+		entry = eventLogEntries["logged"]
+	}
+	message := fmt.Sprintf("%s %s %s\r\n\r\n%s", e.Method, e.URL, e.EnforcementAction, bytes.TrimSuffix(data, []byte("\n")))
+	if len(message) > maxEventLogMessage {
+		message = strings.ToValidUTF8(message[:maxEventLogMessage], "")
+	}
+	return s.report(entry.kind, entry.id, message)
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package trusera
+
+import "errors"
+
+// eventLogSink is unavailable outside Windows
+type eventLogSink struct {
+	source string
+}
+
+func (s *eventLogSink) open() error {
+	return errors.New("the Windows Event Log is only available on Windows")
+}
+
+func (s *eventLogSink) report(kind uint16, id uint32, message string) error {
+	return errors.New("the Windows Event Log is only available on Windows")
+}
+
+func (s *eventLogSink) Close() error {
+	return nil
+}
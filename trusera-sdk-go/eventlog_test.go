@@ -0,0 +1,15 @@
+package trusera
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestEventLogSinkUnavailable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the Event Log is available")
+	}
+	if _, err := NewEventLogSink("trusera"); err == nil {
+		t.Error("expected an error outside Windows")
+	}
+}
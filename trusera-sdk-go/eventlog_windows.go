@@ -0,0 +1,62 @@
+package trusera
+
+import (
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	procRegisterEventSourceW  = advapi32.NewProc("RegisterEventSourceW")
+	procReportEventW          = advapi32.NewProc("ReportEventW")
+	procDeregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+)
+
+// eventLogSink reports events through an Event Log source handle
+type eventLogSink struct {
+	source string
+
+	mu     sync.Mutex
+	handle uintptr
+}
+
+func (s *eventLogSink) open() error {
+	source, err := syscall.UTF16PtrFromString(s.source)
+	if err != nil {
+		return err
+	}
+	handle, _, err := procRegisterEventSourceW.Call(0, uintptr(unsafe.Pointer(source)))
+	if handle == 0 {
+		return err
+	}
+	s.handle = handle
+	return nil
+}
+
+// report writes message as a single insertion string
+func (s *eventLogSink) report(kind uint16, id uint32, message string) error {
+	text, err := syscall.UTF16PtrFromString(message)
+	if err != nil {
+		return err
+	}
+	strs := []*uint16{text}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ok, _, err := procReportEventW.Call(s.handle, uintptr(kind), 0, uintptr(id), 0,
+		1, 0, uintptr(unsafe.Pointer(&strs[0])), 0)
+	if ok == 0 {
+		return err
+	}
+	return nil
+}
+
+func (s *eventLogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ok, _, err := procDeregisterEventSource.Call(s.handle); ok == 0 {
+		return err
+	}
+	return nil
+}
@@ -16,6 +16,9 @@ const (
 	EventAPICall    EventType = "api_call"
 	EventFileWrite  EventType = "file_write"
 	EventDecision   EventType = "decision"
+	EventHeartbeat  EventType = "heartbeat"
+	EventLLMCall    EventType = "llm_call"
+	EventSpan       EventType = "span"
 )
 
 // Event represents an agent action tracked by Trusera
@@ -26,6 +29,22 @@ type Event struct {
 	Payload   map[string]any `json:"payload"`
 	Metadata  map[string]any `json:"metadata,omitempty"`
 	Timestamp string         `json:"timestamp"`
+
+	// DurationMs is how long the action took, in milliseconds
+	DurationMs int64 `json:"duration_ms,omitempty"`
+	// Error describes why the action failed
+	Error string `json:"error,omitempty"`
+	// ParentID is the ID of the event or span that caused this one
+	ParentID string `json:"parent_id,omitempty"`
+
+	// IdempotencyKey lets the API discard copies of an event delivered more
+	// than once, e.g. when a flush is retried after a timeout
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+
+	// Sequence is assigned by the client when the event is tracked. It
+	// increases by one per event for the lifetime of the client, so the API
+	// can order events and detect gaps.
+	Sequence uint64 `json:"sequence,omitempty"`
 }
 
 // generateID creates a random hex ID
@@ -64,3 +83,34 @@ func (e Event) WithMetadata(key string, value any) Event {
 	e.Metadata[key] = value
 	return e
 }
+
+// WithDuration records how long the action took (builder pattern). Negative
+// durations are recorded as zero.
+func (e Event) WithDuration(d time.Duration) Event {
+	if d < 0 {
+		d = 0
+	}
+	e.DurationMs = d.Milliseconds()
+	return e
+}
+
+// WithError marks the action as failed with err (builder pattern). A nil err
+// leaves the event unchanged.
+func (e Event) WithError(err error) Event {
+	if err != nil {
+		e.Error = err.Error()
+	}
+	return e
+}
+
+// WithParent links the event to the event or span with the given ID (builder pattern)
+func (e Event) WithParent(id string) Event {
+	e.ParentID = id
+	return e
+}
+
+// WithIdempotencyKey sets the key used to deduplicate the event (builder pattern)
+func (e Event) WithIdempotencyKey(key string) Event {
+	e.IdempotencyKey = key
+	return e
+}
@@ -2,6 +2,7 @@ package trusera
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 )
@@ -118,6 +119,9 @@ func TestEventTypes(t *testing.T) {
 		EventAPICall,
 		EventFileWrite,
 		EventDecision,
+		EventHeartbeat,
+		EventLLMCall,
+		EventSpan,
 	}
 
 	for _, eventType := range types {
@@ -155,3 +159,49 @@ func TestChainedBuilderPattern(t *testing.T) {
 		t.Errorf("expected 2 metadata entries, got %d", len(event.Metadata))
 	}
 }
+
+func TestEventBuilder(t *testing.T) {
+	parent := NewEvent(EventDecision, "plan")
+	event := NewEvent(EventToolCall, "search").
+		WithDuration(1500*time.Millisecond).
+		WithMetadata("attempt", 2).
+		WithError(errors.New("rate limited")).
+		WithParent(parent.ID)
+
+	if event.DurationMs != 1500 {
+		t.Errorf("expected 1500ms, got %d", event.DurationMs)
+	}
+	if event.Error != "rate limited" {
+		t.Errorf("expected error message, got %q", event.Error)
+	}
+	if event.ParentID != parent.ID {
+		t.Errorf("expected parent %s, got %s", parent.ID, event.ParentID)
+	}
+
+	if NewEvent(EventToolCall, "x").WithDuration(-time.Second).DurationMs != 0 {
+		t.Error("expected negative duration to be recorded as zero")
+	}
+	if NewEvent(EventToolCall, "x").WithError(nil).Error != "" {
+		t.Error("expected nil error to leave the event unchanged")
+	}
+}
+
+func TestEventJSONIsStable(t *testing.T) {
+	event := Event{
+		ID:        "e1",
+		Type:      EventToolCall,
+		Name:      "search",
+		Payload:   map[string]any{"b": 1, "a": 2},
+		Timestamp: "2026-01-02T03:04:05Z",
+	}
+	event = event.WithDuration(time.Second).WithParent("p1")
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":"e1","type":"tool_call","name":"search","payload":{"a":2,"b":1},"timestamp":"2026-01-02T03:04:05Z","duration_ms":1000,"parent_id":"p1"}`
+	if string(data) != want {
+		t.Errorf("got  %s\nwant %s", data, want)
+	}
+}
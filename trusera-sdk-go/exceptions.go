@@ -0,0 +1,210 @@
+package trusera
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TemporaryException is a time-boxed permit that overrides forbid decisions
+// for matching requests until it expires
+type TemporaryException struct {
+	ID        string
+	Rule      string
+	CreatedBy string
+	Reason    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+
+	rules []PolicyRule
+	timer *time.Timer
+}
+
+// ExceptionOption configures a temporary exception
+type ExceptionOption func(*TemporaryException)
+
+// WithExceptionCreatedBy records who granted the exception
+func WithExceptionCreatedBy(who string) ExceptionOption {
+	return func(ex *TemporaryException) {
+		ex.CreatedBy = who
+	}
+}
+
+// WithExceptionReason records why the exception was granted
+func WithExceptionReason(why string) ExceptionOption {
+	return func(ex *TemporaryException) {
+		ex.Reason = why
+	}
+}
+
+// exceptionLog is the exception metadata attached to JSONL entries it affected
+type exceptionLog struct {
+	ID        string `json:"id"`
+	CreatedBy string `json:"created_by,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// AddTemporaryException grants a permit that overrides forbid rules for ttl.
+// rule is either a full Cedar permit statement or a single condition such as
+// `resource.hostname == "api.example.com"`.
+func (si *StandaloneInterceptor) AddTemporaryException(rule string, ttl time.Duration, opts ...ExceptionOption) (TemporaryException, error) {
+	if ttl <= 0 {
+		return TemporaryException{}, errors.New("exception ttl must be positive")
+	}
+
+	policyText := strings.TrimSpace(rule)
+	if !strings.HasPrefix(policyText, string(ActionPermit)) && !strings.HasPrefix(policyText, string(ActionForbid)) {
+		policyText = fmt.Sprintf("permit ( principal, action == Action::\"deploy\", resource )\nwhen {\n    %s;\n};", strings.TrimSuffix(policyText, ";"))
+	}
+
+	rules, err := ParseCedarPolicy(policyText)
+	if err != nil {
+		return TemporaryException{}, fmt.Errorf("failed to parse exception rule: %w", err)
+	}
+	if len(rules) == 0 {
+		return TemporaryException{}, errors.New("exception rule has no conditions")
+	}
+	for _, r := range rules {
+		if r.Action != ActionPermit {
+			return TemporaryException{}, errors.New("exception rule must be a permit")
+		}
+	}
+
+	now := time.Now()
+	ex := &TemporaryException{
+		ID:        generateID(),
+		Rule:      policyText,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+		rules:     rules,
+	}
+	for _, opt := range opts {
+		opt(ex)
+	}
+
+	si.exceptionsMu.Lock()
+	if si.exceptions == nil {
+		si.exceptions = make(map[string]*TemporaryException)
+	}
+	si.exceptions[ex.ID] = ex
+	ex.timer = time.AfterFunc(ttl, func() { si.expireException(ex.ID) })
+	si.exceptionsMu.Unlock()
+
+	si.logger.Info("temporary policy exception added",
+		"id", ex.ID,
+		"rule", strings.Join(strings.Fields(ex.Rule), " "),
+		"created_by", ex.CreatedBy,
+		"reason", ex.Reason,
+		"expires_at", ex.ExpiresAt.UTC().Format(time.RFC3339),
+	)
+
+	return *ex, nil
+}
+
+// RemoveTemporaryException revokes an exception before it expires
+func (si *StandaloneInterceptor) RemoveTemporaryException(id string) bool {
+	si.exceptionsMu.Lock()
+	ex, ok := si.exceptions[id]
+	if ok {
+		ex.timer.Stop()
+		delete(si.exceptions, id)
+	}
+	si.exceptionsMu.Unlock()
+
+	if ok {
+		si.logger.Info("temporary policy exception removed", "id", id)
+	}
+	return ok
+}
+
+// TemporaryExceptions returns the active exceptions ordered by expiry
+func (si *StandaloneInterceptor) TemporaryExceptions() []TemporaryException {
+	si.exceptionsMu.Lock()
+	defer si.exceptionsMu.Unlock()
+
+	now := time.Now()
+	out := make([]TemporaryException, 0, len(si.exceptions))
+	for _, ex := range si.exceptions {
+		if now.Before(ex.ExpiresAt) {
+			out = append(out, *ex)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ExpiresAt.Before(out[j].ExpiresAt) })
+	return out
+}
+
+// expireException drops an exception once its ttl has elapsed
+func (si *StandaloneInterceptor) expireException(id string) {
+	si.exceptionsMu.Lock()
+	ex, ok := si.exceptions[id]
+	if ok {
+		delete(si.exceptions, id)
+	}
+	si.exceptionsMu.Unlock()
+
+	if ok {
+		si.logger.Info("temporary policy exception expired",
+			"id", id,
+			"created_by", ex.CreatedBy,
+			"reason", ex.Reason,
+		)
+	}
+}
+
+// matchException returns the first active exception permitting the request
+func (si *StandaloneInterceptor) matchException(ctx RequestContext) *TemporaryException {
+	si.exceptionsMu.Lock()
+	defer si.exceptionsMu.Unlock()
+
+	now := time.Now()
+	for _, ex := range si.exceptions {
+		if !now.Before(ex.ExpiresAt) {
+			continue
+		}
+		for _, rule := range ex.rules {
+			if evaluateCondition(rule, ctx) {
+				return ex
+			}
+		}
+	}
+	return nil
+}
+
+// stopExceptions cancels pending expiry timers
+func (si *StandaloneInterceptor) stopExceptions() {
+	si.exceptionsMu.Lock()
+	defer si.exceptionsMu.Unlock()
+
+	for _, ex := range si.exceptions {
+		ex.timer.Stop()
+	}
+}
+
+// applyException overrides a Deny decision when an active exception permits the
+// request. Obligations of the denied decision are kept, as exceptions cannot waive them.
+func (si *StandaloneInterceptor) applyException(ctx RequestContext, decision PolicyDecision) (PolicyDecision, *exceptionLog) {
+	if decision.Decision != "Deny" {
+		return decision, nil
+	}
+
+	ex := si.matchException(ctx)
+	if ex == nil {
+		return decision, nil
+	}
+
+	reason := fmt.Sprintf("temporary exception %s overrides: %s", ex.ID, strings.Join(decision.Reasons, "; "))
+	return PolicyDecision{
+		Decision:    "Allow",
+		Reasons:     []string{reason},
+		Matched:     []string{ex.Rule},
+		Obligations: decision.Obligations,
+	}, &exceptionLog{
+		ID:        ex.ID,
+		CreatedBy: ex.CreatedBy,
+		Reason:    ex.Reason,
+		ExpiresAt: ex.ExpiresAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,229 @@
+package trusera
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a goroutine-safe log destination for tests
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func newExceptionTestInterceptor(t *testing.T, logs *lockedBuffer) (*StandaloneInterceptor, string) {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	policyPath := writeTestPolicy(t, tmpDir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "blocked.example.com";
+};
+`)
+	logPath := filepath.Join(tmpDir, "events.jsonl")
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithLogFile(logPath),
+		WithLogger(slog.New(slog.NewTextHandler(logs, nil))),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	t.Cleanup(func() { si.Close() })
+	return si, logPath
+}
+
+// stubTransport answers every request with 200 without touching the network
+type stubTransport struct{}
+
+func (stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     make(http.Header),
+		Body:       http.NoBody,
+		Request:    req,
+	}, nil
+}
+
+func TestTemporaryExceptionOverridesForbid(t *testing.T) {
+	logs := &lockedBuffer{}
+	si, logPath := newExceptionTestInterceptor(t, logs)
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+
+	if _, err := client.Get("https://blocked.example.com/data"); err == nil {
+		t.Fatal("expected request to be blocked before exception")
+	}
+
+	ex, err := si.AddTemporaryException(`resource.hostname == "blocked.example.com"`, time.Hour,
+		WithExceptionCreatedBy("oncall@example.com"),
+		WithExceptionReason("INC-42 unblock ingestion"),
+	)
+	if err != nil {
+		t.Fatalf("AddTemporaryException failed: %v", err)
+	}
+
+	resp, err := client.Get("https://blocked.example.com/data")
+	if err != nil {
+		t.Fatalf("expected exception to allow request: %v", err)
+	}
+	resp.Body.Close()
+
+	if !strings.Contains(logs.String(), "oncall@example.com") {
+		t.Errorf("expected exception creation to be logged with creator, got: %s", logs.String())
+	}
+
+	logData, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(logData)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log entries, got %d", len(lines))
+	}
+
+	var entry eventLog
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("failed to parse log entry: %v", err)
+	}
+	if entry.PolicyDecision != "Allow" || entry.EnforcementAction != "allowed" {
+		t.Errorf("expected allowed entry, got %s/%s", entry.PolicyDecision, entry.EnforcementAction)
+	}
+	if entry.Exception == nil || entry.Exception.ID != ex.ID || entry.Exception.Reason != "INC-42 unblock ingestion" {
+		t.Errorf("expected exception metadata on entry, got %+v", entry.Exception)
+	}
+}
+
+func TestTemporaryExceptionKeepsObligations(t *testing.T) {
+	policyPath := writeTestPolicy(t, t.TempDir(), `
+@obligation("block_secrets")
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "blocked.example.com";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithSecretScanning(),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	if _, err := si.AddTemporaryException(`resource.hostname == "blocked.example.com"`, time.Hour); err != nil {
+		t.Fatalf("AddTemporaryException failed: %v", err)
+	}
+
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+
+	resp, err := client.Post("https://blocked.example.com/upload", "text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("expected exception to allow clean request: %v", err)
+	}
+	resp.Body.Close()
+
+	_, err = client.Post("https://blocked.example.com/upload", "text/plain", strings.NewReader("key="+testAWSKey))
+	if err == nil {
+		t.Fatal("expected secret to be blocked despite the exception")
+	}
+	if !strings.Contains(err.Error(), "block_secrets") {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
+
+func TestTemporaryExceptionExpires(t *testing.T) {
+	logs := &lockedBuffer{}
+	si, _ := newExceptionTestInterceptor(t, logs)
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+
+	if _, err := si.AddTemporaryException(`resource.hostname == "blocked.example.com"`, 50*time.Millisecond); err != nil {
+		t.Fatalf("AddTemporaryException failed: %v", err)
+	}
+	if n := len(si.TemporaryExceptions()); n != 1 {
+		t.Fatalf("expected 1 active exception, got %d", n)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if _, err := client.Get("https://blocked.example.com/data"); err == nil {
+		t.Error("expected request to be blocked after exception expired")
+	}
+	if n := len(si.TemporaryExceptions()); n != 0 {
+		t.Errorf("expected no active exceptions, got %d", n)
+	}
+	if !strings.Contains(logs.String(), "temporary policy exception expired") {
+		t.Errorf("expected expiry to be logged, got: %s", logs.String())
+	}
+}
+
+func TestRemoveTemporaryException(t *testing.T) {
+	si, _ := newExceptionTestInterceptor(t, &lockedBuffer{})
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+
+	ex, err := si.AddTemporaryException(`permit ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "blocked.example.com";
+};`, time.Hour)
+	if err != nil {
+		t.Fatalf("AddTemporaryException failed: %v", err)
+	}
+
+	if !si.RemoveTemporaryException(ex.ID) {
+		t.Fatal("expected exception to be removed")
+	}
+	if si.RemoveTemporaryException(ex.ID) {
+		t.Error("expected second removal to report false")
+	}
+
+	if _, err := client.Get("https://blocked.example.com/data"); err == nil {
+		t.Error("expected request to be blocked after exception removed")
+	}
+}
+
+func TestAddTemporaryExceptionValidation(t *testing.T) {
+	si, _ := newExceptionTestInterceptor(t, &lockedBuffer{})
+
+	tests := []struct {
+		name string
+		rule string
+		ttl  time.Duration
+	}{
+		{"zero ttl", `resource.hostname == "x"`, 0},
+		{"forbid rule", `forbid ( principal, action == Action::"deploy", resource ) when { resource.hostname == "x"; };`, time.Hour},
+		{"no conditions", `not a condition`, time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := si.AddTemporaryException(tt.rule, tt.ttl); err == nil {
+				t.Errorf("expected error for %s", tt.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// WithOTLPExport sends every tracked event to an OpenTelemetry collector as a
+// log record over OTLP/HTTP, so organizations routing telemetry through
+// collectors receive Trusera data there too. endpoint is the collector base
+// URL, e.g. "http://localhost:4318". The event name becomes the record body,
+// other fields become attributes prefixed with "trusera.event.". Events are
+// still sent to the Trusera API unless WithAPIExport(false) is given.
+func WithOTLPExport(endpoint, serviceName string, opts ...HTTPSinkOption) Option {
+	return func(c *Client) {
+		u, err := otlpURL(endpoint)
+		if err != nil {
+			c.setupErr = errors.Join(c.setupErr, err)
+			return
+		}
+
+		encode := func(events [][]byte) ([]byte, error) {
+			return encodeOTLPEvents(events, serviceName)
+		}
+		c.exporters = append(c.exporters, newHTTPSink(u, "application/json", encode, opts))
+	}
+}
+
+// WithAPIExport controls whether events are sent to the Trusera API (default
+// true). Disable it to send events only to exporters such as WithOTLPExport.
+func WithAPIExport(enabled bool) Option {
+	return func(c *Client) {
+		c.apiDisabled = !enabled
+	}
+}
+
+// exportedEvent is the record handed to exporters
+type exportedEvent struct {
+	Event
+	AgentID string `json:"agent_id,omitempty"`
+}
+
+// export copies events to the configured exporters
+func (c *Client) export(events []Event) {
+	if len(c.exporters) == 0 {
+		return
+	}
+
+	for _, event := range events {
+		data, err := json.Marshal(exportedEvent{Event: event, AgentID: c.agentID})
+		if err != nil {
+			continue
+		}
+		for _, exp := range c.exporters {
+			if exp.WriteEvent(data) != nil {
+				c.dropped.Add(1)
+			}
+		}
+	}
+}
+
+// closeExporters delivers what the exporters have queued until ctx is done
+func (c *Client) closeExporters(ctx context.Context) (int, error) {
+	var errs []error
+	dropped := 0
+	for _, exp := range c.exporters {
+		if ds, ok := exp.(DrainingSink); ok {
+			n, err := ds.CloseContext(ctx)
+			dropped += n
+			errs = append(errs, err)
+			continue
+		}
+		errs = append(errs, exp.Close())
+	}
+	c.exporters = nil
+	return dropped, errors.Join(errs...)
+}
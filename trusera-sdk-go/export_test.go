@@ -0,0 +1,89 @@
+package trusera
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClientOTLPExport(t *testing.T) {
+	var mu sync.Mutex
+	var records []otlpLogRecord
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ResourceLogs []struct {
+				ScopeLogs []struct {
+					LogRecords []otlpLogRecord `json:"logRecords"`
+				} `json:"scopeLogs"`
+			} `json:"resourceLogs"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rl := range request.ResourceLogs {
+			for _, sl := range rl.ScopeLogs {
+				records = append(records, sl.LogRecords...)
+			}
+		}
+	}))
+	defer collector.Close()
+
+	var apiCalls atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiCalls.Add(1)
+	}))
+	defer api.Close()
+
+	client := NewClient("test-key",
+		WithBaseURL(api.URL),
+		WithAgentID("agent-1"),
+		WithOTLPExport(collector.URL, "research-agent"),
+		WithAPIExport(false),
+	)
+
+	client.Track(NewEvent(EventToolCall, "web_search").WithPayload("query", "weather"))
+	if err := client.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	if n := apiCalls.Load(); n != 0 {
+		t.Errorf("expected no API calls with API export disabled, got %d", n)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(records) != 1 {
+		t.Fatalf("expected 1 log record, got %d", len(records))
+	}
+
+	rec := records[0]
+	if *rec.Body.StringValue != "web_search" {
+		t.Errorf("expected event name as body, got %q", *rec.Body.StringValue)
+	}
+	attrs := map[string]otlpValue{}
+	for _, kv := range rec.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	if v := attrs["trusera.agent_id"]; v.StringValue == nil || *v.StringValue != "agent-1" {
+		t.Errorf("expected agent id attribute, got %+v", rec.Attributes)
+	}
+	if v := attrs["trusera.event.type"]; v.StringValue == nil || *v.StringValue != "tool_call" {
+		t.Errorf("expected event type attribute, got %+v", rec.Attributes)
+	}
+	if v := attrs["trusera.event.payload"]; v.KvlistValue == nil || v.KvlistValue.Values[0].Key != "query" {
+		t.Errorf("expected payload attribute, got %+v", rec.Attributes)
+	}
+}
+
+func TestClientOTLPExportInvalidEndpoint(t *testing.T) {
+	client := NewClient("test-key", WithOTLPExport("not a url", "svc"), WithFlushRetry(0, 0), WithBaseURL("http://127.0.0.1:1"))
+	defer client.Close()
+
+	if client.setupErr == nil {
+		t.Error("expected invalid endpoint to be reported")
+	}
+}
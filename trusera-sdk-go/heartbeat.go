@@ -0,0 +1,50 @@
+package trusera
+
+import (
+	"os"
+	"runtime"
+	"time"
+)
+
+// WithHeartbeat tracks an EventHeartbeat every interval with basic process
+// stats, so dashboards can tell an idle agent from one whose process or
+// telemetry has died
+func WithHeartbeat(interval time.Duration) Option {
+	return func(c *Client) {
+		c.heartbeat = interval
+	}
+}
+
+// heartbeatLoop tracks heartbeats until the client is closed
+func (c *Client) heartbeatLoop(started time.Time) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.Track(c.heartbeatEvent(started))
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// heartbeatEvent describes the process for a heartbeat
+func (c *Client) heartbeatEvent(started time.Time) Event {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	c.mu.Lock()
+	queued := len(c.events)
+	c.mu.Unlock()
+
+	return NewEvent(EventHeartbeat, "heartbeat").
+		WithPayload("pid", os.Getpid()).
+		WithPayload("uptime_seconds", int64(time.Since(started).Seconds())).
+		WithPayload("goroutines", runtime.NumGoroutine()).
+		WithPayload("heap_alloc_bytes", mem.HeapAlloc).
+		WithPayload("queued_events", queued)
+}
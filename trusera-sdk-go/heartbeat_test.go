@@ -0,0 +1,57 @@
+package trusera
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHeartbeat(t *testing.T) {
+	var mu sync.Mutex
+	var beats []Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		beats = append(beats, payload.Events...)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithHeartbeat(10*time.Millisecond))
+	time.Sleep(55 * time.Millisecond)
+	if err := client.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(beats) < 2 {
+		t.Fatalf("expected several heartbeats, got %d", len(beats))
+	}
+	beat := beats[0]
+	if beat.Type != EventHeartbeat {
+		t.Errorf("expected heartbeat event, got %s", beat.Type)
+	}
+	for _, key := range []string{"pid", "uptime_seconds", "goroutines", "heap_alloc_bytes", "queued_events"} {
+		if _, ok := beat.Payload[key]; !ok {
+			t.Errorf("heartbeat payload missing %q", key)
+		}
+	}
+}
+
+func TestHeartbeatStopsOnClose(t *testing.T) {
+	client := NewClient("test-key", WithBaseURL("http://127.0.0.1:1"), WithFlushRetry(0, 0), WithHeartbeat(time.Millisecond))
+	client.Close()
+
+	queued := client.Stats().Queued
+	time.Sleep(10 * time.Millisecond)
+	if n := client.Stats().Queued; n != queued {
+		t.Errorf("expected no heartbeats after close, queue grew from %d to %d", queued, n)
+	}
+}
@@ -0,0 +1,125 @@
+package trusera
+
+import (
+	"sync"
+	"time"
+)
+
+// DecisionEvent describes the outcome of a single intercepted request
+type DecisionEvent struct {
+	Timestamp         time.Time
+	Method            string
+	URL               string
+	Hostname          string
+	Path              string
+	LLMProvider       string // Set when the destination is a known LLM API
+	LLMModel          string
+	LLMGateway        string // Set for requests to a host given to WithLLMGateways
+	ModelLicense      string // Set by WithModelLicenses
+	Region            string // Cloud region of a regional LLM endpoint or SigV4-signed request
+	TraceID           string // Empty when the request carried no trace context
+	SpanID            string
+	RunID             string
+	SessionID         string
+	Status            int // Zero when the request was blocked or failed
+	Duration          time.Duration
+	Decision          string // "Allow" or "Deny"
+	EnforcementAction string // "allowed", "warned", "logged", "blocked" or "timed_out"
+	Reasons           []string
+	Matched           []string
+}
+
+// DecisionHook receives decision events
+type DecisionHook func(DecisionEvent)
+
+// BlockEvent is the decision event passed to WithOnBlock hooks
+type BlockEvent = DecisionEvent
+
+// WithOnBlock registers a hook invoked asynchronously for every blocked request.
+// Hooks accumulate, so several sinks can observe the same decisions.
+func WithOnBlock(fn func(BlockEvent)) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.hooks.onBlock = append(si.hooks.onBlock, fn)
+	}
+}
+
+// WithOnWarn registers a hook invoked asynchronously for every denied request
+// that was allowed through in warn or log mode
+func WithOnWarn(fn DecisionHook) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.hooks.onWarn = append(si.hooks.onWarn, fn)
+	}
+}
+
+// WithOnAllow registers a hook invoked asynchronously for every allowed request
+func WithOnAllow(fn DecisionHook) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.hooks.onAllow = append(si.hooks.onAllow, fn)
+	}
+}
+
+// decisionHooks holds the registered callbacks and tracks in-flight invocations
+type decisionHooks struct {
+	onBlock []DecisionHook
+	onWarn  []DecisionHook
+	onAllow []DecisionHook
+	mu      sync.Mutex
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// forAction returns the hooks subscribed to an enforcement action. Timed out
+// requests go to the hooks of the policy outcome that let them through.
+func (h *decisionHooks) forAction(action, decision string) []DecisionHook {
+	switch action {
+	case "timed_out":
+		if decision == "Deny" {
+			return h.onWarn
+		}
+		return h.onAllow
+	case "blocked":
+		return h.onBlock
+	case "warned", "logged":
+		return h.onWarn
+	case "allowed":
+		return h.onAllow
+	}
+	return nil
+}
+
+// start registers an in-flight hook, reporting false once the hooks are closed
+func (h *decisionHooks) start() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	h.wg.Add(1)
+	return true
+}
+
+// close stops new hooks from starting so in-flight ones can be waited for
+func (h *decisionHooks) close() {
+	h.mu.Lock()
+	h.closed = true
+	h.mu.Unlock()
+}
+
+// notify dispatches a decision event to the matching hooks without blocking the request
+func (si *StandaloneInterceptor) notify(ev DecisionEvent) {
+	for _, fn := range si.hooks.forAction(ev.EnforcementAction, ev.Decision) {
+		if !si.hooks.start() {
+			return
+		}
+		go func(fn DecisionHook) {
+			defer si.hooks.wg.Done()
+			// A misbehaving hook must not take the agent down with it
+			defer func() {
+				if r := recover(); r != nil {
+					si.logger.Error("decision hook panicked", "action", ev.EnforcementAction, "url", ev.URL, "panic", r)
+				}
+			}()
+			fn(ev)
+		}(fn)
+	}
+}
@@ -0,0 +1,187 @@
+package trusera
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDecisionHooks(t *testing.T) {
+	policyPath := writeTestPolicy(t, t.TempDir(), `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "blocked.example.com";
+};
+`)
+
+	var mu sync.Mutex
+	var blocked, allowed []DecisionEvent
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithOnBlock(func(ev DecisionEvent) {
+			mu.Lock()
+			blocked = append(blocked, ev)
+			mu.Unlock()
+		}),
+		WithOnAllow(func(ev DecisionEvent) {
+			mu.Lock()
+			allowed = append(allowed, ev)
+			mu.Unlock()
+		}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer backend.Close()
+
+	client := si.WrapClient(&http.Client{})
+
+	if _, err := client.Get("https://blocked.example.com/data"); err == nil {
+		t.Fatal("expected blocked request")
+	}
+
+	resp, err := client.Get(backend.URL + "/ok")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	// Close waits for in-flight hooks
+	si.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(blocked) != 1 {
+		t.Fatalf("expected 1 block event, got %d", len(blocked))
+	}
+	if blocked[0].Hostname != "blocked.example.com" || blocked[0].Decision != "Deny" {
+		t.Errorf("unexpected block event: %+v", blocked[0])
+	}
+	if len(blocked[0].Matched) != 1 {
+		t.Errorf("expected matched rule on block event, got %v", blocked[0].Matched)
+	}
+
+	if len(allowed) != 1 {
+		t.Fatalf("expected 1 allow event, got %d", len(allowed))
+	}
+	if allowed[0].Status != http.StatusAccepted || allowed[0].Path != "/ok" {
+		t.Errorf("unexpected allow event: %+v", allowed[0])
+	}
+}
+
+func TestDecisionHooksWarnAndLogModes(t *testing.T) {
+	for _, mode := range []EnforcementAction{EnforcementWarn, EnforcementLog} {
+		t.Run(string(mode), func(t *testing.T) {
+			policyPath := writeTestPolicy(t, t.TempDir(), `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.method == "DELETE";
+};
+`)
+
+			var mu sync.Mutex
+			var warned []DecisionEvent
+
+			si, err := NewStandaloneInterceptor(
+				WithPolicyFile(policyPath),
+				WithEnforcement(mode),
+				WithOnWarn(func(ev DecisionEvent) {
+					mu.Lock()
+					warned = append(warned, ev)
+					mu.Unlock()
+				}),
+				WithOnBlock(func(ev DecisionEvent) {
+					t.Errorf("unexpected block hook in %s mode", mode)
+				}),
+			)
+			if err != nil {
+				t.Fatalf("failed to create interceptor: %v", err)
+			}
+
+			backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer backend.Close()
+
+			req, _ := http.NewRequest("DELETE", backend.URL+"/item", nil)
+			resp, err := si.WrapClient(&http.Client{}).Do(req)
+			if err != nil {
+				t.Fatalf("request should succeed: %v", err)
+			}
+			resp.Body.Close()
+
+			si.Close()
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(warned) != 1 {
+				t.Fatalf("expected 1 warn event, got %d", len(warned))
+			}
+		})
+	}
+}
+
+func TestDecisionHookPanicRecovered(t *testing.T) {
+	logs := &bytes.Buffer{}
+	si, err := NewStandaloneInterceptor(
+		WithLogger(slog.New(slog.NewTextHandler(logs, nil))),
+		WithOnAllow(func(ev DecisionEvent) {
+			panic("hook failure")
+		}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	resp, err := si.WrapClient(&http.Client{}).Get(backend.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if err := si.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if !strings.Contains(logs.String(), "decision hook panicked") || !strings.Contains(logs.String(), "hook failure") {
+		t.Errorf("expected hook panic to be logged, got %q", logs.String())
+	}
+}
+
+func TestDecisionHooksSkippedAfterClose(t *testing.T) {
+	var calls atomic.Int32
+	si, err := NewStandaloneInterceptor(
+		WithOnAllow(func(ev DecisionEvent) {
+			calls.Add(1)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	if err := si.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	si.notify(DecisionEvent{EnforcementAction: "allowed", Decision: "Allow"})
+	si.hooks.wg.Wait()
+
+	if n := calls.Load(); n != 0 {
+		t.Errorf("expected no hook calls after Close, got %d", n)
+	}
+}
@@ -0,0 +1,82 @@
+package trusera
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WithIdempotencyKeys gives every tracked event without an idempotency key a
+// random UUID, so the API can discard copies delivered twice when a flush is
+// retried after an ambiguous failure. Events tracked again with a key already
+// seen within window are dropped on the client. A zero window only assigns keys.
+func WithIdempotencyKeys(window time.Duration) Option {
+	return func(c *Client) {
+		c.dedupe = &dedupeWindow{window: window, seen: make(map[string]time.Time)}
+	}
+}
+
+// dedupeWindow remembers recent idempotency keys
+type dedupeWindow struct {
+	window time.Duration
+
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastPrune time.Time
+}
+
+// admit reports whether an event with key should be queued, remembering key
+func (d *dedupeWindow) admit(key string, now time.Time) bool {
+	if d.window <= 0 {
+		return true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if now.Sub(d.lastPrune) > d.window {
+		for k, t := range d.seen {
+			if now.Sub(t) > d.window {
+				delete(d.seen, k)
+			}
+		}
+		d.lastPrune = now
+	}
+
+	if t, ok := d.seen[key]; ok && now.Sub(t) <= d.window {
+		return false
+	}
+	d.seen[key] = now
+	return true
+}
+
+// assignKeys fills in missing idempotency keys and removes duplicates
+func (c *Client) assignKeys(events []Event) []Event {
+	if c.dedupe == nil {
+		return events
+	}
+
+	now := time.Now()
+	kept := events[:0:0]
+	for _, e := range events {
+		if e.IdempotencyKey == "" {
+			e.IdempotencyKey = newUUID()
+		}
+		if !c.dedupe.admit(e.IdempotencyKey, now) {
+			c.counters.deduplicated.Add(1)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	return kept
+}
+
+// newUUID returns a random version 4 UUID
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
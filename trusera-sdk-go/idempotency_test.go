@@ -0,0 +1,92 @@
+package trusera
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKeysAssigned(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		for _, e := range payload.Events {
+			keys = append(keys, e.IdempotencyKey)
+		}
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithIdempotencyKeys(0))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	client.Track(NewEvent(EventToolCall, "fetch").WithIdempotencyKey("caller-key"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(keys))
+	}
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if !uuid.MatchString(keys[0]) {
+		t.Errorf("expected a UUID key, got %q", keys[0])
+	}
+	if keys[1] != "caller-key" {
+		t.Errorf("expected caller key to be kept, got %q", keys[1])
+	}
+}
+
+func TestIdempotencyKeysDedupeWithinWindow(t *testing.T) {
+	collector := &eventCollector{}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithIdempotencyKeys(time.Minute))
+	defer client.Close()
+
+	event := NewEvent(EventToolCall, "search").WithIdempotencyKey("k1")
+	client.Track(event)
+	client.Track(event)
+	client.TrackBatch([]Event{event, NewEvent(EventToolCall, "fetch")})
+	if err := client.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	if got := collector.received(); len(got) != 2 {
+		t.Fatalf("expected duplicates to be dropped, got %v", got)
+	}
+	if n := client.Stats().Deduplicated; n != 2 {
+		t.Errorf("expected 2 deduplicated events, got %d", n)
+	}
+}
+
+func TestDedupeWindowExpires(t *testing.T) {
+	d := &dedupeWindow{window: time.Minute, seen: make(map[string]time.Time)}
+	now := time.Now()
+
+	if !d.admit("k", now) {
+		t.Fatal("expected first key to be admitted")
+	}
+	if d.admit("k", now.Add(30*time.Second)) {
+		t.Fatal("expected repeat within window to be dropped")
+	}
+	if !d.admit("k", now.Add(2*time.Minute)) {
+		t.Fatal("expected key to be admitted after the window")
+	}
+	if len(d.seen) != 1 {
+		t.Errorf("expected expired keys to be pruned, have %d", len(d.seen))
+	}
+}
@@ -66,8 +66,10 @@ func (t *interceptingTransport) RoundTrip(req *http.Request) (*http.Response, er
 
 	// Read and restore request body for logging
 	var bodySnippet string
+	var bodyBytes []byte
 	if req.Body != nil {
-		bodyBytes, err := io.ReadAll(req.Body)
+		var err error
+		bodyBytes, err = io.ReadAll(req.Body)
 		if err == nil {
 			req.Body.Close()
 			req.Body = io.NopCloser(bytes.NewReader(bodyBytes))
@@ -91,6 +93,12 @@ func (t *interceptingTransport) RoundTrip(req *http.Request) (*http.Response, er
 	if bodySnippet != "" {
 		event = event.WithPayload("body_snippet", bodySnippet)
 	}
+	if llm, ok := DetectLLM(req.URL, bodyBytes); ok {
+		event = event.WithPayload("llm_provider", llm.Provider)
+		if llm.Model != "" {
+			event = event.WithPayload("llm_model", llm.Model)
+		}
+	}
 
 	// Handle enforcement modes
 	if blocked {
@@ -138,14 +146,9 @@ func (t *interceptingTransport) RoundTrip(req *http.Request) (*http.Response, er
 	return resp, nil
 }
 
-// shouldExclude checks if URL matches any exclude patterns
+// shouldExclude checks if URL matches any exclude patterns, see matchesExclude
 func (t *interceptingTransport) shouldExclude(url string) bool {
-	for _, pattern := range t.opts.ExcludePatterns {
-		if strings.Contains(url, pattern) {
-			return true
-		}
-	}
-	return false
+	return matchesExclude(url, t.opts.ExcludePatterns)
 }
 
 // isBlocked checks if URL matches any block patterns
@@ -161,17 +164,10 @@ func (t *interceptingTransport) isBlocked(url string) bool {
 // sanitizeHeaders removes sensitive headers from logging
 func sanitizeHeaders(headers http.Header) map[string]string {
 	sanitized := make(map[string]string)
-	sensitiveHeaders := map[string]bool{
-		"authorization": true,
-		"cookie":        true,
-		"set-cookie":    true,
-		"x-api-key":     true,
-	}
 
 	for key, values := range headers {
-		lowerKey := strings.ToLower(key)
-		if sensitiveHeaders[lowerKey] {
-			sanitized[key] = "[REDACTED]"
+		if defaultRedactedHeaderSet[http.CanonicalHeaderKey(key)] {
+			sanitized[key] = redactedValue
 		} else if len(values) > 0 {
 			sanitized[key] = values[0]
 		}
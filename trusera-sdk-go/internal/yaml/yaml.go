@@ -0,0 +1,289 @@
+// Package yaml decodes the block-style YAML subset used by policy test cases
+// and interceptor config files, so the module needs no YAML dependency
+package yaml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlLine is one significant line of a YAML document
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// yamlParser reads the block-style YAML subset: mappings, sequences, plain
+// and quoted scalars, flow sequences of scalars and comments. Anchors, tags,
+// block scalars and multiple documents are not supported. Values decode to
+// map[string]any, []any, string, int64, float64, bool or nil, ready for
+// encoding/json.
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// Parse decodes a YAML document
+func Parse(data []byte) (any, error) {
+	p := &yamlParser{}
+	for i, raw := range strings.Split(string(data), "\n") {
+		text := strings.TrimRight(stripYAMLComment(raw), " \t\r")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || (i == 0 && trimmed == "---") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		p.lines = append(p.lines, yamlLine{num: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+	if len(p.lines) == 0 {
+		return nil, nil
+	}
+
+	v, err := p.node(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].num)
+	}
+	return v, nil
+}
+
+// node parses the sequence, mapping or scalar starting at the current line
+func (p *yamlParser) node(indent int) (any, error) {
+	line := p.lines[p.pos]
+	switch {
+	case line.text == "-" || strings.HasPrefix(line.text, "- "):
+		return p.sequence(indent)
+	case isYAMLKey(line.text):
+		return p.mapping(indent)
+	default:
+		p.pos++
+		return yamlScalar(line.text, line.num)
+	}
+}
+
+// sequence parses "- item" lines at indent
+func (p *yamlParser) sequence(indent int) ([]any, error) {
+	items := []any{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent || !(line.text == "-" || strings.HasPrefix(line.text, "- ")) {
+			break
+		}
+
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if rest == "" {
+			p.pos++
+			if p.pos >= len(p.lines) || p.lines[p.pos].indent <= indent {
+				items = append(items, nil)
+				continue
+			}
+			item, err := p.node(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+
+		// The item's content continues at the column after the dash, so
+		// "- name: x" starts a mapping whose next keys align with name
+		p.lines[p.pos] = yamlLine{num: line.num, indent: line.indent + len(line.text) - len(rest), text: rest}
+		item, err := p.node(p.lines[p.pos].indent)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// mapping parses "key: value" lines at indent
+func (p *yamlParser) mapping(indent int) (map[string]any, error) {
+	m := make(map[string]any)
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent {
+			if line.indent > indent {
+				return nil, fmt.Errorf("line %d: unexpected indentation", line.num)
+			}
+			break
+		}
+		if !isYAMLKey(line.text) {
+			return nil, fmt.Errorf("line %d: expected a key", line.num)
+		}
+
+		key, value := splitYAMLKey(line.text)
+		if k, err := yamlScalar(key, line.num); err == nil {
+			key = fmt.Sprint(k)
+		}
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.num, key)
+		}
+		p.pos++
+
+		if value != "" {
+			v, err := yamlScalar(value, line.num)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+			continue
+		}
+
+		// An empty value holds a nested block, or a sequence that YAML
+		// allows at the key's own indentation
+		next := p.pos < len(p.lines)
+		switch {
+		case next && p.lines[p.pos].indent > indent:
+			v, err := p.node(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+		case next && p.lines[p.pos].indent == indent && strings.HasPrefix(p.lines[p.pos].text, "-"):
+			v, err := p.sequence(indent)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+		default:
+			m[key] = nil
+		}
+	}
+	return m, nil
+}
+
+// yamlScalar decodes a scalar or a flow sequence of scalars
+func yamlScalar(s string, num int) (any, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid double-quoted string %s", num, s)
+		}
+		return v, nil
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return nil, fmt.Errorf("line %d: unterminated single-quoted string %s", num, s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case strings.HasPrefix(s, "["):
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("line %d: unterminated flow sequence %s", num, s)
+		}
+		items := []any{}
+		for _, part := range splitFlow(s[1 : len(s)-1]) {
+			v, err := yamlScalar(part, num)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	case s == "{}":
+		return map[string]any{}, nil
+	case strings.HasPrefix(s, "|"), strings.HasPrefix(s, ">"), strings.HasPrefix(s, "&"), strings.HasPrefix(s, "*"), strings.HasPrefix(s, "!"):
+		return nil, fmt.Errorf("line %d: unsupported YAML syntax %q", num, s)
+	}
+
+	switch s {
+	case "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, nil
+	}
+	return s, nil
+}
+
+// splitFlow splits the inside of a flow sequence on commas outside quotes
+func splitFlow(s string) []string {
+	var (
+		parts []string
+		quote byte
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" || len(parts) > 0 {
+		parts = append(parts, last)
+	}
+	return parts
+}
+
+// isYAMLKey reports whether a line is a "key: value" or "key:" entry
+func isYAMLKey(text string) bool {
+	key, _ := splitYAMLKey(text)
+	return key != ""
+}
+
+// splitYAMLKey splits "key: value" at the first colon outside quotes that is
+// followed by a space or ends the line
+func splitYAMLKey(text string) (key, value string) {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case i == 0 && (c == '"' || c == '\''):
+			quote = c
+		case c == ':' && (i+1 == len(text) || text[i+1] == ' '):
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:])
+		}
+	}
+	return "", ""
+}
+
+// stripYAMLComment removes a "#" comment that starts a line or follows a
+// space outside quotes
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			// Quotes only open a string at the start of a scalar
+			if i == 0 || strings.ContainsRune(" :-[,", rune(line[i-1])) {
+				quote = c
+			}
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
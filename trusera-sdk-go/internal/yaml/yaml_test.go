@@ -0,0 +1,74 @@
+package yaml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	doc := `---
+# policy test cases
+cases:
+- name: "deletes: denied"   # quoted colon
+  request:
+    method: DELETE
+    url: https://api.example.com/users/1
+    secret_count: 2
+  expect: deny
+-
+  name: 'it''s allowed'
+  tags: [a, "b, c", 3]
+  enabled: true
+  ratio: 0.5
+  note: ~
+  empty:
+`
+	got, err := Parse([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"cases": []any{
+			map[string]any{
+				"name": "deletes: denied",
+				"request": map[string]any{
+					"method":       "DELETE",
+					"url":          "https://api.example.com/users/1",
+					"secret_count": int64(2),
+				},
+				"expect": "deny",
+			},
+			map[string]any{
+				"name":    "it's allowed",
+				"tags":    []any{"a", "b, c", int64(3)},
+				"enabled": true,
+				"ratio":   0.5,
+				"note":    nil,
+				"empty":   nil,
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v", got)
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		doc, want string
+	}{
+		{"a: 1\n  b: 2\n", "line 2: unexpected indentation"},
+		{"a: 1\na: 2\n", `line 2: duplicate key "a"`},
+		{"a:\n\t- b\n", "line 2: tabs are not allowed"},
+		{"a: 'open\n", "line 1: unterminated single-quoted string"},
+		{"a: |\n  text\n", "unsupported YAML syntax"},
+		{"a: 1\njust text\n", "line 2: expected a key"},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.doc))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) = %v, want %q", tt.doc, err, tt.want)
+		}
+	}
+}
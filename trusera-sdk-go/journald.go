@@ -0,0 +1,140 @@
+package trusera
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultJournaldSocket is where journald receives native protocol messages
+const defaultJournaldSocket = "/run/systemd/journal/socket"
+
+// journalPriorities maps enforcement actions to syslog priorities. Unknown
+// actions are notices like logged denials.
+var journalPriorities = map[string]int{
+	"allowed":   6, // info
+	"logged":    5, // notice
+	"warned":    4, // warning
+	"timed_out": 4,
+	"blocked":   3, // err
+}
+
+// JournaldOption configures a journald sink
+type JournaldOption func(*journaldSink)
+
+// WithJournaldIdentifier sets SYSLOG_IDENTIFIER, which journalctl -t
+// filters on (default "trusera")
+func WithJournaldIdentifier(id string) JournaldOption {
+	return func(s *journaldSink) {
+		s.identifier = id
+	}
+}
+
+// WithJournaldSocket sets the journald socket (default
+// /run/systemd/journal/socket)
+func WithJournaldSocket(path string) JournaldOption {
+	return func(s *journaldSink) {
+		s.socket = path
+	}
+}
+
+// NewJournaldSink writes events to the systemd journal over its native
+// protocol, so host tooling reading the journal sees them as structured
+// entries. Each event field becomes a TRUSERA_<FIELD> journal field, nested
+// values as JSON, with a one-line MESSAGE and a PRIORITY from the
+// enforcement action: info when allowed up to err when blocked. Events too
+// large for a datagram are passed to journald in a file descriptor, like
+// sd_journal_send does. It fails on platforms other than Linux and where
+// journald is not running.
+func NewJournaldSink(opts ...JournaldOption) (LogSink, error) {
+	s := &journaldSink{identifier: "trusera", socket: defaultJournaldSocket}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if err := s.dial(); err != nil {
+		return nil, fmt.Errorf("failed to connect to journald: %w", err)
+	}
+	return s, nil
+}
+
+func (s *journaldSink) WriteEvent(data []byte) error {
+	msg, err := s.encode(data)
+	if err != nil {
+		return err
+	}
+	return s.send(msg)
+}
+
+// encode renders a JSONL event as a native protocol message
+func (s *journaldSink) encode(data []byte) ([]byte, error) {
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("invalid event: %w", err)
+	}
+
+	action, _ := fields["enforcement_action"].(string)
+	priority, ok := journalPriorities[action]
+	if !ok {
+		priority = journalPriorities["logged"]
+	}
+
+	var msg bytes.Buffer
+	writeJournalField(&msg, "MESSAGE", fmt.Sprintf("%v %v %v", fields["method"], fields["url"], action))
+	writeJournalField(&msg, "PRIORITY", strconv.Itoa(priority))
+	writeJournalField(&msg, "SYSLOG_IDENTIFIER", s.identifier)
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var value string
+		switch v := fields[k].(type) {
+		case string:
+			value = v
+		case float64:
+			value = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			value = strconv.FormatBool(v)
+		case nil:
+			continue
+		default:
+			b, _ := json.Marshal(v)
+			value = string(b)
+		}
+		writeJournalField(&msg, journalFieldName(k), value)
+	}
+	return msg.Bytes(), nil
+}
+
+// journalFieldName turns an event key into a journal field name, which
+// may only hold uppercase letters, digits and underscores
+func journalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, key)
+	return "TRUSERA_" + name
+}
+
+// writeJournalField appends a field to a native protocol message. Values
+// spanning lines are length-prefixed.
+func writeJournalField(buf *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		buf.WriteString(name + "=" + value + "\n")
+		return
+	}
+	buf.WriteString(name + "\n")
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value + "\n")
+}
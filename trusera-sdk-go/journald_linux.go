@@ -0,0 +1,78 @@
+package trusera
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// journaldSink sends events to journald over a datagram socket
+type journaldSink struct {
+	identifier string
+	socket     string
+
+	mu   sync.Mutex
+	conn *net.UnixConn
+	addr *net.UnixAddr
+}
+
+// dial opens an unbound, unconnected socket. net only connects or binds
+// datagram sockets, and file descriptors cannot be passed on a connected one.
+func (s *journaldSink) dial() error {
+	if _, err := os.Stat(s.socket); err != nil {
+		return err
+	}
+	fd, err := syscall.Socket(syscall.AF_UNIX, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	f := os.NewFile(uintptr(fd), "journald")
+	defer f.Close()
+	conn, err := net.FileConn(f)
+	if err != nil {
+		return err
+	}
+	s.conn, s.addr = conn.(*net.UnixConn), &net.UnixAddr{Name: s.socket, Net: "unixgram"}
+	return nil
+}
+
+// send writes msg as a datagram, or through a file when it is too large
+func (s *journaldSink) send(msg []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.conn.WriteToUnix(msg, s.addr)
+	if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS) {
+		return s.sendFile(msg)
+	}
+	return err
+}
+
+// sendFile passes msg in an unlinked temporary file, which journald reads
+// in place of the datagram
+func (s *journaldSink) sendFile(msg []byte) error {
+	dir := "/dev/shm"
+	if _, err := os.Stat(dir); err != nil {
+		dir = os.TempDir()
+	}
+	f, err := os.CreateTemp(dir, "trusera-journal-")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	os.Remove(f.Name())
+
+	if _, err := f.Write(msg); err != nil {
+		return err
+	}
+	_, _, err = s.conn.WriteMsgUnix(nil, syscall.UnixRights(int(f.Fd())), s.addr)
+	return err
+}
+
+func (s *journaldSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn.Close()
+}
@@ -0,0 +1,90 @@
+package trusera
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// listenJournal stands in for journald's socket
+func listenJournal(t *testing.T) (string, *net.UnixConn) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return path, conn
+}
+
+// readJournal reads one message, from the datagram or a passed file
+func readJournal(t *testing.T, conn *net.UnixConn) map[string]string {
+	t.Helper()
+	buf := make([]byte, 1<<16)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if oobn == 0 {
+		return parseJournalMessage(t, buf[:n])
+	}
+
+	msgs, _ := syscall.ParseSocketControlMessage(oob[:oobn])
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatalf("expected a file descriptor: %v", err)
+	}
+	f := os.NewFile(uintptr(fds[0]), "journal")
+	defer f.Close()
+	f.Seek(0, io.SeekStart)
+	data, _ := io.ReadAll(f)
+	return parseJournalMessage(t, data)
+}
+
+func TestJournaldSink(t *testing.T) {
+	socket, journal := listenJournal(t)
+	sink, err := NewJournaldSink(WithJournaldSocket(socket))
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+
+	si, err := NewStandaloneInterceptor(WithLogSinks(sink))
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	resp, err := client.Get("https://api.example.com/users")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	fields := readJournal(t, journal)
+	if fields["PRIORITY"] != "6" || fields["SYSLOG_IDENTIFIER"] != "trusera" || fields["TRUSERA_HOSTNAME"] != "api.example.com" {
+		t.Errorf("unexpected journal entry %v", fields)
+	}
+
+	// Events larger than a datagram are passed in a file
+	large := `{"method":"POST","url":"https://api.example.com/upload","enforcement_action":"warned","reasons":"` + strings.Repeat("x", 1<<20) + `"}`
+	if err := sink.WriteEvent([]byte(large)); err != nil {
+		t.Fatalf("large event failed: %v", err)
+	}
+	fields = readJournal(t, journal)
+	if fields["PRIORITY"] != "4" || len(fields["TRUSERA_REASONS"]) != 1<<20 {
+		t.Errorf("unexpected large journal entry with %d bytes of reasons", len(fields["TRUSERA_REASONS"]))
+	}
+	si.Close()
+}
+
+func TestJournaldSinkUnavailable(t *testing.T) {
+	if _, err := NewJournaldSink(WithJournaldSocket(filepath.Join(t.TempDir(), "missing.sock"))); err == nil {
+		t.Error("expected an error without a journald socket")
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+package trusera
+
+import "errors"
+
+// journaldSink is unavailable outside Linux
+type journaldSink struct {
+	identifier string
+	socket     string
+}
+
+func (s *journaldSink) dial() error {
+	return errors.New("journald is only available on Linux")
+}
+
+func (s *journaldSink) send(msg []byte) error {
+	return errors.New("journald is only available on Linux")
+}
+
+func (s *journaldSink) Close() error {
+	return nil
+}
@@ -0,0 +1,74 @@
+package trusera
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// parseJournalMessage decodes a native protocol message into its fields
+func parseJournalMessage(t *testing.T, msg []byte) map[string]string {
+	t.Helper()
+	fields := make(map[string]string)
+	for len(msg) > 0 {
+		line, rest, _ := bytes.Cut(msg, []byte("\n"))
+		if name, value, ok := bytes.Cut(line, []byte("=")); ok {
+			fields[string(name)] = string(value)
+			msg = rest
+			continue
+		}
+		if len(rest) < 8 {
+			t.Fatalf("truncated field %s", line)
+		}
+		n := binary.LittleEndian.Uint64(rest)
+		fields[string(line)] = string(rest[8 : 8+n])
+		msg = rest[8+n+1:]
+	}
+	return fields
+}
+
+func TestJournaldEncode(t *testing.T) {
+	s := &journaldSink{identifier: "agent"}
+	msg, err := s.encode([]byte(`{"timestamp":"2024-05-01T12:00:00Z","method":"DELETE","url":"https://api.example.com/users/1","status":0,"duration_ms":1.5,"policy_decision":"Deny","enforcement_action":"blocked","reasons":"line one\nline two","request_headers":{"User-Agent":"go"}}` + "\n"))
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	fields := parseJournalMessage(t, msg)
+	want := map[string]string{
+		"MESSAGE":                    "DELETE https://api.example.com/users/1 blocked",
+		"PRIORITY":                   "3",
+		"SYSLOG_IDENTIFIER":          "agent",
+		"TRUSERA_METHOD":             "DELETE",
+		"TRUSERA_DURATION_MS":        "1.5",
+		"TRUSERA_STATUS":             "0",
+		"TRUSERA_ENFORCEMENT_ACTION": "blocked",
+		"TRUSERA_REASONS":            "line one\nline two",
+		"TRUSERA_REQUEST_HEADERS":    `{"User-Agent":"go"}`,
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("%s = %q, want %q", k, fields[k], v)
+		}
+	}
+	if !strings.HasPrefix(string(msg), "MESSAGE=") {
+		t.Errorf("expected MESSAGE first, got %q", msg)
+	}
+
+	if _, err := s.encode([]byte("not json")); err == nil {
+		t.Error("expected an error for an invalid event")
+	}
+}
+
+func TestJournalFieldName(t *testing.T) {
+	for key, want := range map[string]string{
+		"llm_provider": "TRUSERA_LLM_PROVIDER",
+		"traceId":      "TRUSERA_TRACEID",
+		"a.b-c":        "TRUSERA_A_B_C",
+	} {
+		if got := journalFieldName(key); got != want {
+			t.Errorf("journalFieldName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
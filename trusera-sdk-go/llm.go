@@ -0,0 +1,386 @@
+package trusera
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Trusera/ai-bom/trusera-sdk-go/cost"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/tokenizer"
+)
+
+// LLMCall describes one call to a language model provider
+type LLMCall struct {
+	Provider         string // e.g. "openai" or "anthropic"
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Latency          time.Duration
+	FinishReason     string  // e.g. "stop", "length", "end_turn"
+	CostUSD          float64 // Zero when unknown, see package cost
+}
+
+// NewLLMCallEvent returns an EventLLMCall event named after the model, with
+// the call's fields in the payload. A zero CostUSD is estimated from the
+// cost.Default price table when the model is listed there.
+func NewLLMCallEvent(call LLMCall) Event {
+	if call.CostUSD == 0 {
+		call.CostUSD, _ = cost.Estimate(call.Model, call.PromptTokens, call.CompletionTokens)
+	}
+
+	e := NewEvent(EventLLMCall, call.Model).
+		WithPayload("provider", call.Provider).
+		WithPayload("model", call.Model).
+		WithPayload("prompt_tokens", call.PromptTokens).
+		WithPayload("completion_tokens", call.CompletionTokens).
+		WithPayload("total_tokens", call.PromptTokens+call.CompletionTokens).
+		WithPayload("latency_ms", call.Latency.Milliseconds()).
+		WithDuration(call.Latency)
+	if call.FinishReason != "" {
+		e = e.WithPayload("finish_reason", call.FinishReason)
+	}
+	if call.CostUSD > 0 {
+		e = e.WithPayload("cost_usd", call.CostUSD)
+	}
+	return e
+}
+
+// ParseLLMCall builds an LLMCall from an intercepted OpenAI-, Anthropic-,
+// Bedrock-, Gemini- or Ollama-style exchange: the request URL, the JSON
+// request and response bodies, and the observed latency. A streamed
+// response, as server-sent events, newline-delimited JSON, a JSON array of
+// chunks or an AWS event stream, is merged first. The provider is taken
+// from the host when it is a known API, otherwise from the shape of the
+// response.
+func ParseLLMCall(requestURL string, reqBody, respBody []byte, latency time.Duration) (LLMCall, error) {
+	resp, err := decodeLLMResponse(respBody)
+	if err != nil {
+		return LLMCall{}, fmt.Errorf("failed to parse LLM response: %w", err)
+	}
+
+	call := LLMCall{Model: resp.Model, Latency: latency}
+	if call.Model == "" {
+		call.Model = resp.ModelVersion
+	}
+	if u, err := url.Parse(requestURL); err == nil {
+		if ep, ok := DetectLLM(u, reqBody); ok {
+			call.Provider = ep.Provider
+			if call.Model == "" {
+				call.Model = ep.Model
+			}
+		}
+	}
+
+	switch {
+	case resp.Choices != nil || resp.Usage.PromptTokens > 0:
+		if call.Provider == "" {
+			call.Provider = "openai"
+		}
+		call.PromptTokens = resp.Usage.PromptTokens
+		call.CompletionTokens = resp.Usage.CompletionTokens
+		if len(resp.Choices) > 0 {
+			call.FinishReason = resp.Choices[0].FinishReason
+		}
+	case resp.ConverseStopReason != "" || resp.Usage.ConverseInputTokens > 0 || resp.InvocationMetrics.InputTokenCount > 0:
+		if call.Provider == "" {
+			call.Provider = "bedrock"
+		}
+		call.PromptTokens = resp.Usage.ConverseInputTokens
+		call.CompletionTokens = resp.Usage.ConverseOutputTokens
+		call.FinishReason = resp.ConverseStopReason
+		if call.FinishReason == "" {
+			call.FinishReason = resp.StopReason
+		}
+		if call.PromptTokens == 0 {
+			call.PromptTokens = resp.InvocationMetrics.InputTokenCount
+			call.CompletionTokens = resp.InvocationMetrics.OutputTokenCount
+		}
+	case resp.StopReason != "" || resp.Usage.InputTokens > 0:
+		if call.Provider == "" {
+			call.Provider = "anthropic"
+		}
+		call.PromptTokens = resp.Usage.InputTokens
+		call.CompletionTokens = resp.Usage.OutputTokens
+		call.FinishReason = resp.StopReason
+	case resp.Candidates != nil || resp.UsageMetadata.PromptTokenCount > 0:
+		if call.Provider == "" {
+			call.Provider = "google"
+		}
+		call.PromptTokens = resp.UsageMetadata.PromptTokenCount
+		call.CompletionTokens = resp.UsageMetadata.CandidatesTokenCount
+		if len(resp.Candidates) > 0 {
+			call.FinishReason = strings.ToLower(resp.Candidates[0].FinishReason)
+		}
+	case resp.Done || resp.PromptEvalCount > 0 || resp.EvalCount > 0:
+		if call.Provider == "" {
+			call.Provider = "ollama"
+		}
+		call.PromptTokens = resp.PromptEvalCount
+		call.CompletionTokens = resp.EvalCount
+		call.FinishReason = resp.DoneReason
+	default:
+		return LLMCall{}, errors.New("response is not an OpenAI, Anthropic, Bedrock, Gemini or Ollama completion")
+	}
+
+	var req llmRequest
+	json.Unmarshal(reqBody, &req)
+	if call.Model == "" {
+		call.Model = req.Model
+	}
+	if call.PromptTokens == 0 {
+		// Streaming and some gateways leave out usage
+		call.PromptTokens = tokenizer.Count(call.Model, req.promptText())
+	}
+	return call, nil
+}
+
+// llmResponse holds the fields of OpenAI-, Anthropic-, Bedrock-, Gemini- and
+// Ollama-style responses that describe the call
+type llmResponse struct {
+	Model string `json:"model"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		InputTokens      int `json:"input_tokens"`
+		OutputTokens     int `json:"output_tokens"`
+
+		// Bedrock Converse
+		ConverseInputTokens  int `json:"inputTokens"`
+		ConverseOutputTokens int `json:"outputTokens"`
+	} `json:"usage"`
+	Choices []struct {
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	StopReason string `json:"stop_reason"`
+	Delta      struct {
+		StopReason string `json:"stop_reason"` // Anthropic message_delta
+	} `json:"delta"`
+
+	// Bedrock Converse, and the metrics Bedrock appends to the last chunk
+	// of a streamed InvokeModel response
+	ConverseStopReason string `json:"stopReason"`
+	InvocationMetrics  struct {
+		InputTokenCount  int `json:"inputTokenCount"`
+		OutputTokenCount int `json:"outputTokenCount"`
+	} `json:"amazon-bedrock-invocationMetrics"`
+	Bytes []byte `json:"bytes"`
+
+	// Gemini
+	ModelVersion  string `json:"modelVersion"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+	Candidates []struct {
+		FinishReason string `json:"finishReason"`
+	} `json:"candidates"`
+
+	// Ollama
+	Done            bool   `json:"done"`
+	DoneReason      string `json:"done_reason"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+}
+
+// decodeLLMResponse decodes a JSON response, or merges the chunks of a
+// streamed one so that later chunks' usage and finish reasons win. Chunks
+// that do not decode, such as one cut short by a capture limit, are skipped.
+func decodeLLMResponse(body []byte) (llmResponse, error) {
+	var resp llmResponse
+	trimmed := bytes.TrimSpace(body)
+	switch {
+	case isEventStream(body):
+		return decodeEventStream(body)
+	case bytes.HasPrefix(trimmed, []byte("data:")):
+		decoded := false
+		for _, line := range bytes.Split(trimmed, []byte("\n")) {
+			data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+			if !ok {
+				continue
+			}
+			var chunk llmResponse
+			if json.Unmarshal(bytes.TrimSpace(data), &chunk) == nil {
+				resp.merge(chunk)
+				decoded = true
+			}
+		}
+		if !decoded {
+			return resp, errors.New("no JSON events in stream")
+		}
+		return resp, nil
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		var chunks []json.RawMessage
+		if err := json.Unmarshal(trimmed, &chunks); err != nil {
+			return resp, err
+		}
+		for _, raw := range chunks {
+			var chunk llmResponse
+			if json.Unmarshal(raw, &chunk) == nil {
+				resp.merge(chunk)
+			}
+		}
+		return resp, nil
+	}
+
+	// A body is one JSON object, or newline-delimited ones when streamed
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	if err := dec.Decode(&resp); err != nil {
+		return resp, err
+	}
+	for {
+		var chunk llmResponse
+		if dec.Decode(&chunk) != nil {
+			return resp, nil
+		}
+		resp.merge(chunk)
+	}
+}
+
+// isEventStream reports whether body starts with an AWS event stream
+// message, as Bedrock streams responses, by checking its prelude checksum
+func isEventStream(body []byte) bool {
+	if len(body) < 16 {
+		return false
+	}
+	total := binary.BigEndian.Uint32(body)
+	return total >= 16 && crc32.ChecksumIEEE(body[:8]) == binary.BigEndian.Uint32(body[8:])
+}
+
+// decodeEventStream merges the JSON payloads of the messages in an AWS event
+// stream. InvokeModel streams wrap each model chunk in a "bytes" field,
+// which is decoded in turn. A message cut short ends the stream.
+func decodeEventStream(body []byte) (llmResponse, error) {
+	var resp llmResponse
+	decoded := false
+	for len(body) >= 16 {
+		total := int(binary.BigEndian.Uint32(body))
+		headers := int(binary.BigEndian.Uint32(body[4:]))
+		if total < 16+headers || total > len(body) {
+			break
+		}
+		payload := body[12+headers : total-4]
+		body = body[total:]
+
+		var chunk llmResponse
+		if json.Unmarshal(payload, &chunk) != nil {
+			continue
+		}
+		if len(chunk.Bytes) > 0 {
+			var inner llmResponse
+			if json.Unmarshal(chunk.Bytes, &inner) != nil {
+				continue
+			}
+			chunk = inner
+		}
+		resp.merge(chunk)
+		decoded = true
+	}
+	if !decoded {
+		return resp, errors.New("no JSON events in stream")
+	}
+	return resp, nil
+}
+
+// merge copies the fields set in a later stream chunk over r
+func (r *llmResponse) merge(chunk llmResponse) {
+	override := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	count := func(dst *int, v int) {
+		if v != 0 {
+			*dst = v
+		}
+	}
+	override(&r.Model, chunk.Model)
+	override(&r.ModelVersion, chunk.ModelVersion)
+	override(&r.StopReason, chunk.StopReason)
+	override(&r.StopReason, chunk.Delta.StopReason)
+	override(&r.ConverseStopReason, chunk.ConverseStopReason)
+	override(&r.DoneReason, chunk.DoneReason)
+	r.Done = r.Done || chunk.Done
+	count(&r.Usage.PromptTokens, chunk.Usage.PromptTokens)
+	count(&r.Usage.CompletionTokens, chunk.Usage.CompletionTokens)
+	count(&r.Usage.InputTokens, chunk.Usage.InputTokens)
+	count(&r.Usage.OutputTokens, chunk.Usage.OutputTokens)
+	count(&r.Usage.ConverseInputTokens, chunk.Usage.ConverseInputTokens)
+	count(&r.Usage.ConverseOutputTokens, chunk.Usage.ConverseOutputTokens)
+	count(&r.InvocationMetrics.InputTokenCount, chunk.InvocationMetrics.InputTokenCount)
+	count(&r.InvocationMetrics.OutputTokenCount, chunk.InvocationMetrics.OutputTokenCount)
+	count(&r.UsageMetadata.PromptTokenCount, chunk.UsageMetadata.PromptTokenCount)
+	count(&r.UsageMetadata.CandidatesTokenCount, chunk.UsageMetadata.CandidatesTokenCount)
+	count(&r.PromptEvalCount, chunk.PromptEvalCount)
+	count(&r.EvalCount, chunk.EvalCount)
+
+	// Later choices or candidates replace earlier ones only when they
+	// finish the response, so a trailing usage chunk keeps the reason
+	if chunk.Choices != nil && (r.Choices == nil || len(chunk.Choices) > 0 && chunk.Choices[0].FinishReason != "") {
+		r.Choices = chunk.Choices
+	}
+	if chunk.Candidates != nil && (r.Candidates == nil || len(chunk.Candidates) > 0 && chunk.Candidates[0].FinishReason != "") {
+		r.Candidates = chunk.Candidates
+	}
+}
+
+// llmRequest holds the prompt fields of OpenAI-, Anthropic- and Gemini-style
+// requests
+type llmRequest struct {
+	Model    string          `json:"model"`
+	Prompt   json.RawMessage `json:"prompt"`
+	System   json.RawMessage `json:"system"`
+	Messages []struct {
+		Content json.RawMessage `json:"content"`
+	} `json:"messages"`
+	Contents          []geminiContent `json:"contents"`
+	SystemInstruction *geminiContent  `json:"systemInstruction"`
+}
+
+// geminiContent is a Gemini message made of parts
+type geminiContent struct {
+	Parts []struct {
+		Text string `json:"text"`
+	} `json:"parts"`
+}
+
+// promptText joins the text of the prompt, system prompt and messages
+func (r llmRequest) promptText() string {
+	var parts []string
+	add := func(raw json.RawMessage) {
+		// Content is a string or a list of parts with text
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			parts = append(parts, s)
+			return
+		}
+		var blocks []struct {
+			Text string `json:"text"`
+		}
+		if json.Unmarshal(raw, &blocks) == nil {
+			for _, b := range blocks {
+				parts = append(parts, b.Text)
+			}
+		}
+	}
+
+	add(r.Prompt)
+	add(r.System)
+	for _, m := range r.Messages {
+		add(m.Content)
+	}
+	if r.SystemInstruction != nil {
+		r.Contents = append([]geminiContent{*r.SystemInstruction}, r.Contents...)
+	}
+	for _, c := range r.Contents {
+		for _, p := range c.Parts {
+			parts = append(parts, p.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
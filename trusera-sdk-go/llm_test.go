@@ -0,0 +1,258 @@
+package trusera
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+	"time"
+)
+
+// eventStreamMessage encodes payload as an AWS event stream message with one
+// string header
+func eventStreamMessage(eventType, payload string) []byte {
+	var headers []byte
+	headers = append(headers, byte(len(":event-type")))
+	headers = append(headers, ":event-type"...)
+	headers = append(headers, 7) // string
+	headers = binary.BigEndian.AppendUint16(headers, uint16(len(eventType)))
+	headers = append(headers, eventType...)
+
+	total := 16 + len(headers) + len(payload)
+	msg := binary.BigEndian.AppendUint32(nil, uint32(total))
+	msg = binary.BigEndian.AppendUint32(msg, uint32(len(headers)))
+	msg = binary.BigEndian.AppendUint32(msg, crc32.ChecksumIEEE(msg))
+	msg = append(msg, headers...)
+	msg = append(msg, payload...)
+	return binary.BigEndian.AppendUint32(msg, crc32.ChecksumIEEE(msg))
+}
+
+func TestParseLLMCall(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		req  string
+		resp string
+		want LLMCall
+	}{
+		{
+			"openai",
+			"https://api.openai.com/v1/chat/completions",
+			`{"model":"gpt-4o","messages":[]}`,
+			`{"model":"gpt-4o-2024-08-06","choices":[{"finish_reason":"stop"}],"usage":{"prompt_tokens":120,"completion_tokens":30}}`,
+			LLMCall{Provider: "openai", Model: "gpt-4o-2024-08-06", PromptTokens: 120, CompletionTokens: 30, FinishReason: "stop"},
+		},
+		{
+			"anthropic",
+			"https://api.anthropic.com/v1/messages",
+			`{"model":"claude-sonnet-4-5"}`,
+			`{"model":"claude-sonnet-4-5","stop_reason":"end_turn","usage":{"input_tokens":80,"output_tokens":12}}`,
+			LLMCall{Provider: "anthropic", Model: "claude-sonnet-4-5", PromptTokens: 80, CompletionTokens: 12, FinishReason: "end_turn"},
+		},
+		{
+			"gemini",
+			"https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-flash:generateContent",
+			`{"contents":[{"parts":[{"text":"hi"}]}]}`,
+			`{"candidates":[{"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":3,"candidatesTokenCount":8}}`,
+			LLMCall{Provider: "google", Model: "gemini-1.5-flash", PromptTokens: 3, CompletionTokens: 8, FinishReason: "stop"},
+		},
+		{
+			"gemini json array stream",
+			"https://generativelanguage.googleapis.com/v1beta/models/gemini-2.5-pro:streamGenerateContent",
+			`{}`,
+			`[{"candidates":[{}],"modelVersion":"gemini-2.5-pro"},{"candidates":[{"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":11,"candidatesTokenCount":40}}]`,
+			LLMCall{Provider: "google", Model: "gemini-2.5-pro", PromptTokens: 11, CompletionTokens: 40, FinishReason: "stop"},
+		},
+		{
+			"openai stream with usage",
+			"https://api.openai.com/v1/chat/completions",
+			`{"model":"gpt-4o","stream":true}`,
+			"data: {\"model\":\"gpt-4o\",\"choices\":[{\"delta\":{}}]}\n\ndata: {\"model\":\"gpt-4o\",\"choices\":[{\"finish_reason\":\"stop\"}]}\n\ndata: {\"model\":\"gpt-4o\",\"choices\":[],\"usage\":{\"prompt_tokens\":7,\"completion_tokens\":2}}\n\ndata: [DONE]\n\n",
+			LLMCall{Provider: "openai", Model: "gpt-4o", PromptTokens: 7, CompletionTokens: 2, FinishReason: "stop"},
+		},
+		{
+			"bedrock converse",
+			"https://bedrock-runtime.eu-west-1.amazonaws.com/model/amazon.nova-pro-v1:0/converse",
+			`{"messages":[{"role":"user","content":[{"text":"hi"}]}]}`,
+			`{"output":{"message":{"role":"assistant","content":[{"text":"Hello"}]}},"stopReason":"end_turn","usage":{"inputTokens":14,"outputTokens":5,"totalTokens":19},"metrics":{"latencyMs":320}}`,
+			LLMCall{Provider: "bedrock", Model: "amazon.nova-pro-v1:0", PromptTokens: 14, CompletionTokens: 5, FinishReason: "end_turn"},
+		},
+		{
+			"bedrock invoke claude",
+			"https://bedrock-runtime.us-east-1.amazonaws.com/model/anthropic.claude-3-haiku-20240307-v1%3A0/invoke",
+			`{"anthropic_version":"bedrock-2023-05-31","max_tokens":100}`,
+			`{"type":"message","stop_reason":"max_tokens","usage":{"input_tokens":20,"output_tokens":100}}`,
+			LLMCall{Provider: "bedrock", Model: "anthropic.claude-3-haiku-20240307-v1:0", PromptTokens: 20, CompletionTokens: 100, FinishReason: "max_tokens"},
+		},
+		{
+			"ollama",
+			"http://localhost:11434/api/chat",
+			`{"model":"llama3.2","messages":[{"role":"user","content":"hi"}],"stream":false}`,
+			`{"model":"llama3.2","message":{"role":"assistant","content":"Hello"},"done":true,"done_reason":"stop","prompt_eval_count":26,"eval_count":298}`,
+			LLMCall{Provider: "ollama", Model: "llama3.2", PromptTokens: 26, CompletionTokens: 298, FinishReason: "stop"},
+		},
+		{
+			"ollama stream",
+			"http://127.0.0.1:11434/api/generate",
+			`{"model":"qwen2.5:7b","prompt":"hi"}`,
+			"{\"model\":\"qwen2.5:7b\",\"response\":\"He\",\"done\":false}\n{\"model\":\"qwen2.5:7b\",\"response\":\"llo\",\"done\":false}\n{\"model\":\"qwen2.5:7b\",\"response\":\"\",\"done\":true,\"done_reason\":\"length\",\"prompt_eval_count\":4,\"eval_count\":2}\n",
+			LLMCall{Provider: "ollama", Model: "qwen2.5:7b", PromptTokens: 4, CompletionTokens: 2, FinishReason: "length"},
+		},
+		{
+			"ollama openai-compatible",
+			"http://localhost:11434/v1/chat/completions",
+			`{"model":"llama3.2"}`,
+			`{"model":"llama3.2","choices":[{"finish_reason":"stop"}],"usage":{"prompt_tokens":9,"completion_tokens":3}}`,
+			LLMCall{Provider: "ollama", Model: "llama3.2", PromptTokens: 9, CompletionTokens: 3, FinishReason: "stop"},
+		},
+		{
+			"openai-compatible proxy",
+			"http://localhost:4000/v1/chat/completions",
+			`{"model":"llama-3"}`,
+			`{"choices":[{"finish_reason":"length"}],"usage":{"prompt_tokens":5,"completion_tokens":7}}`,
+			LLMCall{Provider: "openai", Model: "llama-3", PromptTokens: 5, CompletionTokens: 7, FinishReason: "length"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Latency = time.Second
+			got, err := ParseLLMCall(tt.url, []byte(tt.req), []byte(tt.resp), time.Second)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLLMCallBedrockStream(t *testing.T) {
+	var converse []byte
+	for _, m := range [][2]string{
+		{"messageStart", `{"role":"assistant"}`},
+		{"contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"Hi"}}`},
+		{"messageStop", `{"stopReason":"end_turn"}`},
+		{"metadata", `{"usage":{"inputTokens":9,"outputTokens":2,"totalTokens":11},"metrics":{"latencyMs":210}}`},
+	} {
+		converse = append(converse, eventStreamMessage(m[0], m[1])...)
+	}
+
+	// InvokeModel streams wrap each model chunk, and Bedrock adds its
+	// invocation metrics to the last one
+	chunk := func(s string) string {
+		return `{"bytes":"` + base64.StdEncoding.EncodeToString([]byte(s)) + `"}`
+	}
+	invoke := append(eventStreamMessage("chunk", chunk(`{"type":"message_start","message":{"role":"assistant"}}`)),
+		eventStreamMessage("chunk", chunk(`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":6},"amazon-bedrock-invocationMetrics":{"inputTokenCount":17,"outputTokenCount":6}}`))...)
+
+	tests := []struct {
+		name string
+		url  string
+		body []byte
+		want LLMCall
+	}{
+		{
+			"converse stream",
+			"https://bedrock-runtime.eu-west-1.amazonaws.com/model/amazon.nova-lite-v1:0/converse-stream",
+			converse,
+			LLMCall{Provider: "bedrock", Model: "amazon.nova-lite-v1:0", PromptTokens: 9, CompletionTokens: 2, FinishReason: "end_turn"},
+		},
+		{
+			"invoke stream",
+			"https://bedrock-runtime.eu-west-1.amazonaws.com/model/anthropic.claude-3-haiku-20240307-v1:0/invoke-with-response-stream",
+			invoke,
+			LLMCall{Provider: "bedrock", Model: "anthropic.claude-3-haiku-20240307-v1:0", PromptTokens: 17, CompletionTokens: 6, FinishReason: "end_turn"},
+		},
+		{
+			"cut short",
+			"https://bedrock-runtime.eu-west-1.amazonaws.com/model/amazon.nova-lite-v1:0/converse-stream",
+			converse[:len(converse)-10],
+			LLMCall{Provider: "bedrock", Model: "amazon.nova-lite-v1:0", PromptTokens: 0, FinishReason: "end_turn"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Latency = time.Second
+			got, err := ParseLLMCall(tt.url, []byte(`{}`), tt.body, time.Second)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLLMCallRejectsOtherResponses(t *testing.T) {
+	if _, err := ParseLLMCall("https://api.example.com", nil, []byte(`{"status":"ok"}`), 0); err == nil {
+		t.Error("expected an error for a non-LLM response")
+	}
+	if _, err := ParseLLMCall("https://api.openai.com", nil, []byte(`not json`), 0); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func TestNewLLMCallEvent(t *testing.T) {
+	event := NewLLMCallEvent(LLMCall{
+		Provider:         "openai",
+		Model:            "gpt-4o",
+		PromptTokens:     100,
+		CompletionTokens: 50,
+		Latency:          1200 * time.Millisecond,
+		FinishReason:     "stop",
+		CostUSD:          0.00075,
+	})
+
+	if event.Type != EventLLMCall || event.Name != "gpt-4o" {
+		t.Errorf("unexpected event %s %s", event.Type, event.Name)
+	}
+	if event.Payload["total_tokens"] != 150 || event.Payload["latency_ms"] != int64(1200) {
+		t.Errorf("unexpected payload %v", event.Payload)
+	}
+	if event.Payload["cost_usd"] != 0.00075 || event.Payload["finish_reason"] != "stop" {
+		t.Errorf("unexpected payload %v", event.Payload)
+	}
+	if event.DurationMs != 1200 {
+		t.Errorf("expected duration 1200ms, got %d", event.DurationMs)
+	}
+}
+
+func TestNewLLMCallEventEstimatesCost(t *testing.T) {
+	event := NewLLMCallEvent(LLMCall{Provider: "openai", Model: "gpt-4o-mini", PromptTokens: 1000, CompletionTokens: 1000})
+	if got, ok := event.Payload["cost_usd"].(float64); !ok || got < 0.00074 || got > 0.00076 {
+		t.Errorf("expected estimated cost, got %v", event.Payload["cost_usd"])
+	}
+
+	event = NewLLMCallEvent(LLMCall{Model: "my-fine-tune", PromptTokens: 10})
+	if _, ok := event.Payload["cost_usd"]; ok {
+		t.Error("expected no cost for an unknown model")
+	}
+}
+
+func TestParseLLMCallCountsPromptWithoutUsage(t *testing.T) {
+	req := `{"model":"gpt-4o","messages":[{"role":"system","content":"Be brief."},{"role":"user","content":[{"type":"text","text":"What is an AI-BOM?"}]}]}`
+	resp := `{"choices":[{"finish_reason":"stop"}]}`
+
+	call, err := ParseLLMCall("https://api.openai.com/v1/chat/completions", []byte(req), []byte(resp), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if call.PromptTokens == 0 {
+		t.Error("expected prompt tokens to be counted locally")
+	}
+}
+
+func TestParseLLMCallCountsGeminiPrompt(t *testing.T) {
+	req := `{"systemInstruction":{"parts":[{"text":"Be brief."}]},"contents":[{"role":"user","parts":[{"text":"What is an AI-BOM?"}]}]}`
+	resp := `{"candidates":[{"finishReason":"STOP"}]}`
+
+	call, err := ParseLLMCall("https://generativelanguage.googleapis.com/v1beta/models/gemini-2.0-flash:generateContent", []byte(req), []byte(resp), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if call.PromptTokens == 0 || call.Model != "gemini-2.0-flash" {
+		t.Errorf("expected the prompt to be counted for the path's model, got %+v", call)
+	}
+}
@@ -0,0 +1,144 @@
+package trusera
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxLLMCallBody bounds how much of an LLM request and response is kept to
+// build its EventLLMCall
+const maxLLMCallBody = 4 << 20
+
+// GenAIHTTPClient returns an http.Client for the Google Gen AI Go SDK that
+// records each call to the Gemini API or Vertex AI as an EventLLMCall on
+// client, with the model, token counts, finish reason and latency. Pass it
+// as genai.ClientConfig.HTTPClient, or with option.WithHTTPClient for
+// github.com/google/generative-ai-go.
+//
+// base supplies the transport and is not modified. For Vertex AI it must
+// already authenticate, as one from google.DefaultClient does, since the SDK
+// uses a client it is given as is. A nil base uses http.DefaultTransport.
+func GenAIHTTPClient(client *Client, base *http.Client) *http.Client {
+	return newLLMCallClient(client, base, "google", "vertex-ai")
+}
+
+// OllamaHTTPClient returns an http.Client for the Ollama Go client
+// (api.NewClient) or an OpenAI-compatible client pointed at a local Ollama
+// server that records each call as an EventLLMCall on client, with the
+// model, prompt and completion token counts, done reason and latency. base
+// is used as in GenAIHTTPClient.
+func OllamaHTTPClient(client *Client, base *http.Client) *http.Client {
+	return newLLMCallClient(client, base, "ollama")
+}
+
+// BedrockHTTPClient returns an http.Client for the AWS SDK for Go v2 that
+// records each Bedrock Runtime call (Converse, InvokeModel and their
+// streaming forms) as an EventLLMCall on client, with the model, token
+// counts, stop reason and latency. Pass it with config.WithHTTPClient or as
+// bedrockruntime.Options.HTTPClient. Requests arrive signed with SigV4 and
+// are forwarded unchanged. base is used as in GenAIHTTPClient.
+func BedrockHTTPClient(client *Client, base *http.Client) *http.Client {
+	return newLLMCallClient(client, base, "bedrock")
+}
+
+// newLLMCallClient returns a copy of base whose transport tracks calls to
+// the given LLM providers
+func newLLMCallClient(client *Client, base *http.Client, providers ...string) *http.Client {
+	c := &http.Client{}
+	if base != nil {
+		copied := *base
+		c = &copied
+	}
+	transport := c.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	c.Transport = &llmCallTransport{base: transport, client: client, providers: providers}
+	return c
+}
+
+// llmCallTransport tracks calls to some LLM providers passing through base
+type llmCallTransport struct {
+	base      http.RoundTripper
+	client    *Client
+	providers []string
+}
+
+// RoundTrip forwards req and, for the transport's providers, tracks the
+// call once the caller has read or closed the response body, so streamed
+// responses are measured to their end
+func (t *llmCallTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ep, ok := detectLLMHost(req.URL)
+	if !ok || !slices.Contains(t.providers, ep.Provider) {
+		return t.base.RoundTrip(req)
+	}
+
+	start := time.Now()
+	req, reqBody, _ := captureRequestBody(req, bodyCaptureConfig{maxBytes: maxLLMCallBody})
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp, err
+	}
+
+	requestURL := req.URL.String()
+	header := resp.Header
+	resp.Body = &recordedBody{body: resp.Body, onDone: func(respBody []byte) {
+		latency := time.Since(start)
+		call, err := ParseLLMCall(requestURL, reqBody, respBody, latency)
+		// Bedrock reports exact counts in headers for every model,
+		// including those whose response bodies carry none
+		if in, out, ok := bedrockTokenCounts(header); ok {
+			if err != nil {
+				call, err = LLMCall{Provider: ep.Provider, Model: ep.Model, Latency: latency}, nil
+			}
+			call.PromptTokens, call.CompletionTokens = in, out
+		}
+		if err == nil {
+			t.client.Track(NewLLMCallEvent(call))
+		}
+	}}
+	return resp, nil
+}
+
+// bedrockTokenCounts returns the token counts in Bedrock InvokeModel
+// response headers
+func bedrockTokenCounts(h http.Header) (in, out int, ok bool) {
+	in, inErr := strconv.Atoi(h.Get("X-Amzn-Bedrock-Input-Token-Count"))
+	out, outErr := strconv.Atoi(h.Get("X-Amzn-Bedrock-Output-Token-Count"))
+	return in, out, inErr == nil && outErr == nil
+}
+
+// recordedBody keeps up to maxLLMCallBody bytes of a response body as the
+// caller reads it and passes them to onDone once, at EOF or Close
+type recordedBody struct {
+	body   io.ReadCloser
+	buf    bytes.Buffer
+	once   sync.Once
+	onDone func([]byte)
+}
+
+func (b *recordedBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if room := maxLLMCallBody - b.buf.Len(); room > 0 && n > 0 {
+		b.buf.Write(p[:min(n, room)])
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *recordedBody) Close() error {
+	err := b.body.Close()
+	b.finish()
+	return err
+}
+
+func (b *recordedBody) finish() {
+	b.once.Do(func() { b.onDone(b.buf.Bytes()) })
+}
@@ -0,0 +1,192 @@
+package trusera
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// cannedTransport answers every request with a fixed status and body
+type cannedTransport struct {
+	status      int
+	contentType string
+	body        string
+}
+
+func (c cannedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+	return &http.Response{
+		StatusCode: c.status,
+		Header:     http.Header{"Content-Type": {c.contentType}},
+		Body:       io.NopCloser(strings.NewReader(c.body)),
+		Request:    req,
+	}, nil
+}
+
+// trackedLLMCalls returns the llm_call events queued on client
+func trackedLLMCalls(client *Client) []Event {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	var calls []Event
+	for _, e := range client.events {
+		if e.Type == EventLLMCall {
+			calls = append(calls, e)
+		}
+	}
+	return calls
+}
+
+func TestGenAIHTTPClient(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		transport cannedTransport
+		provider  string
+		model     string
+		prompt    int
+		finish    string
+	}{
+		{
+			"gemini api",
+			"https://generativelanguage.googleapis.com/v1beta/models/gemini-2.0-flash:generateContent",
+			cannedTransport{200, "application/json", `{"candidates":[{"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":9,"candidatesTokenCount":4},"modelVersion":"gemini-2.0-flash-001"}`},
+			"google", "gemini-2.0-flash-001", 9, "stop",
+		},
+		{
+			"vertex ai stream",
+			"https://us-central1-aiplatform.googleapis.com/v1/projects/p/locations/us-central1/publishers/google/models/gemini-1.5-pro:streamGenerateContent?alt=sse",
+			cannedTransport{200, "text/event-stream", "data: {\"candidates\":[{}]}\r\n\r\ndata: {\"candidates\":[{\"finishReason\":\"MAX_TOKENS\"}],\"usageMetadata\":{\"promptTokenCount\":20,\"candidatesTokenCount\":64}}\r\n\r\n"},
+			"vertex-ai", "gemini-1.5-pro", 20, "max_tokens",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient("test-key", WithFlushRetry(0, 0))
+			defer client.Close()
+			httpClient := GenAIHTTPClient(client, &http.Client{Transport: tt.transport})
+
+			resp, err := httpClient.Post(tt.url, "application/json", strings.NewReader(`{"contents":[{"parts":[{"text":"hi"}]}]}`))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(trackedLLMCalls(client)) != 0 {
+				t.Error("expected the call to be tracked only after the body is read")
+			}
+			io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			calls := trackedLLMCalls(client)
+			if len(calls) != 1 {
+				t.Fatalf("expected 1 llm_call event, got %d", len(calls))
+			}
+			p := calls[0].Payload
+			if p["provider"] != tt.provider || p["model"] != tt.model || p["prompt_tokens"] != tt.prompt || p["finish_reason"] != tt.finish {
+				t.Errorf("unexpected payload %v", p)
+			}
+		})
+	}
+}
+
+func TestGenAIHTTPClientIgnoresOtherRequests(t *testing.T) {
+	client := NewClient("test-key", WithFlushRetry(0, 0))
+	defer client.Close()
+
+	base := &http.Client{Transport: cannedTransport{200, "application/json", `{"candidates":[]}`}}
+	httpClient := GenAIHTTPClient(client, base)
+	if base.Transport != (cannedTransport{200, "application/json", `{"candidates":[]}`}) {
+		t.Error("expected the base client to be left unchanged")
+	}
+	resp, err := httpClient.Get("https://storage.googleapis.com/bucket/object")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	failing := GenAIHTTPClient(client, &http.Client{Transport: cannedTransport{429, "application/json", `{"error":{"code":429}}`}})
+	resp, err = failing.Post("https://generativelanguage.googleapis.com/v1beta/models/gemini-2.0-flash:generateContent", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if calls := trackedLLMCalls(client); len(calls) != 0 {
+		t.Errorf("expected no llm_call events, got %v", calls)
+	}
+}
+
+func TestOllamaHTTPClient(t *testing.T) {
+	client := NewClient("test-key", WithFlushRetry(0, 0))
+	defer client.Close()
+	stream := "{\"model\":\"llama3.2\",\"done\":false}\n{\"model\":\"llama3.2\",\"done\":true,\"done_reason\":\"stop\",\"prompt_eval_count\":12,\"eval_count\":30}\n"
+	httpClient := OllamaHTTPClient(client, &http.Client{Transport: cannedTransport{200, "application/x-ndjson", stream}})
+
+	resp, err := httpClient.Post("http://localhost:11434/api/chat", "application/json", strings.NewReader(`{"model":"llama3.2"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	// Gemini calls are left to GenAIHTTPClient
+	resp, err = httpClient.Post("https://generativelanguage.googleapis.com/v1beta/models/gemini-2.0-flash:generateContent", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	calls := trackedLLMCalls(client)
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 llm_call event, got %d", len(calls))
+	}
+	p := calls[0].Payload
+	if p["provider"] != "ollama" || p["model"] != "llama3.2" || p["prompt_tokens"] != 12 || p["completion_tokens"] != 30 || p["finish_reason"] != "stop" {
+		t.Errorf("unexpected payload %v", p)
+	}
+}
+
+// bedrockTransport answers like Bedrock InvokeModel, with the token counts
+// in response headers
+type bedrockTransport struct {
+	cannedTransport
+	input, output string
+}
+
+func (b bedrockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := b.cannedTransport.RoundTrip(req)
+	resp.Header.Set("X-Amzn-Bedrock-Input-Token-Count", b.input)
+	resp.Header.Set("X-Amzn-Bedrock-Output-Token-Count", b.output)
+	return resp, err
+}
+
+func TestBedrockHTTPClient(t *testing.T) {
+	client := NewClient("test-key", WithFlushRetry(0, 0))
+	defer client.Close()
+	// Titan responses name no model and use their own usage fields
+	titan := `{"inputTextTokenCount":6,"results":[{"tokenCount":40,"outputText":"...","completionReason":"FINISH"}]}`
+	httpClient := BedrockHTTPClient(client, &http.Client{Transport: bedrockTransport{cannedTransport{200, "application/json", titan}, "6", "40"}})
+
+	req, _ := http.NewRequest("POST", "https://bedrock-runtime.us-west-2.amazonaws.com/model/amazon.titan-text-express-v1/invoke", strings.NewReader(`{"inputText":"hi"}`))
+	req.Header.Set("Authorization", testSigV4Auth)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	calls := trackedLLMCalls(client)
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 llm_call event, got %d", len(calls))
+	}
+	p := calls[0].Payload
+	if p["provider"] != "bedrock" || p["model"] != "amazon.titan-text-express-v1" || p["prompt_tokens"] != 6 || p["completion_tokens"] != 40 {
+		t.Errorf("unexpected payload %v", p)
+	}
+}
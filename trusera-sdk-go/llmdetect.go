@@ -0,0 +1,148 @@
+package trusera
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// llmDetectBytes bounds how much of a request body is read to find the model
+const llmDetectBytes = 16 << 10
+
+// LLMEndpoint names the provider and model a request is addressed to
+type LLMEndpoint struct {
+	Provider string // e.g. "openai", "anthropic", "bedrock", "ollama"
+	Model    string // Empty when neither the path nor the body names one
+	Region   string // Cloud region of regional endpoints (Bedrock, Vertex AI)
+	Gateway  string // LLM gateway the call goes through, see WithLLMGateways
+}
+
+// llmHosts maps exact API hostnames to providers
+var llmHosts = map[string]string{
+	"api.openai.com":                    "openai",
+	"api.anthropic.com":                 "anthropic",
+	"generativelanguage.googleapis.com": "google",
+	"api.mistral.ai":                    "mistral",
+	"api.cohere.ai":                     "cohere",
+	"api.cohere.com":                    "cohere",
+	"api.groq.com":                      "groq",
+	"api.together.xyz":                  "together",
+	"api.deepseek.com":                  "deepseek",
+	"api.x.ai":                          "xai",
+	"openrouter.ai":                     "openrouter",
+	"api.perplexity.ai":                 "perplexity",
+}
+
+// ollamaPort is the default port of a local Ollama server
+const ollamaPort = "11434"
+
+// DetectLLM recognizes requests to well-known LLM APIs and reports the
+// provider and model. The model is taken from the path for APIs that name
+// it there (Azure OpenAI deployments, Gemini, Vertex AI, Bedrock) and
+// otherwise from the "model" field of the JSON body, which may be truncated.
+func DetectLLM(u *url.URL, body []byte) (LLMEndpoint, bool) {
+	ep, ok := detectLLMHost(u)
+	if ok && ep.Model == "" {
+		ep.Model = bodyModel(body)
+	}
+	return ep, ok
+}
+
+// detectLLMHost identifies the provider from the host, and the model when
+// the path names it
+func detectLLMHost(u *url.URL) (LLMEndpoint, bool) {
+	if u == nil {
+		return LLMEndpoint{}, false
+	}
+	host := strings.ToLower(u.Hostname())
+
+	switch {
+	case llmHosts[host] == "google":
+		return LLMEndpoint{Provider: "google", Model: pathModel(u.Path, "/models/")}, true
+	case llmHosts[host] != "":
+		return LLMEndpoint{Provider: llmHosts[host]}, true
+	case strings.HasSuffix(host, ".openai.azure.com"):
+		return LLMEndpoint{Provider: "azure-openai", Model: pathSegmentAfter(u.Path, "/deployments/")}, true
+	case strings.HasSuffix(host, "aiplatform.googleapis.com"):
+		// The global endpoint has no region prefix
+		region, ok := strings.CutSuffix(host, "-aiplatform.googleapis.com")
+		if !ok {
+			region = ""
+		}
+		return LLMEndpoint{Provider: "vertex-ai", Model: pathModel(u.Path, "/models/"), Region: region}, true
+	case strings.HasPrefix(host, "bedrock-runtime.") && strings.HasSuffix(host, ".amazonaws.com"):
+		region, _, _ := strings.Cut(strings.TrimPrefix(host, "bedrock-runtime."), ".")
+		return LLMEndpoint{Provider: "bedrock", Model: bedrockModel(u), Region: region}, true
+	case u.Port() == ollamaPort && isLoopback(host):
+		return LLMEndpoint{Provider: "ollama"}, true
+	}
+	return LLMEndpoint{}, false
+}
+
+// pathSegmentAfter returns the path segment following marker
+func pathSegmentAfter(path, marker string) string {
+	_, rest, ok := strings.Cut(path, marker)
+	if !ok {
+		return ""
+	}
+	segment, _, _ := strings.Cut(rest, "/")
+	return segment
+}
+
+// bedrockModel returns the model ID from a Bedrock runtime path. The ID is
+// read from the escaped path, since inference profile and provisioned
+// model ARNs contain an escaped "/".
+func bedrockModel(u *url.URL) string {
+	segment := pathSegmentAfter(u.EscapedPath(), "/model/")
+	if model, err := url.PathUnescape(segment); err == nil {
+		return model
+	}
+	return segment
+}
+
+// pathModel returns a Google-style model segment without its ":method" suffix
+func pathModel(path, marker string) string {
+	segment := pathSegmentAfter(path, marker)
+	model, _, _ := strings.Cut(segment, ":")
+	return model
+}
+
+// isLoopback reports whether host is localhost or a loopback address
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// bodyModel returns the top-level "model" string of a JSON object. It scans
+// tokens rather than decoding the whole body, so a body cut short by a
+// capture limit still yields the model if it comes before the cut.
+func bodyModel(body []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return ""
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return ""
+		}
+		key, _ := tok.(string)
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return ""
+		}
+		if key == "model" {
+			var model string
+			json.Unmarshal(value, &model)
+			return model
+		}
+	}
+	return ""
+}
@@ -0,0 +1,129 @@
+package trusera
+
+import (
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectLLM(t *testing.T) {
+	tests := []struct {
+		url      string
+		body     string
+		provider string
+		model    string
+	}{
+		{"https://api.openai.com/v1/chat/completions", `{"model":"gpt-4o","messages":[]}`, "openai", "gpt-4o"},
+		{"https://api.anthropic.com/v1/messages", `{"max_tokens":10,"model":"claude-3-5-sonnet-latest"}`, "anthropic", "claude-3-5-sonnet-latest"},
+		{"https://myco.openai.azure.com/openai/deployments/prod-gpt4/chat/completions?api-version=2024-02-01", `{}`, "azure-openai", "prod-gpt4"},
+		{"https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-pro:generateContent", ``, "google", "gemini-1.5-pro"},
+		{"https://us-central1-aiplatform.googleapis.com/v1/projects/p/locations/us-central1/publishers/google/models/gemini-1.5-flash:streamGenerateContent", ``, "vertex-ai", "gemini-1.5-flash"},
+		{"https://bedrock-runtime.us-east-1.amazonaws.com/model/anthropic.claude-3-haiku-20240307-v1%3A0/invoke", ``, "bedrock", "anthropic.claude-3-haiku-20240307-v1:0"},
+		{"https://bedrock-runtime.eu-west-1.amazonaws.com/model/arn%3Aaws%3Abedrock%3Aeu-west-1%3A123456789012%3Ainference-profile%2Feu.anthropic.claude-3-5-sonnet-20240620-v1%3A0/converse", `{}`, "bedrock", "arn:aws:bedrock:eu-west-1:123456789012:inference-profile/eu.anthropic.claude-3-5-sonnet-20240620-v1:0"},
+		{"http://localhost:11434/api/chat", `{"model":"llama3.1"}`, "ollama", "llama3.1"},
+		{"http://127.0.0.1:11434/api/generate", ``, "ollama", ""},
+		{"https://API.Mistral.AI/v1/chat/completions", `{"model":"mistral-large-latest"}`, "mistral", "mistral-large-latest"},
+		{"https://openrouter.ai/api/v1/chat/completions", `not json`, "openrouter", ""},
+	}
+
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		ep, ok := DetectLLM(u, []byte(tt.body))
+		if !ok || ep.Provider != tt.provider || ep.Model != tt.model {
+			t.Errorf("DetectLLM(%s) = %+v, %v; want %s/%s", tt.url, ep, ok, tt.provider, tt.model)
+		}
+	}
+
+	for _, raw := range []string{"https://api.example.com/v1/models/x", "http://10.0.0.5:11434/api/chat", "http://localhost:8080/v1/chat"} {
+		u, _ := url.Parse(raw)
+		if ep, ok := DetectLLM(u, []byte(`{"model":"gpt-4o"}`)); ok {
+			t.Errorf("expected %s not to be detected, got %+v", raw, ep)
+		}
+	}
+}
+
+func TestDetectLLMRegion(t *testing.T) {
+	tests := []struct {
+		url    string
+		region string
+	}{
+		{"https://bedrock-runtime.eu-central-1.amazonaws.com/model/amazon.nova-pro-v1:0/converse", "eu-central-1"},
+		{"https://us-east5-aiplatform.googleapis.com/v1/projects/p/locations/us-east5/publishers/google/models/gemini-2.0-flash:generateContent", "us-east5"},
+		// The global Vertex AI endpoint has no region
+		{"https://aiplatform.googleapis.com/v1/projects/p/locations/global/publishers/google/models/gemini-2.0-flash:generateContent", ""},
+		{"https://api.openai.com/v1/chat/completions", ""},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		if ep, _ := DetectLLM(u, nil); ep.Region != tt.region {
+			t.Errorf("DetectLLM(%s).Region = %q, want %q", tt.url, ep.Region, tt.region)
+		}
+	}
+}
+
+func TestBodyModel(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"model":"gpt-4o"}`, "gpt-4o"},
+		{`{"messages":[{"role":"user","content":"{\"model\":\"nested\"}"}],"model":"gpt-4o-mini"}`, "gpt-4o-mini"},
+		{`{"model":"gpt-4o","messages":[{"role":"user","content":"cut sh`, "gpt-4o"},
+		{`{"messages":[{"model":"nested"}]}`, ""},
+		{`{"model":42}`, ""},
+		{`[]`, ""},
+		{``, ""},
+	}
+
+	for _, tt := range tests {
+		if got := bodyModel([]byte(tt.body)); got != tt.want {
+			t.Errorf("bodyModel(%s) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestStandaloneInterceptorLLMModelPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "events.jsonl")
+	policyPath := writeTestPolicy(t, tmpDir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.llm_model == "gpt-4-32k";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithLogFile(logPath),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+
+	for _, model := range []string{"gpt-4o-mini", "gpt-4-32k"} {
+		body := `{"model":"` + model + `","messages":[{"role":"user","content":"hi"}]}`
+		resp, err := client.Post("https://api.openai.com/v1/chat/completions", "application/json", strings.NewReader(body))
+		if err == nil {
+			resp.Body.Close()
+		}
+	}
+	si.Close()
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if e := entries[0]; e.EnforcementAction != "allowed" || e.LLMProvider != "openai" || e.LLMModel != "gpt-4o-mini" {
+		t.Errorf("unexpected first entry: %+v", e)
+	}
+	if e := entries[1]; e.EnforcementAction != "blocked" || e.LLMModel != "gpt-4-32k" {
+		t.Errorf("expected the policy to block gpt-4-32k, got %+v", e)
+	}
+	if entries[0].RequestBody != nil {
+		t.Error("model detection should not enable body capture")
+	}
+}
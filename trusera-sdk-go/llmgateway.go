@@ -0,0 +1,212 @@
+package trusera
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Gateways recognised by WithLLMGateways
+const (
+	GatewayLiteLLM = "litellm"
+	GatewayPortkey = "portkey"
+)
+
+// gatewayProviders maps the provider prefixes LiteLLM and Portkey use in
+// model names, headers and pass-through paths to DetectLLM's provider names
+var gatewayProviders = map[string]string{
+	"openai":        "openai",
+	"azure":         "azure-openai",
+	"azure-openai":  "azure-openai",
+	"anthropic":     "anthropic",
+	"gemini":        "google",
+	"google":        "google",
+	"vertex_ai":     "vertex-ai",
+	"vertex-ai":     "vertex-ai",
+	"bedrock":       "bedrock",
+	"ollama":        "ollama",
+	"ollama_chat":   "ollama",
+	"mistral":       "mistral",
+	"mistral-ai":    "mistral",
+	"cohere":        "cohere",
+	"groq":          "groq",
+	"huggingface":   "huggingface",
+	"together_ai":   "together",
+	"together-ai":   "together",
+	"deepseek":      "deepseek",
+	"xai":           "xai",
+	"x-ai":          "xai",
+	"openrouter":    "openrouter",
+	"perplexity":    "perplexity",
+	"perplexity-ai": "perplexity",
+}
+
+// modelFamilies infers the provider of a bare model name, as gateways do
+// when a model is listed without a provider prefix
+var modelFamilies = []struct{ prefix, provider string }{
+	{"gpt-", "openai"},
+	{"o1", "openai"},
+	{"o3", "openai"},
+	{"o4", "openai"},
+	{"chatgpt-", "openai"},
+	{"claude", "anthropic"},
+	{"gemini", "google"},
+	{"mistral", "mistral"},
+	{"codestral", "mistral"},
+	{"command", "cohere"},
+	{"deepseek", "deepseek"},
+	{"grok", "xai"},
+}
+
+// WithLLMGateways treats requests to the given hosts as calls through an
+// LLM gateway such as LiteLLM or Portkey. A host matches with or without
+// its port. The provider and model the gateway routes to are read from the
+// request, rather than the gateway's own host, for policies
+// (resource.llm_provider and resource.llm_model), the log and the AI-BOM:
+//
+//   - Portkey: the x-portkey-provider header, the provider and
+//     override_params.model of an inline x-portkey-config, and
+//     "@provider/model" names
+//   - LiteLLM: "provider/model" names, such as "bedrock/anthropic.claude-3",
+//     and pass-through routes such as /anthropic/v1/messages
+//
+// Bare model names are attributed by family, so "gpt-4o" is an openai
+// model. When neither names a provider, the gateway itself is the provider.
+// The gateway is logged as llm_gateway.
+func WithLLMGateways(hosts ...string) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.llmGateways = hosts
+	}
+}
+
+// isLLMGateway reports whether u is addressed to a configured gateway
+func (si *StandaloneInterceptor) isLLMGateway(u *url.URL) bool {
+	for _, host := range si.llmGateways {
+		if strings.EqualFold(host, u.Host) || strings.EqualFold(host, u.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+// gatewayEndpoint recovers the provider and model behind a gateway request
+// from its headers, path and body, which may be truncated
+func gatewayEndpoint(req *http.Request, body []byte) LLMEndpoint {
+	ep := LLMEndpoint{Gateway: GatewayLiteLLM, Model: bodyModel(body)}
+	if isPortkey(req) {
+		ep.Gateway = GatewayPortkey
+		ep.Provider = req.Header.Get("X-Portkey-Provider")
+		provider, model := portkeyConfig(req.Header.Get("X-Portkey-Config"))
+		ep.Provider = firstNonEmpty(ep.Provider, provider)
+		ep.Model = firstNonEmpty(model, ep.Model)
+	} else if ep.Model == "" {
+		// LiteLLM pass-through routes name the provider as the first
+		// segment and keep the provider's own path, model included
+		segment, rest, _ := strings.Cut(strings.TrimPrefix(req.URL.EscapedPath(), "/"), "/")
+		if provider, ok := gatewayProviders[strings.ToLower(segment)]; ok {
+			ep.Provider, ep.Model = provider, passThroughModel(provider, "/"+rest)
+		}
+	}
+
+	// Portkey's model catalog and LiteLLM prefix the model with a provider,
+	// e.g. "@openai-prod/gpt-4o" or "anthropic/claude-3-5-sonnet"
+	if prefix, model, ok := strings.Cut(ep.Model, "/"); ok {
+		slug := strings.TrimPrefix(prefix, "@")
+		if provider, known := gatewayProviders[strings.ToLower(slug)]; known || prefix != slug {
+			if !known {
+				provider = slug
+			}
+			ep.Provider = firstNonEmpty(ep.Provider, provider)
+			ep.Model = model
+		}
+	}
+
+	if p, ok := gatewayProviders[strings.ToLower(ep.Provider)]; ok {
+		ep.Provider = p
+	}
+	if ep.Provider == "" {
+		ep.Provider = modelFamily(ep.Model)
+	}
+	if ep.Provider == "" {
+		ep.Provider = ep.Gateway
+	}
+	return ep
+}
+
+// isPortkey reports whether req carries Portkey routing headers or is
+// addressed to Portkey's hosted gateway
+func isPortkey(req *http.Request) bool {
+	if strings.EqualFold(req.URL.Hostname(), "api.portkey.ai") {
+		return true
+	}
+	for name := range req.Header {
+		if strings.HasPrefix(strings.ToLower(name), "x-portkey-") {
+			return true
+		}
+	}
+	return false
+}
+
+// portkeyConfig reads the provider and model override of an inline Portkey
+// config. Saved configs are referenced by ID and cannot be read here. For
+// fallback and load-balanced configs the first target is used.
+func portkeyConfig(raw string) (provider, model string) {
+	type target struct {
+		Provider       string `json:"provider"`
+		OverrideParams struct {
+			Model string `json:"model"`
+		} `json:"override_params"`
+	}
+	var config struct {
+		target
+		Targets []target `json:"targets"`
+	}
+	if json.Unmarshal([]byte(raw), &config) != nil {
+		return "", ""
+	}
+	t := config.target
+	if t.Provider == "" && len(config.Targets) > 0 {
+		t = config.Targets[0]
+	}
+	return t.Provider, t.OverrideParams.Model
+}
+
+// passThroughModel returns the model named in the escaped path of a
+// provider API that puts it there, as DetectLLM reads direct calls
+func passThroughModel(provider, path string) string {
+	var segment string
+	switch provider {
+	case "google", "vertex-ai":
+		segment = pathModel(path, "/models/")
+	case "bedrock":
+		segment = pathSegmentAfter(path, "/model/")
+	case "azure-openai":
+		segment = pathSegmentAfter(path, "/deployments/")
+	}
+	if model, err := url.PathUnescape(segment); err == nil {
+		return model
+	}
+	return segment
+}
+
+// modelFamily returns the provider of a well-known model family
+func modelFamily(model string) string {
+	model = strings.ToLower(model)
+	for _, f := range modelFamilies {
+		if strings.HasPrefix(model, f.prefix) {
+			return f.provider
+		}
+	}
+	return ""
+}
+
+// firstNonEmpty returns the first of values that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+package trusera
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGatewayEndpoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		headers map[string]string
+		body    string
+		want    LLMEndpoint
+	}{
+		{
+			"litellm provider prefix",
+			"http://litellm:4000/v1/chat/completions",
+			nil,
+			`{"model":"bedrock/anthropic.claude-3-haiku-20240307-v1:0","messages":[]}`,
+			LLMEndpoint{Provider: "bedrock", Model: "anthropic.claude-3-haiku-20240307-v1:0", Gateway: GatewayLiteLLM},
+		},
+		{
+			"litellm vertex prefix",
+			"http://litellm:4000/chat/completions",
+			nil,
+			`{"model":"vertex_ai/gemini-1.5-pro"}`,
+			LLMEndpoint{Provider: "vertex-ai", Model: "gemini-1.5-pro", Gateway: GatewayLiteLLM},
+		},
+		{
+			"litellm bare model",
+			"http://litellm:4000/v1/chat/completions",
+			nil,
+			`{"model":"claude-3-5-sonnet-20241022"}`,
+			LLMEndpoint{Provider: "anthropic", Model: "claude-3-5-sonnet-20241022", Gateway: GatewayLiteLLM},
+		},
+		{
+			"litellm alias",
+			"http://litellm:4000/v1/chat/completions",
+			nil,
+			`{"model":"team-default"}`,
+			LLMEndpoint{Provider: GatewayLiteLLM, Model: "team-default", Gateway: GatewayLiteLLM},
+		},
+		{
+			"litellm pass-through",
+			"http://litellm:4000/anthropic/v1/messages",
+			nil,
+			``,
+			LLMEndpoint{Provider: "anthropic", Gateway: GatewayLiteLLM},
+		},
+		{
+			"litellm bedrock pass-through",
+			"http://litellm:4000/bedrock/model/anthropic.claude-3-haiku-20240307-v1%3A0/converse",
+			nil,
+			`{"messages":[]}`,
+			LLMEndpoint{Provider: "bedrock", Model: "anthropic.claude-3-haiku-20240307-v1:0", Gateway: GatewayLiteLLM},
+		},
+		{
+			"portkey provider header",
+			"https://api.portkey.ai/v1/chat/completions",
+			map[string]string{"X-Portkey-Provider": "anthropic"},
+			`{"model":"claude-3-opus-20240229"}`,
+			LLMEndpoint{Provider: "anthropic", Model: "claude-3-opus-20240229", Gateway: GatewayPortkey},
+		},
+		{
+			"portkey inline config",
+			"http://portkey.internal:8787/v1/chat/completions",
+			map[string]string{"X-Portkey-Config": `{"strategy":{"mode":"fallback"},"targets":[{"provider":"azure-openai","override_params":{"model":"gpt-4o"}},{"provider":"openai"}]}`},
+			`{"model":"placeholder"}`,
+			LLMEndpoint{Provider: "azure-openai", Model: "gpt-4o", Gateway: GatewayPortkey},
+		},
+		{
+			"portkey model catalog",
+			"https://api.portkey.ai/v1/chat/completions",
+			nil,
+			`{"model":"@openai-prod/gpt-4o-mini"}`,
+			LLMEndpoint{Provider: "openai-prod", Model: "gpt-4o-mini", Gateway: GatewayPortkey},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", tt.url, strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			if got := gatewayEndpoint(req, []byte(tt.body)); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStandaloneInterceptorLLMGateway(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "events.jsonl")
+	policyPath := writeTestPolicy(t, tmpDir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.llm_provider == "anthropic";
+};
+`)
+
+	allowed := make(chan DecisionEvent, 2)
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithLogFile(logPath),
+		WithLLMGateways("litellm.internal:4000"),
+		WithOnAllow(func(ev DecisionEvent) { allowed <- ev }),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+
+	for _, model := range []string{"openai/gpt-4o", "anthropic/claude-3-5-haiku-latest"} {
+		resp, err := client.Post("http://litellm.internal:4000/v1/chat/completions", "application/json", strings.NewReader(`{"model":"`+model+`"}`))
+		if err == nil {
+			resp.Body.Close()
+		}
+	}
+	// Other hosts on the gateway's machine are not gateways
+	if resp, err := client.Post("http://litellm.internal:9090/v1/chat/completions", "application/json", strings.NewReader(`{"model":"anthropic/claude"}`)); err == nil {
+		resp.Body.Close()
+	}
+	si.Close()
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if e := entries[0]; e.EnforcementAction != "allowed" || e.LLMProvider != "openai" || e.LLMModel != "gpt-4o" || e.LLMGateway != GatewayLiteLLM {
+		t.Errorf("unexpected first entry: %+v", e)
+	}
+	if e := entries[1]; e.EnforcementAction != "blocked" || e.LLMModel != "claude-3-5-haiku-latest" {
+		t.Errorf("expected the policy to block the routed anthropic call, got %+v", e)
+	}
+	if e := entries[2]; e.LLMProvider != "" || e.LLMGateway != "" {
+		t.Errorf("expected no LLM attribution off the gateway, got %+v", e)
+	}
+
+	// Hooks run asynchronously, so the two allowed requests arrive in any order
+	var routed DecisionEvent
+	for i := 0; i < 2; i++ {
+		if ev := <-allowed; ev.LLMGateway != "" {
+			routed = ev
+		}
+	}
+	if routed.LLMGateway != GatewayLiteLLM || routed.LLMProvider != "openai" || routed.LLMModel != "gpt-4o" {
+		t.Errorf("unexpected decision event for the gateway call: %+v", routed)
+	}
+}
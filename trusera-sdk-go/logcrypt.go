@@ -0,0 +1,197 @@
+package trusera
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// encryptedLinePrefix starts every encrypted log line and is authenticated
+// with it, so the format can be versioned
+const encryptedLinePrefix = "trusera-enc:v1:"
+
+// maxLogLine bounds the lines DecryptLog reads
+const maxLogLine = 64 << 20
+
+// WithLogEncryption encrypts every event written to disk with AES-GCM under
+// key, which must be 16, 24 or 32 bytes (AES-128, -192 or -256). It covers
+// the WithLogFile file and sinks created by NewFileSink and NewRotatingSink,
+// whose segments are then made of encrypted lines, including in CEF or
+// LEEF; other sinks are transports and are left alone. Each line becomes "trusera-enc:v1:"
+// followed by the base64 nonce and ciphertext. Read the log back with
+// DecryptLog or `trusera decrypt-log`.
+func WithLogEncryption(key []byte) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.logKey = key
+	}
+}
+
+// NewEncryptedSink encrypts events written to dest like WithLogEncryption,
+// for sinks WithLogEncryption does not cover. Closing the sink closes dest.
+func NewEncryptedSink(dest LogSink, key []byte) (LogSink, error) {
+	aead, err := newLogAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedSink{dest: dest, aead: aead}, nil
+}
+
+// ParseLogKey decodes a log encryption key given as hex or standard base64,
+// e.g. the output of `openssl rand -hex 32`, ignoring surrounding whitespace
+func ParseLogKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	key, err := hex.DecodeString(s)
+	if err != nil {
+		key, err = base64.StdEncoding.DecodeString(s)
+	}
+	if err != nil {
+		return nil, errors.New("log key must be hex or base64")
+	}
+	if _, err := newLogAEAD(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// newLogAEAD returns the AES-GCM cipher for key
+func newLogAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log encryption key: must be 16, 24 or 32 bytes, got %d", len(key))
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptLogSinks wraps the sinks that write to disk with encryption
+func (si *StandaloneInterceptor) encryptLogSinks() error {
+	aead, err := newLogAEAD(si.logKey)
+	if err != nil {
+		return err
+	}
+	for i, sink := range si.sinks {
+		si.sinks[i] = encryptAtRest(sink, aead)
+	}
+	return nil
+}
+
+// encryptAtRest wraps sink with encryption if it writes to disk. CEF and LEEF
+// sinks are wrapped inside, so the formatted lines are what gets encrypted.
+func encryptAtRest(sink LogSink, aead cipher.AEAD) LogSink {
+	switch s := sink.(type) {
+	case *fileSink, *rotatingSink:
+		return &encryptedSink{dest: sink, aead: aead}
+	case *formatSink:
+		s.dest = encryptAtRest(s.dest, aead)
+	}
+	return sink
+}
+
+// encryptedSink encrypts each event before passing it on
+type encryptedSink struct {
+	dest LogSink
+	aead cipher.AEAD
+}
+
+func (s *encryptedSink) WriteEvent(data []byte) error {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	sealed := s.aead.Seal(nonce, nonce, bytes.TrimSuffix(data, []byte("\n")), []byte(encryptedLinePrefix))
+
+	line := make([]byte, len(encryptedLinePrefix)+base64.StdEncoding.EncodedLen(len(sealed))+1)
+	copy(line, encryptedLinePrefix)
+	base64.StdEncoding.Encode(line[len(encryptedLinePrefix):], sealed)
+	line[len(line)-1] = '\n'
+	return s.dest.WriteEvent(line)
+}
+
+func (s *encryptedSink) Close() error {
+	return s.dest.Close()
+}
+
+// CloseContext drains a wrapped rotating sink
+func (s *encryptedSink) CloseContext(ctx context.Context) (int, error) {
+	if ds, ok := s.dest.(DrainingSink); ok {
+		return ds.CloseContext(ctx)
+	}
+	return 0, s.dest.Close()
+}
+
+// reopen lets SIGHUP reopen a wrapped file sink
+func (s *encryptedSink) reopen() error {
+	if r, ok := s.dest.(reopener); ok {
+		return r.reopen()
+	}
+	return nil
+}
+
+// DecryptLogLine returns the JSON event of an encrypted log line, without
+// a trailing newline. Lines that are not encrypted are returned as they are,
+// so logs written before encryption was enabled can be read too.
+func DecryptLogLine(key, line []byte) ([]byte, error) {
+	aead, err := newLogAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return decryptLogLine(aead, line)
+}
+
+func decryptLogLine(aead cipher.AEAD, line []byte) ([]byte, error) {
+	line = bytes.TrimRight(line, "\r\n")
+	encoded, ok := bytes.CutPrefix(line, []byte(encryptedLinePrefix))
+	if !ok {
+		return line, nil
+	}
+
+	sealed := make([]byte, base64.StdEncoding.DecodedLen(len(encoded)))
+	n, err := base64.StdEncoding.Decode(sealed, encoded)
+	if err != nil || n < aead.NonceSize() {
+		return nil, errors.New("malformed encrypted log line")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():n]
+	plain, err := aead.Open(nil, nonce, ciphertext, []byte(encryptedLinePrefix))
+	if err != nil {
+		return nil, errors.New("log line failed authentication: wrong key or tampered line")
+	}
+	return plain, nil
+}
+
+// DecryptLog copies the log read from r to w as plain JSONL, decrypting
+// encrypted lines. It stops at the first line that fails to decrypt,
+// reporting its line number.
+func DecryptLog(w io.Writer, r io.Reader, key []byte) error {
+	aead, err := newLogAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), maxLogLine)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		plain, err := decryptLogLine(aead, scanner.Bytes())
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		// plain may share the scanner's buffer, so the newline is written apart
+		if _, err := w.Write(plain); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
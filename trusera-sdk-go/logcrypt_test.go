@@ -0,0 +1,188 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testLogKey = bytes.Repeat([]byte{0x42}, 32)
+
+func TestLogEncryption(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "events.jsonl")
+	var stdout bytes.Buffer
+
+	si, err := NewStandaloneInterceptor(
+		WithLogFile(logPath),
+		WithLogSinks(NewWriterSink(&stdout)),
+		WithLogEncryption(testLogKey),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	for _, path := range []string{"/users?token=secret", "/orders"} {
+		resp, err := client.Get("https://api.example.com" + path)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	// Encryption survives reopening the file
+	if err := si.ReopenLogs(); err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	si.Close()
+
+	data, _ := os.ReadFile(logPath)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, encryptedLinePrefix) || strings.Contains(line, "api.example.com") {
+			t.Errorf("expected an encrypted line, got %q", line)
+		}
+	}
+	if lines[0] == lines[1] {
+		t.Error("expected distinct nonces")
+	}
+	// Sinks that are transports are not encrypted
+	if !strings.Contains(stdout.String(), `"hostname":"api.example.com"`) {
+		t.Errorf("expected the writer sink to stay plain, got %q", stdout.String())
+	}
+
+	var plain bytes.Buffer
+	if err := DecryptLog(&plain, bytes.NewReader(data), testLogKey); err != nil {
+		t.Fatalf("decrypt failed: %v", err)
+	}
+	if plain.String() != stdout.String() {
+		t.Errorf("decrypted log differs from the plain sink:\n%s\n%s", plain.String(), stdout.String())
+	}
+}
+
+func TestDecryptLogErrors(t *testing.T) {
+	sink, err := NewEncryptedSink(NewWriterSink(&bytes.Buffer{}), []byte("short"))
+	if err == nil || sink != nil {
+		t.Fatal("expected an error for a 5 byte key")
+	}
+	if _, err := NewStandaloneInterceptor(WithLogEncryption([]byte("short"))); err == nil {
+		t.Fatal("expected the interceptor to reject a 5 byte key")
+	}
+
+	var encrypted bytes.Buffer
+	sink, _ = NewEncryptedSink(NewWriterSink(&encrypted), testLogKey)
+	sink.WriteEvent([]byte(`{"hostname":"a.example.com"}` + "\n"))
+	line := encrypted.Bytes()
+
+	// Plain lines pass through, so logs from before encryption still read
+	log := `{"hostname":"plain.example.com"}` + "\n\n" + string(line)
+	var out bytes.Buffer
+	if err := DecryptLog(&out, strings.NewReader(log), testLogKey); err != nil {
+		t.Fatalf("decrypt failed: %v", err)
+	}
+	if out.String() != `{"hostname":"plain.example.com"}`+"\n"+`{"hostname":"a.example.com"}`+"\n" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+
+	wrongKey := bytes.Repeat([]byte{0x24}, 32)
+	if err := DecryptLog(&out, strings.NewReader(log), wrongKey); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected a line 3 error for the wrong key, got %v", err)
+	}
+
+	tampered := bytes.Clone(line)
+	i := len(encryptedLinePrefix) + 24 // inside the ciphertext
+	if tampered[i] == 'A' {
+		tampered[i] = 'B'
+	} else {
+		tampered[i] = 'A'
+	}
+	if _, err := DecryptLogLine(testLogKey, tampered); err == nil {
+		t.Error("expected a tampered line to fail")
+	}
+}
+
+func TestLogEncryptionRotatingSink(t *testing.T) {
+	dir := t.TempDir()
+	segments, err := NewRotatingSink(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	si, err := NewStandaloneInterceptor(WithLogSinks(segments), WithLogEncryption(testLogKey))
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	resp, err := client.Get("https://api.example.com/users")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if err := si.CloseContext(context.Background()); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	files, _ := listSegments(dir)
+	if len(files) != 1 {
+		t.Fatalf("expected 1 segment, got %d", len(files))
+	}
+	data, _ := os.ReadFile(files[0])
+	var plain bytes.Buffer
+	if err := DecryptLog(&plain, bytes.NewReader(data), testLogKey); err != nil || !bytes.HasPrefix(data, []byte(encryptedLinePrefix)) {
+		t.Fatalf("expected an encrypted segment, got %q (%v)", data, err)
+	}
+	var entry eventLog
+	if err := json.Unmarshal(plain.Bytes(), &entry); err != nil || entry.Hostname != "api.example.com" {
+		t.Errorf("unexpected decrypted entry %q", plain.String())
+	}
+}
+
+func TestLogEncryptionCEFSink(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.cef")
+	file, err := NewFileSink(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	si, err := NewStandaloneInterceptor(WithLogSinks(NewCEFSink(file)), WithLogEncryption(testLogKey))
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	resp, err := client.Get("https://api.example.com/users")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	si.Close()
+
+	// The CEF line is encrypted, rather than the event before formatting
+	data, _ := os.ReadFile(logPath)
+	var plain bytes.Buffer
+	if err := DecryptLog(&plain, bytes.NewReader(data), testLogKey); err != nil || !bytes.HasPrefix(data, []byte(encryptedLinePrefix)) {
+		t.Fatalf("expected an encrypted log, got %q (%v)", data, err)
+	}
+	if !strings.HasPrefix(plain.String(), "CEF:0|Trusera|") {
+		t.Errorf("expected a CEF line, got %q", plain.String())
+	}
+}
+
+func TestParseLogKey(t *testing.T) {
+	hexKey := strings.Repeat("42", 32)
+	if key, err := ParseLogKey(hexKey + "\n"); err != nil || !bytes.Equal(key, testLogKey) {
+		t.Errorf("hex key: %x, %v", key, err)
+	}
+	if key, err := ParseLogKey("QkJCQkJCQkJCQkJCQkJCQkJCQkJCQkJCQkJCQkJCQkI="); err != nil || !bytes.Equal(key, testLogKey) {
+		t.Errorf("base64 key: %x, %v", key, err)
+	}
+	for _, bad := range []string{"not a key!", "4242"} {
+		if _, err := ParseLogKey(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
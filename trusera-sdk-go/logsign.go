@@ -0,0 +1,163 @@
+package trusera
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// signatureMember is appended to every signed event, which is signed as it
+// was before the member was added
+const signatureMember = `,"signature":{"key_id":"`
+
+// ErrUnsignedEntry is returned by VerifyLog for an event without a signature
+var ErrUnsignedEntry = errors.New("entry is not signed")
+
+// logSignature is the signature embedded in a signed event
+type logSignature struct {
+	KeyID string `json:"key_id"`
+	Sig   string `json:"sig"`
+}
+
+// WithLogSigning signs every event with the Ed25519 key before it is written
+// to the log sinks, for audit regimes that require attributable records. The
+// signature is embedded as a final "signature" member holding the key ID and
+// the base64 signature over the event without that member. With
+// WithLogEncryption, events are signed first and then encrypted. Sinks that
+// reformat events, such as CEF, LEEF and OTLP, drop the signature. Check a
+// log with VerifyLog or `trusera verify-log`.
+func WithLogSigning(key ed25519.PrivateKey) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.signingKey = key
+	}
+}
+
+// checkSigningKey validates the WithLogSigning key and derives its ID
+func (si *StandaloneInterceptor) checkSigningKey() error {
+	if len(si.signingKey) != ed25519.PrivateKeySize {
+		return fmt.Errorf("invalid log signing key: must be %d bytes, got %d", ed25519.PrivateKeySize, len(si.signingKey))
+	}
+	si.signingKeyID = LogKeyID(si.signingKey.Public().(ed25519.PublicKey))
+	return nil
+}
+
+// LogKeyID returns the ID recorded in events signed with the key for pub:
+// the first 8 bytes of its SHA-256, in hex
+func LogKeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ParseLogSigningKey decodes a PEM PKCS #8 Ed25519 private key, as written
+// by `openssl genpkey -algorithm ed25519`
+func ParseLogSigningKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing key is not PEM encoded")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key: %w", err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key is %T, not Ed25519", key)
+	}
+	return priv, nil
+}
+
+// ParseLogPublicKey decodes a PEM PKIX Ed25519 public key, as written by
+// `openssl pkey -pubout`
+func ParseLogPublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("public key is not PEM encoded")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is %T, not Ed25519", key)
+	}
+	return pub, nil
+}
+
+// signEvent embeds a signature in the JSON object event, which must not
+// end in a newline
+func (si *StandaloneInterceptor) signEvent(event []byte) []byte {
+	sig := ed25519.Sign(si.signingKey, event)
+
+	signed := make([]byte, 0, len(event)+len(signatureMember)+len(si.signingKeyID)+base64.StdEncoding.EncodedLen(len(sig))+12)
+	signed = append(signed, event[:len(event)-1]...)
+	signed = append(signed, signatureMember...)
+	signed = append(signed, si.signingKeyID...)
+	signed = append(signed, `","sig":"`...)
+	encoded := make([]byte, base64.StdEncoding.EncodedLen(len(sig)))
+	base64.StdEncoding.Encode(encoded, sig)
+	signed = append(signed, encoded...)
+	return append(signed, `"}}`...)
+}
+
+// VerifyLogLine checks the signature embedded in a JSONL event against pub.
+// It returns ErrUnsignedEntry for events without one.
+func VerifyLogLine(pub ed25519.PublicKey, line []byte) error {
+	if len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key: must be %d bytes, got %d", ed25519.PublicKeySize, len(pub))
+	}
+	line = bytes.TrimRight(line, "\r\n")
+	i := bytes.LastIndex(line, []byte(signatureMember))
+	if i < 0 {
+		return ErrUnsignedEntry
+	}
+
+	var sig logSignature
+	member := line[i+len(`,"signature":`):]
+	if len(member) == 0 || member[len(member)-1] != '}' || json.Unmarshal(member[:len(member)-1], &sig) != nil {
+		return errors.New("malformed signature")
+	}
+	raw, err := base64.StdEncoding.DecodeString(sig.Sig)
+	if err != nil {
+		return errors.New("malformed signature")
+	}
+
+	event := make([]byte, 0, i+1)
+	event = append(append(event, line[:i]...), '}')
+	if !ed25519.Verify(pub, event, raw) {
+		if id := LogKeyID(pub); sig.KeyID != id {
+			return fmt.Errorf("signed by key %s, not %s", sig.KeyID, id)
+		}
+		return errors.New("signature does not match: the entry was altered")
+	}
+	return nil
+}
+
+// VerifyLog checks every event in the JSONL log read from r against pub and
+// returns how many were verified. It stops at the first entry that is
+// unsigned or fails to verify, reporting its line number. Decrypt encrypted
+// logs with DecryptLog first.
+func VerifyLog(r io.Reader, pub ed25519.PublicKey) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), maxLogLine)
+	verified := 0
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		if err := VerifyLogLine(pub, scanner.Bytes()); err != nil {
+			return verified, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		verified++
+	}
+	return verified, scanner.Err()
+}
@@ -0,0 +1,129 @@
+package trusera
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testSigningKey = ed25519.NewKeyFromSeed(bytes.Repeat([]byte{0x07}, ed25519.SeedSize))
+
+func TestLogSigning(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "events.jsonl")
+
+	si, err := NewStandaloneInterceptor(
+		WithLogFile(logPath),
+		WithLogSigning(testSigningKey),
+		WithLogEncryption(testLogKey),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	for _, path := range []string{"/users", "/orders"} {
+		resp, err := client.Get("https://api.example.com" + path)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	si.Close()
+
+	// Events are signed, then encrypted
+	data, _ := os.ReadFile(logPath)
+	var plain bytes.Buffer
+	if err := DecryptLog(&plain, bytes.NewReader(data), testLogKey); err != nil {
+		t.Fatalf("decrypt failed: %v", err)
+	}
+
+	pub := testSigningKey.Public().(ed25519.PublicKey)
+	n, err := VerifyLog(bytes.NewReader(plain.Bytes()), pub)
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 verified entries, got %d: %v", n, err)
+	}
+
+	var entry struct {
+		Hostname  string       `json:"hostname"`
+		Signature logSignature `json:"signature"`
+	}
+	line, _, _ := bytes.Cut(plain.Bytes(), []byte("\n"))
+	if err := json.Unmarshal(line, &entry); err != nil {
+		t.Fatalf("signed entry is not valid JSON: %v", err)
+	}
+	if entry.Hostname != "api.example.com" || entry.Signature.KeyID != LogKeyID(pub) {
+		t.Errorf("unexpected signed entry %s", line)
+	}
+}
+
+func TestVerifyLogErrors(t *testing.T) {
+	if _, err := NewStandaloneInterceptor(WithLogSigning(ed25519.PrivateKey("short"))); err == nil {
+		t.Fatal("expected the interceptor to reject a 5 byte key")
+	}
+
+	si := &StandaloneInterceptor{signingKey: testSigningKey}
+	if err := si.checkSigningKey(); err != nil {
+		t.Fatal(err)
+	}
+	signed := string(si.signEvent([]byte(`{"hostname":"a.example.com","status":200}`)))
+	pub := testSigningKey.Public().(ed25519.PublicKey)
+	other := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{0x08}, ed25519.SeedSize)).Public().(ed25519.PublicKey)
+
+	if err := VerifyLogLine(pub, []byte(signed+"\n")); err != nil {
+		t.Fatalf("expected the entry to verify, got %v", err)
+	}
+
+	tests := []struct {
+		name string
+		pub  ed25519.PublicKey
+		log  string
+		want string
+	}{
+		{"altered", pub, strings.Replace(signed, "200", "403", 1), "line 1: signature does not match"},
+		{"wrong key", other, signed, "line 1: signed by key " + LogKeyID(pub)},
+		{"unsigned", pub, signed + "\n\n" + `{"hostname":"b.example.com"}`, "line 3: entry is not signed"},
+		{"malformed", pub, signed[:len(signed)-2], "line 1: malformed signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := VerifyLog(strings.NewReader(tt.log), tt.pub)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("expected %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	_, err := VerifyLog(strings.NewReader(`{"hostname":"b.example.com"}`), pub)
+	if !errors.Is(err, ErrUnsignedEntry) {
+		t.Errorf("expected ErrUnsignedEntry, got %v", err)
+	}
+}
+
+func TestParseLogSigningKey(t *testing.T) {
+	privDER, _ := x509.MarshalPKCS8PrivateKey(testSigningKey)
+	priv, err := ParseLogSigningKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}))
+	if err != nil || !priv.Equal(testSigningKey) {
+		t.Fatalf("failed to parse private key: %v", err)
+	}
+
+	der, _ := x509.MarshalPKIXPublicKey(testSigningKey.Public())
+	pub, err := ParseLogPublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil || !pub.Equal(testSigningKey.Public()) {
+		t.Fatalf("failed to parse public key: %v", err)
+	}
+
+	if _, err := ParseLogSigningKey([]byte("not pem")); err == nil {
+		t.Error("expected an error for a key that is not PEM")
+	}
+	if _, err := ParseLogPublicKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})); err == nil {
+		t.Error("expected an error for a public key given as a private one")
+	}
+}
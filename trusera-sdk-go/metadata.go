@@ -0,0 +1,97 @@
+package trusera
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"regexp"
+	"runtime"
+)
+
+// Version is the SDK release reported in runtime metadata
+const Version = "0.1.0"
+
+// RuntimeMetadata describes the process running the client. It is sent with
+// every flush and with RegisterAgent so the backend can slice events by runtime.
+type RuntimeMetadata struct {
+	SDKVersion  string `json:"sdk_version"`
+	GoVersion   string `json:"go_version"`
+	Hostname    string `json:"hostname,omitempty"`
+	OS          string `json:"os"`
+	Arch        string `json:"arch"`
+	ContainerID string `json:"container_id,omitempty"`
+	Environment string `json:"environment,omitempty"`
+}
+
+// WithEnvironment names the deployment environment reported in runtime
+// metadata, e.g. "production". Defaults to TRUSERA_ENVIRONMENT.
+func WithEnvironment(name string) Option {
+	return func(c *Client) {
+		c.environment = name
+	}
+}
+
+// WithRuntimeMetadata controls whether runtime metadata is sent (default true)
+func WithRuntimeMetadata(enabled bool) Option {
+	return func(c *Client) {
+		c.noMetadata = !enabled
+	}
+}
+
+// collectRuntimeMetadata describes the current process
+func collectRuntimeMetadata(environment string) *RuntimeMetadata {
+	hostname, _ := os.Hostname()
+	return &RuntimeMetadata{
+		SDKVersion:  Version,
+		GoVersion:   runtime.Version(),
+		Hostname:    hostname,
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		ContainerID: containerID(),
+		Environment: environment,
+	}
+}
+
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// containerID finds the ID of the container the process runs in, if any
+func containerID() string {
+	for _, p := range []string{"/proc/self/cgroup", "/proc/self/mountinfo"} {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		if id := parseContainerID(data); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// parseContainerID extracts a container ID from cgroup or mountinfo data
+func parseContainerID(data []byte) string {
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		// cgroup v1 paths and v2 mount sources name the container, e.g.
+		// /docker/<id> or /var/lib/docker/containers/<id>/hostname
+		if !bytes.Contains(line, []byte("docker")) && !bytes.Contains(line, []byte("kubepods")) &&
+			!bytes.Contains(line, []byte("containerd")) && !bytes.Contains(line, []byte("libpod")) {
+			continue
+		}
+		if id := containerIDPattern.Find(line); id != nil {
+			return string(id)
+		}
+	}
+	return ""
+}
+
+// batchBody encodes a batch of events for the events API
+func (c *Client) batchBody(agentID string, events any) ([]byte, error) {
+	payload := map[string]interface{}{
+		"agent_id": agentID,
+		"events":   events,
+	}
+	if c.metadata != nil {
+		payload["runtime"] = c.metadata
+	}
+	return json.Marshal(payload)
+}
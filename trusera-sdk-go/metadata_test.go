@@ -0,0 +1,94 @@
+package trusera
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseContainerID(t *testing.T) {
+	id := strings.Repeat("ab12", 16)
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"cgroup v1", "12:memory:/docker/" + id + "\n0::/\n", id},
+		{"kubernetes", "0::/kubepods/besteffort/pod1/cri-containerd-" + id + ".scope\n", id},
+		{"mountinfo", "600 590 0:50 /var/lib/docker/containers/" + id + "/hostname /etc/hostname rw\n", id},
+		{"host", "0::/user.slice/user-1000.slice/session-2.scope\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseContainerID([]byte(tt.data)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuntimeMetadataSentWithBatches(t *testing.T) {
+	var got struct {
+		Runtime *RuntimeMetadata `json:"runtime"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithEnvironment("staging"))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	if got.Runtime == nil {
+		t.Fatal("expected runtime metadata in the batch")
+	}
+	if got.Runtime.SDKVersion != Version || got.Runtime.GoVersion != runtime.Version() {
+		t.Errorf("unexpected versions: %+v", got.Runtime)
+	}
+	if got.Runtime.OS != runtime.GOOS || got.Runtime.Arch != runtime.GOARCH {
+		t.Errorf("unexpected platform: %+v", got.Runtime)
+	}
+	if got.Runtime.Environment != "staging" {
+		t.Errorf("expected environment staging, got %q", got.Runtime.Environment)
+	}
+}
+
+func TestRuntimeMetadataOptOut(t *testing.T) {
+	var body map[string]json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/agents" {
+			w.Write([]byte(`{"agent_id":"agent-1"}`))
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithRuntimeMetadata(false))
+	defer client.Close()
+
+	if _, err := client.RegisterAgent("agent", "custom"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, ok := body["runtime"]; ok {
+		t.Error("expected no runtime metadata when disabled")
+	}
+}
+
+func TestEnvironmentFromEnv(t *testing.T) {
+	t.Setenv("TRUSERA_ENVIRONMENT", "production")
+
+	client := NewClient("test-key", WithBaseURL("http://127.0.0.1:1"), WithFlushRetry(0, 0))
+	defer client.Close()
+
+	if client.metadata.Environment != "production" {
+		t.Errorf("expected production, got %q", client.metadata.Environment)
+	}
+}
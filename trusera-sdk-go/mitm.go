@@ -0,0 +1,349 @@
+package trusera
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	proxyCAValidity   = 2 * 365 * 24 * time.Hour
+	proxyLeafValidity = 7 * 24 * time.Hour
+)
+
+// ProxyCA is a local certificate authority that mints per-host certificates
+// for TLS interception. Clients must trust CertPEM for interception to work.
+type ProxyCA struct {
+	cert    *x509.Certificate
+	key     crypto.Signer
+	certPEM []byte
+
+	leafKey *ecdsa.PrivateKey
+	mu      sync.Mutex
+	leaves  map[string]*tls.Certificate
+}
+
+// NewProxyCA generates a new self-signed CA
+func NewProxyCA() (*ProxyCA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "Trusera Local Proxy CA", Organization: []string{"Trusera"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(proxyCAValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode CA key: %w", err)
+	}
+
+	return LoadProxyCA(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+	)
+}
+
+// LoadProxyCA parses a PEM-encoded CA certificate and private key
+func LoadProxyCA(certPEM, keyPEM []byte) (*ProxyCA, error) {
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CA key pair: %w", err)
+	}
+
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+	if !cert.IsCA {
+		return nil, errors.New("certificate is not a CA")
+	}
+
+	signer, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("CA private key cannot sign")
+	}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate leaf key: %w", err)
+	}
+
+	return &ProxyCA{
+		cert:    cert,
+		key:     signer,
+		certPEM: certPEM,
+		leafKey: leafKey,
+		leaves:  make(map[string]*tls.Certificate),
+	}, nil
+}
+
+// LoadOrCreateProxyCA loads the CA from certPath and keyPath, generating and
+// saving a new one when the files do not exist yet
+func LoadOrCreateProxyCA(certPath, keyPath string) (*ProxyCA, error) {
+	certPEM, certErr := os.ReadFile(certPath)
+	keyPEM, keyErr := os.ReadFile(keyPath)
+	if certErr == nil && keyErr == nil {
+		return LoadProxyCA(certPEM, keyPEM)
+	}
+	if !errors.Is(certErr, os.ErrNotExist) || !errors.Is(keyErr, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read CA files: %w", errors.Join(certErr, keyErr))
+	}
+
+	ca, err := NewProxyCA()
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err = ca.KeyPEM()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write CA key: %w", err)
+	}
+	if err := os.WriteFile(certPath, ca.CertPEM(), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write CA certificate: %w", err)
+	}
+
+	return ca, nil
+}
+
+// CertPEM returns the PEM-encoded CA certificate to install in client trust stores
+func (ca *ProxyCA) CertPEM() []byte {
+	return ca.certPEM
+}
+
+// KeyPEM returns the PEM-encoded PKCS #8 CA private key
+func (ca *ProxyCA) KeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(ca.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode CA key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// certificateFor returns a cached leaf certificate for host, minting one when
+// missing or close to expiry
+func (ca *ProxyCA) certificateFor(host string) (*tls.Certificate, error) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+
+	now := time.Now()
+	if leaf, ok := ca.leaves[host]; ok && now.Add(time.Hour).Before(leaf.Leaf.NotAfter) {
+		return leaf, nil
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	notAfter := now.Add(proxyLeafValidity)
+	if notAfter.After(ca.cert.NotAfter) {
+		notAfter = ca.cert.NotAfter
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &ca.leafKey.PublicKey, ca.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mint certificate for %s: %w", host, err)
+	}
+	leafCert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse minted certificate: %w", err)
+	}
+
+	leaf := &tls.Certificate{
+		Certificate: [][]byte{der, ca.cert.Raw},
+		PrivateKey:  ca.leafKey,
+		Leaf:        leafCert,
+	}
+	ca.leaves[host] = leaf
+	return leaf, nil
+}
+
+// randomSerial returns a random 128-bit certificate serial number
+func randomSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serial, nil
+}
+
+// WithProxyMITM enables TLS interception for CONNECT tunnels. The proxy
+// terminates TLS with certificates minted by ca, so hostname, path and body
+// policies apply to HTTPS destinations. Clients must trust ca.CertPEM().
+func WithProxyMITM(ca *ProxyCA) ProxyOption {
+	return func(p *ProxyServer) {
+		p.ca = ca
+	}
+}
+
+// WithProxyMITMBypass lists hostnames tunnelled without interception, e.g.
+// for certificate-pinned services. Patterns use path.Match syntax, so
+// "*.bank.example.com" matches any subdomain.
+func WithProxyMITMBypass(hosts ...string) ProxyOption {
+	return func(p *ProxyServer) {
+		p.mitmBypass = append(p.mitmBypass, hosts...)
+	}
+}
+
+// bypassMITM reports whether hostname must be tunnelled without interception
+func (p *ProxyServer) bypassMITM(hostname string) bool {
+	hostname = strings.ToLower(hostname)
+	for _, pattern := range p.mitmBypass {
+		if ok, _ := path.Match(strings.ToLower(pattern), hostname); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// intercept terminates the client's TLS session and serves the decrypted
+// requests through the policy transport
+func (p *ProxyServer) intercept(w http.ResponseWriter, host, hostname string) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+
+	client, buf, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		client.Close()
+		return
+	}
+
+	var conn net.Conn = client
+	if n := buf.Reader.Buffered(); n > 0 {
+		conn = &bufferedConn{Conn: client, r: io.MultiReader(io.LimitReader(buf, int64(n)), client)}
+	}
+
+	tlsConn := tls.Server(conn, &tls.Config{
+		NextProtos: []string{"http/1.1"},
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			name := hello.ServerName
+			if name == "" {
+				name = hostname
+			}
+			return p.ca.certificateFor(name)
+		},
+	})
+
+	// Log requests against the origin without the default port
+	origin := host
+	if _, port, _ := net.SplitHostPort(host); port == "443" {
+		origin = hostname
+	}
+
+	p.trackTunnel(client)
+
+	var once sync.Once
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			target := *r.URL
+			target.Scheme = "https"
+			target.Host = origin
+			p.forward(w, r, &target)
+		}),
+		// HTTP/2 is not offered, so disable the server's automatic upgrade
+		TLSNextProto: make(map[string]func(*http.Server, *tls.Conn, http.Handler)),
+		ConnState: func(_ net.Conn, state http.ConnState) {
+			if state == http.StateClosed || state == http.StateHijacked {
+				once.Do(func() { p.untrackTunnel(client) })
+			}
+		},
+	}
+
+	go srv.Serve(&oneConnListener{conn: tlsConn})
+}
+
+// bufferedConn reads from r before falling through to the connection
+type bufferedConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+// oneConnListener yields a single connection to http.Server.Serve
+type oneConnListener struct {
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func (l *oneConnListener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return nil, io.EOF
+	}
+	c := l.conn
+	l.conn = nil
+	return c, nil
+}
+
+func (l *oneConnListener) Close() error {
+	return nil
+}
+
+func (l *oneConnListener) Addr() net.Addr {
+	return &net.TCPAddr{}
+}
@@ -0,0 +1,189 @@
+package trusera
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+)
+
+func newMITMTestProxy(t *testing.T, backend *httptest.Server, opts ...ProxyOption) (*url.URL, string) {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "events.jsonl")
+	policyPath := writeTestPolicy(t, tmpDir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.path == "/admin";
+};
+`)
+
+	p, err := NewProxyServer(append([]ProxyOption{
+		WithProxyUpstream(backend.Client().Transport),
+		WithProxyInterceptorOptions(
+			WithPolicyFile(policyPath),
+			WithEnforcement(EnforcementBlock),
+			WithLogFile(logPath),
+		),
+	}, opts...)...)
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+
+	srv := httptest.NewServer(p)
+	t.Cleanup(func() {
+		srv.Close()
+		p.Close()
+	})
+
+	proxyURL, _ := url.Parse(srv.URL)
+	return proxyURL, logPath
+}
+
+func proxiedClient(proxyURL *url.URL, roots *x509.CertPool) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: &tls.Config{RootCAs: roots},
+	}}
+}
+
+func TestProxyServerMITM(t *testing.T) {
+	backend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "path "+r.URL.Path)
+	}))
+	defer backend.Close()
+
+	ca, err := NewProxyCA()
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+
+	proxyURL, logPath := newMITMTestProxy(t, backend, WithProxyMITM(ca))
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(ca.CertPEM())
+	client := proxiedClient(proxyURL, roots)
+
+	resp, err := client.Get(backend.URL + "/v1/data")
+	if err != nil {
+		t.Fatalf("intercepted request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != "path /v1/data" {
+		t.Errorf("unexpected body: %q", body)
+	}
+	if resp.TLS == nil || resp.TLS.PeerCertificates[0].Issuer.CommonName != "Trusera Local Proxy CA" {
+		t.Error("expected the connection to be terminated with a minted certificate")
+	}
+
+	// Path policies apply to decrypted HTTPS traffic
+	resp, err = client.Get(backend.URL + "/admin")
+	if err != nil {
+		t.Fatalf("blocked request should get a response: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 for forbidden path, got %d", resp.StatusCode)
+	}
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 log entries, got %d", len(entries))
+	}
+	if entries[0].Method != http.MethodGet || entries[0].URL != backend.URL+"/v1/data" {
+		t.Errorf("unexpected intercepted entry: %+v", entries[0])
+	}
+	if entries[1].Path != "/admin" || entries[1].EnforcementAction != "blocked" {
+		t.Errorf("unexpected blocked entry: %+v", entries[1])
+	}
+}
+
+func TestProxyServerMITMBypass(t *testing.T) {
+	backend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "direct")
+	}))
+	defer backend.Close()
+
+	ca, err := NewProxyCA()
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+
+	proxyURL, logPath := newMITMTestProxy(t, backend, WithProxyMITM(ca), WithProxyMITMBypass("127.0.0.*"))
+
+	// Bypassed hosts present their own certificate
+	roots := x509.NewCertPool()
+	roots.AddCert(backend.Certificate())
+	resp, err := proxiedClient(proxyURL, roots).Get(backend.URL + "/admin")
+	if err != nil {
+		t.Fatalf("bypassed request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected path policy not to apply to bypassed host, got %d", resp.StatusCode)
+	}
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 1 || entries[0].Method != http.MethodConnect {
+		t.Errorf("expected a single CONNECT entry, got %+v", entries)
+	}
+}
+
+func TestLoadOrCreateProxyCA(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "ca.pem")
+	keyPath := filepath.Join(dir, "ca-key.pem")
+
+	created, err := LoadOrCreateProxyCA(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+
+	loaded, err := LoadOrCreateProxyCA(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("failed to load CA: %v", err)
+	}
+
+	if !bytes.Equal(created.CertPEM(), loaded.CertPEM()) {
+		t.Error("expected the saved CA to be reused")
+	}
+
+	leaf, err := loaded.certificateFor("api.example.com")
+	if err != nil {
+		t.Fatalf("failed to mint certificate: %v", err)
+	}
+	if err := leaf.Leaf.CheckSignatureFrom(loaded.cert); err != nil {
+		t.Errorf("minted certificate not signed by CA: %v", err)
+	}
+	if again, _ := loaded.certificateFor("api.example.com"); again != leaf {
+		t.Error("expected minted certificates to be cached")
+	}
+}
+
+func TestLoadProxyCARejectsLeaf(t *testing.T) {
+	ca, err := NewProxyCA()
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	leaf, _ := ca.certificateFor("example.com")
+	keyPEM, _ := (&ProxyCA{key: ca.leafKey}).KeyPEM()
+	certPEM := pemEncodeCert(leaf.Certificate[0])
+
+	if _, err := LoadProxyCA(certPEM, keyPEM); err == nil {
+		t.Error("expected non-CA certificate to be rejected")
+	}
+}
+
+func pemEncodeCert(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
@@ -0,0 +1,42 @@
+package trusera
+
+import (
+	"context"
+	"strings"
+)
+
+// ModelLicenseFunc returns the license of a model, e.g. "apache-2.0" or
+// "cc-by-nc-4.0", or "" when unknown. It runs on the request path, so it
+// should answer from memory or a cache.
+type ModelLicenseFunc func(ctx context.Context, provider, model string) string
+
+// WithModelLicenses resolves the license of each model found by LLM
+// detection. The license is exposed to policies as resource.model_license,
+// logged as model_license and passed to decision hooks, so requests to
+// models whose licenses forbid commercial use can be blocked.
+func WithModelLicenses(fn ModelLicenseFunc) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.modelLicense = fn
+	}
+}
+
+// ModelLicenseTable returns a ModelLicenseFunc that looks models up in
+// licenses, keyed by model name. Keys match case-insensitively as the
+// longest prefix of the name, so "llama3.1" covers "llama3.1:70b".
+func ModelLicenseTable(licenses map[string]string) ModelLicenseFunc {
+	table := make(map[string]string, len(licenses))
+	for model, license := range licenses {
+		table[strings.ToLower(model)] = license
+	}
+
+	return func(_ context.Context, _, model string) string {
+		model = strings.ToLower(model)
+		best, license := -1, ""
+		for prefix, l := range table {
+			if strings.HasPrefix(model, prefix) && len(prefix) > best {
+				best, license = len(prefix), l
+			}
+		}
+		return license
+	}
+}
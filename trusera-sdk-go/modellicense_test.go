@@ -0,0 +1,83 @@
+package trusera
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestModelLicenseTable(t *testing.T) {
+	lookup := ModelLicenseTable(map[string]string{
+		"llama3":                "llama3",
+		"llama3.1":              "llama3.1",
+		"CohereForAI/aya-23-8B": "cc-by-nc-4.0",
+	})
+
+	tests := map[string]string{
+		"llama3.1:70b":          "llama3.1",
+		"llama3:8b":             "llama3",
+		"cohereforai/aya-23-8b": "cc-by-nc-4.0",
+		"gpt-4o":                "",
+	}
+	for model, want := range tests {
+		if got := lookup(context.Background(), "", model); got != want {
+			t.Errorf("license for %s = %q, want %q", model, got, want)
+		}
+	}
+}
+
+func TestStandaloneInterceptorModelLicensePolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "events.jsonl")
+	policyPath := writeTestPolicy(t, tmpDir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.model_license == "cc-by-nc-4.0";
+};
+`)
+
+	var licensed []string
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithLogFile(logPath),
+		WithModelLicenses(func(ctx context.Context, provider, model string) string {
+			licensed = append(licensed, provider+"/"+model)
+			return ModelLicenseTable(map[string]string{"aya": "cc-by-nc-4.0", "llama3.1": "llama3.1"})(ctx, provider, model)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+
+	for _, model := range []string{"llama3.1", "aya:35b"} {
+		resp, err := client.Post("http://localhost:11434/api/chat", "application/json", strings.NewReader(`{"model":"`+model+`"}`))
+		if err == nil {
+			resp.Body.Close()
+		}
+	}
+	// Requests to other hosts are not resolved
+	resp, err := client.Get("https://api.example.com/items")
+	if err == nil {
+		resp.Body.Close()
+	}
+	si.Close()
+
+	if len(licensed) != 2 || licensed[0] != "ollama/llama3.1" {
+		t.Errorf("expected licenses resolved for the two model requests, got %v", licensed)
+	}
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if e := entries[0]; e.EnforcementAction != "allowed" || e.ModelLicense != "llama3.1" {
+		t.Errorf("unexpected first entry: %+v", e)
+	}
+	if e := entries[1]; e.EnforcementAction != "blocked" || e.ModelLicense != "cc-by-nc-4.0" {
+		t.Errorf("expected the non-commercial model to be blocked, got %+v", e)
+	}
+}
@@ -0,0 +1,227 @@
+package trusera
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	otlpLogsPath  = "/v1/logs"
+	otlpScopeName = "github.com/Trusera/ai-bom/trusera-sdk-go"
+
+	// OpenTelemetry severity numbers
+	otlpSeverityInfo = 9
+	otlpSeverityWarn = 13
+)
+
+// NewOTLPSink exports events as OpenTelemetry log records over OTLP/HTTP with
+// JSON encoding, so policy decisions land in existing observability backends.
+// endpoint is the collector base URL, e.g. "http://localhost:4318"; the
+// /v1/logs path is added when missing. Event fields become attributes prefixed
+// with "trusera.", and denied requests are logged at WARN severity. Batching
+// and headers are configured with the HTTPSinkOption functions.
+func NewOTLPSink(endpoint, serviceName string, opts ...HTTPSinkOption) (LogSink, error) {
+	u, err := otlpURL(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	encode := func(events [][]byte) ([]byte, error) {
+		return encodeOTLPLogs(events, serviceName)
+	}
+	return newHTTPSink(u, "application/json", encode, opts), nil
+}
+
+// otlpURL checks a collector URL, adding the logs path when missing
+func otlpURL(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid OTLP endpoint %q", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = otlpLogsPath
+	}
+	return u.String(), nil
+}
+
+// otlpValue is an OTLP AnyValue in the protobuf JSON mapping
+type otlpValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"`
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+	KvlistValue *otlpKvlist     `json:"kvlistValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpValue `json:"values"`
+}
+
+type otlpKvlist struct {
+	Values []otlpKeyValue `json:"values"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	TraceID              string         `json:"traceId,omitempty"`
+	SpanID               string         `json:"spanId,omitempty"`
+	Body                 otlpValue      `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes"`
+}
+
+// encodeOTLPLogs builds an ExportLogsServiceRequest from JSONL events
+func encodeOTLPLogs(events [][]byte, serviceName string) ([]byte, error) {
+	observed := strconv.FormatInt(time.Now().UnixNano(), 10)
+	records := make([]otlpLogRecord, 0, len(events))
+
+	for _, data := range events {
+		var fields map[string]any
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, err
+		}
+
+		record := otlpLogRecord{
+			TimeUnixNano:         observed,
+			ObservedTimeUnixNano: observed,
+			SeverityNumber:       otlpSeverityInfo,
+			SeverityText:         "INFO",
+			Attributes:           otlpAttributes("trusera.", fields),
+		}
+
+		if ts, ok := fields["timestamp"].(string); ok {
+			if t, err := time.Parse(time.RFC3339, ts); err == nil {
+				record.TimeUnixNano = strconv.FormatInt(t.UnixNano(), 10)
+			}
+		}
+		record.TraceID, _ = fields["trace_id"].(string)
+		record.SpanID, _ = fields["span_id"].(string)
+		if fields["policy_decision"] == "Deny" {
+			record.SeverityNumber = otlpSeverityWarn
+			record.SeverityText = "WARN"
+		}
+
+		body := fmt.Sprintf("%v %v %v", fields["method"], fields["url"], fields["enforcement_action"])
+		record.Body = otlpValue{StringValue: &body}
+
+		records = append(records, record)
+	}
+
+	return otlpRequest(serviceName, records)
+}
+
+// encodeOTLPEvents builds an ExportLogsServiceRequest from Client events. The
+// event name is the record body; the other fields become attributes.
+func encodeOTLPEvents(events [][]byte, serviceName string) ([]byte, error) {
+	observed := strconv.FormatInt(time.Now().UnixNano(), 10)
+	records := make([]otlpLogRecord, 0, len(events))
+
+	for _, data := range events {
+		var fields map[string]any
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, err
+		}
+
+		record := otlpLogRecord{
+			TimeUnixNano:         observed,
+			ObservedTimeUnixNano: observed,
+			SeverityNumber:       otlpSeverityInfo,
+			SeverityText:         "INFO",
+		}
+		if ts, ok := fields["timestamp"].(string); ok {
+			if t, err := time.Parse(time.RFC3339, ts); err == nil {
+				record.TimeUnixNano = strconv.FormatInt(t.UnixNano(), 10)
+			}
+		}
+
+		name := fmt.Sprint(fields["name"])
+		record.Body = otlpValue{StringValue: &name}
+		agentID, _ := fields["agent_id"].(string)
+		delete(fields, "name")
+		delete(fields, "timestamp")
+		delete(fields, "agent_id")
+
+		record.Attributes = otlpAttributes("trusera.event.", fields)
+		if agentID != "" {
+			record.Attributes = append([]otlpKeyValue{{Key: "trusera.agent_id", Value: otlpAnyValue(agentID)}}, record.Attributes...)
+		}
+
+		records = append(records, record)
+	}
+
+	return otlpRequest(serviceName, records)
+}
+
+// otlpRequest wraps log records in an ExportLogsServiceRequest
+func otlpRequest(serviceName string, records []otlpLogRecord) ([]byte, error) {
+	request := map[string]any{
+		"resourceLogs": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": []otlpKeyValue{{Key: "service.name", Value: otlpAnyValue(serviceName)}},
+			},
+			"scopeLogs": []any{map[string]any{
+				"scope":      map[string]string{"name": otlpScopeName},
+				"logRecords": records,
+			}},
+		}},
+	}
+
+	return json.Marshal(request)
+}
+
+// otlpAttributes converts decoded JSON fields into sorted, prefixed attributes
+func otlpAttributes(prefix string, fields map[string]any) []otlpKeyValue {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, otlpKeyValue{Key: prefix + k, Value: otlpAnyValue(fields[k])})
+	}
+	return attrs
+}
+
+// otlpAnyValue maps a decoded JSON value onto an OTLP AnyValue
+func otlpAnyValue(v any) otlpValue {
+	switch v := v.(type) {
+	case string:
+		return otlpValue{StringValue: &v}
+	case bool:
+		return otlpValue{BoolValue: &v}
+	case float64:
+		if v == float64(int64(v)) {
+			s := strconv.FormatInt(int64(v), 10)
+			return otlpValue{IntValue: &s}
+		}
+		return otlpValue{DoubleValue: &v}
+	case []any:
+		arr := &otlpArrayValue{Values: make([]otlpValue, 0, len(v))}
+		for _, item := range v {
+			arr.Values = append(arr.Values, otlpAnyValue(item))
+		}
+		return otlpValue{ArrayValue: arr}
+	case map[string]any:
+		return otlpValue{KvlistValue: &otlpKvlist{Values: otlpAttributes("", v)}}
+	default:
+		s := ""
+		if v != nil {
+			s = fmt.Sprint(v)
+		}
+		return otlpValue{StringValue: &s}
+	}
+}
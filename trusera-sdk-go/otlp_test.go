@@ -0,0 +1,130 @@
+package trusera
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestOTLPSinkExportsLogRecords(t *testing.T) {
+	var mu sync.Mutex
+	var path, contentType string
+	var request struct {
+		ResourceLogs []struct {
+			Resource struct {
+				Attributes []otlpKeyValue `json:"attributes"`
+			} `json:"resource"`
+			ScopeLogs []struct {
+				LogRecords []otlpLogRecord `json:"logRecords"`
+			} `json:"scopeLogs"`
+		} `json:"resourceLogs"`
+	}
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		path = r.URL.Path
+		contentType = r.Header.Get("Content-Type")
+		json.NewDecoder(r.Body).Decode(&request)
+	}))
+	defer collector.Close()
+
+	sink, err := NewOTLPSink(collector.URL, "research-agent")
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+
+	policyPath := writeTestPolicy(t, t.TempDir(), `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "blocked.example.com";
+};
+`)
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithLogSinks(sink),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	for _, host := range []string{"api.example.com", "blocked.example.com"} {
+		resp, err := client.Get("http://" + host + "/v1/chat")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if err := si.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if path != "/v1/logs" {
+		t.Errorf("expected /v1/logs, got %s", path)
+	}
+	if contentType != "application/json" {
+		t.Errorf("expected JSON content type, got %s", contentType)
+	}
+	if len(request.ResourceLogs) != 1 || len(request.ResourceLogs[0].ScopeLogs) != 1 {
+		t.Fatalf("unexpected request shape: %+v", request)
+	}
+
+	service := request.ResourceLogs[0].Resource.Attributes
+	if len(service) != 1 || service[0].Key != "service.name" || *service[0].Value.StringValue != "research-agent" {
+		t.Errorf("unexpected resource attributes: %+v", service)
+	}
+
+	records := request.ResourceLogs[0].ScopeLogs[0].LogRecords
+	if len(records) != 2 {
+		t.Fatalf("expected 2 log records, got %d", len(records))
+	}
+	if records[0].SeverityText != "INFO" || records[1].SeverityText != "WARN" {
+		t.Errorf("expected INFO then WARN, got %s and %s", records[0].SeverityText, records[1].SeverityText)
+	}
+	if got := *records[1].Body.StringValue; got != "GET http://blocked.example.com/v1/chat logged" {
+		t.Errorf("unexpected body %q", got)
+	}
+
+	attrs := make(map[string]otlpValue)
+	for _, kv := range records[1].Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	if v := attrs["trusera.hostname"]; v.StringValue == nil || *v.StringValue != "blocked.example.com" {
+		t.Errorf("expected hostname attribute, got %+v", v)
+	}
+	if v := attrs["trusera.policy_decision"]; v.StringValue == nil || *v.StringValue != "Deny" {
+		t.Errorf("expected decision attribute, got %+v", v)
+	}
+	if v := attrs["trusera.status"]; v.IntValue == nil || *v.IntValue != "200" {
+		t.Errorf("expected integer status attribute, got %+v", v)
+	}
+}
+
+func TestOTLPSinkInvalidEndpoint(t *testing.T) {
+	if _, err := NewOTLPSink("not a url", "agent"); err == nil {
+		t.Error("expected error for invalid endpoint")
+	}
+}
+
+func TestOTLPAnyValue(t *testing.T) {
+	v := otlpAnyValue(map[string]any{"ratio": 0.5, "tags": []any{"a", true}})
+	if v.KvlistValue == nil || len(v.KvlistValue.Values) != 2 {
+		t.Fatalf("expected kvlist with 2 values, got %+v", v)
+	}
+
+	ratio := v.KvlistValue.Values[0]
+	if ratio.Key != "ratio" || ratio.Value.DoubleValue == nil || *ratio.Value.DoubleValue != 0.5 {
+		t.Errorf("unexpected ratio %+v", ratio)
+	}
+
+	tags := v.KvlistValue.Values[1].Value.ArrayValue
+	if tags == nil || len(tags.Values) != 2 || tags.Values[1].BoolValue == nil || !*tags.Values[1].BoolValue {
+		t.Errorf("unexpected tags %+v", tags)
+	}
+}
@@ -0,0 +1,64 @@
+package trusera
+
+import "context"
+
+// PageFunc fetches the page starting at cursor, an empty cursor being the
+// first page. It returns the page's items and the cursor of the next page,
+// empty on the last one.
+type PageFunc[T any] func(ctx context.Context, cursor string) (items []T, next string, err error)
+
+// Pager iterates over every item of a paginated list endpoint:
+//
+//	pager := client.AgentPager(trusera.ListAgentsOptions{})
+//	for pager.Next(ctx) {
+//		agent := pager.Item()
+//	}
+//	if err := pager.Err(); err != nil { ... }
+type Pager[T any] struct {
+	fetch  PageFunc[T]
+	page   []T
+	index  int
+	cursor string
+	done   bool
+	err    error
+}
+
+// NewPager returns a Pager that fetches pages with fetch
+func NewPager[T any](fetch PageFunc[T]) *Pager[T] {
+	return &Pager[T]{fetch: fetch, index: -1}
+}
+
+// Next advances to the next item, fetching the next page when needed. It
+// returns false when the items run out or a fetch fails; check Err.
+func (p *Pager[T]) Next(ctx context.Context) bool {
+	if p.err != nil {
+		return false
+	}
+
+	p.index++
+	for p.index >= len(p.page) {
+		if p.done {
+			return false
+		}
+
+		items, next, err := p.fetch(ctx, p.cursor)
+		if err != nil {
+			p.err = err
+			return false
+		}
+		// A repeated cursor would loop forever
+		p.done = next == "" || next == p.cursor
+		p.page, p.index, p.cursor = items, 0, next
+	}
+	return true
+}
+
+// Item returns the current item
+func (p *Pager[T]) Item() T {
+	return p.page[p.index]
+}
+
+// Err returns the error that stopped iteration, if any
+func (p *Pager[T]) Err() error {
+	return p.err
+}
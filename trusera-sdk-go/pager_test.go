@@ -0,0 +1,106 @@
+package trusera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestPager(t *testing.T) {
+	pages := map[string]struct {
+		items []int
+		next  string
+	}{
+		"":   {[]int{1, 2}, "c1"},
+		"c1": {nil, "c2"}, // empty pages are skipped
+		"c2": {[]int{3}, ""},
+	}
+	fetches := 0
+	pager := NewPager(func(ctx context.Context, cursor string) ([]int, string, error) {
+		fetches++
+		p := pages[cursor]
+		return p.items, p.next, nil
+	})
+
+	var got []int
+	for pager.Next(context.Background()) {
+		got = append(got, pager.Item())
+	}
+	if err := pager.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("got %v", got)
+	}
+	if fetches != 3 {
+		t.Errorf("expected 3 fetches, got %d", fetches)
+	}
+	if pager.Next(context.Background()) {
+		t.Error("expected Next to stay false after the last page")
+	}
+}
+
+func TestPagerStopsOnError(t *testing.T) {
+	boom := errors.New("boom")
+	pager := NewPager(func(ctx context.Context, cursor string) ([]string, string, error) {
+		if cursor == "" {
+			return []string{"a"}, "c1", nil
+		}
+		return nil, "", boom
+	})
+
+	n := 0
+	for pager.Next(context.Background()) {
+		n++
+	}
+	if n != 1 || !errors.Is(pager.Err(), boom) {
+		t.Errorf("expected 1 item then boom, got %d items and %v", n, pager.Err())
+	}
+}
+
+func TestPagerRepeatedCursor(t *testing.T) {
+	pager := NewPager(func(ctx context.Context, cursor string) ([]string, string, error) {
+		return []string{"a"}, "same", nil
+	})
+
+	n := 0
+	for pager.Next(context.Background()) && n < 10 {
+		n++
+	}
+	if n != 2 {
+		t.Errorf("expected iteration to stop on a repeated cursor, got %d items", n)
+	}
+}
+
+func TestAgentPager(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			w.Write([]byte(`{"agents": [{"agent_id": "a1"}, {"agent_id": "a2"}], "next_cursor": "c1"}`))
+		case "c1":
+			w.Write([]byte(`{"agents": [{"agent_id": "a3"}]}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	var ids []string
+	pager := client.AgentPager(ListAgentsOptions{Limit: 2})
+	for pager.Next(context.Background()) {
+		ids = append(ids, pager.Item().ID)
+	}
+	if err := pager.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fmt.Sprint(ids) != "[a1 a2 a3]" {
+		t.Errorf("got %v", ids)
+	}
+}
@@ -0,0 +1,232 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// PagerDutyEventsURL is the PagerDuty Events API v2 endpoint
+const PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// Incident classes, also used in dedup keys
+const (
+	PagerDutyClassCircuitBreaker = "circuit_breaker"
+	PagerDutyClassBlockRate      = "block_rate"
+)
+
+// PagerDutyOption configures PagerDuty incidents
+type PagerDutyOption func(*pagerDuty)
+
+// WithPagerDutyCircuitTrip sets whether a circuit breaker trip opens an
+// incident (default true). Trips require WithCircuitBreaker.
+func WithPagerDutyCircuitTrip(enabled bool) PagerDutyOption {
+	return func(p *pagerDuty) {
+		p.onCircuitTrip = enabled
+	}
+}
+
+// WithPagerDutyBlockRate opens an incident when count requests are blocked
+// within window, e.g. 20 per minute. Off by default.
+func WithPagerDutyBlockRate(count int, window time.Duration) PagerDutyOption {
+	return func(p *pagerDuty) {
+		p.blockRate = &circuitBreaker{threshold: count, window: window}
+	}
+}
+
+// WithPagerDutySeverity sets the severity of incidents: critical, error,
+// warning or info (default error)
+func WithPagerDutySeverity(severity string) PagerDutyOption {
+	return func(p *pagerDuty) {
+		p.severity = severity
+	}
+}
+
+// WithPagerDutySource sets the source of incidents, which also scopes their
+// dedup keys (default the machine's hostname)
+func WithPagerDutySource(source string) PagerDutyOption {
+	return func(p *pagerDuty) {
+		p.source = source
+	}
+}
+
+// WithPagerDutyURL sets the Events API endpoint, e.g. the EU service region's
+func WithPagerDutyURL(url string) PagerDutyOption {
+	return func(p *pagerDuty) {
+		p.url = url
+	}
+}
+
+// WithPagerDutyClient sets the client used to send events (default 10s timeout)
+func WithPagerDutyClient(client *http.Client) PagerDutyOption {
+	return func(p *pagerDuty) {
+		p.client = client
+	}
+}
+
+// WithPagerDuty opens PagerDuty incidents through the Events API v2 when
+// enforcement turns anomalous: by default when the circuit breaker trips,
+// and with WithPagerDutyBlockRate when blocks exceed a rate. routingKey is
+// the integration key of the service to page. Each condition has its own
+// dedup key, so repeated triggers while an incident is open add to it rather
+// than paging again. Events are sent asynchronously like decision hooks and
+// retried on connection errors, 429 and 5xx responses; failures are logged.
+func WithPagerDuty(routingKey string, opts ...PagerDutyOption) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		p := &pagerDuty{
+			routingKey:    routingKey,
+			url:           PagerDutyEventsURL,
+			severity:      "error",
+			onCircuitTrip: true,
+			client:        &http.Client{Timeout: 10 * time.Second},
+			retry:         retryPolicy{max: defaultWebhookRetries, backoff: defaultWebhookBackoff},
+			interceptor:   si,
+		}
+		if host, err := os.Hostname(); err == nil {
+			p.source = host
+		}
+		for _, opt := range opts {
+			opt(p)
+		}
+		if p.source == "" {
+			p.source = "trusera"
+		}
+
+		if p.onCircuitTrip {
+			si.onCircuitTrip = append(si.onCircuitTrip, p.circuitTripped)
+		}
+		if p.blockRate != nil && p.blockRate.threshold > 0 {
+			si.hooks.onBlock = append(si.hooks.onBlock, p.blocked)
+		}
+	}
+}
+
+// pagerDuty triggers incidents for enforcement anomalies
+type pagerDuty struct {
+	routingKey    string
+	url           string
+	severity      string
+	source        string
+	onCircuitTrip bool
+	client        *http.Client
+	retry         retryPolicy
+
+	// blockRate counts blocks in a sliding window, like the circuit
+	// breaker counts denials
+	blockRate *circuitBreaker
+
+	// interceptor provides the logger for failed deliveries
+	interceptor *StandaloneInterceptor
+}
+
+// pagerDutyEvent is an Events API v2 trigger
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key"`
+	Payload     pagerDutyPayload `json:"payload"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string         `json:"summary"`
+	Source        string         `json:"source"`
+	Severity      string         `json:"severity"`
+	Timestamp     string         `json:"timestamp"`
+	Component     string         `json:"component"`
+	Class         string         `json:"class"`
+	CustomDetails map[string]any `json:"custom_details"`
+}
+
+// circuitTripped opens an incident for a circuit breaker trip
+func (p *pagerDuty) circuitTripped(trip CircuitTrip) {
+	p.trigger(PagerDutyClassCircuitBreaker, trip.TrippedAt,
+		fmt.Sprintf("Trusera circuit breaker tripped on %s after %d denials; egress denied until %s",
+			p.source, trip.Denials, trip.OpenUntil.UTC().Format(time.RFC3339)),
+		map[string]any{
+			"denials":    trip.Denials,
+			"tripped_at": trip.TrippedAt.UTC().Format(time.RFC3339),
+			"open_until": trip.OpenUntil.UTC().Format(time.RFC3339),
+		})
+}
+
+// blocked counts a blocked request and opens an incident once the block
+// rate is exceeded, starting a new count
+func (p *pagerDuty) blocked(ev DecisionEvent) {
+	trip, exceeded := p.blockRate.recordDenial(ev.Timestamp)
+	if !exceeded {
+		return
+	}
+	p.trigger(PagerDutyClassBlockRate, ev.Timestamp,
+		fmt.Sprintf("Trusera blocked %d requests within %s on %s", trip.Denials, p.blockRate.window, p.source),
+		map[string]any{
+			"blocked":        trip.Denials,
+			"window":         p.blockRate.window.String(),
+			"latest_request": ev.Method + " " + ev.Hostname + ev.Path,
+			"matched_rules":  compactMatched(ev.Matched),
+			"reasons":        ev.Reasons,
+		})
+}
+
+// trigger sends a trigger event, logging failures
+func (p *pagerDuty) trigger(class string, at time.Time, summary string, details map[string]any) {
+	event := pagerDutyEvent{
+		RoutingKey:  p.routingKey,
+		EventAction: "trigger",
+		DedupKey:    "trusera/" + p.source + "/" + class,
+		Payload: pagerDutyPayload{
+			Summary:       summary,
+			Source:        p.source,
+			Severity:      p.severity,
+			Timestamp:     at.UTC().Format(time.RFC3339),
+			Component:     "trusera-interceptor",
+			Class:         class,
+			CustomDetails: details,
+		},
+	}
+	if err := p.send(context.Background(), event); err != nil {
+		p.interceptor.logger.Warn("PagerDuty event delivery failed", "class", class, "error", err)
+	}
+}
+
+// send posts event, retrying transient failures and throttling
+func (p *pagerDuty) send(ctx context.Context, event pagerDutyEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	attempts := 1
+	for p.retry.retryable(req, resp, err, attempts) ||
+		(err == nil && resp.StatusCode == http.StatusTooManyRequests && attempts <= p.retry.max) {
+		discardResponse(resp)
+		if !sleepContext(ctx, p.retry.delay(attempts)) {
+			break
+		}
+
+		retryReq, rewindErr := rewindRequest(req)
+		if rewindErr != nil {
+			break
+		}
+		attempts++
+		resp, err = p.client.Do(retryReq)
+	}
+
+	if err != nil {
+		return err
+	}
+	discardResponse(resp)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("pagerduty returned status %d after %d attempts", resp.StatusCode, attempts)
+	}
+	return nil
+}
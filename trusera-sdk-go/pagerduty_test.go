@@ -0,0 +1,108 @@
+package trusera
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pagerDutyReceiver records events, answering the first failures with 429
+type pagerDutyReceiver struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	events   []pagerDutyEvent
+}
+
+func (r *pagerDutyReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.attempts++
+	if r.attempts <= r.failures {
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+	var ev pagerDutyEvent
+	json.NewDecoder(req.Body).Decode(&ev)
+	r.events = append(r.events, ev)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func TestPagerDutyTriggersOnAnomalies(t *testing.T) {
+	receiver := &pagerDutyReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	policyPath := writeTestPolicy(t, t.TempDir(), `
+forbid ( principal, action == Action::"delete", resource )
+when {
+    resource.method == "DELETE";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithCircuitBreaker(3, time.Minute, time.Minute),
+		WithPagerDuty("routing-key",
+			WithPagerDutyURL(server.URL),
+			WithPagerDutyBlockRate(2, time.Minute),
+			WithPagerDutySource("agent-host"),
+			WithPagerDutySeverity("critical"),
+		),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest("DELETE", "https://api.example.com/users/1", nil)
+		if _, err := client.Do(req); err == nil {
+			t.Fatal("expected the DELETE to be blocked")
+		}
+	}
+	si.Close()
+
+	if len(receiver.events) != 2 {
+		t.Fatalf("expected 2 incidents, got %d", len(receiver.events))
+	}
+	sort.Slice(receiver.events, func(i, j int) bool {
+		return receiver.events[i].Payload.Class < receiver.events[j].Payload.Class
+	})
+	rate, trip := receiver.events[0], receiver.events[1]
+
+	if rate.Payload.Class != PagerDutyClassBlockRate || rate.DedupKey != "trusera/agent-host/block_rate" ||
+		rate.Payload.CustomDetails["blocked"] != float64(2) || rate.Payload.CustomDetails["latest_request"] != "DELETE api.example.com/users/1" {
+		t.Errorf("unexpected block rate incident %+v", rate)
+	}
+	if trip.Payload.Class != PagerDutyClassCircuitBreaker || trip.DedupKey != "trusera/agent-host/circuit_breaker" ||
+		trip.Payload.CustomDetails["denials"] != float64(3) {
+		t.Errorf("unexpected circuit breaker incident %+v", trip)
+	}
+	for _, ev := range receiver.events {
+		if ev.RoutingKey != "routing-key" || ev.EventAction != "trigger" || ev.Payload.Severity != "critical" || ev.Payload.Source != "agent-host" {
+			t.Errorf("unexpected event envelope %+v", ev)
+		}
+	}
+}
+
+func TestPagerDutyRetriesThrottling(t *testing.T) {
+	receiver := &pagerDutyReceiver{failures: 1}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	p := &pagerDuty{url: server.URL, client: server.Client(), retry: retryPolicy{max: 2, backoff: time.Millisecond}}
+	if err := p.send(context.Background(), pagerDutyEvent{EventAction: "trigger"}); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if receiver.attempts != 2 || len(receiver.events) != 1 {
+		t.Errorf("expected one retry after the 429, got %d attempts", receiver.attempts)
+	}
+}
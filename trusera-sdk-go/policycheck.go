@@ -0,0 +1,89 @@
+package trusera
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// policyFields are the resource fields requests are evaluated on, see getFieldValue
+var policyFields = map[string]bool{
+	"url": true, "method": true, "hostname": true, "path": true,
+	"secret_count": true, "port": true, "ip": true,
+	"llm_provider": true, "llm_model": true, "model_license": true,
+	"region": true,
+}
+
+// ruleKeywordPattern finds the start of each rule, well-formed or not
+var ruleKeywordPattern = regexp.MustCompile(`\b(forbid|permit)\s*\(`)
+
+// ValidatePolicy parses policyText like ParseCedarPolicy, then reports what
+// the parser would silently skip: rules that are not well formed, conditions
+// it cannot read, several conditions on one line (only the first is used)
+// and fields requests do not have, which never match. Problems are returned
+// together, each with its line number, alongside the rules that did parse.
+func ValidatePolicy(policyText string) ([]PolicyRule, error) {
+	rules, err := ParseCedarPolicy(policyText)
+	if err != nil {
+		return nil, err
+	}
+
+	// Comments are blanked without removing newlines, so offsets keep their lines
+	cleaned := commentPattern.ReplaceAllString(policyText, "")
+	lineOf := func(offset int) int {
+		return strings.Count(cleaned[:offset], "\n") + 1
+	}
+
+	var errs []error
+	spans := rulePattern.FindAllStringSubmatchIndex(cleaned, -1)
+	for _, loc := range ruleKeywordPattern.FindAllStringSubmatchIndex(cleaned, -1) {
+		inRule := false
+		for _, span := range spans {
+			if loc[0] >= span[0] && loc[0] < span[1] {
+				inRule = true
+				break
+			}
+		}
+		if !inRule {
+			keyword := cleaned[loc[2]:loc[3]]
+			errs = append(errs, fmt.Errorf("line %d: malformed %s rule, expected %s ( principal, action == Action::\"name\", resource ) when { ... };",
+				lineOf(loc[0]), keyword, keyword))
+		}
+	}
+
+	for _, span := range spans {
+		// Group 4 is the condition block
+		offset := span[8]
+		for _, line := range strings.SplitAfter(cleaned[span[8]:span[9]], "\n") {
+			cond := strings.TrimSpace(line)
+			n := lineOf(offset)
+			offset += len(line)
+			if cond == "" {
+				continue
+			}
+
+			field := ""
+			if m := rangePattern.FindStringSubmatch(cond); m != nil {
+				field = m[1]
+			} else if m := conditionPattern.FindStringSubmatch(cond); m != nil {
+				field = m[1]
+			} else {
+				errs = append(errs, fmt.Errorf("line %d: unrecognized condition %q", n, cond))
+				continue
+			}
+
+			if strings.Contains(cond, "&&") || strings.Contains(cond, "||") {
+				errs = append(errs, fmt.Errorf("line %d: only the first condition on a line is evaluated; put each condition on its own line", n))
+			}
+			if !policyFields[field] {
+				errs = append(errs, fmt.Errorf("line %d: unknown field resource.%s never matches", n, field))
+			}
+		}
+	}
+
+	if len(rules) == 0 && len(errs) == 0 {
+		errs = append(errs, errors.New("policy has no rules"))
+	}
+	return rules, errors.Join(errs...)
+}
@@ -0,0 +1,67 @@
+package trusera
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePolicy(t *testing.T) {
+	policy := `// Block deploys
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "prod.example.com";
+};
+
+permit ( principal, action == Action::"read", resource )
+when {
+    resource.method == "GET";
+};
+`
+	rules, err := ValidatePolicy(policy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Errorf("expected 2 rules, got %d", len(rules))
+	}
+}
+
+func TestValidatePolicyProblems(t *testing.T) {
+	policy := `forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "prod.example.com";
+    resource.host == "staging.example.com";
+    resource.method == "POST" && resource.path == "/admin";
+    hostname is prod;
+};
+
+forbid ( principal, action = "deploy", resource ) when { resource.method == "DELETE"; };
+`
+	rules, err := ValidatePolicy(policy)
+	if err == nil {
+		t.Fatal("expected problems to be reported")
+	}
+	if len(rules) != 3 {
+		t.Errorf("expected the parsable conditions to be returned, got %d rules", len(rules))
+	}
+	for _, want := range []string{
+		"line 4: unknown field resource.host",
+		"line 5: only the first condition",
+		`line 6: unrecognized condition "hostname is prod;"`,
+		"line 9: malformed forbid rule",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "line 3") {
+		t.Errorf("valid condition reported: %v", err)
+	}
+
+	if _, err := ValidatePolicy("// nothing yet\n"); err == nil || !strings.Contains(err.Error(), "no rules") {
+		t.Errorf("expected an empty policy to be reported, got %v", err)
+	}
+	if _, err := ValidatePolicy(`forbid ( principal, action == Action::"x", resource ) when { resource.ip.isInRange(ip("10.0.0.0/33")); };`); err == nil {
+		t.Error("expected an invalid IP range to fail")
+	}
+}
@@ -0,0 +1,396 @@
+package trusera
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProxyServer is an HTTP forward proxy that enforces the interceptor's Cedar
+// policies, so agents written in other languages can point HTTP_PROXY and
+// HTTPS_PROXY at it and get the same enforcement and logging as Go clients.
+// HTTPS traffic is tunnelled with CONNECT and evaluated on hostname and port,
+// unless TLS interception is enabled with WithProxyMITM.
+type ProxyServer struct {
+	addr            string
+	interceptorOpts []StandaloneOption
+	upstream        http.RoundTripper
+	dialer          *net.Dialer
+	ca              *ProxyCA
+	mitmBypass      []string
+
+	interceptor *StandaloneInterceptor
+	transport   *standaloneTransport
+	server      *http.Server
+
+	tunnelsMu sync.Mutex
+	tunnels   map[net.Conn]struct{}
+	closing   bool
+	tunnelWg  sync.WaitGroup
+}
+
+// ProxyOption configures a ProxyServer
+type ProxyOption func(*ProxyServer)
+
+// WithProxyAddr sets the listen address (default "127.0.0.1:8080")
+func WithProxyAddr(addr string) ProxyOption {
+	return func(p *ProxyServer) {
+		p.addr = addr
+	}
+}
+
+// WithProxyInterceptorOptions configures the policy interceptor used by the proxy
+func WithProxyInterceptorOptions(opts ...StandaloneOption) ProxyOption {
+	return func(p *ProxyServer) {
+		p.interceptorOpts = append(p.interceptorOpts, opts...)
+	}
+}
+
+// WithProxyUpstream sets the transport used to forward plain HTTP requests
+func WithProxyUpstream(rt http.RoundTripper) ProxyOption {
+	return func(p *ProxyServer) {
+		p.upstream = rt
+	}
+}
+
+// NewProxyServer creates a forward proxy. Call ListenAndServe or Serve to start it.
+func NewProxyServer(opts ...ProxyOption) (*ProxyServer, error) {
+	p := &ProxyServer{
+		addr:    "127.0.0.1:8080",
+		dialer:  &net.Dialer{Timeout: 30 * time.Second},
+		tunnels: make(map[net.Conn]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.upstream == nil {
+		// The proxy must not chain to itself through HTTP_PROXY
+		upstream := http.DefaultTransport.(*http.Transport).Clone()
+		upstream.Proxy = nil
+		p.upstream = upstream
+	}
+
+	si, err := NewStandaloneInterceptor(p.interceptorOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	p.interceptor = si
+	p.transport = &standaloneTransport{base: p.upstream, interceptor: si, blockResponse: true}
+	p.server = &http.Server{Addr: p.addr, Handler: p}
+
+	return p, nil
+}
+
+// Interceptor returns the policy interceptor, e.g. to add temporary exceptions
+func (p *ProxyServer) Interceptor() *StandaloneInterceptor {
+	return p.interceptor
+}
+
+// ListenAndServe listens on the configured address and serves proxy requests
+func (p *ProxyServer) ListenAndServe() error {
+	return p.server.ListenAndServe()
+}
+
+// Serve accepts proxy connections on l
+func (p *ProxyServer) Serve(l net.Listener) error {
+	return p.server.Serve(l)
+}
+
+// Shutdown stops accepting connections, waits for active requests, closes open
+// tunnels and then closes the interceptor
+func (p *ProxyServer) Shutdown(ctx context.Context) error {
+	err := p.server.Shutdown(ctx)
+	p.closeTunnels()
+	return errors.Join(err, p.interceptor.CloseContext(ctx))
+}
+
+// Close immediately closes all connections and the interceptor
+func (p *ProxyServer) Close() error {
+	err := p.server.Close()
+	p.closeTunnels()
+	return errors.Join(err, p.interceptor.Close())
+}
+
+// ServeHTTP handles a proxied request
+func (p *ProxyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.handleConnect(w, r)
+		return
+	}
+
+	if !r.URL.IsAbs() {
+		http.Error(w, "proxy requests must use an absolute URL", http.StatusBadRequest)
+		return
+	}
+
+	p.forward(w, r, r.URL)
+}
+
+// forward sends r to target through the policy transport and relays the response
+func (p *ProxyServer) forward(w http.ResponseWriter, r *http.Request, target *url.URL) {
+	out := r.Clone(r.Context())
+	out.URL = target
+	out.RequestURI = ""
+	removeHopHeaders(out.Header)
+
+	resp, err := p.transport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, "proxy error: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	removeHopHeaders(resp.Header)
+	for k, vv := range resp.Header {
+		for _, v := range vv {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	copyFlushing(w, resp.Body)
+}
+
+// handleConnect evaluates a CONNECT tunnel against the policy and splices it
+// to the destination when allowed
+func (p *ProxyServer) handleConnect(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	t := p.transport
+
+	host := r.URL.Host
+	if host == "" {
+		host = r.Host
+	}
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil {
+		hostname, port = host, "443"
+		host = net.JoinHostPort(hostname, port)
+	}
+
+	target := "https://" + host
+	if t.shouldExclude(target) {
+		p.tunnel(w, host, nil)
+		return
+	}
+
+	// Intercepted requests are evaluated individually once decrypted
+	if p.ca != nil && !p.bypassMITM(hostname) {
+		p.intercept(w, host, hostname)
+		return
+	}
+
+	portNum, _ := strconv.Atoi(port)
+	ctx := RequestContext{
+		URL:      target,
+		Method:   http.MethodConnect,
+		Hostname: hostname,
+		Port:     portNum,
+		IP:       literalIP(hostname),
+	}
+
+	decision, exception := p.interceptor.evaluate(ctx, nil, startTime)
+	enforcementAction, blockRequest := p.interceptor.enforce(decision)
+
+	entry := eventLog{
+		Method:            http.MethodConnect,
+		URL:               target,
+		Hostname:          hostname,
+		PolicyDecision:    decision.Decision,
+		EnforcementAction: enforcementAction,
+		Exception:         exception,
+	}
+	if len(decision.Reasons) > 0 {
+		entry.Reasons = strings.Join(decision.Reasons, "; ")
+	}
+
+	if blockRequest {
+		t.record(entry, decision, startTime, time.Since(startTime))
+
+		resp := t.blockedResponse(r, decision, enforcementAction)
+		for k, vv := range resp.Header {
+			w.Header()[k] = vv
+		}
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+		return
+	}
+
+	p.tunnel(w, host, func(status int) {
+		entry.Status = status
+		t.record(entry, decision, startTime, time.Since(startTime))
+	})
+}
+
+// tunnel dials host and splices the client connection to it. established, if
+// set, is called with the status returned to the client.
+func (p *ProxyServer) tunnel(w http.ResponseWriter, host string, established func(status int)) {
+	report := func(status int) {
+		if established != nil {
+			established(status)
+		}
+	}
+
+	upstream, err := p.dialer.Dial("tcp", host)
+	if err != nil {
+		report(http.StatusBadGateway)
+		http.Error(w, "proxy error: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		report(http.StatusInternalServerError)
+		http.Error(w, "connection hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+
+	client, buf, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		report(http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		client.Close()
+		upstream.Close()
+		report(http.StatusBadGateway)
+		return
+	}
+	report(http.StatusOK)
+
+	if !p.trackTunnel(client, upstream) {
+		client.Close()
+		upstream.Close()
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		// Forward anything the client sent before the tunnel was established
+		io.Copy(upstream, io.MultiReader(io.LimitReader(buf, int64(buf.Reader.Buffered())), client))
+		closeWrite(upstream)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(client, upstream)
+		closeWrite(client)
+	}()
+
+	go func() {
+		wg.Wait()
+		client.Close()
+		upstream.Close()
+		p.untrackTunnel(client, upstream)
+	}()
+}
+
+// trackTunnel registers tunnel connections so Close can tear them down. It
+// reports false once the proxy is closing, and the tunnel must not be used.
+func (p *ProxyServer) trackTunnel(conns ...net.Conn) bool {
+	p.tunnelsMu.Lock()
+	defer p.tunnelsMu.Unlock()
+
+	if p.closing {
+		return false
+	}
+	p.tunnelWg.Add(1)
+	for _, c := range conns {
+		p.tunnels[c] = struct{}{}
+	}
+	return true
+}
+
+// untrackTunnel forgets a finished tunnel
+func (p *ProxyServer) untrackTunnel(conns ...net.Conn) {
+	p.tunnelsMu.Lock()
+	defer p.tunnelsMu.Unlock()
+
+	for _, c := range conns {
+		delete(p.tunnels, c)
+	}
+	p.tunnelWg.Done()
+}
+
+// closeTunnels refuses new tunnels, closes open ones and waits for their
+// copy loops to exit
+func (p *ProxyServer) closeTunnels() {
+	p.tunnelsMu.Lock()
+	p.closing = true
+	for c := range p.tunnels {
+		c.Close()
+	}
+	p.tunnelsMu.Unlock()
+
+	p.tunnelWg.Wait()
+}
+
+// closeWrite half-closes a TCP connection so the peer sees EOF
+func closeWrite(c net.Conn) {
+	if tc, ok := c.(interface{ CloseWrite() error }); ok {
+		tc.CloseWrite()
+		return
+	}
+	c.Close()
+}
+
+// hopHeaders are connection-scoped and must not be forwarded by proxies (RFC 9110)
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// removeHopHeaders strips hop-by-hop headers, including those named in Connection
+func removeHopHeaders(h http.Header) {
+	for _, v := range h.Values("Connection") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				h.Del(name)
+			}
+		}
+	}
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+}
+
+// copyFlushing copies the response body, flushing after each chunk so
+// streamed responses such as server-sent events reach the client promptly
+func copyFlushing(w http.ResponseWriter, body io.Reader) {
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 32<<10)
+
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
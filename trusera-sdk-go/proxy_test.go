@@ -0,0 +1,197 @@
+package trusera
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestProxy(t *testing.T, opts ...StandaloneOption) (*ProxyServer, *url.URL, string) {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "events.jsonl")
+	policyPath := writeTestPolicy(t, tmpDir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "blocked.example.com";
+};
+`)
+
+	p, err := NewProxyServer(WithProxyInterceptorOptions(
+		append([]StandaloneOption{
+			WithPolicyFile(policyPath),
+			WithEnforcement(EnforcementBlock),
+			WithLogFile(logPath),
+		}, opts...)...,
+	))
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+
+	srv := httptest.NewServer(p)
+	t.Cleanup(func() {
+		srv.Close()
+		p.Close()
+	})
+
+	proxyURL, _ := url.Parse(srv.URL)
+	return p, proxyURL, logPath
+}
+
+func TestProxyServerHTTP(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Connection") != "" {
+			t.Error("hop-by-hop header forwarded upstream")
+		}
+		w.Header().Set("X-Backend", "yes")
+		io.WriteString(w, "hello from "+r.URL.Path)
+	}))
+	defer backend.Close()
+
+	_, proxyURL, logPath := newTestProxy(t)
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+	req, _ := http.NewRequest("GET", backend.URL+"/data", nil)
+	req.Header.Set("Proxy-Connection", "keep-alive")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("proxied request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || string(body) != "hello from /data" {
+		t.Errorf("unexpected response: %d %q", resp.StatusCode, body)
+	}
+	if resp.Header.Get("X-Backend") != "yes" {
+		t.Error("expected backend headers to be relayed")
+	}
+
+	resp, err = client.Get("http://blocked.example.com/exfil")
+	if err != nil {
+		t.Fatalf("blocked request should get a response: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 for blocked request, got %d", resp.StatusCode)
+	}
+	if !strings.Contains(string(body), "request blocked by Cedar policy") {
+		t.Errorf("unexpected block body: %s", body)
+	}
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 log entries, got %d", len(entries))
+	}
+	if entries[0].EnforcementAction != "allowed" || entries[0].Path != "/data" {
+		t.Errorf("unexpected allowed entry: %+v", entries[0])
+	}
+	if entries[1].EnforcementAction != "blocked" || entries[1].Hostname != "blocked.example.com" {
+		t.Errorf("unexpected blocked entry: %+v", entries[1])
+	}
+}
+
+func TestProxyServerConnect(t *testing.T) {
+	backend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "secure")
+	}))
+	defer backend.Close()
+
+	_, proxyURL, logPath := newTestProxy(t)
+
+	tlsConfig := backend.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: tlsConfig,
+	}}
+
+	resp, err := client.Get(backend.URL + "/")
+	if err != nil {
+		t.Fatalf("tunnelled request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != "secure" {
+		t.Errorf("unexpected tunnelled body: %q", body)
+	}
+
+	blockedClient := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+	if _, err := blockedClient.Get("https://blocked.example.com/"); err == nil {
+		t.Fatal("expected CONNECT to blocked host to fail")
+	}
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 log entries, got %d", len(entries))
+	}
+	if entries[0].Method != http.MethodConnect || entries[0].Status != http.StatusOK || entries[0].EnforcementAction != "allowed" {
+		t.Errorf("unexpected tunnel entry: %+v", entries[0])
+	}
+	if entries[1].Method != http.MethodConnect || entries[1].EnforcementAction != "blocked" {
+		t.Errorf("unexpected blocked tunnel entry: %+v", entries[1])
+	}
+}
+
+func TestProxyServerRefusesTunnelsWhileClosing(t *testing.T) {
+	p, _, _ := newTestProxy(t)
+
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	if !p.trackTunnel(a, b) {
+		t.Fatal("expected tunnel to be tracked before Close")
+	}
+	p.untrackTunnel(a, b)
+
+	p.closeTunnels()
+	if p.trackTunnel(a, b) {
+		t.Error("expected tunnel to be refused once the proxy is closing")
+	}
+}
+
+func TestProxyServerRejectsRelativeURL(t *testing.T) {
+	_, proxyURL, _ := newTestProxy(t)
+
+	resp, err := http.Get(proxyURL.String() + "/direct")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for non-proxy request, got %d", resp.StatusCode)
+	}
+}
+
+func TestRemoveHopHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Connection", "close, X-Session")
+	h.Set("X-Session", "abc")
+	h.Set("Keep-Alive", "timeout=5")
+	h.Set("Content-Type", "application/json")
+
+	removeHopHeaders(h)
+
+	for _, name := range []string{"Connection", "X-Session", "Keep-Alive"} {
+		if h.Get(name) != "" {
+			t.Errorf("expected %s to be removed", name)
+		}
+	}
+	if h.Get("Content-Type") == "" {
+		t.Error("expected end-to-end headers to be kept")
+	}
+}
@@ -0,0 +1,285 @@
+package trusera
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// storedEvent is what purging reads back from a logged event
+type storedEvent struct {
+	timestamp time.Time
+	hostname  string
+}
+
+// lineDecoder reads a stored event from a log line, reporting false for lines
+// it cannot interpret, which are kept
+type lineDecoder func(line []byte) (storedEvent, bool)
+
+// purger is a LogSink whose events are stored locally and can be deleted
+type purger interface {
+	// purge rewrites the log without the events match selects, decoding the
+	// lines the sink is given with decode, and returns how many it deleted
+	purge(decode lineDecoder, match func(storedEvent) bool) (int, error)
+}
+
+// PurgeEvents deletes events older than olderThan from the local logs: the
+// WithLogFile file and sinks created by NewFileSink and NewRotatingSink,
+// also when encrypted or in CEF or LEEF. Files are rewritten in place and
+// emptied segments removed. Events already shipped elsewhere, by HTTP or
+// segment uploads, are not touched. It returns how many events were deleted.
+func (si *StandaloneInterceptor) PurgeEvents(olderThan time.Duration) (int, error) {
+	cutoff := time.Now().Add(-olderThan)
+	return si.purge(func(e storedEvent) bool {
+		return e.timestamp.Before(cutoff)
+	})
+}
+
+// PurgeHostEvents deletes events for hostnames matching pattern (path.Match
+// syntax, e.g. "*.example.com") from the local logs, such as to honour an
+// erasure request. It covers the same logs as PurgeEvents.
+func (si *StandaloneInterceptor) PurgeHostEvents(pattern string) (int, error) {
+	pattern = strings.ToLower(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return 0, fmt.Errorf("invalid host pattern %q: %w", pattern, err)
+	}
+	return si.purge(func(e storedEvent) bool {
+		ok, _ := path.Match(pattern, strings.ToLower(e.hostname))
+		return ok
+	})
+}
+
+// purge deletes the events match selects from every local log. Writes wait
+// until it is done.
+func (si *StandaloneInterceptor) purge(match func(storedEvent) bool) (int, error) {
+	si.logMu.Lock()
+	defer si.logMu.Unlock()
+
+	total := 0
+	var errs []error
+	for _, sink := range si.sinks {
+		if p, ok := sink.(purger); ok {
+			n, err := p.purge(decodeJSONLine, match)
+			total += n
+			errs = append(errs, err)
+		}
+	}
+	return total, errors.Join(errs...)
+}
+
+// decodeJSONLine reads a JSONL event
+func decodeJSONLine(line []byte) (storedEvent, bool) {
+	var e struct {
+		Timestamp string `json:"timestamp"`
+		Hostname  string `json:"hostname"`
+	}
+	if err := json.Unmarshal(line, &e); err != nil {
+		return storedEvent{}, false
+	}
+	t, err := time.Parse(time.RFC3339, e.Timestamp)
+	if err != nil {
+		return storedEvent{}, false
+	}
+	return storedEvent{timestamp: t, hostname: e.Hostname}, true
+}
+
+// decodeSIEMLine reads the time and destination host of a CEF or LEEF line
+func decodeSIEMLine(line []byte) (storedEvent, bool) {
+	var (
+		headerFields  int
+		attrSeparator string
+		timeKey       string
+		parseTime     func(string) (time.Time, error)
+	)
+	switch {
+	case bytes.HasPrefix(line, []byte("CEF:")):
+		headerFields, attrSeparator, timeKey = 7, " ", "rt"
+		parseTime = func(v string) (time.Time, error) {
+			ms, err := strconv.ParseInt(v, 10, 64)
+			return time.UnixMilli(ms), err
+		}
+	case bytes.HasPrefix(line, []byte("LEEF:")):
+		headerFields, attrSeparator, timeKey = 5, "\t", "devTime"
+		parseTime = func(v string) (time.Time, error) {
+			return time.Parse("2006-01-02T15:04:05.000Z", v)
+		}
+	default:
+		return storedEvent{}, false
+	}
+
+	ext, ok := siemExtension(string(line), headerFields)
+	if !ok {
+		return storedEvent{}, false
+	}
+	var e storedEvent
+	found := false
+	for _, attr := range strings.Split(ext, attrSeparator) {
+		key, value, ok := strings.Cut(attr, "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case timeKey:
+			t, err := parseTime(value)
+			if err != nil {
+				return storedEvent{}, false
+			}
+			e.timestamp, found = t, true
+		case "dhost":
+			e.hostname = value
+		}
+	}
+	return e, found
+}
+
+// siemExtension returns what follows the header of a CEF or LEEF line, whose
+// first n fields end in unescaped pipes
+func siemExtension(line string, n int) (string, bool) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '|':
+			if n--; n == 0 {
+				return line[i+1:], true
+			}
+		}
+	}
+	return "", false
+}
+
+// purgeFile rewrites the log at p without the lines decode and match select.
+// The rewrite goes through a temporary file, so readers never see a partial
+// log. It reports how many lines were deleted and how many remain.
+func purgeFile(p string, decode lineDecoder, match func(storedEvent) bool) (removed, kept int, err error) {
+	in, err := os.Open(p)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	out, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+".purge-")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to purge %s: %w", p, err)
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	w := bufio.NewWriter(out)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64<<10), maxLogLine)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if e, ok := decode(bytes.TrimRight(line, "\r")); ok && match(e) {
+			removed++
+			continue
+		}
+		kept++
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, fmt.Errorf("failed to purge %s: %w", p, err)
+	}
+	// Windows cannot replace a file that is open
+	in.Close()
+	if removed == 0 {
+		return 0, kept, nil
+	}
+
+	if err := w.Flush(); err != nil {
+		return 0, 0, fmt.Errorf("failed to purge %s: %w", p, err)
+	}
+	if err := out.Chmod(info.Mode().Perm()); err != nil {
+		return 0, 0, err
+	}
+	if err := out.Close(); err != nil {
+		return 0, 0, err
+	}
+	if err := os.Rename(out.Name(), p); err != nil {
+		return 0, 0, fmt.Errorf("failed to purge %s: %w", p, err)
+	}
+	return removed, kept, nil
+}
+
+// purge rewrites the log file with it closed, then reopens it, so later
+// events are appended to the rewritten file
+func (s *fileSink) purge(decode lineDecoder, match func(storedEvent) bool) (int, error) {
+	if err := s.f.Close(); err != nil {
+		return 0, err
+	}
+	removed, _, err := purgeFile(s.path, decode, match)
+	f, openErr := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if openErr != nil {
+		return removed, errors.Join(err, fmt.Errorf("failed to reopen log file: %w", openErr))
+	}
+	s.f = f
+	return removed, err
+}
+
+// purge rotates the active segment, then rewrites finished segments and
+// deletes those left empty
+func (s *rotatingSink) purge(decode lineDecoder, match func(storedEvent) bool) (int, error) {
+	s.shipMu.Lock()
+	defer s.shipMu.Unlock()
+
+	s.mu.Lock()
+	err := s.rotateLocked()
+	s.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	segments, err := s.segments()
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, p := range segments {
+		removed, kept, err := purgeFile(p, decode, match)
+		if err != nil {
+			return total, err
+		}
+		total += removed
+		if kept == 0 {
+			os.Remove(p)
+		}
+	}
+	return total, nil
+}
+
+// purge decrypts the stored lines before decoding them
+func (s *encryptedSink) purge(decode lineDecoder, match func(storedEvent) bool) (int, error) {
+	p, ok := s.dest.(purger)
+	if !ok {
+		return 0, nil
+	}
+	return p.purge(func(line []byte) (storedEvent, bool) {
+		plain, err := decryptLogLine(s.aead, line)
+		if err != nil {
+			return storedEvent{}, false
+		}
+		return decode(plain)
+	}, match)
+}
+
+// purge decodes the stored lines as CEF or LEEF
+func (s *formatSink) purge(_ lineDecoder, match func(storedEvent) bool) (int, error) {
+	p, ok := s.dest.(purger)
+	if !ok {
+		return 0, nil
+	}
+	return p.purge(decodeSIEMLine, match)
+}
@@ -0,0 +1,137 @@
+package trusera
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testEvent returns a JSONL event for hostname logged at t
+func testEvent(hostname string, t time.Time) []byte {
+	return []byte(fmt.Sprintf(`{"timestamp":%q,"method":"GET","url":"https://%s/","hostname":%q,"policy_decision":"allow","enforcement_action":"allowed"}`+"\n",
+		t.UTC().Format(time.RFC3339), hostname, hostname))
+}
+
+func TestPurgeEvents(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+	old := testEvent("old.example.com", time.Now().Add(-48*time.Hour))
+	if err := os.WriteFile(logPath, old, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	si, err := NewStandaloneInterceptor(WithLogFile(logPath))
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	get := func(url string) {
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	get("https://api.example.com/users")
+
+	n, err := si.PurgeEvents(24 * time.Hour)
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 purged event, got %d: %v", n, err)
+	}
+	// Later events are appended to the rewritten file
+	get("https://api.example.com/orders")
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 2 || entries[0].Path != "/users" || entries[1].Path != "/orders" {
+		t.Errorf("unexpected entries after purge: %+v", entries)
+	}
+	if info, _ := os.Stat(logPath); info.Mode().Perm() != 0600 {
+		t.Errorf("expected the file mode to be kept, got %v", info.Mode())
+	}
+}
+
+func TestPurgeHostEvents(t *testing.T) {
+	dir := t.TempDir()
+	segments, err := NewRotatingSink(filepath.Join(dir, "segments"), WithRotationInterval(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cefFile, err := NewFileSink(filepath.Join(dir, "events.cef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	leefFile, err := NewFileSink(filepath.Join(dir, "events.leef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	si, err := NewStandaloneInterceptor(
+		WithLogSinks(segments, NewCEFSink(cefFile), NewLEEFSink(leefFile)),
+		WithLogEncryption(testLogKey),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	now := time.Now()
+	for _, host := range []string{"api.example.com", "CRM.Example.org", "api.example.com"} {
+		for _, sink := range si.sinks {
+			if err := sink.WriteEvent(testEvent(host, now)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if _, err := si.PurgeHostEvents("[x"); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+	n, err := si.PurgeHostEvents("*.example.org")
+	if err != nil || n != 3 {
+		t.Fatalf("expected 1 event purged from each of 3 logs, got %d: %v", n, err)
+	}
+
+	files, _ := listSegments(filepath.Join(dir, "segments"))
+	files = append(files, filepath.Join(dir, "events.cef"), filepath.Join(dir, "events.leef"))
+	for _, p := range files {
+		data, _ := os.ReadFile(p)
+		var plain bytes.Buffer
+		if err := DecryptLog(&plain, bytes.NewReader(data), testLogKey); err != nil {
+			t.Fatalf("%s: %v", p, err)
+		}
+		if strings.Count(plain.String(), "\n") != 2 || strings.Contains(strings.ToLower(plain.String()), "example.org") {
+			t.Errorf("%s: unexpected log after purge:\n%s", p, plain.String())
+		}
+	}
+
+	// A segment left with no events is removed
+	if n, err := si.PurgeHostEvents("api.example.com"); err != nil || n != 6 {
+		t.Fatalf("expected 6 purged events, got %d: %v", n, err)
+	}
+	if files, _ := listSegments(filepath.Join(dir, "segments")); len(files) != 0 {
+		t.Errorf("expected emptied segments to be removed, got %v", files)
+	}
+}
+
+func TestDecodeSIEMLine(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+	event := `{"timestamp":"2026-03-01T12:30:00Z","url":"https://api.example.com/a|b","hostname":"api.example.com","enforcement_action":"a|b","reasons":"dhost=evil.example.org"}`
+	for _, format := range []func([]byte) (string, error){FormatCEF, FormatLEEF} {
+		line, err := format([]byte(event))
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, ok := decodeSIEMLine([]byte(line))
+		if !ok || !e.timestamp.Equal(at) || e.hostname != "api.example.com" {
+			t.Errorf("decoded %+v, %v from %q", e, ok, line)
+		}
+	}
+
+	if _, ok := decodeSIEMLine([]byte(`{"hostname":"api.example.com"}`)); ok {
+		t.Error("expected a JSON line not to decode")
+	}
+}
@@ -0,0 +1,231 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// WithDiskQueue spools tracked events to JSONL segment files in dir instead of
+// memory, so telemetry survives crashes and network outages. Segments left by
+// a previous process are sent on the next flush. When the queue grows past
+// maxBytes, the oldest segments are deleted and reported as dropped by
+// CloseContext. If dir cannot be used, the client keeps events in memory and
+// Flush reports the error.
+func WithDiskQueue(dir string, maxBytes int64) Option {
+	return func(c *Client) {
+		c.diskDir = dir
+		c.diskMax = maxBytes
+	}
+}
+
+// diskQueue stores queued events as segment files, one flush batch per segment
+type diskQueue struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	active  *os.File
+	pending int
+	seq     int
+	size    int64
+
+	// drainMu serializes flushes so each segment is sent once
+	drainMu sync.Mutex
+	dropped atomic.Int64
+}
+
+// openDiskQueue prepares dir, recovering segments from a previous process
+func openDiskQueue(dir string, maxBytes int64) (*diskQueue, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %w", err)
+	}
+	if err := recoverSegments(dir); err != nil {
+		return nil, err
+	}
+
+	q := &diskQueue{dir: dir, maxBytes: maxBytes}
+
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range segments {
+		if fi, err := os.Stat(p); err == nil {
+			q.size += fi.Size()
+		}
+	}
+	return q, nil
+}
+
+// append writes one encoded event and returns how many events the active
+// segment holds
+func (q *diskQueue) append(line []byte) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.active == nil {
+		f, err := os.OpenFile(filepath.Join(q.dir, newSegmentName(q.seq)), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+		if err != nil {
+			return 0, fmt.Errorf("failed to open queue segment: %w", err)
+		}
+		q.seq++
+		q.active, q.pending = f, 0
+	}
+
+	n, err := q.active.Write(line)
+	q.size += int64(n)
+	if err != nil {
+		return q.pending, err
+	}
+	q.pending++
+
+	q.evictLocked()
+	return q.pending, nil
+}
+
+// evictLocked deletes the oldest finished segments while the queue is over its limit
+func (q *diskQueue) evictLocked() {
+	if q.maxBytes <= 0 || q.size <= q.maxBytes {
+		return
+	}
+
+	segments, err := listSegments(q.dir)
+	if err != nil {
+		return
+	}
+	for _, p := range segments {
+		if q.size <= q.maxBytes {
+			return
+		}
+		data, err := os.ReadFile(p)
+		if err != nil || os.Remove(p) != nil {
+			continue
+		}
+		q.size -= int64(len(data))
+		q.dropped.Add(int64(bytes.Count(data, []byte{'\n'})))
+	}
+}
+
+// seal finishes the active segment so it can be sent
+func (q *diskQueue) seal() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.active == nil {
+		return nil
+	}
+
+	name := q.active.Name()
+	err := q.active.Close()
+	q.active, q.pending = nil, 0
+	if err != nil {
+		return err
+	}
+	return os.Rename(name, strings.TrimSuffix(name, ".part"))
+}
+
+// remove deletes a segment that was sent or rejected
+func (q *diskQueue) remove(p string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if fi, err := os.Stat(p); err == nil && os.Remove(p) == nil {
+		q.size -= fi.Size()
+	}
+}
+
+// close finishes the active segment; unsent segments stay for the next process
+func (q *diskQueue) close() error {
+	return q.seal()
+}
+
+// spool writes event to the disk queue, returning the active segment's length
+func (c *Client) spool(event Event) (int, error) {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	return c.disk.append(append(line, '\n'))
+}
+
+// flushDisk sends finished segments oldest first. A segment that fails
+// transiently stays on disk and ends the pass, so it is sent again on the next
+// flush; a segment the API rejects is moved to the dead-letter file, if any,
+// or deleted. Returns the number of events lost.
+func (c *Client) flushDisk(ctx context.Context, stop <-chan struct{}) (int, error) {
+	q := c.disk
+	q.drainMu.Lock()
+	defer q.drainMu.Unlock()
+
+	if err := q.seal(); err != nil {
+		return 0, fmt.Errorf("failed to seal queue segment: %w", err)
+	}
+
+	segments, err := listSegments(q.dir)
+	if err != nil {
+		return 0, err
+	}
+
+	lost := 0
+	var rejected error
+	for _, p := range segments {
+		events, err := readSegment(p)
+		if err != nil {
+			return lost, err
+		}
+		if len(events) == 0 {
+			q.remove(p)
+			continue
+		}
+
+		body, err := c.batchBody(c.agentID, events)
+		if err != nil {
+			return lost, fmt.Errorf("failed to marshal events: %w", err)
+		}
+
+		err = c.deliver(ctx, stop, eventsPath, body)
+		c.recordDelivery(len(events), err)
+		if err != nil && isTransient(err) {
+			return lost, err
+		}
+		if err != nil {
+			rejected = err
+			if c.deadLetterPath == "" {
+				lost += len(events)
+			} else if dlErr := c.writeDeadLetter(events, err); dlErr != nil {
+				// Keep the segment rather than lose it
+				rejected = errors.Join(err, dlErr)
+				continue
+			} else {
+				c.counters.deadLettered.Add(int64(len(events)))
+			}
+		}
+		q.remove(p)
+	}
+	return lost, rejected
+}
+
+// readSegment parses the events in a segment, skipping a line cut short by a crash
+func readSegment(p string) ([]json.RawMessage, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []json.RawMessage
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		if len(line) == 0 || !json.Valid(line) {
+			continue
+		}
+		events = append(events, json.RawMessage(line))
+	}
+	return events, nil
+}
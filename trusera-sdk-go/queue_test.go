@@ -0,0 +1,162 @@
+package trusera
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// eventCollector is a fake events API that can be switched offline
+type eventCollector struct {
+	mu      sync.Mutex
+	names   []string
+	offline atomic.Bool
+}
+
+func (ec *eventCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if ec.offline.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var payload struct {
+		Events []Event `json:"events"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ec.mu.Lock()
+	for _, ev := range payload.Events {
+		ec.names = append(ec.names, ev.Name)
+	}
+	ec.mu.Unlock()
+}
+
+func (ec *eventCollector) received() []string {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	return append([]string(nil), ec.names...)
+}
+
+func TestDiskQueueSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	collector := &eventCollector{}
+	collector.offline.Store(true)
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithDiskQueue(dir, 0), WithFlushRetry(0, 0))
+	client.Track(NewEvent(EventToolCall, "search"))
+	client.Track(NewEvent(EventToolCall, "fetch"))
+
+	// The API is down: Close fails, but the events stay on disk
+	err := client.Close()
+	if err == nil {
+		t.Fatal("expected close to report the failed flush")
+	}
+	var dropped *DroppedError
+	if errors.As(err, &dropped) {
+		t.Fatalf("expected no dropped events, got %v", err)
+	}
+
+	collector.offline.Store(false)
+
+	client = NewClient("test-key", WithBaseURL(server.URL), WithDiskQueue(dir, 0))
+	defer client.Close()
+	if err := client.Flush(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+
+	if got := collector.received(); len(got) != 2 || got[0] != "search" || got[1] != "fetch" {
+		t.Errorf("expected spooled events in order, got %v", got)
+	}
+	if segments, _ := listSegments(dir); len(segments) != 0 {
+		t.Errorf("expected sent segments to be removed, got %v", segments)
+	}
+}
+
+func TestDiskQueueRecoversCrashedSegment(t *testing.T) {
+	dir := t.TempDir()
+	collector := &eventCollector{}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	// A segment whose writer died mid-line
+	line, _ := json.Marshal(NewEvent(EventToolCall, "search"))
+	partial := append(line, '\n')
+	partial = append(partial, `{"id":"trunc`...)
+	os.WriteFile(filepath.Join(dir, newSegmentName(0)), partial, 0600)
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithDiskQueue(dir, 0))
+	defer client.Close()
+	if err := client.Flush(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+
+	if got := collector.received(); len(got) != 1 || got[0] != "search" {
+		t.Errorf("expected the complete event to be sent, got %v", got)
+	}
+}
+
+func TestDiskQueueEvictsOldestSegments(t *testing.T) {
+	dir := t.TempDir()
+	collector := &eventCollector{}
+	collector.offline.Store(true)
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	line, _ := json.Marshal(NewEvent(EventToolCall, "search"))
+	limit := int64(len(line)+1) * 3
+
+	client := NewClient("test-key",
+		WithBaseURL(server.URL),
+		WithDiskQueue(dir, limit),
+		WithFlushRetry(0, 0),
+		WithFlushInterval(time.Hour),
+	)
+
+	// Each flush fails and seals a segment of two events
+	for i := 0; i < 3; i++ {
+		client.Track(NewEvent(EventToolCall, "search"))
+		client.Track(NewEvent(EventToolCall, "search"))
+		client.Flush()
+	}
+
+	var dropped *DroppedError
+	if err := client.Close(); !errors.As(err, &dropped) || dropped.Dropped != 4 {
+		t.Fatalf("expected 4 evicted events, got %v", err)
+	}
+	if segments, _ := listSegments(dir); len(segments) != 1 {
+		t.Errorf("expected one segment left, got %v", segments)
+	}
+}
+
+func TestDiskQueueUnavailableFallsBackToMemory(t *testing.T) {
+	collector := &eventCollector{}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	// A file where the directory should be
+	path := filepath.Join(t.TempDir(), "queue")
+	os.WriteFile(path, nil, 0600)
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithDiskQueue(path, 0))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	if err := client.Flush(); err == nil {
+		t.Error("expected flush to report the disk queue error")
+	}
+	if got := collector.received(); len(got) != 1 {
+		t.Errorf("expected the event to be sent from memory, got %v", got)
+	}
+}
@@ -0,0 +1,220 @@
+package trusera
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const redactedValue = "[REDACTED]"
+
+// defaultRedactedHeaders lists headers whose values never reach the logs
+var defaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"Api-Key",
+	"X-Goog-Api-Key",
+	"X-Amz-Security-Token",
+}
+
+// defaultRedactedHeaderSet is the lookup form of defaultRedactedHeaders
+var defaultRedactedHeaderSet = newHeaderSet(defaultRedactedHeaders)
+
+// WithCaptureHeaders records request and response headers in the event log.
+// Sensitive values are redacted, see WithRedactHeaders.
+func WithCaptureHeaders(enabled bool) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.captureHeaders = enabled
+	}
+}
+
+// WithRedactHeaders replaces the default set of headers whose values are
+// redacted in the event log (Authorization, Cookie, X-Api-Key, ...)
+func WithRedactHeaders(names ...string) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.redactHeaders = newHeaderSet(names)
+	}
+}
+
+// WithHashRedactedHeaders logs a truncated SHA-256 of redacted header values
+// instead of [REDACTED], so identical credentials can be correlated
+func WithHashRedactedHeaders(enabled bool) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.hashRedacted = enabled
+	}
+}
+
+// newHeaderSet builds a case-insensitive header name set
+func newHeaderSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[http.CanonicalHeaderKey(name)] = true
+	}
+	return set
+}
+
+// redactHeaderValue returns the logged form of a sensitive header value
+func redactHeaderValue(value string, hash bool) string {
+	if !hash {
+		return redactedValue
+	}
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// scrubHeaders flattens headers for logging, redacting sensitive values
+func scrubHeaders(headers http.Header, redact map[string]bool, hash bool) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	out := make(map[string]string, len(headers))
+	for key, values := range headers {
+		value := strings.Join(values, ", ")
+		if redact[http.CanonicalHeaderKey(key)] {
+			value = redactHeaderValue(value, hash)
+		}
+		out[key] = value
+	}
+	return out
+}
+
+// PIIDetector finds one kind of personal data in text
+type PIIDetector struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// Validate optionally filters pattern matches to reduce false positives
+	Validate func(match string) bool
+}
+
+// Built-in PII detectors
+var (
+	PIIEmail = PIIDetector{
+		Name:    "email",
+		Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	}
+	PIICreditCard = PIIDetector{
+		Name:     "credit_card",
+		Pattern:  regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		Validate: luhnValid,
+	}
+	PIIPhone = PIIDetector{
+		Name:    "phone",
+		Pattern: regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{3}\)\s?|\b\d{3}[\s.-])\d{3}[\s.-]\d{4}\b|\+\d{8,15}\b`),
+	}
+)
+
+// DefaultPIIDetectors returns the built-in detectors for emails, credit card
+// numbers and phone numbers
+func DefaultPIIDetectors() []PIIDetector {
+	return []PIIDetector{PIIEmail, PIICreditCard, PIIPhone}
+}
+
+// Redactor replaces personal data with [REDACTED:<detector>] placeholders
+type Redactor struct {
+	detectors []PIIDetector
+}
+
+// NewRedactor creates a redactor from the given detectors, or the defaults when none are given
+func NewRedactor(detectors ...PIIDetector) *Redactor {
+	if len(detectors) == 0 {
+		detectors = DefaultPIIDetectors()
+	}
+	return &Redactor{detectors: detectors}
+}
+
+// WithPIIRedaction scrubs personal data from logged URL query strings and body
+// previews before events are written. With no arguments the default detectors are used.
+func WithPIIRedaction(detectors ...PIIDetector) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.redactor = NewRedactor(detectors...)
+	}
+}
+
+// Redact applies every detector to s in order
+func (r *Redactor) Redact(s string) string {
+	for _, d := range r.detectors {
+		d := d
+		s = d.Pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if d.Validate != nil && !d.Validate(match) {
+				return match
+			}
+			return "[REDACTED:" + d.Name + "]"
+		})
+	}
+	return s
+}
+
+// RedactURL redacts the query string of rawURL, leaving the scheme, host and
+// path intact so policies and logs still identify the endpoint
+func (r *Redactor) RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return rawURL
+	}
+
+	params := strings.Split(u.RawQuery, "&")
+	for i, param := range params {
+		key, value, found := strings.Cut(param, "=")
+		if !found {
+			continue
+		}
+		decoded, err := url.QueryUnescape(value)
+		if err != nil {
+			decoded = value
+		}
+		if redacted := r.Redact(decoded); redacted != decoded {
+			params[i] = key + "=" + url.QueryEscape(redacted)
+		}
+	}
+	u.RawQuery = strings.Join(params, "&")
+	return u.String()
+}
+
+// redactEntry scrubs personal data from the URL, reasons and body previews of an entry
+func (si *StandaloneInterceptor) redactEntry(entry eventLog) eventLog {
+	entry.URL = si.redactor.RedactURL(entry.URL)
+	entry.Reasons = si.redactor.Redact(entry.Reasons)
+
+	if entry.RequestBody != nil && entry.RequestBody.Preview != "" {
+		body := *entry.RequestBody
+		body.Preview = si.redactor.Redact(body.Preview)
+		entry.RequestBody = &body
+	}
+	if entry.ResponseBody != nil && entry.ResponseBody.Preview != "" {
+		body := *entry.ResponseBody
+		body.Preview = si.redactor.Redact(body.Preview)
+		entry.ResponseBody = &body
+	}
+
+	return entry
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum
+func luhnValid(s string) bool {
+	sum, count := 0, 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		count++
+		double = !double
+	}
+	return count >= 13 && count <= 19 && sum%10 == 0
+}
@@ -0,0 +1,195 @@
+package trusera
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCaptureHeadersRedactsDefaults(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+
+	si, err := NewStandaloneInterceptor(
+		WithLogFile(logPath),
+		WithCaptureHeaders(true),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret-session"})
+		w.Header().Set("X-Request-Id", "req-1")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	req, _ := http.NewRequest("GET", backend.URL, nil)
+	req.Header.Set("Authorization", "Bearer sk-live-123")
+	req.Header.Set("x-api-key", "key-456")
+	req.Header.Set("X-Trace", "abc")
+
+	resp, err := si.WrapClient(&http.Client{}).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	entry := readLogEntries(t, logPath)[0]
+
+	if got := entry.RequestHeaders["Authorization"]; got != "[REDACTED]" {
+		t.Errorf("expected Authorization redacted, got %q", got)
+	}
+	if got := entry.RequestHeaders["X-Api-Key"]; got != "[REDACTED]" {
+		t.Errorf("expected X-Api-Key redacted, got %q", got)
+	}
+	if got := entry.RequestHeaders["X-Trace"]; got != "abc" {
+		t.Errorf("expected X-Trace kept, got %q", got)
+	}
+	if got := entry.ResponseHeaders["Set-Cookie"]; got != "[REDACTED]" {
+		t.Errorf("expected Set-Cookie redacted, got %q", got)
+	}
+	if got := entry.ResponseHeaders["X-Request-Id"]; got != "req-1" {
+		t.Errorf("expected X-Request-Id kept, got %q", got)
+	}
+}
+
+func TestRedactHeadersCustomSetWithHashing(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+
+	si, err := NewStandaloneInterceptor(
+		WithLogFile(logPath),
+		WithCaptureHeaders(true),
+		WithRedactHeaders("x-tenant-token"),
+		WithHashRedactedHeaders(true),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", "https://api.example.com/", nil)
+		req.Header.Set("X-Tenant-Token", "tenant-secret")
+		req.Header.Set("Authorization", "Bearer visible")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	entries := readLogEntries(t, logPath)
+	first := entries[0].RequestHeaders["X-Tenant-Token"]
+	if !strings.HasPrefix(first, "sha256:") || strings.Contains(first, "tenant-secret") {
+		t.Errorf("expected hashed value, got %q", first)
+	}
+	if second := entries[1].RequestHeaders["X-Tenant-Token"]; second != first {
+		t.Errorf("expected stable hash across requests, got %q and %q", first, second)
+	}
+	if got := entries[0].RequestHeaders["Authorization"]; got != "Bearer visible" {
+		t.Errorf("expected custom set to replace defaults, got %q", got)
+	}
+}
+
+func TestHeadersNotCapturedByDefault(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+
+	si, err := NewStandaloneInterceptor(WithLogFile(logPath))
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	req, _ := http.NewRequest("GET", "https://api.example.com/", nil)
+	req.Header.Set("X-Trace", "abc")
+	resp, err := si.WrapClient(&http.Client{Transport: stubTransport{}}).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if entry := readLogEntries(t, logPath)[0]; entry.RequestHeaders != nil {
+		t.Errorf("expected no headers without WithCaptureHeaders, got %v", entry.RequestHeaders)
+	}
+}
+
+func TestRedactorDefaults(t *testing.T) {
+	r := NewRedactor()
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"contact jane.doe@example.com today", "contact [REDACTED:email] today"},
+		{"card 4111 1111 1111 1111 on file", "card [REDACTED:credit_card] on file"},
+		{"call (555) 123-4567 now", "call [REDACTED:phone] now"},
+		{"call +14155552671", "call [REDACTED:phone]"},
+		{"order 1234567890123 shipped", "order 1234567890123 shipped"},
+		{"invoice 4111 1111 1111 1112", "invoice 4111 1111 1111 1112"},
+	}
+
+	for _, tt := range tests {
+		if got := r.Redact(tt.in); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRedactorRedactURL(t *testing.T) {
+	r := NewRedactor(PIIEmail)
+
+	got := r.RedactURL("https://api.example.com/users/lookup?email=jane%40example.com&limit=5")
+	want := "https://api.example.com/users/lookup?email=%5BREDACTED%3Aemail%5D&limit=5"
+	if got != want {
+		t.Errorf("RedactURL = %q, want %q", got, want)
+	}
+
+	unchanged := "https://api.example.com/v1/data?limit=5"
+	if got := r.RedactURL(unchanged); got != unchanged {
+		t.Errorf("expected URL without PII unchanged, got %q", got)
+	}
+}
+
+func TestPIIRedactionInEventLog(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+
+	si, err := NewStandaloneInterceptor(
+		WithLogFile(logPath),
+		WithRequestBodyCapture(1024, 1024),
+		WithPIIRedaction(),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	resp, err := client.Post("https://crm.example.com/search?q=bob@example.com", "text/plain",
+		strings.NewReader("customer bob@example.com paid with 4111-1111-1111-1111"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	entry := readLogEntries(t, logPath)[0]
+	if strings.Contains(entry.URL, "bob@example.com") || !strings.Contains(entry.URL, "crm.example.com/search") {
+		t.Errorf("expected redacted query string, got %q", entry.URL)
+	}
+	if entry.Hostname != "crm.example.com" {
+		t.Errorf("expected hostname untouched, got %q", entry.Hostname)
+	}
+
+	preview := entry.RequestBody.Preview
+	if preview != "customer [REDACTED:email] paid with [REDACTED:credit_card]" {
+		t.Errorf("unexpected redacted preview %q", preview)
+	}
+	if entry.RequestBody.SHA256 != sha256Hex("customer bob@example.com paid with 4111-1111-1111-1111") {
+		t.Error("expected hash to cover the original body")
+	}
+}
@@ -0,0 +1,99 @@
+package trusera
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxRetryDrain bounds how much of a failed response is read so its
+// connection can be reused
+const maxRetryDrain = 64 << 10
+
+// WithRetry retries transient upstream failures up to max times: connection
+// errors and 5xx responses other than 501. The delay starts at backoff and
+// doubles after each attempt. Policy is re-evaluated before every retry, but
+// a denied request counts once toward the circuit breaker. The number of
+// attempts is recorded in the event. Requests whose body cannot be replayed
+// (no GetBody) are not retried.
+func WithRetry(max int, backoff time.Duration) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.retry = retryPolicy{max: max, backoff: backoff}
+	}
+}
+
+// retryPolicy decides whether and when failed attempts are retried
+type retryPolicy struct {
+	max     int
+	backoff time.Duration
+}
+
+// enabled reports whether retries are configured
+func (p retryPolicy) enabled() bool {
+	return p.max > 0
+}
+
+// retryable reports whether the outcome of attempt warrants another try
+func (p retryPolicy) retryable(req *http.Request, resp *http.Response, err error, attempt int) bool {
+	if attempt > p.max {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	if err != nil {
+		// Cancellation and deadlines are the caller's decision, not a transient fault
+		return req.Context().Err() == nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented
+}
+
+// delay returns the wait before the retry following attempt
+func (p retryPolicy) delay(attempt int) time.Duration {
+	return p.backoff << (attempt - 1)
+}
+
+// rewindRequest returns a copy of req with a fresh body for another attempt
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	return retry, nil
+}
+
+// discardResponse drains and closes a response that is being retried
+func discardResponse(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	io.CopyN(io.Discard, resp.Body, maxRetryDrain)
+	resp.Body.Close()
+}
+
+// sleepContext waits for d, returning false if ctx ends first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
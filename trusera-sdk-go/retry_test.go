@@ -0,0 +1,184 @@
+package trusera
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStandaloneRetryTransientFailures(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+
+	var calls atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"prompt":"hi"}` {
+			t.Errorf("attempt %d got body %q", calls.Load()+1, body)
+		}
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer backend.Close()
+
+	si, err := NewStandaloneInterceptor(WithLogFile(logPath), WithRetry(3, time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	client := si.WrapClient(&http.Client{})
+	resp, err := client.Post(backend.URL+"/v1/chat", "application/json", strings.NewReader(`{"prompt":"hi"}`))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Errorf("expected success on the third attempt, got %d after %d calls", resp.StatusCode, calls.Load())
+	}
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 1 {
+		t.Fatalf("expected a single entry for all attempts, got %d", len(entries))
+	}
+	if entries[0].Attempts != 3 || entries[0].Status != http.StatusOK {
+		t.Errorf("unexpected entry: attempts %d status %d", entries[0].Attempts, entries[0].Status)
+	}
+}
+
+func TestStandaloneRetryGivesUp(t *testing.T) {
+	var calls atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path == "/unsupported" {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer backend.Close()
+
+	si, err := NewStandaloneInterceptor(WithRetry(2, time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	client := si.WrapClient(&http.Client{})
+
+	resp, err := client.Get(backend.URL + "/flaky")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || calls.Load() != 3 {
+		t.Errorf("expected the last 502 after 3 attempts, got %d after %d", resp.StatusCode, calls.Load())
+	}
+
+	calls.Store(0)
+	resp, err = client.Get(backend.URL + "/unsupported")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 1 {
+		t.Errorf("expected 501 not to be retried, got %d attempts", calls.Load())
+	}
+}
+
+func TestStandaloneRetryReevaluatesPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "events.jsonl")
+	policyPath := writeTestPolicy(t, tmpDir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.path == "/v1/embeddings";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithLogFile(logPath),
+		WithRetry(3, time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	ex, err := si.AddTemporaryException(`resource.path == "/v1/embeddings"`, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to add exception: %v", err)
+	}
+
+	var calls atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		// The exception is revoked while the first attempt is in flight
+		si.RemoveTemporaryException(ex.ID)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer backend.Close()
+
+	client := si.WrapClient(&http.Client{})
+	if _, err := client.Get(backend.URL + "/v1/embeddings"); err == nil {
+		t.Fatal("expected retry to be blocked once the exception was revoked")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 upstream call, got %d", calls.Load())
+	}
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 1 || entries[0].EnforcementAction != "blocked" || entries[0].Attempts != 1 {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+func TestStandaloneRetryCountsOneDenial(t *testing.T) {
+	policyPath := writeTestPolicy(t, t.TempDir(), `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.path == "/v1/embeddings";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementWarn),
+		WithRetry(3, time.Millisecond),
+		WithCircuitBreaker(2, time.Minute, time.Hour),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	var calls atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer backend.Close()
+
+	resp, err := si.WrapClient(&http.Client{}).Get(backend.URL + "/v1/embeddings")
+	if err != nil {
+		t.Fatalf("warned request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if calls.Load() != 4 {
+		t.Errorf("expected 4 upstream calls, got %d", calls.Load())
+	}
+	if si.CircuitOpen() {
+		t.Error("expected retries of one request to count as a single denial")
+	}
+}
@@ -0,0 +1,69 @@
+package trusera
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Obligations that rewrite allowed requests before they are forwarded.
+// Arguments follow a colon, e.g. @obligation("strip_header:X-Debug-Token").
+const (
+	ObligationStripHeader = "strip_header"
+	ObligationForceHTTPS  = "force_https"
+	ObligationRewriteHost = "rewrite_host"
+)
+
+// applyRewrites returns a clone of req with the rewrite obligations applied and
+// a description of each change made. req is returned untouched when nothing applies.
+// Rewrites that would invalidate an AWS SigV4 signature, of the host or a
+// signed header, are skipped so signed requests such as Bedrock calls still
+// reach the service.
+func applyRewrites(req *http.Request, obligations []string) (*http.Request, []string) {
+	var clone *http.Request
+	var rewrites []string
+	sig, signed := parseSigV4(req)
+
+	// Clone lazily so requests without rewrites are forwarded as-is
+	target := func() *http.Request {
+		if clone == nil {
+			clone = req.Clone(req.Context())
+		}
+		return clone
+	}
+
+	for _, o := range obligations {
+		name, arg, _ := strings.Cut(o, ":")
+		arg = strings.TrimSpace(arg)
+
+		switch name {
+		case ObligationStripHeader:
+			if arg == "" || req.Header.Get(arg) == "" || sig.SignedHeaders[strings.ToLower(arg)] {
+				continue
+			}
+			target().Header.Del(arg)
+			rewrites = append(rewrites, ObligationStripHeader+":"+http.CanonicalHeaderKey(arg))
+
+		case ObligationForceHTTPS:
+			if req.URL.Scheme != "http" {
+				continue
+			}
+			target().URL.Scheme = "https"
+			rewrites = append(rewrites, ObligationForceHTTPS)
+
+		case ObligationRewriteHost:
+			if arg == "" || arg == req.URL.Host || signed {
+				continue
+			}
+			r := target()
+			rewrites = append(rewrites, ObligationRewriteHost+":"+r.URL.Host+"->"+arg)
+			r.URL.Host = arg
+			// Let the transport derive the Host header from the new URL
+			r.Host = ""
+		}
+	}
+
+	if clone == nil {
+		return req, nil
+	}
+	return clone, rewrites
+}
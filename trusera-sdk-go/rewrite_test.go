@@ -0,0 +1,160 @@
+package trusera
+
+import (
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// recordingTransport remembers the last request it forwarded
+type recordingTransport struct {
+	last *http.Request
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.last = req
+	return stubTransport{}.RoundTrip(req)
+}
+
+func TestRewriteObligations(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "events.jsonl")
+	policyPath := writeTestPolicy(t, tmpDir, `
+@obligation("strip_header:X-Debug-Token")
+@obligation("force_https")
+@obligation("rewrite_host:llm-proxy.internal:8443")
+permit ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "api.openai.com";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithLogFile(logPath),
+		WithCaptureHeaders(true),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	base := &recordingTransport{}
+	client := si.WrapClient(&http.Client{Transport: base})
+
+	req, _ := http.NewRequest("GET", "http://api.openai.com/v1/models", nil)
+	req.Header.Set("X-Debug-Token", "secret")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	sent := base.last
+	if sent.URL.String() != "https://llm-proxy.internal:8443/v1/models" {
+		t.Errorf("expected rewritten URL, got %s", sent.URL)
+	}
+	if sent.Header.Get("X-Debug-Token") != "" {
+		t.Error("expected X-Debug-Token to be stripped")
+	}
+	if sent.Header.Get("Accept") != "application/json" {
+		t.Error("expected other headers to be preserved")
+	}
+
+	// The caller's request is never mutated
+	if req.URL.Host != "api.openai.com" || req.Header.Get("X-Debug-Token") != "secret" {
+		t.Errorf("original request was modified: %s %v", req.URL, req.Header)
+	}
+
+	entry := readLogEntries(t, logPath)[0]
+	want := []string{
+		"strip_header:X-Debug-Token",
+		"force_https",
+		"rewrite_host:api.openai.com->llm-proxy.internal:8443",
+	}
+	if !reflect.DeepEqual(entry.Rewrites, want) {
+		t.Errorf("expected rewrites %v, got %v", want, entry.Rewrites)
+	}
+	if entry.URL != "http://api.openai.com/v1/models" {
+		t.Errorf("expected the original URL to be logged, got %s", entry.URL)
+	}
+	if _, ok := entry.RequestHeaders["X-Debug-Token"]; ok {
+		t.Error("expected logged headers to reflect the stripped request")
+	}
+}
+
+func TestRewriteObligationsNoop(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://api.example.com/", nil)
+
+	out, rewrites := applyRewrites(req, []string{
+		ObligationForceHTTPS,
+		ObligationStripHeader + ":Authorization",
+		ObligationRewriteHost + ":api.example.com",
+		"unknown_obligation",
+	})
+
+	if out != req {
+		t.Error("expected request to be returned as-is when nothing applies")
+	}
+	if len(rewrites) != 0 {
+		t.Errorf("expected no rewrites, got %v", rewrites)
+	}
+}
+
+func TestRewriteObligationsSkippedWhenBlocked(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "events.jsonl")
+	policyPath := writeTestPolicy(t, tmpDir, `
+@obligation("force_https")
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "blocked.example.com";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithLogFile(logPath),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	if _, err := client.Get("http://blocked.example.com/"); err == nil {
+		t.Fatal("expected request to be blocked")
+	}
+
+	if entry := readLogEntries(t, logPath)[0]; len(entry.Rewrites) != 0 {
+		t.Errorf("expected no rewrites on blocked request, got %v", entry.Rewrites)
+	}
+}
+
+func TestRewriteObligationsKeepSigV4Signature(t *testing.T) {
+	req, _ := http.NewRequest("POST", "http://bedrock-runtime.eu-west-1.amazonaws.com/model/m/converse", nil)
+	req.Header.Set("Authorization", testSigV4Auth)
+	req.Header.Set("X-Amz-Security-Token", "session")
+	req.Header.Set("X-Debug-Token", "secret")
+
+	out, rewrites := applyRewrites(req, []string{
+		ObligationStripHeader + ":X-Amz-Security-Token",
+		ObligationStripHeader + ":X-Debug-Token",
+		ObligationRewriteHost + ":llm-proxy.internal:8443",
+		ObligationForceHTTPS,
+	})
+
+	// Stripping an unsigned header and changing the scheme leave the
+	// signature valid; the signed token and the host must not change
+	want := []string{"strip_header:X-Debug-Token", "force_https"}
+	if !reflect.DeepEqual(rewrites, want) {
+		t.Errorf("expected rewrites %v, got %v", want, rewrites)
+	}
+	if out.URL.Host != "bedrock-runtime.eu-west-1.amazonaws.com" || out.Header.Get("X-Amz-Security-Token") != "session" {
+		t.Errorf("signed parts of the request changed: %s %v", out.URL, out.Header)
+	}
+}
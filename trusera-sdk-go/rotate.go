@@ -0,0 +1,370 @@
+package trusera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRotationInterval = 5 * time.Minute
+	defaultRotationSize     = 64 << 20
+	defaultLocalRetention   = 100
+	segmentUploadTimeout    = time.Minute
+
+	segmentPrefix     = "events-"
+	segmentExt        = ".jsonl"
+	activeSegmentExt  = ".jsonl.part"
+	segmentTimeLayout = "20060102T150405.000000000Z"
+)
+
+// SegmentUploader ships a finished JSONL segment to durable storage
+type SegmentUploader interface {
+	Upload(ctx context.Context, name string, data []byte) error
+}
+
+// RotatingSinkOption configures a rotating log sink
+type RotatingSinkOption func(*rotatingSink)
+
+// WithRotationInterval sets how often the active segment is closed and
+// uploaded (default 5m)
+func WithRotationInterval(d time.Duration) RotatingSinkOption {
+	return func(s *rotatingSink) {
+		if d > 0 {
+			s.interval = d
+		}
+	}
+}
+
+// WithRotationSize closes the active segment early once it reaches maxBytes
+// (default 64 MiB)
+func WithRotationSize(maxBytes int64) RotatingSinkOption {
+	return func(s *rotatingSink) {
+		if maxBytes > 0 {
+			s.maxBytes = maxBytes
+		}
+	}
+}
+
+// WithSegmentUploader ships finished segments to object storage, deleting
+// them locally once uploaded. Failed uploads are retried on the next rotation.
+func WithSegmentUploader(u SegmentUploader) RotatingSinkOption {
+	return func(s *rotatingSink) {
+		s.uploader = u
+	}
+}
+
+// WithLocalRetention caps how many finished segments are kept on disk
+// (default 100). When exceeded, the oldest segments are deleted even if they
+// were never uploaded, so a storage outage cannot fill the disk.
+func WithLocalRetention(maxSegments int) RotatingSinkOption {
+	return func(s *rotatingSink) {
+		if maxSegments > 0 {
+			s.retention = maxSegments
+		}
+	}
+}
+
+// WithRetentionPeriod deletes finished segments last written more than d
+// ago, uploaded or not, each time the sink rotates. Events can outlive d by
+// up to one rotation interval while their segment is still being written.
+func WithRetentionPeriod(d time.Duration) RotatingSinkOption {
+	return func(s *rotatingSink) {
+		if d > 0 {
+			s.maxAge = d
+		}
+	}
+}
+
+// rotatingSink writes events to time- and size-bounded segment files
+type rotatingSink struct {
+	dir       string
+	interval  time.Duration
+	maxBytes  int64
+	uploader  SegmentUploader
+	retention int
+	maxAge    time.Duration
+
+	// shipMu keeps purges from rewriting segments while they are shipped
+	shipMu sync.Mutex
+
+	mu     sync.Mutex
+	active *os.File
+	size   int64
+	seq    int
+	closed bool
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+
+	// ctx aborts background uploads when CloseContext gives up
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	errMu sync.Mutex
+	err   error
+}
+
+// NewRotatingSink writes events to JSONL segments in dir, rotating them on an
+// interval and by size and optionally uploading finished segments with
+// WithSegmentUploader. Segments left behind by a previous process are picked
+// up and uploaded too, so ephemeral containers with a persistent volume lose
+// nothing on exit. Close rotates and uploads the final segment.
+func NewRotatingSink(dir string, opts ...RotatingSinkOption) (LogSink, error) {
+	s := &rotatingSink{
+		dir:       dir,
+		interval:  defaultRotationInterval,
+		maxBytes:  defaultRotationSize,
+		retention: defaultLocalRetention,
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create segment directory: %w", err)
+	}
+
+	if err := recoverSegments(dir); err != nil {
+		return nil, err
+	}
+
+	go s.run()
+
+	return s, nil
+}
+
+func (s *rotatingSink) WriteEvent(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.New("log sink closed")
+	}
+
+	if s.active == nil {
+		name := newSegmentName(s.seq)
+		s.seq++
+		f, err := os.OpenFile(filepath.Join(s.dir, name), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open segment: %w", err)
+		}
+		s.active, s.size = f, 0
+	}
+
+	n, err := s.active.Write(data)
+	s.size += int64(n)
+	if err != nil {
+		return err
+	}
+
+	if s.size >= s.maxBytes {
+		if err := s.rotateLocked(); err != nil {
+			return err
+		}
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Close rotates the active segment, makes a final upload attempt and returns
+// the last upload error, if any
+func (s *rotatingSink) Close() error {
+	_, err := s.CloseContext(context.Background())
+	return err
+}
+
+// CloseContext is like Close but stops uploading once ctx is done. Segments
+// that could not be uploaded stay on disk for the next process, so none are
+// reported as dropped.
+func (s *rotatingSink) CloseContext(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return 0, nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	close(s.stop)
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		s.cancel()
+		<-s.done
+	}
+	defer s.cancel()
+
+	s.mu.Lock()
+	err := s.rotateLocked()
+	s.mu.Unlock()
+
+	s.ship(ctx)
+
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	return 0, errors.Join(err, s.err)
+}
+
+// rotateLocked closes the active segment and marks it ready for upload
+func (s *rotatingSink) rotateLocked() error {
+	if s.active == nil {
+		return nil
+	}
+
+	name := s.active.Name()
+	err := s.active.Close()
+	s.active = nil
+	if err != nil {
+		return err
+	}
+	return os.Rename(name, strings.TrimSuffix(name, ".part"))
+}
+
+// run rotates on the interval and uploads finished segments
+func (s *rotatingSink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.ship(s.ctx)
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			err := s.rotateLocked()
+			s.mu.Unlock()
+			if err != nil {
+				s.setErr(err)
+			}
+			s.ship(s.ctx)
+		case <-s.wake:
+			s.ship(s.ctx)
+		}
+	}
+}
+
+// ship uploads finished segments oldest first, then enforces local retention.
+// The outcome of the pass becomes the error reported by Close.
+func (s *rotatingSink) ship(ctx context.Context) {
+	s.shipMu.Lock()
+	defer s.shipMu.Unlock()
+
+	segments, err := s.segments()
+	if err != nil {
+		s.setErr(err)
+		return
+	}
+
+	var uploadErr error
+	if s.uploader != nil {
+		remaining := segments[:0]
+		for _, p := range segments {
+			if err := s.upload(ctx, p); err != nil {
+				uploadErr = err
+				remaining = append(remaining, p)
+				continue
+			}
+			os.Remove(p)
+		}
+		segments = remaining
+	}
+	s.setErr(uploadErr)
+
+	if s.maxAge > 0 {
+		segments = s.expire(segments)
+	}
+	for len(segments) > s.retention {
+		os.Remove(segments[0])
+		segments = segments[1:]
+	}
+}
+
+// expire deletes segments older than the retention period and returns the rest
+func (s *rotatingSink) expire(segments []string) []string {
+	cutoff := time.Now().Add(-s.maxAge)
+	remaining := segments[:0]
+	for _, p := range segments {
+		if info, err := os.Stat(p); err == nil && info.ModTime().Before(cutoff) {
+			os.Remove(p)
+			continue
+		}
+		remaining = append(remaining, p)
+	}
+	return remaining
+}
+
+// upload sends one segment file to the uploader
+func (s *rotatingSink) upload(ctx context.Context, p string) error {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, segmentUploadTimeout)
+	defer cancel()
+
+	if err := s.uploader.Upload(ctx, filepath.Base(p), data); err != nil {
+		return fmt.Errorf("failed to upload segment %s: %w", filepath.Base(p), err)
+	}
+	return nil
+}
+
+// segments lists finished segment files, oldest first
+func (s *rotatingSink) segments() ([]string, error) {
+	return listSegments(s.dir)
+}
+
+// recoverSegments finishes segments a previous process was writing when it exited
+func recoverSegments(dir string) error {
+	partial, err := filepath.Glob(filepath.Join(dir, segmentPrefix+"*"+activeSegmentExt))
+	if err != nil {
+		return err
+	}
+	for _, p := range partial {
+		if err := os.Rename(p, strings.TrimSuffix(p, ".part")); err != nil {
+			return fmt.Errorf("failed to recover segment: %w", err)
+		}
+	}
+	return nil
+}
+
+// listSegments returns the finished segment files in dir, oldest first
+func listSegments(dir string) ([]string, error) {
+	segments, err := filepath.Glob(filepath.Join(dir, segmentPrefix+"*"+segmentExt))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(segments)
+	return segments, nil
+}
+
+// newSegmentName returns the file name for a new active segment
+func newSegmentName(seq int) string {
+	return fmt.Sprintf("%s%s-%04d%s", segmentPrefix, time.Now().UTC().Format(segmentTimeLayout), seq, activeSegmentExt)
+}
+
+func (s *rotatingSink) setErr(err error) {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	s.err = err
+}
@@ -0,0 +1,176 @@
+package trusera
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryUploader records uploaded segments, failing while fail is set
+type memoryUploader struct {
+	mu      sync.Mutex
+	fail    bool
+	objects map[string]string
+}
+
+func (u *memoryUploader) Upload(ctx context.Context, name string, data []byte) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.fail {
+		return errors.New("bucket unavailable")
+	}
+	if u.objects == nil {
+		u.objects = make(map[string]string)
+	}
+	u.objects[name] = string(data)
+	return nil
+}
+
+func (u *memoryUploader) names() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	var names []string
+	for name := range u.objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRotatingSinkRotatesBySizeAndUploads(t *testing.T) {
+	dir := t.TempDir()
+	uploader := &memoryUploader{}
+
+	sink, err := NewRotatingSink(dir, WithRotationSize(10), WithRotationInterval(time.Hour), WithSegmentUploader(uploader))
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+
+	for _, line := range []string{"{\"n\":1}\n", "{\"n\":2}\n", "{\"n\":3}\n"} {
+		if err := sink.WriteEvent([]byte(line)); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	names := uploader.names()
+	if len(names) != 2 {
+		t.Fatalf("expected 2 uploaded segments, got %v", names)
+	}
+	if got := uploader.objects[names[0]]; got != "{\"n\":1}\n{\"n\":2}\n" {
+		t.Errorf("unexpected first segment %q", got)
+	}
+	if got := uploader.objects[names[1]]; got != "{\"n\":3}\n" {
+		t.Errorf("unexpected final segment %q", got)
+	}
+
+	if left, _ := os.ReadDir(dir); len(left) != 0 {
+		t.Errorf("expected uploaded segments to be removed, found %d files", len(left))
+	}
+
+	if err := sink.WriteEvent([]byte("{}\n")); err == nil {
+		t.Error("expected error writing to closed sink")
+	}
+}
+
+func TestRotatingSinkRotatesOnInterval(t *testing.T) {
+	uploader := &memoryUploader{}
+
+	sink, err := NewRotatingSink(t.TempDir(), WithRotationInterval(20*time.Millisecond), WithSegmentUploader(uploader))
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	defer sink.Close()
+
+	sink.WriteEvent([]byte("{}\n"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(uploader.names()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("segment was not uploaded on the interval")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRotatingSinkLocalRetention(t *testing.T) {
+	dir := t.TempDir()
+	uploader := &memoryUploader{fail: true}
+
+	sink, err := NewRotatingSink(dir, WithRotationSize(1), WithRotationInterval(time.Hour),
+		WithSegmentUploader(uploader), WithLocalRetention(2))
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		sink.WriteEvent([]byte("{}\n"))
+	}
+
+	err = sink.Close()
+	if err == nil || !strings.Contains(err.Error(), "bucket unavailable") {
+		t.Errorf("expected upload error from close, got %v", err)
+	}
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "events-*.jsonl"))
+	if len(segments) != 2 {
+		t.Errorf("expected 2 retained segments, got %d", len(segments))
+	}
+}
+
+func TestRotatingSinkRetentionPeriod(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "events-20260101T000000.000000000Z-0000.jsonl")
+	recent := filepath.Join(dir, "events-20260101T000000.000000000Z-0001.jsonl")
+	for _, p := range []string{old, recent} {
+		if err := os.WriteFile(p, []byte("{}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	past := time.Now().Add(-2 * time.Hour)
+	os.Chtimes(old, past, past)
+
+	sink, err := NewRotatingSink(dir, WithRetentionPeriod(time.Hour), WithRotationInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	segments, _ := listSegments(dir)
+	if len(segments) != 1 || segments[0] != recent {
+		t.Errorf("expected only the recent segment to be kept, got %v", segments)
+	}
+}
+
+func TestRotatingSinkRecoversPartialSegments(t *testing.T) {
+	dir := t.TempDir()
+	leftover := filepath.Join(dir, "events-20260101T000000.000000000Z-0000.jsonl.part")
+	if err := os.WriteFile(leftover, []byte("{\"old\":true}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	uploader := &memoryUploader{}
+	sink, err := NewRotatingSink(dir, WithSegmentUploader(uploader))
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	if got := uploader.objects["events-20260101T000000.000000000Z-0000.jsonl"]; got != "{\"old\":true}\n" {
+		t.Errorf("expected leftover segment to be uploaded, got %v", uploader.names())
+	}
+}
@@ -0,0 +1,216 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Runtime bundles the event client, the policy interceptor, a wrapped
+// http.Client and an optional BOM recorder behind a single Close
+type Runtime struct {
+	client      *Client
+	interceptor *StandaloneInterceptor
+	httpClient  *http.Client
+	bom         BOMRecorder
+}
+
+// BOMRecorder accumulates an AI Bill of Materials from interception
+// decisions and writes it as JSON. *aibom.Builder implements it.
+type BOMRecorder interface {
+	AddDecision(DecisionEvent)
+	WriteJSON(w io.Writer) error
+}
+
+// RuntimeOption configures a Runtime
+type RuntimeOption func(*runtimeConfig)
+
+// runtimeConfig collects the options forwarded to the underlying components
+type runtimeConfig struct {
+	clientOpts      []Option
+	interceptorOpts []StandaloneOption
+	httpClient      *http.Client
+	bom             BOMRecorder
+}
+
+// WithClientOptions passes options through to the event Client
+func WithClientOptions(opts ...Option) RuntimeOption {
+	return func(c *runtimeConfig) {
+		c.clientOpts = append(c.clientOpts, opts...)
+	}
+}
+
+// WithInterceptorOptions passes options through to the StandaloneInterceptor
+func WithInterceptorOptions(opts ...StandaloneOption) RuntimeOption {
+	return func(c *runtimeConfig) {
+		c.interceptorOpts = append(c.interceptorOpts, opts...)
+	}
+}
+
+// WithBaseHTTPClient sets the http.Client to wrap instead of a new default client
+func WithBaseHTTPClient(client *http.Client) RuntimeOption {
+	return func(c *runtimeConfig) {
+		c.httpClient = client
+	}
+}
+
+// WithBOM records every intercepted request in rec, so the runtime can
+// export or upload a BOM of what the agent used:
+//
+//	rt, err := trusera.Start(apiKey, policyPath, trusera.WithBOM(aibom.NewBuilder("agent-123")))
+func WithBOM(rec BOMRecorder) RuntimeOption {
+	return func(c *runtimeConfig) {
+		c.bom = rec
+	}
+}
+
+// Start sets up policy enforcement and event reporting in one call.
+// If apiKey is empty and TRUSERA_API_KEY is unset, the runtime runs offline
+// without an event client. If policyPath is empty, no policy is loaded.
+// Every intercepted request is also tracked as an api_call event.
+func Start(apiKey, policyPath string, opts ...RuntimeOption) (*Runtime, error) {
+	cfg := &runtimeConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	rt := &Runtime{bom: cfg.bom}
+
+	if apiKey != "" || os.Getenv("TRUSERA_API_KEY") != "" {
+		rt.client = NewClient(apiKey, cfg.clientOpts...)
+	}
+
+	interceptorOpts := make([]StandaloneOption, 0, len(cfg.interceptorOpts)+7)
+	if policyPath != "" {
+		interceptorOpts = append(interceptorOpts, WithPolicyFile(policyPath))
+	}
+	if rt.bom != nil {
+		// Blocked requests never reached their host, so the recorder skips them
+		interceptorOpts = append(interceptorOpts,
+			WithOnAllow(rt.bom.AddDecision),
+			WithOnWarn(rt.bom.AddDecision),
+			WithOnBlock(rt.bom.AddDecision),
+		)
+	}
+	if rt.client != nil {
+		interceptorOpts = append(interceptorOpts,
+			WithOnAllow(rt.trackDecision),
+			WithOnWarn(rt.trackDecision),
+			WithOnBlock(rt.trackDecision),
+		)
+	}
+	interceptorOpts = append(interceptorOpts, cfg.interceptorOpts...)
+
+	si, err := NewStandaloneInterceptor(interceptorOpts...)
+	if err != nil {
+		if rt.client != nil {
+			rt.client.Close()
+		}
+		return nil, err
+	}
+	rt.interceptor = si
+	rt.httpClient = si.WrapClient(cfg.httpClient)
+
+	return rt, nil
+}
+
+// HTTPClient returns the policy-enforcing http.Client
+func (rt *Runtime) HTTPClient() *http.Client {
+	return rt.httpClient
+}
+
+// Client returns the event client, or nil when running offline
+func (rt *Runtime) Client() *Client {
+	return rt.client
+}
+
+// Interceptor returns the underlying StandaloneInterceptor
+func (rt *Runtime) Interceptor() *StandaloneInterceptor {
+	return rt.interceptor
+}
+
+// BOM returns the recorder given to WithBOM, or nil
+func (rt *Runtime) BOM() BOMRecorder {
+	return rt.bom
+}
+
+// ExportBOM writes the BOM recorded so far as JSON. Decision hooks run
+// asynchronously, so requests still completing may be missing until Close.
+func (rt *Runtime) ExportBOM(w io.Writer) error {
+	if rt.bom == nil {
+		return errors.New("no BOM recorder configured, see WithBOM")
+	}
+	return rt.bom.WriteJSON(w)
+}
+
+// UploadBOM sends the BOM recorded so far to the Trusera API with the event
+// client's UploadBOM
+func (rt *Runtime) UploadBOM(ctx context.Context) error {
+	if rt.client == nil {
+		return errors.New("cannot upload BOM without an event client")
+	}
+
+	var buf bytes.Buffer
+	if err := rt.ExportBOM(&buf); err != nil {
+		return err
+	}
+	return rt.client.UploadBOM(ctx, json.RawMessage(buf.Bytes()))
+}
+
+// Close shuts down the interceptor, then flushes and closes the event client
+func (rt *Runtime) Close() error {
+	return rt.CloseContext(context.Background())
+}
+
+// CloseContext shuts down the interceptor, then flushes and closes the event
+// client, giving up once ctx is done
+func (rt *Runtime) CloseContext(ctx context.Context) error {
+	var errs []error
+
+	errs = append(errs, rt.interceptor.CloseContext(ctx))
+	if rt.client != nil {
+		errs = append(errs, rt.client.CloseContext(ctx))
+	}
+
+	return errors.Join(errs...)
+}
+
+// trackDecision forwards an interception decision to the event client
+func (rt *Runtime) trackDecision(ev DecisionEvent) {
+	event := NewEvent(EventAPICall, ev.Method+" "+ev.URL).
+		WithPayload("method", ev.Method).
+		WithPayload("url", ev.URL).
+		WithPayload("blocked", ev.EnforcementAction == "blocked").
+		WithPayload("enforcement_action", ev.EnforcementAction).
+		WithPayload("policy_decision", ev.Decision).
+		WithPayload("duration_ms", ev.Duration.Milliseconds())
+
+	if ev.Status != 0 {
+		event = event.WithPayload("status_code", ev.Status)
+	}
+	if ev.LLMProvider != "" {
+		event = event.WithPayload("llm_provider", ev.LLMProvider)
+	}
+	if ev.LLMModel != "" {
+		event = event.WithPayload("llm_model", ev.LLMModel)
+	}
+	if ev.ModelLicense != "" {
+		event = event.WithPayload("model_license", ev.ModelLicense)
+	}
+	if ev.Decision == "Deny" {
+		event = event.WithPayload("reasons", strings.Join(ev.Reasons, "; "))
+	}
+	if ev.RunID != "" {
+		event = event.WithMetadata("run_id", ev.RunID)
+	}
+	if ev.SessionID != "" {
+		event = event.WithMetadata("session_id", ev.SessionID)
+	}
+
+	rt.client.Track(event)
+}
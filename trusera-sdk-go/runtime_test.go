@@ -0,0 +1,114 @@
+package trusera
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestStartTracksDecisions(t *testing.T) {
+	var mu sync.Mutex
+	var received []Event
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+
+		mu.Lock()
+		received = append(received, payload.Events...)
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	policyPath := writeTestPolicy(t, t.TempDir(), `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "blocked.example.com";
+};
+`)
+
+	rt, err := Start("test-key", policyPath,
+		WithClientOptions(WithBaseURL(api.URL)),
+		WithInterceptorOptions(WithEnforcement(EnforcementBlock)),
+	)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if rt.Client() == nil {
+		t.Fatal("expected event client when an API key is given")
+	}
+
+	resp, err := rt.HTTPClient().Get(backend.URL + "/ok")
+	if err != nil {
+		t.Fatalf("allowed request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if _, err := rt.HTTPClient().Get("https://blocked.example.com/"); err == nil {
+		t.Error("expected blocked request")
+	}
+
+	if err := rt.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(received) != 2 {
+		t.Fatalf("expected 2 events flushed on Close, got %d", len(received))
+	}
+
+	actions := map[string]bool{}
+	for _, ev := range received {
+		if ev.Type != EventAPICall {
+			t.Errorf("expected api_call event, got %s", ev.Type)
+		}
+		actions[ev.Payload["enforcement_action"].(string)] = true
+	}
+	if !actions["allowed"] || !actions["blocked"] {
+		t.Errorf("expected allowed and blocked events, got %v", actions)
+	}
+}
+
+func TestStartOffline(t *testing.T) {
+	t.Setenv("TRUSERA_API_KEY", "")
+
+	rt, err := Start("", "")
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer rt.Close()
+
+	if rt.Client() != nil {
+		t.Error("expected no event client without an API key")
+	}
+	if rt.Interceptor() == nil || rt.HTTPClient() == nil {
+		t.Fatal("expected interceptor and http client")
+	}
+	if _, ok := rt.HTTPClient().Transport.(*standaloneTransport); !ok {
+		t.Error("expected http client to use the standalone transport")
+	}
+	if err := rt.ExportBOM(io.Discard); err == nil {
+		t.Error("expected ExportBOM to fail without WithBOM")
+	}
+}
+
+func TestStartInvalidPolicy(t *testing.T) {
+	if _, err := Start("test-key", "/nonexistent/policy.cedar"); err == nil {
+		t.Fatal("expected error for missing policy file")
+	}
+}
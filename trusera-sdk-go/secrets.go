@@ -0,0 +1,124 @@
+package trusera
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// ObligationBlockSecrets denies matching requests whose body contains credentials
+	ObligationBlockSecrets = "block_secrets"
+
+	defaultSecretScanBytes = 64 << 10
+)
+
+// SecretDetector finds one kind of credential in outbound request bodies
+type SecretDetector struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// Built-in secret detectors
+var (
+	SecretAWSAccessKey = SecretDetector{
+		Name:    "aws_access_key_id",
+		Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	}
+	SecretAWSSecretKey = SecretDetector{
+		Name:    "aws_secret_access_key",
+		Pattern: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}`),
+	}
+	SecretGitHubToken = SecretDetector{
+		Name:    "github_token",
+		Pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{82})\b`),
+	}
+	SecretPrivateKey = SecretDetector{
+		Name:    "private_key",
+		Pattern: regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`),
+	}
+	SecretSlackToken = SecretDetector{
+		Name:    "slack_token",
+		Pattern: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`),
+	}
+	SecretGoogleAPIKey = SecretDetector{
+		Name:    "google_api_key",
+		Pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+	}
+)
+
+// DefaultSecretDetectors returns the built-in credential detectors
+func DefaultSecretDetectors() []SecretDetector {
+	return []SecretDetector{
+		SecretAWSAccessKey,
+		SecretAWSSecretKey,
+		SecretGitHubToken,
+		SecretPrivateKey,
+		SecretSlackToken,
+		SecretGoogleAPIKey,
+	}
+}
+
+// WithSecretScanning scans outbound request bodies for credentials. Findings
+// are listed in the event's secrets_detected field, exposed to policies as
+// resource.secret_count, and deny the request when a matched rule carries
+// @obligation("block_secrets"). With no arguments the default detectors are used.
+// Bodies are read up to the WithRequestBodyCapture limit, or 64 KiB.
+func WithSecretScanning(detectors ...SecretDetector) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		if len(detectors) == 0 {
+			detectors = DefaultSecretDetectors()
+		}
+		si.secretDetectors = detectors
+	}
+}
+
+// scanSecrets returns the names of the detectors that matched data
+func scanSecrets(data []byte, detectors []SecretDetector) []string {
+	var found []string
+	for _, d := range detectors {
+		if d.Pattern.Match(data) {
+			found = append(found, d.Name)
+		}
+	}
+	return found
+}
+
+// redactSecrets masks detected credentials so they never reach the logs
+func redactSecrets(s string, detectors []SecretDetector) string {
+	for _, d := range detectors {
+		s = d.Pattern.ReplaceAllString(s, "[REDACTED:"+d.Name+"]")
+	}
+	return s
+}
+
+// hasObligation reports whether the decision carries the named obligation
+func hasObligation(decision PolicyDecision, name string) bool {
+	for _, o := range decision.Obligations {
+		if o == name {
+			return true
+		}
+	}
+	return false
+}
+
+// applySecretObligation turns the decision into a Deny when secrets were found
+// and a matched rule demands they be blocked
+func applySecretObligation(decision PolicyDecision, found []string) PolicyDecision {
+	if len(found) == 0 || !hasObligation(decision, ObligationBlockSecrets) {
+		return decision
+	}
+
+	reason := fmt.Sprintf("obligation %s: request body contains %s", ObligationBlockSecrets, strings.Join(found, ", "))
+	if decision.Decision == "Deny" {
+		decision.Reasons = append(decision.Reasons, reason)
+		return decision
+	}
+
+	return PolicyDecision{
+		Decision:    "Deny",
+		Reasons:     []string{reason},
+		Matched:     decision.Matched,
+		Obligations: decision.Obligations,
+	}
+}
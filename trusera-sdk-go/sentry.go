@@ -0,0 +1,210 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SentryOption configures Sentry reporting
+type SentryOption func(*sentryReporter)
+
+// WithSentryAgentID tags events with the agent that made the request
+func WithSentryAgentID(id string) SentryOption {
+	return func(r *sentryReporter) {
+		r.agentID = id
+	}
+}
+
+// WithSentryEnvironment sets the environment of events, e.g. "production"
+func WithSentryEnvironment(env string) SentryOption {
+	return func(r *sentryReporter) {
+		r.environment = env
+	}
+}
+
+// WithSentryClient sets the client used to send events (default 10s timeout)
+func WithSentryClient(client *http.Client) SentryOption {
+	return func(r *sentryReporter) {
+		r.client = client
+	}
+}
+
+// WithSentry captures a Sentry event for every blocked request, so policy
+// violations surface in existing error triage. dsn is the project's client
+// key, e.g. "https://<key>@o0.ingest.sentry.io/<project>", and is checked
+// when the interceptor is created. Events are warnings grouped by host and
+// matched rule, tagged with the decision, host and agent, and carry the
+// URL without its query string, the matched rules and reasons. They are sent
+// asynchronously like decision hooks, and failures are logged.
+func WithSentry(dsn string, opts ...SentryOption) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		r := &sentryReporter{
+			dsn:         dsn,
+			client:      &http.Client{Timeout: 10 * time.Second},
+			interceptor: si,
+		}
+		for _, opt := range opts {
+			opt(r)
+		}
+		si.sentry = r
+		si.hooks.onBlock = append(si.hooks.onBlock, r.capture)
+	}
+}
+
+// sentryReporter sends blocked decisions to a Sentry project
+type sentryReporter struct {
+	dsn         string
+	agentID     string
+	environment string
+	client      *http.Client
+
+	// Set by parseDSN
+	envelopeURL string
+	auth        string
+
+	// interceptor provides the logger for failed deliveries
+	interceptor *StandaloneInterceptor
+}
+
+// parseDSN derives the envelope endpoint and auth header from the DSN,
+// "{scheme}://{key}@{host}{/path}/{project}"
+func (r *sentryReporter) parseDSN() error {
+	u, err := url.Parse(r.dsn)
+	if err != nil || u.User == nil || u.User.Username() == "" || u.Host == "" {
+		return fmt.Errorf("invalid Sentry DSN %q", r.dsn)
+	}
+	path, project, _ := cutLast(strings.TrimSuffix(u.Path, "/"), "/")
+	if project == "" {
+		return fmt.Errorf("invalid Sentry DSN %q: missing project ID", r.dsn)
+	}
+
+	r.envelopeURL = fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, path, project)
+	r.auth = fmt.Sprintf("Sentry sentry_version=7, sentry_client=trusera-go/%s, sentry_key=%s", Version, u.User.Username())
+	return nil
+}
+
+// cutLast slices s around the last instance of sep
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// sentryEvent is the part of the Sentry event payload the reporter sets
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Platform    string            `json:"platform"`
+	Level       string            `json:"level"`
+	Logger      string            `json:"logger"`
+	Message     map[string]string `json:"message"`
+	Environment string            `json:"environment,omitempty"`
+	Fingerprint []string          `json:"fingerprint"`
+	Tags        map[string]string `json:"tags"`
+	Extra       map[string]any    `json:"extra"`
+	Request     map[string]string `json:"request"`
+	Contexts    map[string]any    `json:"contexts,omitempty"`
+}
+
+// newSentryEvent describes a blocked decision as a Sentry event
+func (r *sentryReporter) newSentryEvent(ev DecisionEvent) sentryEvent {
+	matched := compactMatched(ev.Matched)
+	rule := ""
+	if len(matched) > 0 {
+		rule = matched[0]
+	}
+
+	// The query string is left out, since it may hold secrets
+	target := ev.URL
+	if u, err := url.Parse(ev.URL); err == nil {
+		u.RawQuery, u.Fragment = "", ""
+		target = u.String()
+	}
+
+	e := sentryEvent{
+		EventID:     generateID(),
+		Timestamp:   ev.Timestamp.UTC().Format(time.RFC3339),
+		Platform:    "go",
+		Level:       "warning",
+		Logger:      "trusera",
+		Message:     map[string]string{"formatted": fmt.Sprintf("Blocked %s %s%s", ev.Method, ev.Hostname, ev.Path)},
+		Environment: r.environment,
+		Fingerprint: []string{"trusera-blocked", ev.Hostname, rule},
+		Tags: map[string]string{
+			"decision":           ev.Decision,
+			"enforcement_action": ev.EnforcementAction,
+			"hostname":           ev.Hostname,
+		},
+		Extra: map[string]any{
+			"url":           target,
+			"matched_rules": matched,
+			"reasons":       ev.Reasons,
+		},
+		Request: map[string]string{"method": ev.Method, "url": target},
+	}
+	tag := func(key, value string) {
+		if value != "" {
+			e.Tags[key] = value
+		}
+	}
+	tag("agent_id", r.agentID)
+	tag("llm_provider", ev.LLMProvider)
+	tag("llm_model", ev.LLMModel)
+	tag("run_id", ev.RunID)
+	tag("session_id", ev.SessionID)
+	if ev.TraceID != "" {
+		e.Contexts = map[string]any{"trace": map[string]string{"trace_id": ev.TraceID, "span_id": ev.SpanID}}
+	}
+	return e
+}
+
+// capture sends a blocked decision as a Sentry event
+func (r *sentryReporter) capture(ev DecisionEvent) {
+	if r.envelopeURL == "" {
+		return
+	}
+	if err := r.send(context.Background(), r.newSentryEvent(ev)); err != nil {
+		r.interceptor.logger.Warn("Sentry event delivery failed", "error", err)
+	}
+}
+
+// send posts event in a Sentry envelope: a header line, an item header line
+// and the event
+func (r *sentryReporter) send(ctx context.Context, event sentryEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	header, _ := json.Marshal(map[string]string{"event_id": event.EventID, "sent_at": time.Now().UTC().Format(time.RFC3339)})
+	item, _ := json.Marshal(map[string]any{"type": "event", "length": len(payload)})
+
+	var body bytes.Buffer
+	for _, line := range [][]byte{header, item, payload} {
+		body.Write(line)
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.envelopeURL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	discardResponse(resp)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry returned status %d", resp.StatusCode)
+	}
+	return nil
+}
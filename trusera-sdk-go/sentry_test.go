@@ -0,0 +1,135 @@
+package trusera
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// sentryReceiver records the envelopes posted to a Sentry project
+type sentryReceiver struct {
+	mu        sync.Mutex
+	paths     []string
+	auth      []string
+	envelopes [][]byte
+}
+
+func (r *sentryReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paths = append(r.paths, req.URL.Path)
+	r.auth = append(r.auth, req.Header.Get("X-Sentry-Auth"))
+	r.envelopes = append(r.envelopes, body)
+}
+
+func TestSentryCapturesBlockedRequests(t *testing.T) {
+	receiver := &sentryReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	policyPath := writeTestPolicy(t, t.TempDir(), `
+forbid ( principal, action == Action::"delete", resource )
+when {
+    resource.method == "DELETE";
+};
+`)
+
+	dsn := strings.Replace(server.URL, "://", "://public-key@", 1) + "/42"
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithSentry(dsn, WithSentryAgentID("agent-1"), WithSentryEnvironment("staging")),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+
+	resp, err := client.Get("https://api.example.com/users")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	req, _ := http.NewRequest("DELETE", "https://api.example.com/users/1?token=secret", nil)
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("expected the DELETE to be blocked")
+	}
+	si.Close()
+
+	if len(receiver.envelopes) != 1 {
+		t.Fatalf("expected 1 event for the blocked request, got %d", len(receiver.envelopes))
+	}
+	if receiver.paths[0] != "/api/42/envelope/" {
+		t.Errorf("unexpected envelope path %q", receiver.paths[0])
+	}
+	if !strings.Contains(receiver.auth[0], "sentry_key=public-key") {
+		t.Errorf("unexpected auth header %q", receiver.auth[0])
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(receiver.envelopes[0]), []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("expected a 3 line envelope, got %d lines", len(lines))
+	}
+	var header struct {
+		EventID string `json:"event_id"`
+	}
+	var item struct {
+		Type   string `json:"type"`
+		Length int    `json:"length"`
+	}
+	var event sentryEvent
+	json.Unmarshal(lines[0], &header)
+	json.Unmarshal(lines[1], &item)
+	if err := json.Unmarshal(lines[2], &event); err != nil {
+		t.Fatalf("invalid event: %v", err)
+	}
+	if header.EventID != event.EventID || item.Type != "event" || item.Length != len(lines[2]) {
+		t.Errorf("unexpected envelope headers %s %s", lines[0], lines[1])
+	}
+
+	if event.Level != "warning" || event.Environment != "staging" || event.Message["formatted"] != "Blocked DELETE api.example.com/users/1" {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if event.Tags["agent_id"] != "agent-1" || event.Tags["hostname"] != "api.example.com" ||
+		event.Tags["decision"] != "Deny" || event.Tags["enforcement_action"] != "blocked" {
+		t.Errorf("unexpected tags %v", event.Tags)
+	}
+	if event.Extra["url"] != "https://api.example.com/users/1" || event.Request["url"] != "https://api.example.com/users/1" {
+		t.Errorf("expected the URL without its query, got %v", event.Extra["url"])
+	}
+	if matched, _ := event.Extra["matched_rules"].([]any); len(matched) != 1 || event.Fingerprint[2] != matched[0] {
+		t.Errorf("expected the matched rule in extra and the fingerprint, got %v and %v", event.Extra["matched_rules"], event.Fingerprint)
+	}
+}
+
+func TestSentryInvalidDSN(t *testing.T) {
+	for _, dsn := range []string{
+		"https://o0.ingest.sentry.io/42",
+		"https://key@o0.ingest.sentry.io/",
+		"://key@host/42",
+	} {
+		if _, err := NewStandaloneInterceptor(WithSentry(dsn)); err == nil {
+			t.Errorf("expected an error for DSN %q", dsn)
+		}
+	}
+}
+
+func TestSentryParseDSN(t *testing.T) {
+	r := &sentryReporter{dsn: "https://abc@sentry.example.com/relay/7"}
+	if err := r.parseDSN(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.envelopeURL != "https://sentry.example.com/relay/api/7/envelope/" {
+		t.Errorf("unexpected envelope URL %q", r.envelopeURL)
+	}
+	if !strings.HasPrefix(r.auth, "Sentry sentry_version=7, sentry_client=trusera-go/"+Version) {
+		t.Errorf("unexpected auth header %q", r.auth)
+	}
+}
@@ -0,0 +1,37 @@
+package trusera
+
+import "context"
+
+type runIDKey struct{}
+
+// WithRunID returns a copy of ctx carrying the ID of an agent run, such as a
+// task or conversation. Requests made with the context record it as run_id.
+func WithRunID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, id)
+}
+
+// RunIDFromContext returns the run ID set with WithRunID, if any
+func RunIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(runIDKey{}).(string)
+	return id
+}
+
+// WithSessionIDFunc derives a session ID, recorded as session_id, from each
+// request's context, e.g. to reuse IDs an agent framework already tracks
+func WithSessionIDFunc(fn func(ctx context.Context) string) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.sessionID = fn
+	}
+}
+
+// groupIDs returns the run and session IDs for a request context
+func (si *StandaloneInterceptor) groupIDs(ctx context.Context) (runID, sessionID string) {
+	if ctx == nil {
+		return "", ""
+	}
+	runID = RunIDFromContext(ctx)
+	if si.sessionID != nil {
+		sessionID = si.sessionID(ctx)
+	}
+	return runID, sessionID
+}
@@ -0,0 +1,74 @@
+package trusera
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+type sessionKey struct{}
+
+func TestStandaloneInterceptorRunAndSessionIDs(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+
+	events := make(chan DecisionEvent, 2)
+	si, err := NewStandaloneInterceptor(
+		WithLogFile(logPath),
+		WithSessionIDFunc(func(ctx context.Context) string {
+			id, _ := ctx.Value(sessionKey{}).(string)
+			return id
+		}),
+		WithOnAllow(func(e DecisionEvent) { events <- e }),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+
+	ctx := WithRunID(context.Background(), "run-42")
+	ctx = context.WithValue(ctx, sessionKey{}, "conv-7")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.example.com/a", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	resp, err = client.Get("https://api.example.com/b")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	si.Close()
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].RunID != "run-42" || entries[0].SessionID != "conv-7" {
+		t.Errorf("unexpected IDs %q %q", entries[0].RunID, entries[0].SessionID)
+	}
+	if entries[1].RunID != "" || entries[1].SessionID != "" {
+		t.Errorf("expected no IDs, got %q %q", entries[1].RunID, entries[1].SessionID)
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		e := <-events
+		seen[e.RunID] = true
+	}
+	if !seen["run-42"] {
+		t.Error("expected hook to see the run ID")
+	}
+}
+
+func TestRunIDFromContext(t *testing.T) {
+	if id := RunIDFromContext(context.Background()); id != "" {
+		t.Errorf("expected empty run ID, got %q", id)
+	}
+	if id := RunIDFromContext(WithRunID(context.Background(), "run-1")); id != "run-1" {
+		t.Errorf("expected run-1, got %q", id)
+	}
+}
@@ -0,0 +1,213 @@
+package trusera
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Header fields of CEF and LEEF lines
+const (
+	siemVendor  = "Trusera"
+	siemProduct = "trusera-sdk-go"
+)
+
+// siemSeverities maps enforcement actions to CEF severities (0-10), which
+// LEEF shares. Unknown actions get the "logged" severity.
+var siemSeverities = map[string]int{
+	"allowed":   1,
+	"logged":    3,
+	"warned":    5,
+	"timed_out": 6,
+	"blocked":   8,
+}
+
+// siemNames describes each enforcement action in the CEF name field
+var siemNames = map[string]string{
+	"allowed":   "Request allowed",
+	"logged":    "Policy denial logged",
+	"warned":    "Policy denial warned",
+	"timed_out": "Request timed out",
+	"blocked":   "Request blocked by policy",
+}
+
+// NewCEFSink writes events to dest as ArcSight CEF lines, for SIEMs that do
+// not ingest JSON. See FormatCEF for the fields. Closing the sink closes dest.
+func NewCEFSink(dest LogSink) LogSink {
+	return &formatSink{dest: dest, format: FormatCEF}
+}
+
+// NewLEEFSink writes events to dest as QRadar LEEF 1.0 lines. See
+// FormatLEEF for the fields. Closing the sink closes dest.
+func NewLEEFSink(dest LogSink) LogSink {
+	return &formatSink{dest: dest, format: FormatLEEF}
+}
+
+// formatSink renders JSONL events in another line format
+type formatSink struct {
+	dest   LogSink
+	format func(event []byte) (string, error)
+}
+
+func (s *formatSink) WriteEvent(data []byte) error {
+	line, err := s.format(data)
+	if err != nil {
+		return err
+	}
+	return s.dest.WriteEvent([]byte(line + "\n"))
+}
+
+func (s *formatSink) Close() error {
+	return s.dest.Close()
+}
+
+// reopen lets SIGHUP reopen a wrapped file sink
+func (s *formatSink) reopen() error {
+	if r, ok := s.dest.(reopener); ok {
+		return r.reopen()
+	}
+	return nil
+}
+
+// siemField is an event attribute in output order. Attributes CEF has no
+// key for go in a custom field with a label, which LEEF uses as the key.
+type siemField struct {
+	key, label, value string
+}
+
+// siemFields maps a JSONL event to its CEF and LEEF attributes. Empty
+// values are left out.
+func siemFields(data []byte) (eventLog, []siemField, error) {
+	var e eventLog
+	if err := json.Unmarshal(data, &e); err != nil {
+		return e, nil, fmt.Errorf("invalid event: %w", err)
+	}
+
+	dst := "dst"
+	if ip := net.ParseIP(e.IP); ip != nil && ip.To4() == nil {
+		dst = "c6a3"
+	}
+	var status string
+	if e.Status != 0 {
+		status = strconv.Itoa(e.Status)
+	}
+	all := []siemField{
+		{"act", "", e.EnforcementAction},
+		{"requestMethod", "", e.Method},
+		{"request", "", e.URL},
+		{"dhost", "", e.Hostname},
+		{dst, "", e.IP},
+		{"reason", "", e.Reasons},
+		{"cs1", "policyDecision", e.PolicyDecision},
+		{"cs2", "llmProvider", e.LLMProvider},
+		{"cs3", "llmModel", e.LLMModel},
+		{"cs4", "traceId", e.TraceID},
+		{"cs5", "runId", e.RunID},
+		{"cs6", "sessionId", e.SessionID},
+		{"cn1", "httpStatus", status},
+		{"cn2", "durationMs", strconv.FormatInt(int64(e.DurationMs), 10)},
+	}
+
+	fields := all[:0]
+	for _, f := range all {
+		if f.value != "" {
+			fields = append(fields, f)
+		}
+	}
+	return e, fields, nil
+}
+
+// siemSeverity returns the severity of an enforcement action
+func siemSeverity(action string) int {
+	if sev, ok := siemSeverities[action]; ok {
+		return sev
+	}
+	return siemSeverities["logged"]
+}
+
+// FormatCEF renders a JSONL interception event as a CEF:0 line without a
+// trailing newline. The signature ID is the enforcement action and the
+// severity rises from 1 for allowed to 8 for blocked requests. Extensions
+// use the standard rt, request, requestMethod, dhost, dst, act and reason
+// keys, with the policy decision, LLM provider and model, trace, run and
+// session IDs in labelled cs1-cs6 fields, and the status and duration in
+// cn1-cn2.
+func FormatCEF(event []byte) (string, error) {
+	e, fields, err := siemFields(event)
+	if err != nil {
+		return "", err
+	}
+
+	var ext []string
+	if t, err := time.Parse(time.RFC3339, e.Timestamp); err == nil {
+		ext = append(ext, "rt="+strconv.FormatInt(t.UnixMilli(), 10))
+	}
+	for _, f := range fields {
+		if f.label != "" {
+			ext = append(ext, f.key+"Label="+f.label)
+		}
+		ext = append(ext, f.key+"="+cefValue(f.value))
+	}
+
+	name := siemNames[e.EnforcementAction]
+	if name == "" {
+		name = "Request " + e.EnforcementAction
+	}
+	return fmt.Sprintf("CEF:0|%s|%s|%s|%s|%s|%d|%s",
+		siemVendor, siemProduct, Version, cefHeader(e.EnforcementAction), cefHeader(name),
+		siemSeverity(e.EnforcementAction), strings.Join(ext, " ")), nil
+}
+
+// FormatLEEF renders a JSONL interception event as a tab-delimited LEEF:1.0
+// line without a trailing newline. The event ID is the enforcement action,
+// cat is the policy decision, devTime is in UTC and sev is the CEF severity.
+// Other attributes use the FormatCEF keys, except that labelled fields are
+// named by their label and both address families go in dst.
+func FormatLEEF(event []byte) (string, error) {
+	e, fields, err := siemFields(event)
+	if err != nil {
+		return "", err
+	}
+
+	var attrs []string
+	if t, err := time.Parse(time.RFC3339, e.Timestamp); err == nil {
+		attrs = append(attrs, "devTime="+t.UTC().Format("2006-01-02T15:04:05.000Z"), "devTimeFormat=yyyy-MM-dd'T'HH:mm:ss.SSSX")
+	}
+	attrs = append(attrs, "cat="+leefValue(e.PolicyDecision), "sev="+strconv.Itoa(siemSeverity(e.EnforcementAction)))
+	for _, f := range fields {
+		key := f.key
+		switch {
+		case f.label != "":
+			key = f.label
+		case key == "c6a3":
+			key = "dst"
+		}
+		attrs = append(attrs, key+"="+leefValue(f.value))
+	}
+	return fmt.Sprintf("LEEF:1.0|%s|%s|%s|%s|%s",
+		siemVendor, siemProduct, Version, cefHeader(e.EnforcementAction), strings.Join(attrs, "\t")), nil
+}
+
+var (
+	cefHeaderEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r", " ", "\n", " ")
+	cefValueEscaper  = strings.NewReplacer(`\`, `\\`, "=", `\=`, "\r", `\r`, "\n", `\n`)
+	leefValueEscaper = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+)
+
+// cefHeader escapes a CEF header field
+func cefHeader(s string) string {
+	return cefHeaderEscaper.Replace(s)
+}
+
+// cefValue escapes a CEF extension value
+func cefValue(s string) string {
+	return cefValueEscaper.Replace(s)
+}
+
+// leefValue keeps a LEEF attribute value from breaking the delimiter
+func leefValue(s string) string {
+	return leefValueEscaper.Replace(s)
+}
@@ -0,0 +1,101 @@
+package trusera
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const siemTestEvent = `{"timestamp":"2024-05-01T12:00:00Z","method":"DELETE","url":"https://api.example.com/users/1","hostname":"api.example.com","path":"/users/1","ip":"203.0.113.7","llm_provider":"openai","duration_ms":12.7,"policy_decision":"Deny","enforcement_action":"blocked","reasons":"forbid: resource.method == DELETE\nsecond|line"}`
+
+func TestFormatCEF(t *testing.T) {
+	line, err := FormatCEF([]byte(siemTestEvent))
+	if err != nil {
+		t.Fatalf("FormatCEF failed: %v", err)
+	}
+	want := "CEF:0|Trusera|trusera-sdk-go|" + Version + "|blocked|Request blocked by policy|8|" +
+		`rt=1714564800000 act=blocked requestMethod=DELETE request=https://api.example.com/users/1 dhost=api.example.com dst=203.0.113.7 ` +
+		`reason=forbid: resource.method \=\= DELETE\nsecond|line cs1Label=policyDecision cs1=Deny cs2Label=llmProvider cs2=openai cn2Label=durationMs cn2=12`
+	if line != want {
+		t.Errorf("got\n%s\nwant\n%s", line, want)
+	}
+
+	// IPv6 destinations and unknown actions
+	line, _ = FormatCEF([]byte(`{"ip":"2001:db8::1","enforcement_action":"queued|x","policy_decision":"Allow","status":200}`))
+	if !strings.Contains(line, `|queued\|x|Request queued\|x|3|`) || !strings.Contains(line, "c6a3=2001:db8::1") ||
+		!strings.Contains(line, "cn1Label=httpStatus cn1=200") {
+		t.Errorf("unexpected line %s", line)
+	}
+
+	if _, err := FormatCEF([]byte("not json")); err == nil {
+		t.Error("expected an error for an invalid event")
+	}
+}
+
+func TestFormatLEEF(t *testing.T) {
+	line, err := FormatLEEF([]byte(siemTestEvent))
+	if err != nil {
+		t.Fatalf("FormatLEEF failed: %v", err)
+	}
+	header, attrs, _ := strings.Cut(line, "|devTime=")
+	if header != "LEEF:1.0|Trusera|trusera-sdk-go|"+Version+"|blocked" {
+		t.Errorf("unexpected header %q", header)
+	}
+	got := strings.Split("devTime="+attrs, "\t")
+	want := []string{
+		"devTime=2024-05-01T12:00:00.000Z",
+		"devTimeFormat=yyyy-MM-dd'T'HH:mm:ss.SSSX",
+		"cat=Deny",
+		"sev=8",
+		"act=blocked",
+		"requestMethod=DELETE",
+		"request=https://api.example.com/users/1",
+		"dhost=api.example.com",
+		"dst=203.0.113.7",
+		"reason=forbid: resource.method == DELETE second|line",
+		"policyDecision=Deny",
+		"llmProvider=openai",
+		"durationMs=12",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got attributes\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSIEMSinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	file, err := NewFileSink(filepath.Join(tmpDir, "events.cef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var leef bytes.Buffer
+	si, err := NewStandaloneInterceptor(WithLogSinks(NewCEFSink(file), NewLEEFSink(NewWriterSink(&leef))))
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	resp, err := client.Get("https://api.example.com/users")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	// The wrapped file sink is reopened on SIGHUP
+	if err := si.ReopenLogs(); err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	si.Close()
+
+	raw, _ := os.ReadFile(filepath.Join(tmpDir, "events.cef"))
+	data := string(raw)
+	if lines := strings.Split(strings.TrimSpace(data), "\n"); len(lines) != 1 || !strings.HasPrefix(lines[0], "CEF:0|Trusera|") ||
+		!strings.Contains(lines[0], "request=https://api.example.com/users") {
+		t.Errorf("unexpected CEF log %q", data)
+	}
+	if !strings.HasPrefix(leef.String(), "LEEF:1.0|Trusera|") || !strings.HasSuffix(leef.String(), "\n") {
+		t.Errorf("unexpected LEEF log %q", leef.String())
+	}
+}
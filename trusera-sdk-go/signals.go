@@ -0,0 +1,77 @@
+package trusera
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// reopener is a LogSink backed by a file that can be reopened after rotation
+type reopener interface {
+	reopen() error
+}
+
+// HandleSignals reloads the policy files and reopens log files whenever the
+// process receives SIGHUP, so the interceptor works with logrotate and
+// `kill -HUP` reloads. Failures are reported through the configured logger
+// and keep the current policy and log file. Handling stops on Close.
+func (si *StandaloneInterceptor) HandleSignals() {
+	si.signalOnce.Do(func() {
+		si.signals = make(chan os.Signal, 1)
+		si.signalsDone = make(chan struct{})
+		signal.Notify(si.signals, syscall.SIGHUP)
+
+		go func() {
+			defer close(si.signalsDone)
+			for range si.signals {
+				if err := si.ReloadPolicy(); err != nil {
+					si.logger.Error("policy reload failed", "error", err)
+				}
+				if err := si.ReopenLogs(); err != nil {
+					si.logger.Error("log reopen failed", "error", err)
+				}
+			}
+		}()
+	})
+}
+
+// stopSignals uninstalls the SIGHUP handler and waits for a running reload
+func (si *StandaloneInterceptor) stopSignals() {
+	if si.signals == nil {
+		return
+	}
+	signal.Stop(si.signals)
+	close(si.signals)
+	<-si.signalsDone
+	si.signals = nil
+}
+
+// ReopenLogs closes and reopens the log file set by WithLogFile and any sink
+// created by NewFileSink. Call it after the files were moved aside by a log
+// rotation tool.
+func (si *StandaloneInterceptor) ReopenLogs() error {
+	si.logMu.Lock()
+	defer si.logMu.Unlock()
+
+	var errs []error
+	for _, sink := range si.sinks {
+		if r, ok := sink.(reopener); ok {
+			errs = append(errs, r.reopen())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reopen replaces the file with a fresh one at the same path. The old file is
+// kept if the new one cannot be opened.
+func (s *fileSink) reopen() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to reopen log file: %w", err)
+	}
+	old := s.f
+	s.f = f
+	return old.Close()
+}
@@ -0,0 +1,29 @@
+package trusera
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReopenLogsKeepsFileOnError(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "logs", "events.jsonl")
+	os.Mkdir(filepath.Dir(logPath), 0755)
+
+	si, err := NewStandaloneInterceptor(WithLogFile(logPath))
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+
+	// Reopening fails once the directory is gone
+	os.RemoveAll(filepath.Dir(logPath))
+	if err := si.ReopenLogs(); err == nil {
+		t.Fatal("expected reopen to fail")
+	}
+
+	// Close after a failed reopen still closes the original file
+	if err := si.Close(); err != nil {
+		t.Errorf("unexpected close error: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+//go:build !windows
+
+package trusera
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestHandleSignalsReloadsAndReopens(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "events.jsonl")
+	policyPath := writeTestPolicy(t, dir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "blocked.example.com";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithLogFile(logPath),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+	si.HandleSignals()
+
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	if _, err := client.Get("https://blocked.example.com/"); err == nil {
+		t.Fatal("expected request to be blocked")
+	}
+
+	// Rotate the log away and relax the policy, then signal
+	rotated := logPath + ".1"
+	if err := os.Rename(logPath, rotated); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(policyPath, []byte(""), 0644)
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(logPath); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("log file was not reopened after SIGHUP")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	resp, err := client.Get("https://blocked.example.com/")
+	if err != nil {
+		t.Fatalf("expected request to be allowed after reload, got %v", err)
+	}
+	resp.Body.Close()
+
+	if entries := readLogEntries(t, rotated); len(entries) != 1 || entries[0].EnforcementAction != "blocked" {
+		t.Errorf("expected the blocked request in the rotated log, got %+v", entries)
+	}
+	if entries := readLogEntries(t, logPath); len(entries) != 1 || entries[0].EnforcementAction != "allowed" {
+		t.Errorf("expected the allowed request in the new log, got %+v", entries)
+	}
+}
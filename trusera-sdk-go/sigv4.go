@@ -0,0 +1,50 @@
+package trusera
+
+import (
+	"net/http"
+	"strings"
+)
+
+// sigV4Algorithm marks requests signed with AWS Signature Version 4
+const sigV4Algorithm = "AWS4-HMAC-SHA256"
+
+// sigV4Signature is the part of a SigV4 signature that constrains how a
+// request may change in flight
+type sigV4Signature struct {
+	Region        string
+	Service       string
+	SignedHeaders map[string]bool // Lower-case names; host is always signed
+}
+
+// parseSigV4 reads the SigV4 signature of req from its Authorization header
+// or, for presigned URLs, its query string
+func parseSigV4(req *http.Request) (sigV4Signature, bool) {
+	var credential, signed string
+	if auth, ok := strings.CutPrefix(req.Header.Get("Authorization"), sigV4Algorithm+" "); ok {
+		for _, part := range strings.Split(auth, ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch key {
+			case "Credential":
+				credential = value
+			case "SignedHeaders":
+				signed = value
+			}
+		}
+	} else if q := req.URL.Query(); q.Get("X-Amz-Algorithm") == sigV4Algorithm {
+		credential, signed = q.Get("X-Amz-Credential"), q.Get("X-Amz-SignedHeaders")
+	} else {
+		return sigV4Signature{}, false
+	}
+
+	// The credential is access-key/date/region/service/aws4_request
+	sig := sigV4Signature{SignedHeaders: map[string]bool{"host": true}}
+	if scope := strings.Split(credential, "/"); len(scope) == 5 {
+		sig.Region, sig.Service = scope[2], scope[3]
+	}
+	for _, name := range strings.Split(signed, ";") {
+		if name != "" {
+			sig.SignedHeaders[strings.ToLower(name)] = true
+		}
+	}
+	return sig, true
+}
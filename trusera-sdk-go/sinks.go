@@ -0,0 +1,282 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultHTTPSinkBatchSize = 100
+	defaultHTTPSinkInterval  = time.Second
+	httpSinkQueueSize        = 4096
+)
+
+// errSinkFull is returned when an asynchronous sink cannot keep up
+var errSinkFull = errors.New("log sink queue full, event dropped")
+
+// LogSink receives JSONL interception events. WriteEvent is called with one
+// newline-terminated JSON object at a time and must not retain data.
+type LogSink interface {
+	WriteEvent(data []byte) error
+	Close() error
+}
+
+// WithLogSinks adds destinations for the JSONL event log, alongside the file
+// set by WithLogFile. Every logged event is written to every sink, so the same
+// stream can go to a file, stdout and a collector at once. Sinks are closed by
+// Close. May be given several times.
+func WithLogSinks(sinks ...LogSink) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.sinks = append(si.sinks, sinks...)
+	}
+}
+
+// fileSink appends events to a file
+type fileSink struct {
+	path string
+	f    *os.File
+}
+
+// NewFileSink opens path for appending, creating it if needed
+func NewFileSink(path string) (LogSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	return &fileSink{path: path, f: f}, nil
+}
+
+func (s *fileSink) WriteEvent(data []byte) error {
+	_, err := s.f.Write(data)
+	return err
+}
+
+func (s *fileSink) Close() error {
+	return s.f.Close()
+}
+
+// writerSink writes events to an io.Writer
+type writerSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink writes events to w, e.g. os.Stdout. Closing the sink does not close w.
+func NewWriterSink(w io.Writer) LogSink {
+	return &writerSink{w: w}
+}
+
+func (s *writerSink) WriteEvent(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.w.Write(data)
+	return err
+}
+
+func (s *writerSink) Close() error {
+	return nil
+}
+
+// HTTPSinkOption configures an HTTP log sink
+type HTTPSinkOption func(*httpSink)
+
+// WithHTTPSinkClient sets the client used to post events (default 10s timeout)
+func WithHTTPSinkClient(client *http.Client) HTTPSinkOption {
+	return func(s *httpSink) {
+		s.client = client
+	}
+}
+
+// WithHTTPSinkHeader adds a header, such as Authorization, to every post
+func WithHTTPSinkHeader(key, value string) HTTPSinkOption {
+	return func(s *httpSink) {
+		s.header.Add(key, value)
+	}
+}
+
+// WithHTTPSinkBatch sets how many events are posted together (default 100)
+// and the longest an event waits before being sent (default 1s)
+func WithHTTPSinkBatch(size int, interval time.Duration) HTTPSinkOption {
+	return func(s *httpSink) {
+		if size > 0 {
+			s.batchSize = size
+		}
+		if interval > 0 {
+			s.interval = interval
+		}
+	}
+}
+
+// httpSink posts batches of events to a collector in the background
+type httpSink struct {
+	url       string
+	client    *http.Client
+	header    http.Header
+	batchSize int
+	interval  time.Duration
+
+	// encode turns a batch of events into a request body of contentType
+	encode      func(events [][]byte) ([]byte, error)
+	contentType string
+
+	// ctx aborts deliveries when CloseContext gives up
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	closed  bool
+	queue   chan []byte
+	done    chan struct{}
+	err     error
+	dropped atomic.Int64
+}
+
+// NewHTTPSink posts events to url as application/x-ndjson batches. Events are
+// queued and sent from a background goroutine so slow collectors never delay
+// requests; when the queue is full events are dropped. Close sends what is left.
+func NewHTTPSink(url string, opts ...HTTPSinkOption) LogSink {
+	return newHTTPSink(url, "application/x-ndjson", func(events [][]byte) ([]byte, error) {
+		return bytes.Join(events, nil), nil
+	}, opts)
+}
+
+// newHTTPSink starts a batching sink posting bodies built by encode
+func newHTTPSink(url, contentType string, encode func([][]byte) ([]byte, error), opts []HTTPSinkOption) *httpSink {
+	s := &httpSink{
+		url:         url,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		header:      make(http.Header),
+		batchSize:   defaultHTTPSinkBatchSize,
+		interval:    defaultHTTPSinkInterval,
+		encode:      encode,
+		contentType: contentType,
+		queue:       make(chan []byte, httpSinkQueueSize),
+		done:        make(chan struct{}),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	go s.run()
+
+	return s
+}
+
+func (s *httpSink) WriteEvent(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.New("log sink closed")
+	}
+
+	select {
+	case s.queue <- append([]byte(nil), data...):
+		return nil
+	default:
+		return errSinkFull
+	}
+}
+
+// Close flushes queued events and returns the last delivery error, if any
+func (s *httpSink) Close() error {
+	_, err := s.CloseContext(context.Background())
+	return err
+}
+
+// CloseContext flushes queued events until ctx is done, then abandons the
+// rest. It returns how many events failed to be delivered.
+func (s *httpSink) CloseContext(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		s.cancel()
+		<-s.done
+	}
+	s.cancel()
+
+	return int(s.dropped.Load()), s.err
+}
+
+// run batches queued events until the queue is closed
+func (s *httpSink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	var batch [][]byte
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.post(batch); err != nil {
+			s.err = err
+			s.dropped.Add(int64(len(batch)))
+		}
+		batch = nil
+	}
+
+	for {
+		select {
+		case data, ok := <-s.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, data)
+			if len(batch) >= s.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// post sends one batch to the collector
+func (s *httpSink) post(events [][]byte) error {
+	body, err := s.encode(events)
+	if err != nil {
+		return fmt.Errorf("failed to encode events: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create log sink request: %w", err)
+	}
+	for k, vv := range s.header {
+		req.Header[k] = vv
+	}
+	req.Header.Set("Content-Type", s.contentType)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post events: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("log sink returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package trusera
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStandaloneInterceptorLogSinksFanOut(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "events.jsonl")
+
+	var mu sync.Mutex
+	var posted []string
+	var contentType, auth string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		contentType = r.Header.Get("Content-Type")
+		auth = r.Header.Get("Authorization")
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			posted = append(posted, scanner.Text())
+		}
+	}))
+	defer collector.Close()
+
+	var stdout lockedBuffer
+	si, err := NewStandaloneInterceptor(
+		WithLogFile(logPath),
+		WithLogSinks(
+			NewWriterSink(&stdout),
+			NewHTTPSink(collector.URL, WithHTTPSinkHeader("Authorization", "Bearer sink-token")),
+		),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	for _, path := range []string{"/a", "/b"} {
+		resp, err := client.Get("http://api.example.com" + path)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if err := si.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	if entries := readLogEntries(t, logPath); len(entries) != 2 {
+		t.Errorf("expected 2 file entries, got %d", len(entries))
+	}
+
+	if lines := strings.Split(strings.TrimSpace(stdout.String()), "\n"); len(lines) != 2 {
+		t.Errorf("expected 2 writer entries, got %d", len(lines))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(posted) != 2 {
+		t.Fatalf("expected 2 posted entries, got %d", len(posted))
+	}
+	var entry eventLog
+	if err := json.Unmarshal([]byte(posted[1]), &entry); err != nil {
+		t.Fatalf("posted entry is not JSON: %v", err)
+	}
+	if entry.Path != "/b" {
+		t.Errorf("expected path /b, got %s", entry.Path)
+	}
+	if contentType != "application/x-ndjson" {
+		t.Errorf("expected ndjson content type, got %q", contentType)
+	}
+	if auth != "Bearer sink-token" {
+		t.Errorf("expected custom header, got %q", auth)
+	}
+}
+
+func TestHTTPSinkBatching(t *testing.T) {
+	var mu sync.Mutex
+	var batches []int
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		buf.ReadFrom(r.Body)
+		mu.Lock()
+		batches = append(batches, strings.Count(buf.String(), "\n"))
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	sink := NewHTTPSink(collector.URL, WithHTTPSinkBatch(2, time.Hour))
+	for i := 0; i < 5; i++ {
+		if err := sink.WriteEvent([]byte("{}\n")); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(batches) != 3 || batches[0] != 2 || batches[1] != 2 || batches[2] != 1 {
+		t.Errorf("expected batches [2 2 1], got %v", batches)
+	}
+
+	if err := sink.WriteEvent([]byte("{}\n")); err == nil {
+		t.Error("expected error writing to closed sink")
+	}
+}
+
+func TestHTTPSinkReportsFailures(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+
+	sink := NewHTTPSink(collector.URL)
+	sink.WriteEvent([]byte("{}\n"))
+
+	err := sink.Close()
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("expected status error on close, got %v", err)
+	}
+}
+
+func TestWriterSinkDoesNotCloseWriter(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+
+	if err := sink.WriteEvent([]byte("{\"a\":1}\n")); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if buf.String() != "{\"a\":1}\n" {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
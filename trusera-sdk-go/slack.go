@@ -0,0 +1,282 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSlackBlockThreshold = 10
+	defaultSlackBlockWindow    = 5 * time.Minute
+	defaultSlackRateLimit      = time.Minute
+)
+
+// SlackOption configures Slack notifications
+type SlackOption func(*slackNotifier)
+
+// WithSlackBlockThreshold alerts when count requests are blocked within
+// window (default 10 in 5m). A count of 0 disables block alerts.
+func WithSlackBlockThreshold(count int, window time.Duration) SlackOption {
+	return func(n *slackNotifier) {
+		n.threshold, n.window = count, window
+	}
+}
+
+// WithSlackNewHosts sets whether the first request to each host is alerted
+// (default true)
+func WithSlackNewHosts(enabled bool) SlackOption {
+	return func(n *slackNotifier) {
+		n.newHosts = enabled
+	}
+}
+
+// WithSlackRateLimit sends at most one message per interval (default 1m).
+// Alerts raised in between are dropped and counted in the next message.
+func WithSlackRateLimit(interval time.Duration) SlackOption {
+	return func(n *slackNotifier) {
+		n.interval = interval
+	}
+}
+
+// WithSlackClient sets the client used to post messages (default 10s timeout)
+func WithSlackClient(client *http.Client) SlackOption {
+	return func(n *slackNotifier) {
+		n.client = client
+	}
+}
+
+// WithSlack posts to a Slack incoming webhook when blocked requests reach a
+// threshold within a window, or when a host is contacted for the first time.
+// Messages are rate limited so a burst of violations does not flood the
+// channel. They are sent asynchronously like decision hooks, and failures are
+// logged.
+func WithSlack(webhookURL string, opts ...SlackOption) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		n := &slackNotifier{
+			url:         webhookURL,
+			threshold:   defaultSlackBlockThreshold,
+			window:      defaultSlackBlockWindow,
+			newHosts:    true,
+			interval:    defaultSlackRateLimit,
+			client:      &http.Client{Timeout: 10 * time.Second},
+			seen:        make(map[string]bool),
+			interceptor: si,
+		}
+		for _, opt := range opts {
+			opt(n)
+		}
+
+		si.hooks.onBlock = append(si.hooks.onBlock, n.observe)
+		si.hooks.onWarn = append(si.hooks.onWarn, n.observe)
+		si.hooks.onAllow = append(si.hooks.onAllow, n.observe)
+	}
+}
+
+// slackNotifier turns decisions into rate limited Slack alerts
+type slackNotifier struct {
+	url       string
+	threshold int
+	window    time.Duration
+	newHosts  bool
+	interval  time.Duration
+	client    *http.Client
+
+	mu         sync.Mutex
+	blocks     []DecisionEvent // blocked requests within the window
+	seen       map[string]bool // hosts contacted so far
+	lastSent   time.Time
+	suppressed int
+
+	// interceptor provides the logger for failed deliveries
+	interceptor *StandaloneInterceptor
+}
+
+// slackMessage is an incoming webhook payload. Text is the notification
+// fallback for the blocks.
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// observe checks ev against the alert triggers and posts any alert that
+// the rate limit lets through
+func (n *slackNotifier) observe(ev DecisionEvent) {
+	n.mu.Lock()
+	var alerts []slackMessage
+	if n.newHosts && !n.seen[ev.Hostname] {
+		n.seen[ev.Hostname] = true
+		alerts = append(alerts, newHostMessage(ev))
+	}
+	if n.threshold > 0 && ev.EnforcementAction == "blocked" {
+		if msg, ok := n.countBlock(ev); ok {
+			alerts = append(alerts, msg)
+		}
+	}
+	if len(alerts) == 0 {
+		n.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	if !n.lastSent.IsZero() && now.Sub(n.lastSent) < n.interval {
+		n.suppressed += len(alerts)
+		n.mu.Unlock()
+		return
+	}
+	// One message per interval, so any further alerts are dropped too
+	msg := alerts[0]
+	dropped := n.suppressed + len(alerts) - 1
+	n.lastSent, n.suppressed = now, 0
+	n.mu.Unlock()
+
+	if dropped > 0 {
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "context", Elements: []slackText{
+			{"mrkdwn", fmt.Sprintf("%d more alerts suppressed by the rate limit", dropped)},
+		}})
+	}
+	if err := n.send(context.Background(), msg); err != nil {
+		n.interceptor.logger.Warn("Slack notification failed", "error", err)
+	}
+}
+
+// countBlock records a blocked request and returns an alert once the
+// threshold is reached, starting a new count. Callers hold n.mu.
+func (n *slackNotifier) countBlock(ev DecisionEvent) (slackMessage, bool) {
+	cutoff := ev.Timestamp.Add(-n.window)
+	kept := n.blocks[:0]
+	for _, b := range n.blocks {
+		if b.Timestamp.After(cutoff) {
+			kept = append(kept, b)
+		}
+	}
+	n.blocks = append(kept, ev)
+	if len(n.blocks) < n.threshold {
+		return slackMessage{}, false
+	}
+
+	msg := blockThresholdMessage(n.blocks, n.window)
+	n.blocks = nil
+	return msg, true
+}
+
+// blockThresholdMessage summarises the blocked requests that crossed the
+// threshold, with the busiest hosts first
+func blockThresholdMessage(blocks []DecisionEvent, window time.Duration) slackMessage {
+	counts := make(map[string]int)
+	for _, b := range blocks {
+		counts[b.Hostname]++
+	}
+	hosts := make([]string, 0, len(counts))
+	for h := range counts {
+		hosts = append(hosts, h)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		if counts[hosts[i]] != counts[hosts[j]] {
+			return counts[hosts[i]] > counts[hosts[j]]
+		}
+		return hosts[i] < hosts[j]
+	})
+	var top []string
+	for _, h := range hosts[:min(len(hosts), 3)] {
+		top = append(top, fmt.Sprintf("%s (%d)", slackEscape(h), counts[h]))
+	}
+
+	latest := blocks[len(blocks)-1]
+	title := fmt.Sprintf("%d requests blocked in the last %s", len(blocks), window)
+	return slackMessage{
+		Text: title,
+		Blocks: []slackBlock{
+			{Type: "section", Text: &slackText{"mrkdwn", ":no_entry: *" + title + "*"}},
+			{Type: "section", Fields: []slackText{
+				{"mrkdwn", "*Top hosts*\n" + strings.Join(top, ", ")},
+				{"mrkdwn", "*Latest*\n" + slackRequest(latest)},
+				{"mrkdwn", "*Rule*\n" + slackRule(latest)},
+			}},
+		},
+	}
+}
+
+// newHostMessage describes the first request to a host
+func newHostMessage(ev DecisionEvent) slackMessage {
+	title := "First request to " + slackEscape(ev.Hostname)
+	fields := []slackText{
+		{"mrkdwn", "*Request*\n" + slackRequest(ev)},
+		{"mrkdwn", fmt.Sprintf("*Decision*\n%s (%s)", ev.Decision, ev.EnforcementAction)},
+	}
+	if ev.LLMProvider != "" {
+		fields = append(fields, slackText{"mrkdwn", "*LLM*\n" + slackEscape(strings.TrimSuffix(ev.LLMProvider+" "+ev.LLMModel, " "))})
+	}
+	if len(compactMatched(ev.Matched)) > 0 {
+		fields = append(fields, slackText{"mrkdwn", "*Rule*\n" + slackRule(ev)})
+	}
+	return slackMessage{
+		Text: title,
+		Blocks: []slackBlock{
+			{Type: "section", Text: &slackText{"mrkdwn", ":new: *" + title + "*"}},
+			{Type: "section", Fields: fields},
+		},
+	}
+}
+
+// slackRequest formats the method, host and path of ev, leaving out the
+// query string, which may hold secrets
+func slackRequest(ev DecisionEvent) string {
+	return "`" + slackEscape(ev.Method+" "+ev.Hostname+ev.Path) + "`"
+}
+
+// slackRule returns the first rule ev matched, or "none"
+func slackRule(ev DecisionEvent) string {
+	if matched := compactMatched(ev.Matched); len(matched) > 0 {
+		return "`" + slackEscape(matched[0]) + "`"
+	}
+	return "none"
+}
+
+// slackEscaper escapes the characters Slack reserves for links and mentions
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func slackEscape(s string) string {
+	return slackEscaper.Replace(s)
+}
+
+// send posts msg to the incoming webhook
+func (n *slackNotifier) send(ctx context.Context, msg slackMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	discardResponse(resp)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack returned status %d", resp.StatusCode)
+	}
+	return nil
+}
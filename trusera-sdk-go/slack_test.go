@@ -0,0 +1,129 @@
+package trusera
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slackReceiver records the messages posted to an incoming webhook
+type slackReceiver struct {
+	mu       sync.Mutex
+	messages []slackMessage
+}
+
+func (r *slackReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var msg slackMessage
+	json.NewDecoder(req.Body).Decode(&msg)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, msg)
+}
+
+func (r *slackReceiver) texts() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var texts []string
+	for _, m := range r.messages {
+		texts = append(texts, m.Text)
+	}
+	sort.Strings(texts)
+	return texts
+}
+
+func TestSlackNotifiesNewHostsAndBlockBursts(t *testing.T) {
+	receiver := &slackReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	policyPath := writeTestPolicy(t, t.TempDir(), `
+forbid ( principal, action == Action::"delete", resource )
+when {
+    resource.method == "DELETE";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithSlack(server.URL, WithSlackBlockThreshold(2, time.Minute), WithSlackRateLimit(0)),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+
+	for _, r := range []struct{ method, url string }{
+		{"GET", "https://api.openai.com/v1/models"},
+		{"GET", "https://api.openai.com/v1/models"},
+		{"DELETE", "https://api.example.com/users/1"},
+		{"DELETE", "https://api.example.com/users/2?token=secret"},
+	} {
+		req, _ := http.NewRequest(r.method, r.url, nil)
+		if resp, err := client.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}
+	si.Close()
+
+	want := []string{
+		"2 requests blocked in the last 1m0s",
+		"First request to api.example.com",
+		"First request to api.openai.com",
+	}
+	if got := receiver.texts(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("got messages %q, want %q", got, want)
+	}
+	for _, m := range receiver.messages {
+		body, _ := json.Marshal(m)
+		if strings.Contains(string(body), "token=secret") {
+			t.Errorf("message leaks the query string: %s", body)
+		}
+		if m.Text == want[0] && !strings.Contains(string(body), "api.example.com (2)") {
+			t.Errorf("expected the top hosts in the burst alert: %s", body)
+		}
+	}
+}
+
+func TestSlackRateLimit(t *testing.T) {
+	receiver := &slackReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	si, err := NewStandaloneInterceptor()
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+	n := &slackNotifier{
+		url:         server.URL,
+		newHosts:    true,
+		interval:    time.Hour,
+		client:      server.Client(),
+		seen:        make(map[string]bool),
+		interceptor: si,
+	}
+	for _, host := range []string{"a.example.com", "b.example.com", "c.example.com"} {
+		n.observe(DecisionEvent{Hostname: host, Method: "GET", Decision: "Allow", EnforcementAction: "allowed"})
+	}
+	if len(receiver.messages) != 1 {
+		t.Fatalf("expected the rate limit to hold back 2 alerts, got %d messages", len(receiver.messages))
+	}
+
+	// Once the interval has passed, the next message counts the dropped alerts
+	n.lastSent = time.Now().Add(-2 * time.Hour)
+	n.observe(DecisionEvent{Hostname: "d.example.com", Method: "GET", Decision: "Allow", EnforcementAction: "allowed"})
+	if len(receiver.messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(receiver.messages))
+	}
+	last := receiver.messages[1].Blocks
+	if ctx := last[len(last)-1]; ctx.Type != "context" || ctx.Elements[0].Text != "2 more alerts suppressed by the rate limit" {
+		t.Errorf("unexpected last block %+v", ctx)
+	}
+}
@@ -0,0 +1,89 @@
+package trusera
+
+import (
+	"sync"
+	"time"
+)
+
+// Span times one step of an agent workflow. End tracks an EventSpan whose ID
+// is the span ID and whose ParentID is the enclosing span, so workflows can be
+// rebuilt as trees from the event stream. Other events join a span with
+// Event.WithParent(span.ID()).
+type Span struct {
+	client   *Client
+	id       string
+	parentID string
+	name     string
+	start    time.Time
+
+	mu       sync.Mutex
+	metadata map[string]any
+	err      error
+	ended    bool
+}
+
+// StartSpan starts a root span
+func (c *Client) StartSpan(name string) *Span {
+	return c.startSpan(name, "")
+}
+
+// StartSpan starts a child span of s
+func (s *Span) StartSpan(name string) *Span {
+	return s.client.startSpan(name, s.id)
+}
+
+func (c *Client) startSpan(name, parentID string) *Span {
+	return &Span{
+		client:   c,
+		id:       generateID(),
+		parentID: parentID,
+		name:     name,
+		start:    time.Now(),
+	}
+}
+
+// ID returns the span ID
+func (s *Span) ID() string {
+	return s.id
+}
+
+// SetMetadata adds metadata to the span's event
+func (s *Span) SetMetadata(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.metadata == nil {
+		s.metadata = make(map[string]any)
+	}
+	s.metadata[key] = value
+}
+
+// SetError marks the span as failed
+func (s *Span) SetError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// End tracks the span's event. Only the first call has an effect.
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+
+	e := NewEvent(EventSpan, s.name).
+		WithDuration(time.Since(s.start)).
+		WithError(s.err).
+		WithParent(s.parentID)
+	e.ID = s.id
+	e.Timestamp = s.start.UTC().Format(time.RFC3339)
+	for k, v := range s.metadata {
+		e.Metadata[k] = v
+	}
+	s.mu.Unlock()
+
+	s.client.Track(e)
+}
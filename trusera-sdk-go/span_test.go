@@ -0,0 +1,61 @@
+package trusera
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestSpans(t *testing.T) {
+	var mu sync.Mutex
+	var events []Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		events = append(events, payload.Events...)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	root := client.StartSpan("answer_question")
+	child := root.StartSpan("search")
+	child.SetMetadata("query", "ai security")
+	client.Track(NewEvent(EventToolCall, "web_search").WithParent(child.ID()))
+	child.SetError(errors.New("no results"))
+	child.End()
+	child.End() // ignored
+	root.End()
+
+	if err := client.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+
+	tool, childEv, rootEv := events[0], events[1], events[2]
+	if tool.ParentID != child.ID() {
+		t.Errorf("expected tool event to join the child span, got parent %q", tool.ParentID)
+	}
+	if childEv.Type != EventSpan || childEv.ID != child.ID() || childEv.ParentID != root.ID() {
+		t.Errorf("unexpected child span event %+v", childEv)
+	}
+	if childEv.Error != "no results" || childEv.Metadata["query"] != "ai security" {
+		t.Errorf("expected error and metadata on child span, got %+v", childEv)
+	}
+	if rootEv.ID != root.ID() || rootEv.ParentID != "" || rootEv.Name != "answer_question" {
+		t.Errorf("unexpected root span event %+v", rootEv)
+	}
+}
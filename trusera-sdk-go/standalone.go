@@ -1,13 +1,25 @@
 package trusera
 
 import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -20,135 +32,467 @@ const (
 	EnforcementBlock EnforcementAction = "block"
 )
 
+// Response headers carrying the policy outcome when WithDecisionHeaders is enabled
+const (
+	HeaderDecision      = "X-Trusera-Decision"
+	HeaderEnforcement   = "X-Trusera-Enforcement"
+	HeaderMatchedPolicy = "X-Trusera-Matched-Policy"
+)
+
 // StandaloneInterceptor intercepts HTTP requests and evaluates them against Cedar policies
 type StandaloneInterceptor struct {
 	policyFile      string
 	enforcement     EnforcementAction
 	logFile         string
 	excludePatterns []string
+	includePatterns []string
+	requestTimeout  time.Duration
+	retry           retryPolicy
+	concurrency     chan struct{}
+	sampleRate      float64
+	decisionHeaders bool
+	blockResponse   bool
+	requestCapture  bodyCaptureConfig
+	responseCapture bodyCaptureConfig
+	captureHeaders  bool
+	redactHeaders   map[string]bool
+	hashRedacted    bool
+	redactor        *Redactor
+	secretDetectors []SecretDetector
+	modelLicense    ModelLicenseFunc
+	tracer          Tracer
+	llmGateways     []string
+	policyMu        sync.RWMutex
+	policyLoadedAt  time.Time
 	rules           []PolicyRule
+	hostPolicies    []hostPolicy
 	logMu           sync.Mutex
-	logWriter       *os.File
+	sinks           []LogSink
+	logKey          []byte
+	signingKey      ed25519.PrivateKey
+	signingKeyID    string
+	droppedEvents   atomic.Int64
+	counters        decisionCounters
+	controlSocket   string
+	control         *controlServer
+	signalOnce      sync.Once
+	signals         chan os.Signal
+	signalsDone     chan struct{}
+	statsd          *statsdEmitter
+	sentry          *sentryReporter
+	hooks           decisionHooks
+	circuit         *circuitBreaker
+	onCircuitTrip   []CircuitHook
+	traceContext    TraceContextFunc
+	sessionID       func(ctx context.Context) string
+	logger          *slog.Logger
+	exceptionsMu    sync.Mutex
+	exceptions      map[string]*TemporaryException
+	dnsLookups      dnsLookups
 }
 
 // StandaloneOption configures a StandaloneInterceptor
 type StandaloneOption func(*StandaloneInterceptor)
 
-// WithPolicyFile sets the path to the Cedar policy file
+// WithPolicyFile sets the path to the Cedar policy file. Defaults to
+// TRUSERA_POLICY_FILE.
 func WithPolicyFile(path string) StandaloneOption {
 	return func(si *StandaloneInterceptor) {
 		si.policyFile = path
 	}
 }
 
-// WithEnforcement sets the enforcement mode (log, warn, block)
+// WithHostPolicy loads an additional policy file whose rules only apply to
+// hostnames matching pattern (path.Match syntax, e.g. "*.openai.com").
+// Rules from WithPolicyFile apply to every request. May be given several times.
+func WithHostPolicy(pattern, file string) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.hostPolicies = append(si.hostPolicies, hostPolicy{pattern: strings.ToLower(pattern), file: file})
+	}
+}
+
+// WithEnforcement sets the enforcement mode (log, warn, block). Defaults to
+// TRUSERA_ENFORCEMENT, then log.
 func WithEnforcement(mode EnforcementAction) StandaloneOption {
 	return func(si *StandaloneInterceptor) {
 		si.enforcement = mode
 	}
 }
 
-// WithLogFile sets the path to the JSONL event log file
+// WithLogFile sets the path to the JSONL event log file. Defaults to
+// TRUSERA_LOG_FILE.
 func WithLogFile(path string) StandaloneOption {
 	return func(si *StandaloneInterceptor) {
 		si.logFile = path
 	}
 }
 
-// WithExcludePatterns sets URL patterns to skip interception
+// WithExcludePatterns sets URL patterns to skip interception. Defaults to the
+// comma-separated TRUSERA_EXCLUDE.
 func WithExcludePatterns(patterns ...string) StandaloneOption {
 	return func(si *StandaloneInterceptor) {
 		si.excludePatterns = patterns
 	}
 }
 
-// NewStandaloneInterceptor creates a standalone interceptor with Cedar policy evaluation
+// WithIncludePatterns restricts interception to URLs matching any of the
+// patterns (substring match). Other requests pass through unevaluated and
+// unlogged. Exclude patterns still apply to included URLs.
+func WithIncludePatterns(patterns ...string) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.includePatterns = patterns
+	}
+}
+
+// WithRequestTimeout bounds how long forwarded requests may take, including
+// reading the response body. The deadline is only injected when the request's
+// context has none. Requests that exceed a deadline are logged with the
+// enforcement action "timed_out".
+func WithRequestTimeout(d time.Duration) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.requestTimeout = d
+	}
+}
+
+// WithMaxConcurrentRequests limits how many intercepted requests may be in
+// flight at once, protecting upstream APIs from agent fan-out storms. Further
+// requests wait for a slot, which is held until the response body is closed.
+// The wait is recorded as queue_wait_ms.
+func WithMaxConcurrentRequests(n int) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		if n > 0 {
+			si.concurrency = make(chan struct{}, n)
+		} else {
+			si.concurrency = nil
+		}
+	}
+}
+
+// WithSampleRate writes only a fraction of allowed requests to the JSONL log,
+// for agents making millions of calls. Denied, warned, blocked and timed out
+// requests are always logged, and metrics and hooks still see every request.
+// Sampled entries carry their sample_rate so counts can be re-weighted.
+func WithSampleRate(rate float64) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.sampleRate = math.Max(0, math.Min(1, rate))
+	}
+}
+
+// WithDecisionHeaders annotates responses with the policy outcome via the
+// X-Trusera-Decision, X-Trusera-Enforcement and X-Trusera-Matched-Policy headers
+func WithDecisionHeaders(enabled bool) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.decisionHeaders = enabled
+	}
+}
+
+// WithBlockResponse makes blocked requests return a synthetic 403 response with
+// a JSON body describing the decision instead of an error. Many SDKs treat
+// transport errors as connection failures and retry them.
+func WithBlockResponse(enabled bool) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.blockResponse = enabled
+	}
+}
+
+// WithLogger sets the logger for operational messages such as exception changes.
+// Messages are discarded by default.
+func WithLogger(logger *slog.Logger) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.logger = logger
+	}
+}
+
+// NewStandaloneInterceptor creates a standalone interceptor with Cedar policy evaluation.
+// Settings not given as options are read from the TRUSERA_POLICY_FILE,
+// TRUSERA_ENFORCEMENT, TRUSERA_LOG_FILE and TRUSERA_EXCLUDE environment variables.
 func NewStandaloneInterceptor(opts ...StandaloneOption) (*StandaloneInterceptor, error) {
 	si := &StandaloneInterceptor{
 		enforcement:     EnforcementLog,
 		excludePatterns: []string{},
+		redactHeaders:   defaultRedactedHeaderSet,
+		sampleRate:      1,
+	}
+
+	if err := si.applyEnv(); err != nil {
+		return nil, err
 	}
 
 	for _, opt := range opts {
 		opt(si)
 	}
 
-	// Load policy file if specified
-	if si.policyFile != "" {
-		content, err := os.ReadFile(si.policyFile)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read policy file: %w", err)
-		}
+	if si.logger == nil {
+		si.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
 
-		rules, err := ParseCedarPolicy(string(content))
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse policy: %w", err)
+	for _, hp := range si.hostPolicies {
+		if _, err := path.Match(hp.pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid host pattern %q: %w", hp.pattern, err)
 		}
+	}
 
-		si.rules = rules
+	rules, hostRules, err := si.loadPolicies()
+	if err != nil {
+		return nil, err
 	}
+	si.setPolicies(rules, hostRules)
 
 	// Open log file if specified
 	if si.logFile != "" {
-		f, err := os.OpenFile(si.logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		sink, err := NewFileSink(si.logFile)
 		if err != nil {
-			return nil, fmt.Errorf("failed to open log file: %w", err)
+			si.Close()
+			return nil, err
+		}
+		si.sinks = append([]LogSink{sink}, si.sinks...)
+	}
+
+	if si.signingKey != nil {
+		if err := si.checkSigningKey(); err != nil {
+			si.Close()
+			return nil, err
+		}
+	}
+
+	if si.logKey != nil {
+		if err := si.encryptLogSinks(); err != nil {
+			si.Close()
+			return nil, err
+		}
+	}
+
+	if si.statsd != nil {
+		if err := si.statsd.open(); err != nil {
+			si.Close()
+			return nil, err
+		}
+	}
+
+	if si.sentry != nil {
+		if err := si.sentry.parseDSN(); err != nil {
+			si.Close()
+			return nil, err
+		}
+	}
+
+	if si.controlSocket != "" {
+		if err := si.startControl(); err != nil {
+			si.Close()
+			return nil, err
 		}
-		si.logWriter = f
 	}
 
 	return si, nil
 }
 
+// bodyReadConfig returns how much of the request body must be read for capture and scanning
+func (si *StandaloneInterceptor) bodyReadConfig() bodyCaptureConfig {
+	if si.requestCapture.enabled() {
+		return si.requestCapture
+	}
+	if len(si.secretDetectors) > 0 {
+		return bodyCaptureConfig{maxBytes: defaultSecretScanBytes}
+	}
+	return bodyCaptureConfig{}
+}
+
 // WrapClient wraps an http.Client to intercept requests
 func (si *StandaloneInterceptor) WrapClient(client *http.Client) *http.Client {
 	if client == nil {
 		client = &http.Client{}
 	}
 
-	transport := client.Transport
-	if transport == nil {
-		transport = http.DefaultTransport
+	client.Transport = si.WrapTransport(client.Transport)
+
+	return client
+}
+
+// WrapTransport returns a RoundTripper enforcing policy on requests before
+// passing them to base, or to http.DefaultTransport if base is nil
+func (si *StandaloneInterceptor) WrapTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
 	}
 
-	client.Transport = &standaloneTransport{
-		base:        transport,
+	return &standaloneTransport{
+		base:        base,
 		interceptor: si,
 	}
-
-	return client
 }
 
-// Close flushes and closes the log file
+// Close waits for pending decision hooks, then flushes and closes the log sinks
+// and any metrics emitter
 func (si *StandaloneInterceptor) Close() error {
-	si.logMu.Lock()
-	defer si.logMu.Unlock()
+	return si.CloseContext(context.Background())
+}
+
+// evaluate runs the policy pipeline for a request. Active temporary exceptions
+// override denials, then obligations are applied, which exceptions cannot
+// waive. An open circuit breaker overrides everything.
+func (si *StandaloneInterceptor) evaluate(ctx RequestContext, secretsFound []string, now time.Time) (PolicyDecision, *exceptionLog) {
+	decision := EvaluatePolicy(ctx, si.rulesFor(ctx.Hostname))
+	decision, exception := si.applyException(ctx, decision)
+	decision = applySecretObligation(decision, secretsFound)
+	decision = si.applyCircuit(decision, now)
+	return decision, exception
+}
+
+// reevaluate runs the policy pipeline again for a retry of a request that
+// evaluate already counted. An open circuit breaker still blocks the retry,
+// but its denials are not counted again.
+func (si *StandaloneInterceptor) reevaluate(ctx RequestContext, secretsFound []string, now time.Time) (PolicyDecision, *exceptionLog) {
+	decision := EvaluatePolicy(ctx, si.rulesFor(ctx.Hostname))
+	decision, exception := si.applyException(ctx, decision)
+	decision = applySecretObligation(decision, secretsFound)
+	if open, ok := si.openCircuit(now); ok {
+		decision = open
+	}
+	return decision, exception
+}
+
+// hostPolicy holds rules scoped to hostnames matching a pattern
+type hostPolicy struct {
+	pattern string
+	file    string
+	rules   []PolicyRule
+}
+
+// loadPolicies reads and parses the policy file and every host policy file
+func (si *StandaloneInterceptor) loadPolicies() ([]PolicyRule, [][]PolicyRule, error) {
+	var rules []PolicyRule
+	if si.policyFile != "" {
+		content, err := os.ReadFile(si.policyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read policy file: %w", err)
+		}
+
+		rules, err = ParseCedarPolicy(string(content))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse policy: %w", err)
+		}
+	}
+
+	hostRules := make([][]PolicyRule, len(si.hostPolicies))
+	for i, hp := range si.hostPolicies {
+		content, err := os.ReadFile(hp.file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read host policy file: %w", err)
+		}
+
+		hostRules[i], err = ParseCedarPolicy(string(content))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse host policy %s: %w", hp.file, err)
+		}
+	}
+
+	return rules, hostRules, nil
+}
+
+// setPolicies installs parsed rules for subsequent requests
+func (si *StandaloneInterceptor) setPolicies(rules []PolicyRule, hostRules [][]PolicyRule) {
+	si.policyMu.Lock()
+	defer si.policyMu.Unlock()
+
+	si.rules = rules
+	for i := range si.hostPolicies {
+		si.hostPolicies[i].rules = hostRules[i]
+	}
+	si.policyLoadedAt = time.Now()
+}
+
+// rulesFor returns the global rules plus those of every host policy matching hostname
+func (si *StandaloneInterceptor) rulesFor(hostname string) []PolicyRule {
+	si.policyMu.RLock()
+	defer si.policyMu.RUnlock()
+
+	if len(si.hostPolicies) == 0 {
+		return si.rules
+	}
+
+	hostname = strings.ToLower(hostname)
+	rules := si.rules
+	scoped := false
+
+	for _, hp := range si.hostPolicies {
+		if ok, _ := path.Match(hp.pattern, hostname); !ok {
+			continue
+		}
+		if !scoped {
+			// Copy so appends never alias the shared global slice
+			rules = append([]PolicyRule(nil), si.rules...)
+			scoped = true
+		}
+		rules = append(rules, hp.rules...)
+	}
+
+	return rules
+}
 
-	if si.logWriter != nil {
-		return si.logWriter.Close()
+// enforce maps a decision to its enforcement action and whether the request must be blocked
+func (si *StandaloneInterceptor) enforce(decision PolicyDecision) (string, bool) {
+	if decision.Decision != "Deny" {
+		return "allowed", false
 	}
 
-	return nil
+	si.policyMu.RLock()
+	mode := si.enforcement
+	si.policyMu.RUnlock()
+
+	switch mode {
+	case EnforcementBlock:
+		return "blocked", true
+	case EnforcementWarn:
+		return "warned", false
+	default:
+		return "logged", false
+	}
 }
 
 // standaloneTransport implements http.RoundTripper
 type standaloneTransport struct {
 	base        http.RoundTripper
 	interceptor *StandaloneInterceptor
+
+	// blockResponse forces synthetic 403 responses regardless of WithBlockResponse
+	blockResponse bool
 }
 
 // eventLog represents a JSONL log entry
 type eventLog struct {
-	Timestamp         string  `json:"timestamp"`
-	Method            string  `json:"method"`
-	URL               string  `json:"url"`
-	Hostname          string  `json:"hostname"`
-	Path              string  `json:"path"`
-	Status            int     `json:"status,omitempty"`
-	DurationMs        float64 `json:"duration_ms"`
-	PolicyDecision    string  `json:"policy_decision"`
-	EnforcementAction string  `json:"enforcement_action"`
-	Reasons           string  `json:"reasons,omitempty"`
+	Timestamp         string            `json:"timestamp"`
+	Method            string            `json:"method"`
+	URL               string            `json:"url"`
+	Hostname          string            `json:"hostname"`
+	Path              string            `json:"path"`
+	LLMProvider       string            `json:"llm_provider,omitempty"`
+	LLMModel          string            `json:"llm_model,omitempty"`
+	LLMGateway        string            `json:"llm_gateway,omitempty"`
+	ModelLicense      string            `json:"model_license,omitempty"`
+	Region            string            `json:"region,omitempty"`
+	IP                string            `json:"ip,omitempty"`
+	TraceID           string            `json:"trace_id,omitempty"`
+	SpanID            string            `json:"span_id,omitempty"`
+	RunID             string            `json:"run_id,omitempty"`
+	SessionID         string            `json:"session_id,omitempty"`
+	Status            int               `json:"status,omitempty"`
+	DurationMs        float64           `json:"duration_ms"`
+	PolicyDecision    string            `json:"policy_decision"`
+	EnforcementAction string            `json:"enforcement_action"`
+	Reasons           string            `json:"reasons,omitempty"`
+	Exception         *exceptionLog     `json:"exception,omitempty"`
+	RequestBody       *bodyLog          `json:"request_body,omitempty"`
+	ResponseBody      *bodyLog          `json:"response_body,omitempty"`
+	Stream            *streamLog        `json:"stream,omitempty"`
+	RequestHeaders    map[string]string `json:"request_headers,omitempty"`
+	SecretsDetected   []string          `json:"secrets_detected,omitempty"`
+	Rewrites          []string          `json:"rewrites,omitempty"`
+	Attempts          int               `json:"attempts,omitempty"`
+	QueueWaitMs       float64           `json:"queue_wait_ms,omitempty"`
+	SampleRate        float64           `json:"sample_rate,omitempty"`
+	ResponseHeaders   map[string]string `json:"response_headers,omitempty"`
+
+	span TraceSpan // Ended by record, see WithTracerProvider
 }
 
 // RoundTrip intercepts HTTP requests and evaluates Cedar policies
@@ -159,90 +503,408 @@ func (t *standaloneTransport) RoundTrip(req *http.Request) (*http.Response, erro
 	}
 
 	startTime := time.Now()
+	req, span := t.interceptor.startSpan(req)
+
+	// Capture the request body before evaluation so blocked payloads are audited too
+	var requestBody *bodyLog
+	var secretsFound []string
+	var captured []byte
+	llm, isLLM := detectLLMHost(req.URL)
+	gateway := t.interceptor.isLLMGateway(req.URL)
+	if gateway {
+		llm, isLLM = LLMEndpoint{}, true
+	}
+	cfg := t.interceptor.bodyReadConfig()
+	if isLLM && llm.Model == "" && !cfg.enabled() {
+		// Read just enough to find the model named in the body
+		cfg.maxBytes = llmDetectBytes
+	}
+	if cfg.enabled() {
+		var truncated bool
+		req, captured, truncated = captureRequestBody(req, cfg)
+
+		if len(t.interceptor.secretDetectors) > 0 {
+			secretsFound = scanSecrets(captured, t.interceptor.secretDetectors)
+		}
+
+		if captured != nil && t.interceptor.requestCapture.enabled() {
+			requestBody = newBodyLog(captured, truncated, t.interceptor.requestCapture.previewBytes)
+			if len(secretsFound) > 0 {
+				clean := redactSecrets(string(captured), t.interceptor.secretDetectors)
+				requestBody.Preview = bodyPreview([]byte(clean), t.interceptor.requestCapture.previewBytes)
+			}
+		}
+	}
+	if gateway {
+		llm = gatewayEndpoint(req, captured)
+	} else if isLLM && llm.Model == "" {
+		llm.Model = bodyModel(captured)
+	}
+	var modelLicense string
+	if llm.Model != "" && t.interceptor.modelLicense != nil {
+		modelLicense = t.interceptor.modelLicense(req.Context(), llm.Provider, llm.Model)
+	}
+
+	// Signed AWS requests name their region even when the host does not
+	region := llm.Region
+	if sig, ok := parseSigV4(req); ok && region == "" {
+		region = sig.Region
+	}
 
 	// Build request context
 	ctx := RequestContext{
-		URL:      req.URL.String(),
-		Method:   req.Method,
-		Hostname: req.URL.Hostname(),
-		Path:     req.URL.Path,
-	}
-
-	// Evaluate policy
-	decision := EvaluatePolicy(ctx, t.interceptor.rules)
-
-	// Determine enforcement action
-	var enforcementAction string
-	var blockRequest bool
-
-	if decision.Decision == "Deny" {
-		switch t.interceptor.enforcement {
-		case EnforcementBlock:
-			enforcementAction = "blocked"
-			blockRequest = true
-		case EnforcementWarn:
-			enforcementAction = "warned"
-			blockRequest = false
-		case EnforcementLog:
-			enforcementAction = "logged"
-			blockRequest = false
-		}
-	} else {
-		enforcementAction = "allowed"
-		blockRequest = false
+		URL:          req.URL.String(),
+		Method:       req.Method,
+		Hostname:     req.URL.Hostname(),
+		Path:         req.URL.Path,
+		SecretCount:  len(secretsFound),
+		Port:         urlPort(req.URL),
+		IP:           literalIP(req.URL.Hostname()),
+		LLMProvider:  llm.Provider,
+		LLMModel:     llm.Model,
+		ModelLicense: modelLicense,
+		Region:       region,
+	}
+
+	decision, exception := t.interceptor.evaluate(ctx, secretsFound, startTime)
+	enforcementAction, blockRequest := t.interceptor.enforce(decision)
+
+	entry := eventLog{
+		Method:          req.Method,
+		URL:             req.URL.String(),
+		Hostname:        req.URL.Hostname(),
+		Path:            req.URL.Path,
+		LLMProvider:     llm.Provider,
+		LLMModel:        llm.Model,
+		LLMGateway:      llm.Gateway,
+		ModelLicense:    modelLicense,
+		Region:          region,
+		RequestBody:     requestBody,
+		SecretsDetected: secretsFound,
+		span:            span,
+	}
+	entry.TraceID, entry.SpanID = t.interceptor.traceIDs(req)
+	entry.RunID, entry.SessionID = t.interceptor.groupIDs(req.Context())
+	entry.setDecision(decision, enforcementAction, exception)
+
+	// Rewrite obligations apply to requests that will be forwarded, so the
+	// logged headers reflect what was actually sent
+	if !blockRequest {
+		req, entry.Rewrites = applyRewrites(req, decision.Obligations)
+	}
+
+	if t.interceptor.captureHeaders {
+		entry.RequestHeaders = scrubHeaders(req.Header, t.interceptor.redactHeaders, t.interceptor.hashRedacted)
 	}
 
-	// Handle blocking
 	if blockRequest {
-		duration := time.Since(startTime).Milliseconds()
-		t.logEvent(eventLog{
-			Timestamp:         time.Now().UTC().Format(time.RFC3339),
-			Method:            req.Method,
-			URL:               req.URL.String(),
-			Hostname:          req.URL.Hostname(),
-			Path:              req.URL.Path,
-			DurationMs:        float64(duration),
-			PolicyDecision:    decision.Decision,
-			EnforcementAction: enforcementAction,
-			Reasons:           strings.Join(decision.Reasons, "; "),
-		})
-
-		return nil, fmt.Errorf("request blocked by Cedar policy: %s", strings.Join(decision.Reasons, "; "))
-	}
-
-	// Forward request
+		return t.block(req, entry, decision, startTime)
+	}
+
+	// Impose the latency budget unless the caller already set a deadline
+	var cancel context.CancelFunc
+	if t.interceptor.requestTimeout > 0 {
+		if _, ok := req.Context().Deadline(); !ok {
+			var ctx context.Context
+			ctx, cancel = context.WithTimeout(req.Context(), t.interceptor.requestTimeout)
+			req = req.WithContext(ctx)
+		}
+	}
+
+	// Wait for a concurrency slot within the request's deadline
+	var release func()
+	if sem := t.interceptor.concurrency; sem != nil {
+		waitStart := time.Now()
+		select {
+		case sem <- struct{}{}:
+		case <-req.Context().Done():
+			err := req.Context().Err()
+			if cancel != nil {
+				cancel()
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				entry.EnforcementAction = "timed_out"
+			}
+			entry.QueueWaitMs = float64(time.Since(waitStart).Milliseconds())
+			t.record(entry, decision, startTime, time.Since(startTime))
+			return nil, err
+		}
+		entry.QueueWaitMs = float64(time.Since(waitStart).Milliseconds())
+
+		var once sync.Once
+		release = func() { once.Do(func() { <-sem }) }
+	}
+
+	// Forward request, retrying transient failures
 	resp, err := t.base.RoundTrip(req)
+	attempts := 1
+	for t.interceptor.retry.retryable(req, resp, err, attempts) {
+		if !sleepContext(req.Context(), t.interceptor.retry.delay(attempts)) {
+			break
+		}
+
+		// Exceptions may have expired or the circuit opened since the last attempt
+		decision, exception = t.interceptor.reevaluate(ctx, secretsFound, time.Now())
+		enforcementAction, blockRequest = t.interceptor.enforce(decision)
+		entry.setDecision(decision, enforcementAction, exception)
+
+		if blockRequest {
+			discardResponse(resp)
+			if cancel != nil {
+				cancel()
+			}
+			if release != nil {
+				release()
+			}
+			entry.Attempts = attempts
+			return t.block(req, entry, decision, startTime)
+		}
+
+		retryReq, rewindErr := rewindRequest(req)
+		if rewindErr != nil {
+			break
+		}
+
+		discardResponse(resp)
+		attempts++
+		resp, err = t.base.RoundTrip(retryReq)
+	}
+	if t.interceptor.retry.enabled() {
+		entry.Attempts = attempts
+	}
 
-	duration := time.Since(startTime).Milliseconds()
+	duration := time.Since(startTime)
 
-	// Log event
-	logEntry := eventLog{
-		Timestamp:         time.Now().UTC().Format(time.RFC3339),
-		Method:            req.Method,
-		URL:               req.URL.String(),
-		Hostname:          req.URL.Hostname(),
-		Path:              req.URL.Path,
-		DurationMs:        float64(duration),
-		PolicyDecision:    decision.Decision,
-		EnforcementAction: enforcementAction,
+	if err != nil && t.interceptor.requestTimeout > 0 && errors.Is(req.Context().Err(), context.DeadlineExceeded) {
+		entry.EnforcementAction = "timed_out"
 	}
 
-	if len(decision.Reasons) > 0 {
-		logEntry.Reasons = strings.Join(decision.Reasons, "; ")
+	// The deadline and concurrency slot must outlive RoundTrip until the body is consumed
+	if cancel != nil || release != nil {
+		var once sync.Once
+		done := func() {
+			once.Do(func() {
+				if cancel != nil {
+					cancel()
+				}
+				if release != nil {
+					release()
+				}
+			})
+		}
+		if resp != nil && resp.Body != nil {
+			resp.Body = &releaseBody{ReadCloser: resp.Body, release: done}
+		} else {
+			done()
+		}
 	}
 
 	if resp != nil {
-		logEntry.Status = resp.StatusCode
-	}
+		entry.Status = resp.StatusCode
 
-	t.logEvent(logEntry)
+		if t.interceptor.captureHeaders {
+			entry.ResponseHeaders = scrubHeaders(resp.Header, t.interceptor.redactHeaders, t.interceptor.hashRedacted)
+		}
+
+		if t.interceptor.decisionHeaders {
+			setDecisionHeaders(resp, decision, enforcementAction)
+		}
+
+		// Defer the entry until the caller has consumed a captured or streamed
+		// body, so it reflects the whole delivery
+		streaming := isStreamingResponse(resp)
+		if (streaming || t.interceptor.responseCapture.enabled()) && resp.Body != nil && resp.Body != http.NoBody {
+			resp.Body = newObservedBody(resp.Body, t.interceptor.responseCapture, startTime, func(body *bodyLog, stream *streamLog) {
+				entry.ResponseBody = body
+				if streaming {
+					entry.Stream = stream
+				}
+				// The deadline may also expire while the body is streamed
+				if cancel != nil && errors.Is(req.Context().Err(), context.DeadlineExceeded) {
+					entry.EnforcementAction = "timed_out"
+				}
+				t.record(entry, decision, startTime, duration)
+			})
+			return resp, err
+		}
+	}
+	t.record(entry, decision, startTime, duration)
 
 	return resp, err
 }
 
-// shouldExclude checks if URL matches any exclude patterns
+// setDecision records the policy outcome on the entry
+func (e *eventLog) setDecision(decision PolicyDecision, enforcementAction string, exception *exceptionLog) {
+	e.PolicyDecision = decision.Decision
+	e.EnforcementAction = enforcementAction
+	e.Exception = exception
+	e.Reasons = strings.Join(decision.Reasons, "; ")
+}
+
+// block records a blocked request and returns the synthetic 403 or an error
+func (t *standaloneTransport) block(req *http.Request, entry eventLog, decision PolicyDecision, startTime time.Time) (*http.Response, error) {
+	t.record(entry, decision, startTime, time.Since(startTime))
+
+	if t.interceptor.blockResponse || t.blockResponse {
+		return t.blockedResponse(req, decision, entry.EnforcementAction), nil
+	}
+
+	return nil, fmt.Errorf("request blocked by Cedar policy: %s", strings.Join(decision.Reasons, "; "))
+}
+
+// releaseBody frees per-request resources, such as the context deadline and
+// concurrency slot, once the response body is read to EOF or closed.
+// release must be safe to call more than once.
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.release()
+	}
+	return n, err
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// record stamps the event with its timing, logs it and notifies decision hooks.
+// duration is measured up to the response headers.
+func (t *standaloneTransport) record(entry eventLog, decision PolicyDecision, startTime time.Time, duration time.Duration) {
+	entry.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	entry.DurationMs = float64(duration.Milliseconds())
+
+	if t.interceptor.redactor != nil {
+		entry = t.interceptor.redactEntry(entry)
+	}
+
+	t.interceptor.counters.count(entry.EnforcementAction)
+	t.logEvent(entry)
+	if entry.span != nil {
+		endSpan(entry.span, entry, decision)
+	}
+
+	t.interceptor.notify(DecisionEvent{
+		Timestamp:         startTime,
+		Method:            entry.Method,
+		URL:               entry.URL,
+		Hostname:          entry.Hostname,
+		Path:              entry.Path,
+		LLMProvider:       entry.LLMProvider,
+		LLMModel:          entry.LLMModel,
+		LLMGateway:        entry.LLMGateway,
+		ModelLicense:      entry.ModelLicense,
+		Region:            entry.Region,
+		TraceID:           entry.TraceID,
+		SpanID:            entry.SpanID,
+		RunID:             entry.RunID,
+		SessionID:         entry.SessionID,
+		Status:            entry.Status,
+		Duration:          duration,
+		Decision:          decision.Decision,
+		EnforcementAction: entry.EnforcementAction,
+		Reasons:           decision.Reasons,
+		Matched:           decision.Matched,
+	})
+}
+
+// blockedResponse builds the synthetic 403 returned for blocked requests
+func (t *standaloneTransport) blockedResponse(req *http.Request, decision PolicyDecision, enforcementAction string) *http.Response {
+	body, _ := json.Marshal(map[string]any{
+		"error":              "request blocked by Cedar policy",
+		"decision":           decision.Decision,
+		"enforcement_action": enforcementAction,
+		"reasons":            decision.Reasons,
+		"matched":            compactMatched(decision.Matched),
+	})
+
+	resp := &http.Response{
+		Status:        "403 Forbidden",
+		StatusCode:    http.StatusForbidden,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+
+	if t.interceptor.decisionHeaders {
+		setDecisionHeaders(resp, decision, enforcementAction)
+	}
+
+	return resp
+}
+
+// setDecisionHeaders records the policy outcome on the response headers
+func setDecisionHeaders(resp *http.Response, decision PolicyDecision, enforcementAction string) {
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+
+	resp.Header.Set(HeaderDecision, decision.Decision)
+	resp.Header.Set(HeaderEnforcement, enforcementAction)
+
+	if matched := compactMatched(decision.Matched); len(matched) > 0 {
+		resp.Header.Set(HeaderMatchedPolicy, strings.Join(matched, "; "))
+	}
+}
+
+// compactMatched collapses matched rules onto single lines, dropping duplicates
+// produced by multi-condition rule blocks
+func compactMatched(matched []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+
+	for _, raw := range matched {
+		line := strings.Join(strings.Fields(raw), " ")
+		if line == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		out = append(out, line)
+	}
+
+	return out
+}
+
+// shouldExclude checks if URL matches any exclude patterns, or misses every
+// include pattern when include patterns are set
 func (t *standaloneTransport) shouldExclude(urlStr string) bool {
-	for _, pattern := range t.interceptor.excludePatterns {
+	if len(t.interceptor.includePatterns) > 0 && !matchesAny(urlStr, t.interceptor.includePatterns) {
+		return true
+	}
+	return matchesExclude(urlStr, t.interceptor.excludePatterns)
+}
+
+// matchesExclude reports whether urlStr matches any exclude pattern. A
+// local Ollama server is an AI provider rather than local plumbing, so
+// broad patterns such as "localhost" leave it intercepted and only one that
+// names its port, such as "localhost:11434", excludes it.
+func matchesExclude(urlStr string, patterns []string) bool {
+	ollama := false
+	if u, err := url.Parse(urlStr); err == nil {
+		ep, ok := detectLLMHost(u)
+		ollama = ok && ep.Provider == "ollama"
+	}
+	for _, pattern := range patterns {
+		if strings.Contains(urlStr, pattern) && (!ollama || strings.Contains(pattern, ":"+ollamaPort)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny reports whether urlStr contains any of the patterns
+func matchesAny(urlStr string, patterns []string) bool {
+	for _, pattern := range patterns {
 		// Support both substring match and regex-like patterns
 		if strings.Contains(urlStr, pattern) {
 			return true
@@ -251,12 +913,24 @@ func (t *standaloneTransport) shouldExclude(urlStr string) bool {
 	return false
 }
 
-// logEvent writes an event to the JSONL log file
+// logEvent writes an event to the JSONL log sinks and metrics emitter
 func (t *standaloneTransport) logEvent(entry eventLog) {
-	if t.interceptor.logWriter == nil {
+	if t.interceptor.statsd != nil {
+		t.interceptor.statsd.record(entry)
+	}
+
+	if len(t.interceptor.sinks) == 0 {
 		return
 	}
 
+	// Violations are never sampled out
+	if rate := t.interceptor.sampleRate; rate < 1 && entry.EnforcementAction == "allowed" {
+		if rand.Float64() >= rate {
+			return
+		}
+		entry.SampleRate = rate
+	}
+
 	t.interceptor.logMu.Lock()
 	defer t.interceptor.logMu.Unlock()
 
@@ -265,8 +939,39 @@ func (t *standaloneTransport) logEvent(entry eventLog) {
 		return
 	}
 
+	if t.interceptor.signingKey != nil {
+		data = t.interceptor.signEvent(data)
+	}
 	data = append(data, '\n')
-	t.interceptor.logWriter.Write(data)
+	for _, sink := range t.interceptor.sinks {
+		if err := sink.WriteEvent(data); err != nil {
+			t.interceptor.droppedEvents.Add(1)
+			t.interceptor.logger.Warn("failed to write event to log sink", "error", err)
+		}
+	}
+}
+
+// urlPort returns the explicit port of u, or the default port for its scheme
+func urlPort(u *url.URL) int {
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		return port
+	}
+	switch u.Scheme {
+	case "http", "ws":
+		return 80
+	case "https", "wss":
+		return 443
+	}
+	return 0
+}
+
+// literalIP returns host when it is an IP address, so IP range rules can
+// match requests made without a hostname
+func literalIP(host string) string {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String()
+	}
+	return ""
 }
 
 // MustNewStandaloneInterceptor creates a standalone interceptor or panics on error
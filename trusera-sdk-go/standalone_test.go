@@ -2,7 +2,9 @@ package trusera
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -48,8 +50,8 @@ when {
 		t.Errorf("expected 1 rule loaded, got %d", len(si.rules))
 	}
 
-	if si.logWriter == nil {
-		t.Error("expected log writer to be initialized")
+	if len(si.sinks) != 1 {
+		t.Error("expected log file sink to be initialized")
 	}
 }
 
@@ -312,6 +314,380 @@ func TestStandaloneInterceptorExcludePatterns(t *testing.T) {
 	}
 }
 
+func TestMatchesExcludeKeepsOllama(t *testing.T) {
+	tests := []struct {
+		url      string
+		patterns []string
+		want     bool
+	}{
+		{"http://localhost:8080/health", []string{"localhost"}, true},
+		{"http://localhost:11434/api/chat", []string{"localhost", "127.0.0.1"}, false},
+		{"http://127.0.0.1:11434/api/generate", []string{"127.0.0.1"}, false},
+		{"http://localhost:11434/api/chat", []string{"localhost:11434"}, true},
+		{"http://localhost:11434/api/chat", []string{"/api/"}, false},
+		{"tcp://localhost:11434", []string{"localhost"}, false},
+	}
+	for _, tt := range tests {
+		if got := matchesExclude(tt.url, tt.patterns); got != tt.want {
+			t.Errorf("matchesExclude(%q, %q) = %v, want %v", tt.url, tt.patterns, got, tt.want)
+		}
+	}
+}
+
+func TestStandaloneInterceptorIncludePatterns(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "events.jsonl")
+	policyPath := writeTestPolicy(t, tmpDir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.method == "DELETE";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithLogFile(logPath),
+		WithIncludePatterns("api.openai.com", "api.anthropic.com"),
+		WithExcludePatterns("api.anthropic.com/v1/health"),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+
+	do := func(method, url string) error {
+		req, _ := http.NewRequest(method, url, nil)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	// Not included: passes through even though the policy forbids it
+	if err := do("DELETE", "https://db.internal.example.com/rows"); err != nil {
+		t.Errorf("expected request outside include patterns to pass through: %v", err)
+	}
+	if err := do("DELETE", "https://api.anthropic.com/v1/health"); err != nil {
+		t.Errorf("expected excluded request to pass through: %v", err)
+	}
+	if err := do("DELETE", "https://api.openai.com/v1/files/1"); err == nil {
+		t.Error("expected included request to be evaluated and blocked")
+	}
+	if err := do("GET", "https://api.anthropic.com/v1/models"); err != nil {
+		t.Errorf("included allowed request failed: %v", err)
+	}
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 2 {
+		t.Fatalf("expected only included requests to be logged, got %d entries", len(entries))
+	}
+	if entries[0].Hostname != "api.openai.com" || entries[1].Hostname != "api.anthropic.com" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+func TestStandaloneInterceptorHostPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	globalPath := writeTestPolicy(t, tmpDir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.method == "DELETE";
+};
+`)
+	openaiPath := filepath.Join(tmpDir, "openai.cedar")
+	if err := os.WriteFile(openaiPath, []byte(`
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.path == "/v1/fine_tuning/jobs";
+};
+`), 0644); err != nil {
+		t.Fatalf("failed to write host policy: %v", err)
+	}
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(globalPath),
+		WithHostPolicy("*.OpenAI.com", openaiPath),
+		WithEnforcement(EnforcementBlock),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+
+	tests := []struct {
+		method  string
+		url     string
+		blocked bool
+	}{
+		{"POST", "https://api.openai.com/v1/fine_tuning/jobs", true},
+		{"POST", "https://api.example.com/v1/fine_tuning/jobs", false},
+		{"DELETE", "https://api.example.com/v1/files", true},
+		{"DELETE", "https://api.openai.com/v1/files", true},
+		{"GET", "https://api.openai.com/v1/models", false},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, tt.url, nil)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if blocked := err != nil; blocked != tt.blocked {
+			t.Errorf("%s %s: expected blocked=%v, got err=%v", tt.method, tt.url, tt.blocked, err)
+		}
+	}
+
+	if len(si.rules) != 1 {
+		t.Errorf("expected host rules not to leak into the global rule set, got %d rules", len(si.rules))
+	}
+}
+
+func TestStandaloneInterceptorHostPolicyErrors(t *testing.T) {
+	if _, err := NewStandaloneInterceptor(WithHostPolicy("*.openai.com", "/nonexistent/openai.cedar")); err == nil {
+		t.Error("expected error for missing host policy file")
+	}
+
+	policyPath := writeTestPolicy(t, t.TempDir(), "")
+	if _, err := NewStandaloneInterceptor(WithHostPolicy("[", policyPath)); err == nil {
+		t.Error("expected error for malformed host pattern")
+	}
+}
+
+func TestStandaloneInterceptorRequestTimeout(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+
+	release := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		io.WriteString(w, "fast")
+	}))
+	defer backend.Close()
+	defer close(release)
+
+	var mu sync.Mutex
+	var allowed []DecisionEvent
+
+	si, err := NewStandaloneInterceptor(
+		WithLogFile(logPath),
+		WithRequestTimeout(50*time.Millisecond),
+		WithOnAllow(func(ev DecisionEvent) {
+			mu.Lock()
+			allowed = append(allowed, ev)
+			mu.Unlock()
+		}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+
+	client := si.WrapClient(&http.Client{})
+
+	resp, err := client.Get(backend.URL + "/fast")
+	if err != nil {
+		t.Fatalf("fast request failed: %v", err)
+	}
+	// The injected deadline stays active until the body has been read
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || string(body) != "fast" {
+		t.Fatalf("failed to read body: %q %v", body, err)
+	}
+
+	if _, err := client.Get(backend.URL + "/slow"); err == nil {
+		t.Fatal("expected slow request to time out")
+	}
+
+	// A caller-provided deadline takes precedence
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", backend.URL+"/fast", nil)
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("request with caller deadline failed: %v", err)
+	}
+	resp.Body.Close()
+
+	si.Close()
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].EnforcementAction != "allowed" || entries[2].EnforcementAction != "allowed" {
+		t.Errorf("unexpected actions for fast requests: %s, %s", entries[0].EnforcementAction, entries[2].EnforcementAction)
+	}
+	if entries[1].EnforcementAction != "timed_out" || entries[1].PolicyDecision != "Allow" {
+		t.Errorf("expected timed_out entry, got %+v", entries[1])
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(allowed) != 3 {
+		t.Errorf("expected timed out requests to reach allow hooks, got %d events", len(allowed))
+	}
+}
+
+func TestStandaloneInterceptorMaxConcurrentRequests(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+
+	var mu sync.Mutex
+	var inFlight, peak int
+	release := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > peak {
+			peak = inFlight
+		}
+		mu.Unlock()
+
+		<-release
+		io.WriteString(w, "ok")
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer backend.Close()
+
+	si, err := NewStandaloneInterceptor(WithLogFile(logPath), WithMaxConcurrentRequests(2))
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	client := si.WrapClient(&http.Client{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(backend.URL)
+			if err != nil {
+				t.Errorf("request failed: %v", err)
+				return
+			}
+			io.ReadAll(resp.Body)
+			resp.Body.Close()
+		}()
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	mu.Lock()
+	if peak != 2 {
+		t.Errorf("expected at most 2 concurrent upstream requests, saw %d", peak)
+	}
+	mu.Unlock()
+
+	var queued int
+	for _, entry := range readLogEntries(t, logPath) {
+		if entry.QueueWaitMs >= 50 {
+			queued++
+		}
+	}
+	if queued != 1 {
+		t.Errorf("expected 1 request to record a queue wait, got %d", queued)
+	}
+
+	// A queued request gives up when its deadline passes
+	si.concurrency <- struct{}{}
+	si.concurrency <- struct{}{}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", backend.URL, nil)
+	if _, err := client.Do(req); err == nil {
+		t.Error("expected queued request to fail at its deadline")
+	}
+
+	entries := readLogEntries(t, logPath)
+	if last := entries[len(entries)-1]; last.EnforcementAction != "timed_out" {
+		t.Errorf("expected timed_out for a request that never got a slot, got %s", last.EnforcementAction)
+	}
+}
+
+func TestStandaloneInterceptorSampleRate(t *testing.T) {
+	tmpDir := t.TempDir()
+	policyPath := writeTestPolicy(t, tmpDir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.method == "DELETE";
+};
+`)
+
+	newClient := func(logPath string, rate float64) (*StandaloneInterceptor, *http.Client) {
+		si, err := NewStandaloneInterceptor(
+			WithPolicyFile(policyPath),
+			WithEnforcement(EnforcementWarn),
+			WithLogFile(logPath),
+			WithSampleRate(rate),
+		)
+		if err != nil {
+			t.Fatalf("failed to create interceptor: %v", err)
+		}
+		return si, si.WrapClient(&http.Client{Transport: stubTransport{}})
+	}
+
+	send := func(client *http.Client, method string, n int) {
+		for i := 0; i < n; i++ {
+			req, _ := http.NewRequest(method, "https://api.example.com/items", nil)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+		}
+	}
+
+	// Rate 0 keeps only violations
+	dropAll := filepath.Join(tmpDir, "none.jsonl")
+	si, client := newClient(dropAll, 0)
+	send(client, "GET", 20)
+	send(client, "DELETE", 3)
+	si.Close()
+
+	entries := readLogEntries(t, dropAll)
+	if len(entries) != 3 {
+		t.Fatalf("expected only the 3 warned requests, got %d entries", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.EnforcementAction != "warned" || entry.SampleRate != 0 {
+			t.Errorf("unexpected entry: %+v", entry)
+		}
+	}
+
+	// Rate 0.5 keeps roughly half of the allowed requests
+	half := filepath.Join(tmpDir, "half.jsonl")
+	si, client = newClient(half, 0.5)
+	send(client, "GET", 400)
+	si.Close()
+
+	entries = readLogEntries(t, half)
+	if len(entries) < 120 || len(entries) > 280 {
+		t.Errorf("expected about 200 sampled entries, got %d", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.SampleRate != 0.5 {
+			t.Fatalf("expected sampled entries to carry sample_rate, got %v", entry.SampleRate)
+		}
+	}
+}
+
 func TestStandaloneInterceptorJSONLFormat(t *testing.T) {
 	tmpDir := t.TempDir()
 	policyPath := filepath.Join(tmpDir, "policy.cedar")
@@ -598,3 +974,165 @@ when {
 		t.Errorf("expected status 200, got %d", logEntry.Status)
 	}
 }
+
+// writeTestPolicy writes a Cedar policy into dir and returns its path
+func writeTestPolicy(t *testing.T, dir, policy string) string {
+	t.Helper()
+
+	policyPath := filepath.Join(dir, "policy.cedar")
+	if err := os.WriteFile(policyPath, []byte(policy), 0644); err != nil {
+		t.Fatalf("failed to write policy file: %v", err)
+	}
+	return policyPath
+}
+
+// readLogEntries parses every JSONL entry in the log file at path
+func readLogEntries(t *testing.T, path string) []eventLog {
+	t.Helper()
+
+	logData, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+
+	var entries []eventLog
+	for _, line := range strings.Split(strings.TrimSpace(string(logData)), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry eventLog
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("failed to parse log entry %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestStandaloneInterceptorDecisionHeaders(t *testing.T) {
+	policyPath := writeTestPolicy(t, t.TempDir(), `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.method == "DELETE";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementWarn),
+		WithDecisionHeaders(true),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	client := si.WrapClient(&http.Client{})
+
+	req, _ := http.NewRequest("DELETE", backend.URL+"/resource/1", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request should succeed in warn mode: %v", err)
+	}
+	resp.Body.Close()
+
+	if got := resp.Header.Get(HeaderDecision); got != "Deny" {
+		t.Errorf("expected %s Deny, got %q", HeaderDecision, got)
+	}
+	if got := resp.Header.Get(HeaderEnforcement); got != "warned" {
+		t.Errorf("expected %s warned, got %q", HeaderEnforcement, got)
+	}
+	matched := resp.Header.Get(HeaderMatchedPolicy)
+	if !strings.Contains(matched, `resource.method == "DELETE"`) || strings.Contains(matched, "\n") {
+		t.Errorf("unexpected %s: %q", HeaderMatchedPolicy, matched)
+	}
+
+	resp, err = client.Get(backend.URL + "/resource/1")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if got := resp.Header.Get(HeaderDecision); got != "Allow" {
+		t.Errorf("expected %s Allow, got %q", HeaderDecision, got)
+	}
+	if got := resp.Header.Get(HeaderMatchedPolicy); got != "" {
+		t.Errorf("expected no matched policy header, got %q", got)
+	}
+}
+
+func TestStandaloneInterceptorDecisionHeadersDisabled(t *testing.T) {
+	si, err := NewStandaloneInterceptor()
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	resp, err := si.WrapClient(&http.Client{}).Get(backend.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if got := resp.Header.Get(HeaderDecision); got != "" {
+		t.Errorf("expected no decision header by default, got %q", got)
+	}
+}
+
+func TestStandaloneInterceptorBlockResponse(t *testing.T) {
+	policyPath := writeTestPolicy(t, t.TempDir(), `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "blocked.example.com";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithBlockResponse(true),
+		WithDecisionHeaders(true),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	resp, err := si.WrapClient(&http.Client{}).Get("https://blocked.example.com/api")
+	if err != nil {
+		t.Fatalf("expected synthetic response instead of error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected status 403, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("expected JSON content type, got %q", got)
+	}
+	if got := resp.Header.Get(HeaderEnforcement); got != "blocked" {
+		t.Errorf("expected %s blocked on synthetic response, got %q", HeaderEnforcement, got)
+	}
+
+	var body struct {
+		Decision          string   `json:"decision"`
+		EnforcementAction string   `json:"enforcement_action"`
+		Reasons           []string `json:"reasons"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if body.Decision != "Deny" || body.EnforcementAction != "blocked" || len(body.Reasons) != 1 {
+		t.Errorf("unexpected body: %+v", body)
+	}
+}
@@ -0,0 +1,146 @@
+package trusera
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ClientStats reports how event delivery is going, so operators can alert
+// when telemetry is failing silently
+type ClientStats struct {
+	// Queued is the number of events waiting in memory
+	Queued int `json:"queued"`
+	// DiskQueueBytes is the size of the disk queue set by WithDiskQueue
+	DiskQueueBytes int64 `json:"disk_queue_bytes"`
+
+	// Sent counts events accepted by the API
+	Sent int64 `json:"sent"`
+	// Failed counts events in failed flushes, including ones sent later
+	Failed int64 `json:"failed"`
+	// Rejected counts events in batches the API refused with a 4xx status
+	Rejected int64 `json:"rejected"`
+	// Dropped counts events discarded by queue limits
+	Dropped int64 `json:"dropped"`
+	// DeadLettered counts events written to the dead-letter file
+	DeadLettered int64 `json:"dead_lettered"`
+	// Deduplicated counts events dropped for repeating an idempotency key
+	Deduplicated int64 `json:"deduplicated"`
+
+	LastFlushAt    time.Time `json:"last_flush_at"`
+	LastFlushError string    `json:"last_flush_error,omitempty"`
+	ThrottledUntil time.Time `json:"throttled_until"`
+}
+
+// clientCounters accumulates delivery outcomes for Stats
+type clientCounters struct {
+	sent         atomic.Int64
+	failed       atomic.Int64
+	rejected     atomic.Int64
+	deadLettered atomic.Int64
+	deduplicated atomic.Int64
+
+	mu        sync.Mutex
+	lastFlush time.Time
+	lastErr   error
+}
+
+// recordDelivery counts the outcome of sending a batch of n events
+func (c *Client) recordDelivery(n int, err error) {
+	switch {
+	case err == nil:
+		c.counters.sent.Add(int64(n))
+	case isTransient(err):
+		c.counters.failed.Add(int64(n))
+	default:
+		c.counters.failed.Add(int64(n))
+		c.counters.rejected.Add(int64(n))
+	}
+
+	c.counters.mu.Lock()
+	c.counters.lastFlush = time.Now()
+	c.counters.lastErr = err
+	c.counters.mu.Unlock()
+}
+
+// Stats returns delivery counters and the current queue state
+func (c *Client) Stats() ClientStats {
+	stats := ClientStats{
+		Sent:           c.counters.sent.Load(),
+		Failed:         c.counters.failed.Load(),
+		Rejected:       c.counters.rejected.Load(),
+		Dropped:        c.dropped.Load(),
+		DeadLettered:   c.counters.deadLettered.Load(),
+		Deduplicated:   c.counters.deduplicated.Load(),
+		ThrottledUntil: c.ThrottledUntil(),
+	}
+
+	c.mu.Lock()
+	stats.Queued = len(c.events)
+	c.mu.Unlock()
+
+	if c.disk != nil {
+		stats.Dropped += c.disk.dropped.Load()
+		c.disk.mu.Lock()
+		stats.DiskQueueBytes = c.disk.size
+		c.disk.mu.Unlock()
+	}
+
+	c.counters.mu.Lock()
+	stats.LastFlushAt = c.counters.lastFlush
+	if c.counters.lastErr != nil {
+		stats.LastFlushError = c.counters.lastErr.Error()
+	}
+	c.counters.mu.Unlock()
+
+	return stats
+}
+
+// WriteMetrics writes Stats in the Prometheus text exposition format
+func (c *Client) WriteMetrics(w io.Writer) error {
+	s := c.Stats()
+
+	var b bytes.Buffer
+	metric := func(name, kind, help string, value float64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", name, help, name, kind, name, value)
+	}
+
+	metric("trusera_client_events_queued", "gauge", "Events waiting in memory.", float64(s.Queued))
+	metric("trusera_client_disk_queue_bytes", "gauge", "Size of the disk queue in bytes.", float64(s.DiskQueueBytes))
+	metric("trusera_client_events_sent_total", "counter", "Events accepted by the API.", float64(s.Sent))
+	metric("trusera_client_events_failed_total", "counter", "Events in failed flushes.", float64(s.Failed))
+	metric("trusera_client_events_rejected_total", "counter", "Events the API refused.", float64(s.Rejected))
+	metric("trusera_client_events_dropped_total", "counter", "Events discarded by queue limits.", float64(s.Dropped))
+	metric("trusera_client_events_dead_lettered_total", "counter", "Events written to the dead-letter file.", float64(s.DeadLettered))
+	metric("trusera_client_events_deduplicated_total", "counter", "Events dropped as duplicates.", float64(s.Deduplicated))
+
+	var lastFlush, lastOK, throttled float64
+	if !s.LastFlushAt.IsZero() {
+		lastFlush = float64(s.LastFlushAt.Unix())
+		if s.LastFlushError == "" {
+			lastOK = 1
+		}
+	}
+	if !s.ThrottledUntil.IsZero() {
+		throttled = 1
+	}
+	metric("trusera_client_last_flush_timestamp_seconds", "gauge", "Time of the last flush attempt.", lastFlush)
+	metric("trusera_client_last_flush_success", "gauge", "Whether the last flush succeeded.", lastOK)
+	metric("trusera_client_throttled", "gauge", "Whether the API is throttling the client.", throttled)
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// MetricsHandler serves WriteMetrics for a Prometheus scrape endpoint, e.g.
+// http.Handle("/metrics", client.MetricsHandler())
+func (c *Client) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.WriteMetrics(w)
+	})
+}
@@ -0,0 +1,80 @@
+package trusera
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClientStats(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key",
+		WithBaseURL(server.URL),
+		WithFlushRetry(0, 0),
+		WithMaxQueuedEvents(2, DropOldest),
+	)
+	defer client.Close()
+
+	client.TrackBatch([]Event{NewEvent(EventToolCall, "a"), NewEvent(EventToolCall, "b"), NewEvent(EventToolCall, "c")})
+	client.Flush()
+
+	s := client.Stats()
+	if s.Queued != 2 || s.Failed != 2 || s.Dropped != 1 || s.Sent != 0 {
+		t.Errorf("unexpected stats after a failed flush: %+v", s)
+	}
+	if !strings.Contains(s.LastFlushError, "503") || s.LastFlushAt.IsZero() {
+		t.Errorf("expected last flush error, got %+v", s)
+	}
+
+	status.Store(http.StatusOK)
+	client.Flush()
+
+	s = client.Stats()
+	if s.Queued != 0 || s.Sent != 2 || s.LastFlushError != "" {
+		t.Errorf("unexpected stats after a successful flush: %+v", s)
+	}
+
+	status.Store(http.StatusBadRequest)
+	client.Track(NewEvent(EventToolCall, "d"))
+	client.Flush()
+	if s = client.Stats(); s.Rejected != 1 {
+		t.Errorf("expected a rejected event, got %+v", s)
+	}
+}
+
+func TestClientMetricsHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	client.Flush()
+
+	rec := httptest.NewRecorder()
+	client.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE trusera_client_events_sent_total counter",
+		"trusera_client_events_sent_total 1\n",
+		"trusera_client_last_flush_success 1\n",
+		"trusera_client_throttled 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in metrics:\n%s", want, body)
+		}
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+}
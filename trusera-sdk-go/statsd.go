@@ -0,0 +1,105 @@
+package trusera
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const defaultStatsdPrefix = "trusera"
+
+// StatsdOption configures the statsd emitter
+type StatsdOption func(*statsdEmitter)
+
+// WithStatsdPrefix sets the metric name prefix (default "trusera")
+func WithStatsdPrefix(prefix string) StatsdOption {
+	return func(e *statsdEmitter) {
+		e.prefix = strings.TrimSuffix(prefix, ".")
+	}
+}
+
+// WithStatsdTags appends DogStatsD-style tags (e.g. "env:prod") to every metric
+func WithStatsdTags(tags ...string) StatsdOption {
+	return func(e *statsdEmitter) {
+		e.tags = append(e.tags, tags...)
+	}
+}
+
+// WithStatsd emits decision counters and latency timers to a statsd agent over UDP.
+// The address is dialed when the interceptor is created.
+func WithStatsd(addr string, opts ...StatsdOption) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		e := &statsdEmitter{addr: addr, prefix: defaultStatsdPrefix}
+		for _, opt := range opts {
+			opt(e)
+		}
+		si.statsd = e
+	}
+}
+
+// statsdEmitter writes plaintext statsd metrics to a UDP socket
+type statsdEmitter struct {
+	addr   string
+	prefix string
+	tags   []string
+	mu     sync.Mutex
+	conn   net.Conn
+}
+
+// open dials the statsd agent
+func (e *statsdEmitter) open() error {
+	conn, err := net.Dial("udp", e.addr)
+	if err != nil {
+		return fmt.Errorf("failed to dial statsd: %w", err)
+	}
+	e.conn = conn
+	return nil
+}
+
+// record emits the counters and timer for a single interception event.
+// Delivery is best effort, matching statsd's fire-and-forget semantics.
+func (e *statsdEmitter) record(entry eventLog) {
+	var b strings.Builder
+	e.writeMetric(&b, "requests", "1", "c")
+	e.writeMetric(&b, "decision."+strings.ToLower(entry.PolicyDecision), "1", "c")
+	e.writeMetric(&b, "enforcement."+entry.EnforcementAction, "1", "c")
+	e.writeMetric(&b, "request.duration", strconv.FormatFloat(entry.DurationMs, 'f', -1, 64), "ms")
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn != nil {
+		e.conn.Write([]byte(strings.TrimSuffix(b.String(), "\n")))
+	}
+}
+
+// writeMetric formats one statsd line into b
+func (e *statsdEmitter) writeMetric(b *strings.Builder, name, value, kind string) {
+	b.WriteString(e.prefix)
+	b.WriteByte('.')
+	b.WriteString(name)
+	b.WriteByte(':')
+	b.WriteString(value)
+	b.WriteByte('|')
+	b.WriteString(kind)
+	if len(e.tags) > 0 {
+		b.WriteString("|#")
+		b.WriteString(strings.Join(e.tags, ","))
+	}
+	b.WriteByte('\n')
+}
+
+// close releases the UDP socket
+func (e *statsdEmitter) close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		return nil
+	}
+	err := e.conn.Close()
+	e.conn = nil
+	return err
+}
@@ -0,0 +1,116 @@
+package trusera
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listenStatsd starts a UDP listener standing in for a statsd agent
+func listenStatsd(t *testing.T) *net.UDPConn {
+	t.Helper()
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readPacket reads a single statsd packet
+func readPacket(t *testing.T, conn *net.UDPConn) string {
+	t.Helper()
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("failed to read statsd packet: %v", err)
+	}
+	return string(buf[:n])
+}
+
+func TestStatsdEmitsDecisionMetrics(t *testing.T) {
+	agent := listenStatsd(t)
+
+	policyPath := writeTestPolicy(t, t.TempDir(), `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "blocked.example.com";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithStatsd(agent.LocalAddr().String(), WithStatsdPrefix("agent."), WithStatsdTags("env:test")),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	client := si.WrapClient(&http.Client{})
+
+	if _, err := client.Get("https://blocked.example.com/data"); err == nil {
+		t.Fatal("expected blocked request")
+	}
+
+	packet := readPacket(t, agent)
+	for _, want := range []string{
+		"agent.requests:1|c|#env:test",
+		"agent.decision.deny:1|c|#env:test",
+		"agent.enforcement.blocked:1|c|#env:test",
+		"agent.request.duration:",
+	} {
+		if !strings.Contains(packet, want) {
+			t.Errorf("expected packet to contain %q, got:\n%s", want, packet)
+		}
+	}
+}
+
+func TestStatsdAllowedRequest(t *testing.T) {
+	agent := listenStatsd(t)
+
+	si, err := NewStandaloneInterceptor(WithStatsd(agent.LocalAddr().String()))
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	resp, err := si.WrapClient(&http.Client{}).Get(backend.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	packet := readPacket(t, agent)
+	if !strings.Contains(packet, "trusera.decision.allow:1|c") {
+		t.Errorf("expected allow counter with default prefix, got:\n%s", packet)
+	}
+	if !strings.Contains(packet, "trusera.enforcement.allowed:1|c") {
+		t.Errorf("expected allowed counter, got:\n%s", packet)
+	}
+	if strings.Contains(packet, "|#") {
+		t.Errorf("expected no tags by default, got:\n%s", packet)
+	}
+}
+
+func TestStatsdInvalidAddress(t *testing.T) {
+	_, err := NewStandaloneInterceptor(WithStatsd("not a valid address"))
+	if err == nil {
+		t.Fatal("expected error for invalid statsd address")
+	}
+	if !strings.Contains(err.Error(), "failed to dial statsd") {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
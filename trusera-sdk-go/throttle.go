@@ -0,0 +1,110 @@
+package trusera
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrThrottled is returned by Flush while the API has asked the client to
+// back off with a 429 response
+var ErrThrottled = errors.New("throttled by the Trusera API")
+
+// maxRetryAfter caps how long a single Retry-After can pause the client
+const maxRetryAfter = 5 * time.Minute
+
+// closeThrottleWait bounds how long Close waits for a pause to end when its
+// context has no deadline
+const closeThrottleWait = 5 * time.Second
+
+// WithOnThrottle registers a function called when the API responds 429 with
+// Retry-After, with the time the client resumes sending. May be given several
+// times.
+func WithOnThrottle(fn func(until time.Time)) Option {
+	return func(c *Client) {
+		c.onThrottle = append(c.onThrottle, fn)
+	}
+}
+
+// ThrottledUntil returns when the client resumes sending after a 429, or the
+// zero time if it is not throttled. While throttled, background flushes are
+// skipped, Flush returns ErrThrottled and queued events are kept.
+func (c *Client) ThrottledUntil() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Now().Before(c.throttled) {
+		return c.throttled
+	}
+	return time.Time{}
+}
+
+// throttle pauses sending for d and notifies the hooks
+func (c *Client) throttle(d time.Duration) time.Time {
+	until := time.Now().Add(d)
+
+	c.mu.Lock()
+	if until.After(c.throttled) {
+		c.throttled = until
+	}
+	until = c.throttled
+	c.mu.Unlock()
+
+	for _, fn := range c.onThrottle {
+		fn(until)
+	}
+	return until
+}
+
+// waitThrottle waits for a pause to end before a final flush. It reports
+// false without waiting if the pause outlasts ctx's deadline, or
+// closeThrottleWait when ctx has none.
+func (c *Client) waitThrottle(ctx context.Context) bool {
+	until := c.ThrottledUntil()
+	if until.IsZero() {
+		return true
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(closeThrottleWait)
+	}
+	if until.After(deadline) {
+		return false
+	}
+	return sleepContext(ctx, time.Until(until))
+}
+
+// isThrottled reports whether err is a 429 carrying Retry-After
+func isThrottled(err error) bool {
+	var fe *flushError
+	return errors.As(err, &fe) && fe.retryAfter > 0
+}
+
+// parseRetryAfter reads a Retry-After header in seconds or as an HTTP date,
+// capped at maxRetryAfter
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+
+	var d time.Duration
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs <= 0 {
+			return 0, false
+		}
+		d = time.Duration(secs) * time.Second
+		if secs > int(maxRetryAfter/time.Second) {
+			d = maxRetryAfter
+		}
+	} else if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		d = t.Sub(now)
+	} else {
+		return 0, false
+	}
+	return min(d, maxRetryAfter), true
+}
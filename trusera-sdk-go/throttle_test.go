@@ -0,0 +1,113 @@
+package trusera
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"30", 30 * time.Second, true},
+		{"3600", maxRetryAfter, true},
+		{"99999999999999999", maxRetryAfter, true},
+		{now.Add(24 * time.Hour).Format(http.TimeFormat), maxRetryAfter, true},
+		{" 5 ", 5 * time.Second, true},
+		{"0", 0, false},
+		{"-1", 0, false},
+		{"", 0, false},
+		{"soon", 0, false},
+		{now.Add(time.Minute).Format(http.TimeFormat), time.Minute, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFlushRespectsRetryAfter(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	var hooked atomic.Int32
+	client := NewClient("test-key",
+		WithBaseURL(server.URL),
+		WithFlushRetry(3, time.Millisecond),
+		WithOnThrottle(func(until time.Time) { hooked.Add(1) }),
+	)
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	if err := client.Flush(); !errors.Is(err, ErrThrottled) {
+		t.Fatalf("expected ErrThrottled, got %v", err)
+	}
+	if n := attempts.Load(); n != 1 {
+		t.Errorf("expected no retries during the pause, got %d attempts", n)
+	}
+	if hooked.Load() != 1 {
+		t.Error("expected the throttle hook to be called")
+	}
+	if client.ThrottledUntil().IsZero() {
+		t.Error("expected the client to report being throttled")
+	}
+
+	// Further flushes don't reach the API until the pause ends
+	if err := client.Flush(); !errors.Is(err, ErrThrottled) {
+		t.Errorf("expected ErrThrottled while paused, got %v", err)
+	}
+	if n := attempts.Load(); n != 1 {
+		t.Errorf("expected no request while paused, got %d attempts", n)
+	}
+
+	// Close waits out the pause and delivers the re-queued event
+	if err := client.Close(); err != nil {
+		t.Fatalf("expected close to deliver after the pause, got %v", err)
+	}
+	if n := attempts.Load(); n != 2 {
+		t.Errorf("expected delivery after the pause, got %d attempts", n)
+	}
+}
+
+func TestCloseDoesNotWaitOutLongPause(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL))
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	if err := client.Flush(); !errors.Is(err, ErrThrottled) {
+		t.Fatalf("expected ErrThrottled, got %v", err)
+	}
+
+	start := time.Now()
+	err := client.Close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected Close to return promptly, took %v", elapsed)
+	}
+
+	var dropped *DroppedError
+	if !errors.As(err, &dropped) || dropped.Dropped != 1 || !errors.Is(err, ErrThrottled) {
+		t.Errorf("expected the queued event reported as dropped, got %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package tokenizer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Pre-tokenizer patterns of the tiktoken encodings, without the `\s+(?!\S)`
+// lookahead, which split handles instead
+var (
+	cl100kPattern = regexp.MustCompile(`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`)
+	o200kPattern  = regexp.MustCompile(`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+`)
+)
+
+// encodingPatterns are the pre-tokenizers of the encodings LoadEncodingFile knows
+var encodingPatterns = map[string]*regexp.Regexp{
+	"cl100k_base": cl100kPattern,
+	"o200k_base":  o200kPattern,
+}
+
+// modelEncodings maps model name prefixes to their tiktoken encoding
+var modelEncodings = map[string]string{
+	"gpt-4":                  "cl100k_base",
+	"gpt-3.5-turbo":          "cl100k_base",
+	"text-embedding-3":       "cl100k_base",
+	"text-embedding-ada-002": "cl100k_base",
+	"gpt-4o":                 "o200k_base",
+	"gpt-4.1":                "o200k_base",
+	"gpt-4.5":                "o200k_base",
+	"o1":                     "o200k_base",
+	"o3":                     "o200k_base",
+	"o4":                     "o200k_base",
+}
+
+// encodingFor returns the encoding of model, or "" if unknown
+func encodingFor(model string) string {
+	return modelEncodings[longestPrefix(model, modelEncodings)]
+}
+
+// BPE is a byte pair encoder compatible with tiktoken
+type BPE struct {
+	ranks   map[string]int
+	pattern *regexp.Regexp
+}
+
+// NewBPE returns an encoder merging byte pairs by ranks after splitting text
+// with pattern. The pattern may not use lookaheads; a trailing `\s+`
+// alternative behaves like tiktoken's `\s+(?!\S)|\s+`.
+func NewBPE(ranks map[string]int, pattern string) (*BPE, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pre-tokenizer pattern: %w", err)
+	}
+	return &BPE{ranks: ranks, pattern: re}, nil
+}
+
+// Encode returns the token IDs of text. Bytes missing from the ranks are skipped.
+func (b *BPE) Encode(text string) []int {
+	var tokens []int
+	for _, piece := range split(b.pattern, text) {
+		if rank, ok := b.ranks[piece]; ok {
+			tokens = append(tokens, rank)
+			continue
+		}
+		for _, part := range b.merge(piece) {
+			if rank, ok := b.ranks[part]; ok {
+				tokens = append(tokens, rank)
+			}
+		}
+	}
+	return tokens
+}
+
+// Count implements Tokenizer
+func (b *BPE) Count(text string) int {
+	return len(b.Encode(text))
+}
+
+// merge splits piece into bytes and repeatedly joins the adjacent pair with
+// the lowest rank
+func (b *BPE) merge(piece string) []string {
+	parts := make([]string, len(piece))
+	for i := range parts {
+		parts[i] = piece[i : i+1]
+	}
+
+	for len(parts) > 1 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i < len(parts)-1; i++ {
+			if rank, ok := b.ranks[parts[i]+parts[i+1]]; ok && rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		parts[best] += parts[best+1]
+		parts = append(parts[:best+1], parts[best+2:]...)
+	}
+	return parts
+}
+
+// split cuts text into pre-tokenizer pieces
+func split(re *regexp.Regexp, text string) []string {
+	var pieces []string
+	for start := 0; start < len(text); {
+		loc := re.FindStringIndex(text[start:])
+		if loc == nil || loc[1] == 0 {
+			// Unmatched text becomes a piece of its own
+			pieces = append(pieces, text[start:])
+			break
+		}
+		if loc[0] > 0 {
+			pieces = append(pieces, text[start:start+loc[0]])
+		}
+		end := trimLookahead(text, start+loc[0], start+loc[1])
+		pieces = append(pieces, text[start+loc[0]:end])
+		start = end
+	}
+	return pieces
+}
+
+// ParseTiktoken reads a .tiktoken rank file: one base64 token and its rank per line
+func ParseTiktoken(r io.Reader) (map[string]int, error) {
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		token, rank, ok := strings.Cut(text, " ")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected token and rank", line)
+		}
+		raw, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		n, err := strconv.Atoi(rank)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		ranks[string(raw)] = n
+	}
+	return ranks, scanner.Err()
+}
+
+// LoadEncodingFile loads a tiktoken rank file for the named encoding
+// ("cl100k_base" or "o200k_base") and uses it for that encoding's models
+func LoadEncodingFile(name, path string) (*BPE, error) {
+	pattern, ok := encodingPatterns[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding %q", name)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ranks, err := ParseTiktoken(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	bpe := &BPE{ranks: ranks, pattern: pattern}
+	registry.Lock()
+	registry.encodings[name] = bpe
+	registry.Unlock()
+	return bpe, nil
+}
@@ -0,0 +1,110 @@
+// Package tokenizer counts LLM tokens locally, for when a provider does not
+// report usage.
+//
+// BPE implements tiktoken-compatible byte pair encoding. The rank files are
+// not bundled; load them with LoadEncodingFile, e.g. from
+// https://openaipublic.blob.core.windows.net/encodings/cl100k_base.tiktoken.
+// Models without a loaded encoding fall back to Approx, a heuristic.
+package tokenizer
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Tokenizer counts the tokens in text
+type Tokenizer interface {
+	Count(text string) int
+}
+
+// Approx estimates token counts without a vocabulary: the larger of the
+// number of pre-tokenizer pieces and one token per CharsPerToken bytes (4 if
+// zero), which is close for English text on GPT-style vocabularies
+type Approx struct {
+	CharsPerToken float64
+}
+
+// Count implements Tokenizer
+func (a Approx) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	per := a.CharsPerToken
+	if per <= 0 {
+		per = 4
+	}
+	byLength := int(math.Ceil(float64(len(text)) / per))
+	if pieces := len(split(cl100kPattern, text)); pieces > byLength {
+		return pieces
+	}
+	return byLength
+}
+
+// registry holds tokenizers added by Register and encodings loaded by
+// LoadEncodingFile
+var registry = struct {
+	sync.RWMutex
+	models    map[string]Tokenizer
+	encodings map[string]*BPE
+}{models: make(map[string]Tokenizer), encodings: make(map[string]*BPE)}
+
+// Register makes t count tokens for model and for models starting with it,
+// e.g. "gpt-4o" also covers "gpt-4o-2024-08-06". Registered tokenizers take
+// precedence over loaded encodings.
+func Register(model string, t Tokenizer) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.models[strings.ToLower(model)] = t
+}
+
+// ForModel returns the tokenizer for model: one registered for the longest
+// matching prefix, else the model's tiktoken encoding if loaded, else Approx
+func ForModel(model string) Tokenizer {
+	model = strings.ToLower(model)
+	if i := strings.LastIndexByte(model, '/'); i >= 0 {
+		model = model[i+1:]
+	}
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	if name := longestPrefix(model, registry.models); name != "" {
+		return registry.models[name]
+	}
+	if bpe, ok := registry.encodings[encodingFor(model)]; ok {
+		return bpe
+	}
+	return Approx{}
+}
+
+// longestPrefix returns the longest key of m that model starts with
+func longestPrefix[T any](model string, m map[string]T) string {
+	best := ""
+	for name := range m {
+		if len(name) > len(best) && strings.HasPrefix(model, name) {
+			best = name
+		}
+	}
+	return best
+}
+
+// Count counts the tokens in text for model
+func Count(model, text string) int {
+	return ForModel(model).Count(text)
+}
+
+// trimLookahead emulates the `\s+(?!\S)` alternative of the tiktoken
+// patterns, which RE2 cannot express: a run of spaces or tabs followed by
+// other text leaves its last character to start the next piece. Runs with a
+// line break were matched by the `\s*[\r\n]+` alternative and are kept whole.
+func trimLookahead(text string, start, end int) int {
+	piece := text[start:end]
+	if end >= len(text) || utf8.RuneCountInString(piece) <= 1 ||
+		strings.TrimSpace(piece) != "" || strings.ContainsAny(piece, "\r\n") {
+		return end
+	}
+	_, size := utf8.DecodeLastRuneInString(piece)
+	return end - size
+}
@@ -0,0 +1,116 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"hello world", []string{"hello", " world"}},
+		{"hello  world", []string{"hello", " ", " world"}},
+		{"it's 12345!", []string{"it", "'s", " ", "123", "45", "!"}},
+		{"line\n\nnext", []string{"line", "\n\n", "next"}},
+		{"trailing   ", []string{"trailing", "   "}},
+	}
+	for _, tt := range tests {
+		if got := split(cl100kPattern, tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("split(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+// testRanks is a tiny vocabulary: every byte, then a few merges
+func testRanks() map[string]int {
+	ranks := make(map[string]int)
+	for i := 0; i < 256; i++ {
+		ranks[string([]byte{byte(i)})] = i
+	}
+	for i, merge := range []string{"he", "ll", "hell", "hello", " w", "or", " wor"} {
+		ranks[merge] = 256 + i
+	}
+	return ranks
+}
+
+func TestBPEEncode(t *testing.T) {
+	bpe, err := NewBPE(testRanks(), cl100kPattern.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// "hello" is a whole token; " world" merges to " wor" + "l" + "d"
+	want := []int{259, 262, 'l', 'd'}
+	if got := bpe.Encode("hello world"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if n := bpe.Count("hello world"); n != 4 {
+		t.Errorf("expected 4 tokens, got %d", n)
+	}
+}
+
+func TestLoadEncodingFile(t *testing.T) {
+	var b strings.Builder
+	for token, rank := range testRanks() {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank)
+	}
+	path := filepath.Join(t.TempDir(), "cl100k_base.tiktoken")
+	if err := os.WriteFile(path, []byte(b.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadEncodingFile("cl100k_base", path); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if n := Count("gpt-4-0613", "hello world"); n != 4 {
+		t.Errorf("expected the loaded encoding to count 4 tokens, got %d", n)
+	}
+	if _, ok := ForModel("gpt-4o").(Approx); !ok {
+		t.Error("expected gpt-4o to keep the approximation without o200k_base")
+	}
+
+	if _, err := LoadEncodingFile("p50k_base", path); err == nil {
+		t.Error("expected an error for an unknown encoding")
+	}
+}
+
+func TestParseTiktokenErrors(t *testing.T) {
+	for _, data := range []string{"aGk=\n", "!!! 1\n", "aGk= x\n"} {
+		if _, err := ParseTiktoken(strings.NewReader(data)); err == nil {
+			t.Errorf("expected an error for %q", data)
+		}
+	}
+}
+
+func TestApprox(t *testing.T) {
+	if n := (Approx{}).Count(""); n != 0 {
+		t.Errorf("expected 0 for empty text, got %d", n)
+	}
+	if n := (Approx{}).Count("The quick brown fox jumps over the lazy dog"); n != 11 {
+		t.Errorf("expected 11 tokens by length, got %d", n)
+	}
+	if n := (Approx{}).Count("a b c d e f"); n != 6 {
+		t.Errorf("expected one token per word, got %d", n)
+	}
+	if _, ok := ForModel("unknown-model").(Approx); !ok {
+		t.Error("expected Approx for an unregistered model")
+	}
+}
+
+type fixedTokenizer int
+
+func (f fixedTokenizer) Count(string) int { return int(f) }
+
+func TestRegister(t *testing.T) {
+	Register("my-model", fixedTokenizer(7))
+	if n := Count("gateway/my-model-v2", "anything"); n != 7 {
+		t.Errorf("expected the registered tokenizer, got %d", n)
+	}
+}
@@ -0,0 +1,129 @@
+package trusera
+
+import (
+	"encoding/json"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultMaxToolArgumentBytes caps captured tool arguments unless
+// WithToolArgumentLimit says otherwise
+const DefaultMaxToolArgumentBytes = 4096
+
+// ToolCallStatus is the outcome of a tool call
+type ToolCallStatus string
+
+const (
+	ToolCallSucceeded ToolCallStatus = "success"
+	ToolCallFailed    ToolCallStatus = "error"
+)
+
+// ToolCall describes one tool invocation by an agent
+type ToolCall struct {
+	Name string
+	// Arguments are encoded as JSON; a json.RawMessage or []byte is used as is
+	Arguments any
+	// Status defaults to ToolCallFailed when Err is set, ToolCallSucceeded otherwise
+	Status   ToolCallStatus
+	Duration time.Duration
+	Err      error
+}
+
+// ToolCallOption configures how NewToolCallEvent captures arguments
+type ToolCallOption func(*toolCallCapture)
+
+type toolCallCapture struct {
+	maxBytes int
+	redactor *Redactor
+	secrets  []SecretDetector
+}
+
+// WithToolArgumentLimit caps the captured arguments at n bytes (default 4096).
+// Zero or less leaves arguments out of the event.
+func WithToolArgumentLimit(n int) ToolCallOption {
+	return func(c *toolCallCapture) {
+		c.maxBytes = n
+	}
+}
+
+// WithToolArgumentRedaction replaces the PII and secret detectors applied to
+// arguments, by default DefaultPIIDetectors and DefaultSecretDetectors. A nil
+// redactor and no detectors turn redaction off.
+func WithToolArgumentRedaction(redactor *Redactor, secrets ...SecretDetector) ToolCallOption {
+	return func(c *toolCallCapture) {
+		c.redactor = redactor
+		c.secrets = secrets
+	}
+}
+
+// NewToolCallEvent returns an EventToolCall event for call. Arguments are
+// encoded as JSON, redacted and then cut to the size limit, so the captured
+// text may not be valid JSON; "arguments_truncated" says when it was cut.
+func NewToolCallEvent(call ToolCall, opts ...ToolCallOption) Event {
+	capture := toolCallCapture{
+		maxBytes: DefaultMaxToolArgumentBytes,
+		redactor: NewRedactor(),
+		secrets:  DefaultSecretDetectors(),
+	}
+	for _, opt := range opts {
+		opt(&capture)
+	}
+
+	status := call.Status
+	if status == "" {
+		status = ToolCallSucceeded
+		if call.Err != nil {
+			status = ToolCallFailed
+		}
+	}
+
+	e := NewEvent(EventToolCall, call.Name).
+		WithPayload("tool", call.Name).
+		WithPayload("status", string(status)).
+		WithDuration(call.Duration).
+		WithError(call.Err)
+
+	if call.Arguments != nil && capture.maxBytes > 0 {
+		args, size := capture.arguments(call.Arguments)
+		e = e.WithPayload("arguments", args).
+			WithPayload("arguments_bytes", size).
+			WithPayload("arguments_truncated", len(args) < size)
+	}
+	return e
+}
+
+// arguments encodes, redacts and truncates args, returning the captured text
+// and the size of the full encoding
+func (c toolCallCapture) arguments(args any) (string, int) {
+	var raw []byte
+	switch v := args.(type) {
+	case json.RawMessage:
+		raw = v
+	case []byte:
+		raw = v
+	default:
+		var err error
+		if raw, err = json.Marshal(v); err != nil {
+			return "", 0
+		}
+	}
+
+	s := string(raw)
+	if c.redactor != nil {
+		s = c.redactor.Redact(s)
+	}
+	s = redactSecrets(s, c.secrets)
+
+	size := len(s)
+	if size > c.maxBytes {
+		s = s[:c.maxBytes]
+		// Do not leave half of a multi-byte character at the end
+		for i := 0; i < utf8.UTFMax && len(s) > 0; i++ {
+			if r, n := utf8.DecodeLastRuneInString(s); r != utf8.RuneError || n != 1 {
+				break
+			}
+			s = s[:len(s)-1]
+		}
+	}
+	return s, size
+}
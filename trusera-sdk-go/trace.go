@@ -0,0 +1,86 @@
+package trusera
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// TraceContextFunc returns the trace and span IDs active in ctx as lowercase
+// hex strings, or empty strings when there is no span
+type TraceContextFunc func(ctx context.Context) (traceID, spanID string)
+
+// WithTraceContext records trace_id and span_id from the request context on
+// every event, so blocked calls can be correlated with distributed traces.
+// For OpenTelemetry:
+//
+//	trusera.WithTraceContext(func(ctx context.Context) (string, string) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		if !sc.IsValid() {
+//			return "", ""
+//		}
+//		return sc.TraceID().String(), sc.SpanID().String()
+//	})
+//
+// Without it, or when ctx carries no span, IDs are taken from a W3C
+// traceparent header on the outgoing request.
+func WithTraceContext(fn TraceContextFunc) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.traceContext = fn
+	}
+}
+
+// traceIDs returns the trace and span IDs for an outgoing request
+func (si *StandaloneInterceptor) traceIDs(req *http.Request) (string, string) {
+	if traceID, spanID := si.traceFromContext(req.Context()); traceID != "" {
+		return traceID, spanID
+	}
+	if traceID, spanID, ok := parseTraceparent(req.Header.Get("Traceparent")); ok {
+		return traceID, spanID
+	}
+	return "", ""
+}
+
+// traceFromContext returns the IDs reported by the configured TraceContextFunc
+func (si *StandaloneInterceptor) traceFromContext(ctx context.Context) (string, string) {
+	if si.traceContext == nil || ctx == nil {
+		return "", ""
+	}
+	return si.traceContext(ctx)
+}
+
+// parseTraceparent extracts the trace ID and parent span ID from a W3C
+// traceparent header ("00-<32 hex>-<16 hex>-<2 hex>")
+func parseTraceparent(header string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 {
+		return "", "", false
+	}
+
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isLowerHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return "", "", false
+	}
+	if !isLowerHex(traceID, 32) || !isLowerHex(spanID, 16) || !isLowerHex(flags, 2) {
+		return "", "", false
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return "", "", false
+	}
+
+	return traceID, spanID, true
+}
+
+// isLowerHex reports whether s is n lowercase hexadecimal digits
+func isLowerHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
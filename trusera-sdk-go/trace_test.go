@@ -0,0 +1,120 @@
+package trusera
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		header  string
+		traceID string
+		spanID  string
+		ok      bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", "", "", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "", "", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", "", "", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", "", "", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", "", "", false},
+		{"00-4bf92f3577b34da6-00f067aa0ba902b7-01", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, tt := range tests {
+		traceID, spanID, ok := parseTraceparent(tt.header)
+		if ok != tt.ok || traceID != tt.traceID || spanID != tt.spanID {
+			t.Errorf("parseTraceparent(%q) = %q, %q, %v", tt.header, traceID, spanID, ok)
+		}
+	}
+}
+
+func TestStandaloneInterceptorTraceparentHeader(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+
+	events := make(chan DecisionEvent, 1)
+	si, err := NewStandaloneInterceptor(
+		WithLogFile(logPath),
+		WithOnAllow(func(e DecisionEvent) { events <- e }),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/v1/chat", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	si.Close()
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	if entries[0].TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || entries[0].SpanID != "00f067aa0ba902b7" {
+		t.Errorf("unexpected trace IDs %q %q", entries[0].TraceID, entries[0].SpanID)
+	}
+
+	if e := <-events; e.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected hook to see trace ID, got %q", e.TraceID)
+	}
+}
+
+type traceKey struct{}
+
+func TestStandaloneInterceptorTraceContextFunc(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+
+	si, err := NewStandaloneInterceptor(
+		WithLogFile(logPath),
+		WithTraceContext(func(ctx context.Context) (string, string) {
+			if ids, ok := ctx.Value(traceKey{}).([2]string); ok {
+				return ids[0], ids[1]
+			}
+			return "", ""
+		}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+
+	// The context span takes precedence over the header
+	ctx := context.WithValue(context.Background(), traceKey{}, [2]string{"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbb"})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.example.com/a", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	// Without a span or header nothing is recorded
+	resp, err = client.Get("https://api.example.com/b")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	si.Close()
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].TraceID != "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa" || entries[0].SpanID != "bbbbbbbbbbbbbbbb" {
+		t.Errorf("unexpected context trace IDs %q %q", entries[0].TraceID, entries[0].SpanID)
+	}
+	if entries[1].TraceID != "" || entries[1].SpanID != "" {
+		t.Errorf("expected no trace IDs, got %q %q", entries[1].TraceID, entries[1].SpanID)
+	}
+}
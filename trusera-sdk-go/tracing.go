@@ -0,0 +1,105 @@
+package trusera
+
+import (
+	"context"
+	"net/http"
+)
+
+// TracerProvider creates the tracer for intercepted request spans. It has
+// the shape of OpenTelemetry's trace.TracerProvider, reduced to what the
+// interceptor uses, so the module does not depend on OpenTelemetry; see
+// WithTracerProvider for an adapter.
+type TracerProvider interface {
+	Tracer(name string) Tracer
+}
+
+// Tracer starts a span as a child of the span active in ctx, if any, and
+// returns a context carrying the new span
+type Tracer interface {
+	Start(ctx context.Context, spanName string) (context.Context, TraceSpan)
+}
+
+// TraceSpan is a span started by a Tracer
+type TraceSpan interface {
+	// SetAttributes records attributes with string, int or []string values
+	SetAttributes(attrs ...SpanAttribute)
+	// SetError marks the span as failed
+	SetError(description string)
+	End()
+}
+
+// SpanAttribute is a key and value set on a TraceSpan
+type SpanAttribute struct {
+	Key   string
+	Value any
+}
+
+// interceptSpanName names the span of an intercepted request
+const interceptSpanName = "trusera.intercept"
+
+// WithTracerProvider starts a span for each intercepted request, as a child
+// of the span active in the request's context, and ends it when the request
+// is logged. The span records the request, the policy decision, matched
+// rules and enforcement action as attributes, and is marked failed when the
+// request is blocked or times out. The forwarded request carries the span
+// in its context, so spans of the underlying transport nest under it, and
+// WithTraceContext reports its IDs. See STANDALONE.md for an OpenTelemetry
+// adapter.
+func WithTracerProvider(tp TracerProvider) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		si.tracer = tp.Tracer(otlpScopeName)
+	}
+}
+
+// startSpan starts the span of an intercepted request and returns the
+// request with the span in its context, or req and nil without a tracer
+func (si *StandaloneInterceptor) startSpan(req *http.Request) (*http.Request, TraceSpan) {
+	if si.tracer == nil {
+		return req, nil
+	}
+	ctx, span := si.tracer.Start(req.Context(), interceptSpanName)
+	return req.WithContext(ctx), span
+}
+
+// endSpan records the outcome of a logged request on its span and ends it
+func endSpan(span TraceSpan, entry eventLog, decision PolicyDecision) {
+	attrs := []SpanAttribute{
+		{"http.request.method", entry.Method},
+		{"url.full", entry.URL},
+		{"server.address", entry.Hostname},
+		{"trusera.decision", decision.Decision},
+		{"trusera.enforcement_action", entry.EnforcementAction},
+	}
+	if entry.Status != 0 {
+		attrs = append(attrs, SpanAttribute{"http.response.status_code", entry.Status})
+	}
+	if len(decision.Matched) > 0 {
+		attrs = append(attrs, SpanAttribute{"trusera.matched_rules", decision.Matched})
+	}
+	if entry.Reasons != "" {
+		// The entry's reasons have been through WithPIIRedaction
+		attrs = append(attrs, SpanAttribute{"trusera.reasons", entry.Reasons})
+	}
+	if entry.Exception != nil {
+		attrs = append(attrs, SpanAttribute{"trusera.exception_id", entry.Exception.ID})
+	}
+	// LLM calls use the OpenTelemetry GenAI attribute names
+	if entry.LLMProvider != "" {
+		attrs = append(attrs, SpanAttribute{"gen_ai.system", entry.LLMProvider})
+	}
+	if entry.LLMModel != "" {
+		attrs = append(attrs, SpanAttribute{"gen_ai.request.model", entry.LLMModel})
+	}
+	if entry.LLMGateway != "" {
+		attrs = append(attrs, SpanAttribute{"trusera.llm_gateway", entry.LLMGateway})
+	}
+	span.SetAttributes(attrs...)
+
+	switch entry.EnforcementAction {
+	case "blocked":
+		span.SetError("request blocked by Cedar policy")
+	case "timed_out":
+		span.SetError("request timed out")
+	}
+	span.End()
+}
@@ -0,0 +1,123 @@
+package trusera
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// recordingTracer keeps the spans it starts, each naming its parent
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	name   string
+	parent *recordedSpan
+	attrs  map[string]any
+	err    string
+	ended  bool
+}
+
+type spanKey struct{}
+
+func (rt *recordingTracer) Tracer(name string) Tracer { return rt }
+
+func (rt *recordingTracer) Start(ctx context.Context, name string) (context.Context, TraceSpan) {
+	parent, _ := ctx.Value(spanKey{}).(*recordedSpan)
+	span := &recordedSpan{name: name, parent: parent, attrs: map[string]any{}}
+	rt.mu.Lock()
+	rt.spans = append(rt.spans, span)
+	rt.mu.Unlock()
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...SpanAttribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *recordedSpan) SetError(description string) { s.err = description }
+func (s *recordedSpan) End()                        { s.ended = true }
+
+// contextTransport records the context of the last request it forwarded
+type contextTransport struct {
+	ctx context.Context
+}
+
+func (ct *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ct.ctx = req.Context()
+	return stubTransport{}.RoundTrip(req)
+}
+
+func TestTracerProvider(t *testing.T) {
+	tmpDir := t.TempDir()
+	policyPath := writeTestPolicy(t, tmpDir, `
+forbid ( principal, action == Action::"delete", resource )
+when {
+    resource.method == "DELETE";
+};
+`)
+
+	tracer := &recordingTracer{}
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithLogFile(filepath.Join(tmpDir, "events.jsonl")),
+		WithTracerProvider(tracer),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	defer si.Close()
+	base := &contextTransport{}
+	client := si.WrapClient(&http.Client{Transport: base})
+
+	// The caller's active span becomes the parent
+	ctx, caller := tracer.Start(context.Background(), "agent.step")
+	req, _ := http.NewRequestWithContext(ctx, "GET", "https://api.openai.com/v1/models", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	req, _ = http.NewRequestWithContext(ctx, "DELETE", "https://api.example.com/users/1", nil)
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("expected the DELETE to be blocked")
+	}
+
+	if len(tracer.spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(tracer.spans))
+	}
+	allowed, blocked := tracer.spans[1], tracer.spans[2]
+	for _, span := range []*recordedSpan{allowed, blocked} {
+		if span.name != interceptSpanName || span.parent != caller || !span.ended {
+			t.Errorf("unexpected span %+v", span)
+		}
+	}
+	if got, _ := base.ctx.Value(spanKey{}).(*recordedSpan); got != allowed {
+		t.Error("expected the forwarded request to carry the interceptor span")
+	}
+
+	if allowed.attrs["trusera.decision"] != "Allow" || allowed.attrs["trusera.enforcement_action"] != "allowed" ||
+		allowed.attrs["http.response.status_code"] != http.StatusOK || allowed.attrs["gen_ai.system"] != "openai" || allowed.err != "" {
+		t.Errorf("unexpected allowed span %+v", allowed)
+	}
+	if blocked.attrs["trusera.enforcement_action"] != "blocked" || blocked.attrs["http.request.method"] != "DELETE" || blocked.err == "" {
+		t.Errorf("unexpected blocked span %+v", blocked)
+	}
+	if matched, _ := blocked.attrs["trusera.matched_rules"].([]string); len(matched) != 1 {
+		t.Errorf("expected the matched rule on the blocked span, got %v", blocked.attrs["trusera.matched_rules"])
+	}
+	if _, ok := blocked.attrs["http.response.status_code"]; ok {
+		t.Error("a blocked request has no response status")
+	}
+	if blocked.attrs["trusera.reasons"] != "forbid: resource.method == DELETE (actual: DELETE)" {
+		t.Errorf("unexpected reasons %v", blocked.attrs["trusera.reasons"])
+	}
+}
@@ -2,12 +2,15 @@ package trusera
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand"
 	"net/http"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,6 +18,10 @@ const (
 	defaultBaseURL       = "https://api.trusera.io"
 	defaultFlushInterval = 30 * time.Second
 	defaultBatchSize     = 100
+	defaultFlushRetries  = 3
+	defaultFlushBackoff  = time.Second
+
+	eventsPath = "/v1/events"
 )
 
 // Client sends agent events to Trusera API
@@ -23,12 +30,55 @@ type Client struct {
 	baseURL    string
 	agentID    string
 	httpClient *http.Client
+	header     http.Header
 	events     []Event
 	mu         sync.Mutex
 	flushSize  int
+	retry      retryPolicy
+	diskDir    string
+	diskMax    int64
+	disk       *diskQueue
+	tlsFiles   clientTLSFiles
+	setupErr   error
 	done       chan struct{}
 	ticker     *time.Ticker
 	wg         sync.WaitGroup
+	closeOnce  sync.Once
+	validator  EventValidator
+	onReject   func(Event, error)
+	throttled  time.Time
+	maxQueued  int
+	dropPolicy DropPolicy
+	dropped    atomic.Int64
+	counters   clientCounters
+	exporters  []LogSink
+	onThrottle []func(until time.Time)
+	dedupe     *dedupeWindow
+	heartbeat  time.Duration
+
+	// metadata is sent with each batch unless disabled by WithRuntimeMetadata
+	metadata    *RuntimeMetadata
+	environment string
+	noMetadata  bool
+
+	// sequence numbers tracked events in order; guarded by mu
+	sequence uint64
+
+	// flushMu serializes flushes so batches reach the API in queue order
+	flushMu sync.Mutex
+
+	deadLetterPath string
+	deadLetterMu   sync.Mutex
+	agentStatePath string
+
+	// decisions are queued by WithDecisionReporting; guarded by mu
+	decisions []DecisionReport
+
+	// apiDisabled stops events from being queued for the Trusera API
+	apiDisabled bool
+
+	// dropsReported is the part of the drop count already returned by CloseContext
+	dropsReported atomic.Int64
 }
 
 // Option configures a Client
@@ -67,6 +117,77 @@ func WithBatchSize(n int) Option {
 	}
 }
 
+// WithHTTPClient sets the http.Client used for API calls, e.g. one configured
+// for a corporate proxy or custom TLS roots. The default has a 10s timeout.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		if client != nil {
+			c.httpClient = client
+		}
+	}
+}
+
+// WithTransport sets the RoundTripper used for API calls, keeping the default
+// 10s timeout. Use it to add instrumentation, or pass
+// StandaloneInterceptor.WrapTransport to run the client's own traffic through
+// policy enforcement.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		client := *c.httpClient
+		client.Transport = rt
+		c.httpClient = &client
+	}
+}
+
+// WithHeader adds a header to every API request, e.g. an org ID or a token
+// for an API gateway. Content-Type and Authorization are set by the client and
+// cannot be overridden.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Add(key, value)
+	}
+}
+
+// WithHeaders adds several headers to every API request, like WithHeader
+func WithHeaders(headers map[string]string) Option {
+	return func(c *Client) {
+		for k, v := range headers {
+			c.header.Add(k, v)
+		}
+	}
+}
+
+// DropPolicy selects which events are discarded when the queue is full
+type DropPolicy int
+
+const (
+	// DropOldest discards the oldest queued events to make room
+	DropOldest DropPolicy = iota
+	// DropNewest discards incoming events until there is room
+	DropNewest
+)
+
+// WithMaxQueuedEvents bounds the in-memory queue to n events, so an API
+// outage cannot grow agent memory without limit. Events beyond the limit are
+// discarded according to policy and reported as dropped by CloseContext.
+func WithMaxQueuedEvents(n int, policy DropPolicy) Option {
+	return func(c *Client) {
+		c.maxQueued = n
+		c.dropPolicy = policy
+	}
+}
+
+// WithFlushRetry sets how many times a failed flush is retried (default 3) and
+// the initial backoff, which doubles per attempt with jitter (default 1s).
+// Connection errors, 429 and 5xx responses are retried; events still
+// undelivered after the last attempt are put back in the queue for the next
+// flush. A max of 0 disables retries.
+func WithFlushRetry(max int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retry = retryPolicy{max: max, backoff: backoff}
+	}
+}
+
 // envOrDefault returns the value of the environment variable named by key,
 // or fallback if the variable is not set or empty.
 func envOrDefault(key, fallback string) string {
@@ -85,22 +206,42 @@ func NewClient(apiKey string, opts ...Option) *Client {
 	}
 
 	c := &Client{
-		apiKey:     apiKey,
-		baseURL:    envOrDefault("TRUSERA_API_URL", defaultBaseURL),
-		httpClient: &http.Client{Timeout: 10 * time.Second},
-		events:     make([]Event, 0, defaultBatchSize),
-		flushSize:  defaultBatchSize,
-		done:       make(chan struct{}),
-		ticker:     time.NewTicker(defaultFlushInterval),
+		apiKey:      apiKey,
+		baseURL:     envOrDefault("TRUSERA_API_URL", defaultBaseURL),
+		environment: os.Getenv("TRUSERA_ENVIRONMENT"),
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		header:      make(http.Header),
+		events:      make([]Event, 0, defaultBatchSize),
+		flushSize:   defaultBatchSize,
+		retry:       retryPolicy{max: defaultFlushRetries, backoff: defaultFlushBackoff},
+		done:        make(chan struct{}),
+		ticker:      time.NewTicker(defaultFlushInterval),
 	}
 
 	for _, opt := range opts {
 		opt(c)
 	}
 
+	if !c.noMetadata {
+		c.metadata = collectRuntimeMetadata(c.environment)
+	}
+	if c.diskDir != "" {
+		var err error
+		c.disk, err = openDiskQueue(c.diskDir, c.diskMax)
+		c.setupErr = errors.Join(c.setupErr, err)
+	}
+	if c.tlsFiles != (clientTLSFiles{}) {
+		c.setupErr = errors.Join(c.setupErr, c.configureTLS())
+	}
+
 	c.wg.Add(1)
 	go c.backgroundFlusher()
 
+	if c.heartbeat > 0 {
+		c.wg.Add(1)
+		go c.heartbeatLoop(time.Now())
+	}
+
 	return c
 }
 
@@ -110,7 +251,7 @@ func (c *Client) backgroundFlusher() {
 	for {
 		select {
 		case <-c.ticker.C:
-			_ = c.Flush()
+			_, _ = c.flush(context.Background(), c.done)
 		case <-c.done:
 			return
 		}
@@ -119,24 +260,105 @@ func (c *Client) backgroundFlusher() {
 
 // Track queues an event for sending
 func (c *Client) Track(event Event) {
+	c.TrackBatch([]Event{event})
+}
+
+// TrackBatch queues several events at once, for instrumentation that buffers
+// its own per-step events. It takes the queue lock once and triggers at most
+// one auto-flush.
+func (c *Client) TrackBatch(events []Event) {
+	events = c.assignKeys(c.validate(events))
+	if len(events) == 0 {
+		return
+	}
+
+	// Number the events and queue them under one lock, so queue order
+	// matches sequence order
+	events = append(events[:0:0], events...)
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	for i := range events {
+		c.sequence++
+		events[i].Sequence = c.sequence
+	}
+	if c.apiDisabled {
+		c.mu.Unlock()
+		c.export(events)
+		return
+	}
 
-	c.events = append(c.events, event)
+	queued := 0
+	for _, event := range events {
+		if c.disk != nil {
+			if n, err := c.spool(event); err == nil {
+				queued = n
+				continue
+			}
+			// Keep the event in memory rather than lose it
+		}
+		c.events = append(c.events, event)
+	}
+	c.trimLocked()
 
-	if len(c.events) >= c.flushSize {
+	if queued+len(c.events) >= c.flushSize {
+		c.wg.Add(1)
 		go func() {
-			_ = c.Flush()
+			defer c.wg.Done()
+			_, _ = c.flush(context.Background(), c.done)
 		}()
 	}
+	c.mu.Unlock()
+
+	c.export(events)
 }
 
 // Flush sends all queued events to the API
 func (c *Client) Flush() error {
+	_, err := c.flush(context.Background(), nil)
+	return err
+}
+
+// flush sends all queued events, returning how many could not be delivered
+// and are not kept on disk. Backoff between retries ends early when stop is
+// closed, so background flushes do not hold up Close.
+func (c *Client) flush(ctx context.Context, stop <-chan struct{}) (int, error) {
+	if until := c.ThrottledUntil(); !until.IsZero() {
+		if stop != nil {
+			// Background flushes wait for the pause to end
+			return 0, nil
+		}
+		return 0, fmt.Errorf("%w until %s", ErrThrottled, until.Format(time.RFC3339))
+	}
+
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	var lost int
+	var errs []error
+
+	if c.setupErr != nil {
+		errs = append(errs, c.setupErr)
+	}
+	if c.disk != nil {
+		n, err := c.flushDisk(ctx, stop)
+		lost += n
+		errs = append(errs, err)
+	}
+
+	n, err := c.flushMemory(ctx, stop)
+	lost += n
+	errs = append(errs, err)
+
+	errs = append(errs, c.flushDecisions(ctx, stop))
+
+	return lost, errors.Join(errs...)
+}
+
+// flushMemory sends the in-memory queue as one batch
+func (c *Client) flushMemory(ctx context.Context, stop <-chan struct{}) (int, error) {
 	c.mu.Lock()
 	if len(c.events) == 0 {
 		c.mu.Unlock()
-		return nil
+		return 0, nil
 	}
 
 	events := make([]Event, len(c.events))
@@ -144,35 +366,167 @@ func (c *Client) Flush() error {
 	c.events = c.events[:0]
 	c.mu.Unlock()
 
-	payload := map[string]interface{}{
-		"agent_id": c.agentID,
-		"events":   events,
+	body, err := c.batchBody(c.agentID, events)
+	if err != nil {
+		return len(events), fmt.Errorf("failed to marshal events: %w", err)
 	}
 
-	body, err := json.Marshal(payload)
+	err = c.deliver(ctx, stop, eventsPath, body)
+	c.recordDelivery(len(events), err)
 	if err != nil {
-		return fmt.Errorf("failed to marshal events: %w", err)
+		if c.deadLetterPath != "" && !isThrottled(err) {
+			dlErr := c.writeDeadLetter(events, err)
+			if dlErr == nil {
+				c.counters.deadLettered.Add(int64(len(events)))
+				return 0, err
+			}
+			err = errors.Join(err, dlErr)
+		}
+		if isTransient(err) {
+			c.requeue(events)
+		}
+		return len(events), err
+	}
+	return 0, nil
+}
+
+// deliver posts body to path, retrying transient failures per the retry
+// policy. A 429 with Retry-After is not retried here; the client pauses instead.
+func (c *Client) deliver(ctx context.Context, stop <-chan struct{}, path string, body []byte) error {
+	err := c.send(ctx, path, body)
+	for attempt := 1; err != nil && isTransient(err) && !isThrottled(err) && attempt <= c.retry.max; attempt++ {
+		if !sleepUntil(ctx, stop, jitter(c.retry.delay(attempt))) {
+			break
+		}
+		err = c.send(ctx, path, body)
 	}
+	return err
+}
 
-	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/v1/events", bytes.NewReader(body))
+// newRequest builds an authenticated JSON request to the API, carrying the
+// headers set by WithHeader
+func (c *Client) newRequest(ctx context.Context, method, path string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
+	for k, vv := range c.header {
+		req.Header[k] = vv
+	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	return req, nil
+}
+
+// send posts one encoded batch to path
+func (c *Client) send(ctx context.Context, path string, body []byte) error {
+	req, err := c.newRequest(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send events: %w", err)
+		return &flushError{err: fmt.Errorf("failed to send events: %w", err), transient: ctx.Err() == nil}
+	}
+	if resp.StatusCode < 400 {
+		discardResponse(resp)
+		return nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	apiErr := newAPIError(resp)
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			until := c.throttle(d)
+			return &flushError{
+				err:        fmt.Errorf("%w: %w until %s", apiErr, ErrThrottled, until.Format(time.RFC3339)),
+				transient:  true,
+				retryAfter: d,
+			}
+		}
+	}
+
+	return &flushError{
+		err:       apiErr,
+		transient: resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500,
+	}
+}
+
+// requeue puts undelivered events back ahead of events tracked since
+func (c *Client) requeue(events []Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.events = append(events, c.events...)
+	c.trimLocked()
+}
+
+// trimLocked enforces the queue limit set by WithMaxQueuedEvents
+func (c *Client) trimLocked() {
+	over := len(c.events) - c.maxQueued
+	if c.maxQueued <= 0 || over <= 0 {
+		return
+	}
+
+	if c.dropPolicy == DropNewest {
+		c.events = c.events[:c.maxQueued]
+	} else {
+		c.events = append(c.events[:0], c.events[over:]...)
+	}
+	c.dropped.Add(int64(over))
+}
+
+// takeDrops returns how many events queue limits discarded since the last call
+func (c *Client) takeDrops() int {
+	total := c.dropped.Load()
+	if c.disk != nil {
+		total += c.disk.dropped.Load()
 	}
+	return int(total - c.dropsReported.Swap(total))
+}
+
+// flushError records whether a failed flush may succeed if retried
+type flushError struct {
+	err        error
+	transient  bool
+	retryAfter time.Duration
+}
+
+func (e *flushError) Error() string { return e.err.Error() }
+func (e *flushError) Unwrap() error { return e.err }
 
-	return nil
+// isTransient reports whether err is a failure worth retrying
+func isTransient(err error) bool {
+	var fe *flushError
+	return errors.As(err, &fe) && fe.transient
+}
+
+// jitter spreads a backoff delay over [d/2, d) so clients recovering from
+// the same outage do not retry in lockstep
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)))
+}
+
+// sleepUntil waits for d, returning false if ctx ends or stop is closed first
+func sleepUntil(ctx context.Context, stop <-chan struct{}, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-stop:
+		return false
+	}
 }
 
 // RegisterAgent registers an agent with Trusera, returns agent ID
@@ -181,24 +535,24 @@ func (c *Client) RegisterAgent(name, framework string) (string, error) {
 		return "", errors.New("agent name is required")
 	}
 
-	payload := map[string]string{
+	payload := map[string]interface{}{
 		"name":      name,
 		"framework": framework,
 	}
+	if c.metadata != nil {
+		payload["runtime"] = c.metadata
+	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/v1/agents", bytes.NewReader(body))
+	req, err := c.newRequest(context.Background(), http.MethodPost, "/v1/agents", body)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to register agent: %w", err)
@@ -206,7 +560,7 @@ func (c *Client) RegisterAgent(name, framework string) (string, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		return "", fmt.Errorf("API returned status %d", resp.StatusCode)
+		return "", newAPIError(resp)
 	}
 
 	var result struct {
@@ -226,9 +580,45 @@ func (c *Client) RegisterAgent(name, framework string) (string, error) {
 
 // Close flushes remaining events and stops background goroutine
 func (c *Client) Close() error {
-	c.ticker.Stop()
-	close(c.done)
-	c.wg.Wait()
+	return c.CloseContext(context.Background())
+}
 
-	return c.Flush()
+// CloseContext stops the background flusher and sends the remaining events,
+// abandoning the request once ctx is done. Undelivered events are reported as
+// a *DroppedError.
+func (c *Client) CloseContext(ctx context.Context) error {
+	c.closeOnce.Do(func() {
+		c.ticker.Stop()
+		close(c.done)
+	})
+
+	if !waitContext(ctx, &c.wg) {
+		c.mu.Lock()
+		pending := len(c.events)
+		c.mu.Unlock()
+		return &DroppedError{Dropped: pending + c.takeDrops(), Err: ctx.Err()}
+	}
+
+	var n int
+	var err error
+	if c.waitThrottle(ctx) {
+		n, err = c.flush(ctx, nil)
+	} else {
+		// A long pause does not hold up shutdown. Spooled events stay in the
+		// disk queue for the next run; those in memory are dropped.
+		c.mu.Lock()
+		n = len(c.events)
+		c.mu.Unlock()
+		err = fmt.Errorf("%w until %s", ErrThrottled, c.ThrottledUntil().Format(time.RFC3339))
+	}
+	if c.disk != nil {
+		err = errors.Join(err, c.disk.close())
+	}
+	exportDropped, exportErr := c.closeExporters(ctx)
+	n += exportDropped + c.takeDrops()
+	err = errors.Join(err, exportErr)
+	if n > 0 {
+		return &DroppedError{Dropped: n, Err: err}
+	}
+	return err
 }
@@ -2,9 +2,13 @@ package trusera
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -53,7 +57,8 @@ func TestClientWithOptions(t *testing.T) {
 }
 
 func TestTrackEvent(t *testing.T) {
-	client := NewClient("test-key")
+	// No API is reachable, so don't retry the final flush on Close
+	client := NewClient("test-key", WithFlushRetry(0, 0))
 	defer client.Close()
 
 	event := NewEvent(EventToolCall, "test-tool")
@@ -267,6 +272,88 @@ func TestBatchAutoFlush(t *testing.T) {
 	mu.Unlock()
 }
 
+func TestTrackBatch(t *testing.T) {
+	var requests atomic.Int32
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		requests.Add(1)
+		received.Add(int32(len(payload.Events)))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithBatchSize(5))
+
+	batch := make([]Event, 12)
+	for i := range batch {
+		batch[i] = NewEvent(EventToolCall, "step")
+	}
+	client.TrackBatch(batch)
+	client.TrackBatch(nil)
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if n := received.Load(); n != 12 {
+		t.Errorf("expected 12 events delivered, got %d", n)
+	}
+	// One auto-flush for the batch, then the final flush on Close at most
+	if n := requests.Load(); n > 2 {
+		t.Errorf("expected at most 2 requests, got %d", n)
+	}
+}
+
+func TestEventsDeliveredInSequence(t *testing.T) {
+	var mu sync.Mutex
+	var seqs []uint64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		// Slow responses give racing flushes a chance to overtake each other
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		for _, e := range payload.Events {
+			seqs = append(seqs, e.Sequence)
+		}
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithBatchSize(3), WithFlushInterval(time.Millisecond))
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				client.Track(NewEvent(EventToolCall, "search"))
+			}
+		}()
+	}
+	wg.Wait()
+	if err := client.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seqs) != 100 {
+		t.Fatalf("expected 100 events, got %d", len(seqs))
+	}
+	for i, seq := range seqs {
+		if seq != uint64(i+1) {
+			t.Fatalf("event %d has sequence %d, want %d", i, seq, i+1)
+		}
+	}
+}
+
 func TestClose(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -292,6 +379,301 @@ func TestClose(t *testing.T) {
 	}
 }
 
+func TestFlushRetriesTransientFailures(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithFlushRetry(3, time.Millisecond))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("expected flush to succeed after retries, got %v", err)
+	}
+	if n := attempts.Load(); n != 3 {
+		t.Errorf("expected 3 attempts, got %d", n)
+	}
+}
+
+func TestFlushRequeuesAfterRetriesExhausted(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithFlushRetry(2, time.Millisecond))
+
+	client.Track(NewEvent(EventToolCall, "first"))
+	if err := client.Flush(); err == nil {
+		t.Fatal("expected flush to fail")
+	}
+	if n := attempts.Load(); n != 3 {
+		t.Errorf("expected 3 attempts, got %d", n)
+	}
+
+	client.Track(NewEvent(EventToolCall, "second"))
+
+	client.mu.Lock()
+	if len(client.events) != 2 || client.events[0].Name != "first" {
+		t.Errorf("expected failed event re-queued ahead of new ones, got %+v", client.events)
+	}
+	client.mu.Unlock()
+
+	client.Close()
+}
+
+func TestFlushDoesNotRetryRejectedBatch(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithFlushRetry(3, time.Millisecond))
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	if err := client.Flush(); err == nil {
+		t.Fatal("expected flush to fail")
+	}
+	if n := attempts.Load(); n != 1 {
+		t.Errorf("expected a single attempt for a 400, got %d", n)
+	}
+
+	client.mu.Lock()
+	if len(client.events) != 0 {
+		t.Errorf("expected rejected events not to be re-queued, got %d", len(client.events))
+	}
+	client.mu.Unlock()
+}
+
+func TestCloseInterruptsBackgroundBackoff(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key",
+		WithBaseURL(server.URL),
+		WithBatchSize(1),
+		WithFlushRetry(5, time.Minute),
+	)
+	client.Track(NewEvent(EventToolCall, "search"))
+	time.Sleep(50 * time.Millisecond)
+
+	// The auto-flush is now backing off; Close delivers the batch itself
+	start := time.Now()
+	if err := client.Close(); err != nil {
+		t.Fatalf("expected close to deliver the batch, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected Close not to wait for backoff, took %v", elapsed)
+	}
+	if n := attempts.Load(); n != 2 {
+		t.Errorf("expected 2 attempts, got %d", n)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if d := jitter(time.Second); d < 500*time.Millisecond || d >= time.Second {
+			t.Fatalf("jitter out of range: %v", d)
+		}
+	}
+}
+
+// countingTransport counts requests before passing them on
+type countingTransport struct {
+	n    atomic.Int32
+	base http.RoundTripper
+}
+
+func (ct *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ct.n.Add(1)
+	return ct.base.RoundTrip(req)
+}
+
+func TestClientWithTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	rt := &countingTransport{base: http.DefaultTransport}
+	client := NewClient("test-key", WithBaseURL(server.URL), WithTransport(rt))
+	defer client.Close()
+
+	if client.httpClient.Timeout != 10*time.Second {
+		t.Errorf("expected default timeout to be kept, got %v", client.httpClient.Timeout)
+	}
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if rt.n.Load() != 1 {
+		t.Errorf("expected custom transport to be used, got %d requests", rt.n.Load())
+	}
+}
+
+func TestClientWithHTTPClient(t *testing.T) {
+	hc := &http.Client{}
+	client := NewClient("test-key", WithHTTPClient(hc), WithHTTPClient(nil), WithFlushRetry(0, 0))
+	defer client.Close()
+
+	if client.httpClient != hc {
+		t.Error("expected custom http client")
+	}
+}
+
+func TestClientSelfMonitoring(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "events.jsonl")
+	policyPath := writeTestPolicy(t, dir, `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "127.0.0.1";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithLogFile(logPath),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+
+	client := NewClient("test-key",
+		WithBaseURL(server.URL),
+		WithTransport(si.WrapTransport(nil)),
+		WithFlushRetry(0, 0),
+	)
+
+	client.Track(NewEvent(EventToolCall, "search"))
+	if err := client.Flush(); err == nil {
+		t.Error("expected the interceptor to block the flush")
+	}
+	client.Close()
+	si.Close()
+
+	entries := readLogEntries(t, logPath)
+	if len(entries) == 0 || entries[0].Path != "/v1/events" || entries[0].EnforcementAction != "blocked" {
+		t.Errorf("expected the blocked flush in the log, got %+v", entries)
+	}
+}
+
+func TestClientCustomHeaders(t *testing.T) {
+	var mu sync.Mutex
+	var seen []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Clone())
+		mu.Unlock()
+		if r.URL.Path == "/v1/agents" {
+			w.Write([]byte(`{"agent_id":"agent-1"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key",
+		WithBaseURL(server.URL),
+		WithHeader("X-Org-ID", "org-42"),
+		WithHeaders(map[string]string{"X-Gateway-Token": "gw", "Authorization": "ignored"}),
+	)
+	defer client.Close()
+
+	if _, err := client.RegisterAgent("agent", "custom"); err != nil {
+		t.Fatalf("RegisterAgent failed: %v", err)
+	}
+	client.Track(NewEvent(EventToolCall, "search"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(seen))
+	}
+	for _, h := range seen {
+		if h.Get("X-Org-ID") != "org-42" || h.Get("X-Gateway-Token") != "gw" {
+			t.Errorf("expected custom headers, got %v", h)
+		}
+		if h.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("expected client authorization to win, got %q", h.Get("Authorization"))
+		}
+	}
+}
+
+func TestMaxQueuedEvents(t *testing.T) {
+	names := func(events []Event) []string {
+		var out []string
+		for _, e := range events {
+			out = append(out, e.Name)
+		}
+		return out
+	}
+
+	tests := []struct {
+		policy DropPolicy
+		want   string
+	}{
+		{DropOldest, "[c d e]"},
+		{DropNewest, "[a b c]"},
+	}
+
+	for _, tt := range tests {
+		client := NewClient("test-key",
+			WithBaseURL("http://127.0.0.1:1"),
+			WithMaxQueuedEvents(3, tt.policy),
+			WithBatchSize(100),
+			WithFlushRetry(0, 0),
+		)
+
+		client.Track(NewEvent(EventToolCall, "a"))
+		client.TrackBatch([]Event{
+			NewEvent(EventToolCall, "b"),
+			NewEvent(EventToolCall, "c"),
+			NewEvent(EventToolCall, "d"),
+		})
+		client.Track(NewEvent(EventToolCall, "e"))
+
+		client.mu.Lock()
+		got := fmt.Sprint(names(client.events))
+		client.mu.Unlock()
+		if got != tt.want {
+			t.Errorf("policy %d: expected %s queued, got %s", tt.policy, tt.want, got)
+		}
+		if n := client.dropped.Load(); n != 2 {
+			t.Errorf("policy %d: expected 2 dropped, got %d", tt.policy, n)
+		}
+
+		// Drops are reported on close, along with the undeliverable queue
+		var dropped *DroppedError
+		if err := client.Close(); !errors.As(err, &dropped) || dropped.Dropped != 5 {
+			t.Errorf("policy %d: expected 5 dropped on close, got %v", tt.policy, err)
+		}
+	}
+}
+
 // ─── Environment variable configuration tests ─────────────────────────
 
 func TestEnvVarAPIKey(t *testing.T) {
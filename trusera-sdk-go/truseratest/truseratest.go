@@ -0,0 +1,463 @@
+// Package truseratest provides an in-memory fake of the Trusera API for
+// testing code instrumented with the SDK, without network access or
+// hand-written handlers.
+//
+// The server accepts events, decisions, BOMs and agent registrations, serves
+// agents and Cedar policies, and records everything it receives:
+//
+//	api := truseratest.NewServer(t)
+//	client := api.Client(trusera.WithAgentID("agent-1"))
+//	runAgent(client)
+//	client.Flush()
+//	for _, e := range api.ReceivedEvents() { ... }
+package truseratest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/aibom"
+)
+
+const (
+	// defaultAPIKey is the key Client uses when WithAPIKey is not given
+	defaultAPIKey = "test-key"
+
+	// defaultPageSize is how many agents a list returns without a limit
+	defaultPageSize = 100
+
+	// waitTimeout bounds how long WaitForEvents waits
+	waitTimeout = 5 * time.Second
+)
+
+// BOMUpload is a BOM received by the server, with the agent and run it was
+// tagged with
+type BOMUpload struct {
+	AgentID string          `json:"agent_id"`
+	RunID   string          `json:"run_id,omitempty"`
+	BOM     json.RawMessage `json:"bom"`
+}
+
+// policy is a Cedar policy served at /v1/policies/cedar
+type policy struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Cedar   string `json:"cedar_dsl"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Server is a fake Trusera API. It is safe for concurrent use.
+type Server struct {
+	// URL is the base URL of the server, for trusera.WithBaseURL
+	URL string
+
+	t      testing.TB
+	srv    *httptest.Server
+	apiKey string
+
+	mu        sync.Mutex
+	events    []trusera.Event
+	seenKeys  map[string]bool
+	decisions []trusera.DecisionReport
+	boms      []BOMUpload
+	agents    []*trusera.Agent
+	nextAgent int
+	policies  []policy
+	failures  []int
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithAPIKey makes the server reject requests not authenticated with key,
+// answering 401. By default any key is accepted.
+func WithAPIKey(key string) Option {
+	return func(s *Server) {
+		s.apiKey = key
+	}
+}
+
+// NewServer starts a fake API that is closed when the test ends
+func NewServer(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+	s := &Server{t: t, seenKeys: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Client returns a client sending to the server, with the key the server
+// expects, followed by opts. It is closed when the test ends, before the
+// server.
+func (s *Server) Client(opts ...trusera.Option) *trusera.Client {
+	key := s.apiKey
+	if key == "" {
+		key = defaultAPIKey
+	}
+	client := trusera.NewClient(key, append([]trusera.Option{trusera.WithBaseURL(s.URL)}, opts...)...)
+	s.t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// ReceivedEvents returns the events received so far, in order. Events
+// repeating an idempotency key already received are discarded, like the
+// real API does.
+func (s *Server) ReceivedEvents() []trusera.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]trusera.Event(nil), s.events...)
+}
+
+// WaitForEvents waits until at least n events were received, such as from a
+// client's background flush, and returns them. The test fails if they do
+// not arrive within 5 seconds.
+func (s *Server) WaitForEvents(n int) []trusera.Event {
+	s.t.Helper()
+	deadline := time.Now().Add(waitTimeout)
+	for {
+		events := s.ReceivedEvents()
+		if len(events) >= n {
+			return events
+		}
+		if time.Now().After(deadline) {
+			s.t.Fatalf("truseratest: expected %d events, received %d", n, len(events))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// ReceivedDecisions returns the decision reports received so far, in order
+func (s *Server) ReceivedDecisions() []trusera.DecisionReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]trusera.DecisionReport(nil), s.decisions...)
+}
+
+// BOMs returns the BOM uploads received so far, in order
+func (s *Server) BOMs() []BOMUpload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]BOMUpload(nil), s.boms...)
+}
+
+// LastBOM decodes the most recent BOM upload. The test fails if no BOM was
+// uploaded or it is not an aibom.BOM.
+func (s *Server) LastBOM() *aibom.BOM {
+	s.t.Helper()
+	boms := s.BOMs()
+	if len(boms) == 0 {
+		s.t.Fatal("truseratest: no BOM was uploaded")
+	}
+	var bom aibom.BOM
+	if err := json.Unmarshal(boms[len(boms)-1].BOM, &bom); err != nil {
+		s.t.Fatalf("truseratest: uploaded BOM is not an aibom.BOM: %v", err)
+	}
+	return &bom
+}
+
+// Agents returns the registered agents, in registration order
+func (s *Server) Agents() []trusera.Agent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	agents := make([]trusera.Agent, len(s.agents))
+	for i, a := range s.agents {
+		agents[i] = *a
+	}
+	return agents
+}
+
+// AddAgent registers agent as if the API already knew it and returns it as
+// stored. An ID is assigned if it has none.
+func (s *Server) AddAgent(agent trusera.Agent) trusera.Agent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.addAgentLocked(agent)
+}
+
+// SetPolicy serves the Cedar policy text under name at /v1/policies/cedar,
+// replacing a policy of the same name. Empty text removes the policy.
+func (s *Server) SetPolicy(name, cedar string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, p := range s.policies {
+		if p.Name == name {
+			s.policies = append(s.policies[:i], s.policies[i+1:]...)
+			break
+		}
+	}
+	if cedar != "" {
+		s.policies = append(s.policies, policy{ID: "policy-" + name, Name: name, Cedar: cedar, Enabled: true})
+	}
+}
+
+// FailNext answers the next n requests with status and an error body
+// instead of handling them, to exercise retries and error handling
+func (s *Server) FailNext(n, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, status)
+	}
+}
+
+// serveHTTP routes a request to its endpoint
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.apiKey != "" && r.Header.Get("Authorization") != "Bearer "+s.apiKey {
+		writeError(w, http.StatusUnauthorized, "unauthorized", "invalid API key")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failures) > 0 {
+		status := s.failures[0]
+		s.failures = s.failures[1:]
+		writeError(w, status, "injected_failure", "failure injected by truseratest")
+		return
+	}
+
+	switch path := r.URL.Path; {
+	case path == "/v1/events" && r.Method == http.MethodPost:
+		s.handleEvents(w, r)
+	case path == "/v1/decisions" && r.Method == http.MethodPost:
+		s.handleDecisions(w, r)
+	case path == "/v1/boms" && r.Method == http.MethodPost:
+		s.handleBOM(w, r)
+	case path == "/v1/policies/cedar" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{"policies": append([]policy{}, s.policies...)})
+	case path == "/v1/agents" && r.Method == http.MethodPost:
+		s.handleRegister(w, r)
+	case path == "/v1/agents" && r.Method == http.MethodGet:
+		s.handleListAgents(w, r)
+	case strings.HasPrefix(path, "/v1/agents/"):
+		s.handleAgent(w, r, strings.TrimPrefix(r.URL.EscapedPath(), "/v1/agents/"))
+	default:
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("no endpoint %s %s", r.Method, path))
+	}
+}
+
+// handleEvents records a batch of events
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	var batch struct {
+		AgentID string          `json:"agent_id"`
+		Events  []trusera.Event `json:"events"`
+	}
+	if !readJSON(w, r, &batch) {
+		return
+	}
+
+	accepted := 0
+	for _, e := range batch.Events {
+		if e.IdempotencyKey != "" {
+			if s.seenKeys[e.IdempotencyKey] {
+				continue
+			}
+			s.seenKeys[e.IdempotencyKey] = true
+		}
+		s.events = append(s.events, e)
+		accepted++
+	}
+	if agent := s.findAgentLocked(batch.AgentID); agent != nil {
+		agent.LastSeenAt = time.Now().UTC()
+	}
+	writeJSON(w, http.StatusAccepted, map[string]int{"accepted": accepted})
+}
+
+// handleDecisions records a batch of decision reports
+func (s *Server) handleDecisions(w http.ResponseWriter, r *http.Request) {
+	var batch struct {
+		Decisions []trusera.DecisionReport `json:"decisions"`
+	}
+	if !readJSON(w, r, &batch) {
+		return
+	}
+	s.decisions = append(s.decisions, batch.Decisions...)
+	writeJSON(w, http.StatusAccepted, map[string]int{"accepted": len(batch.Decisions)})
+}
+
+// handleBOM records a BOM upload
+func (s *Server) handleBOM(w http.ResponseWriter, r *http.Request) {
+	var upload BOMUpload
+	if !readJSON(w, r, &upload) {
+		return
+	}
+	if len(upload.BOM) == 0 || string(upload.BOM) == "null" {
+		writeError(w, http.StatusBadRequest, "invalid_bom", "bom is required")
+		return
+	}
+	s.boms = append(s.boms, upload)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// handleRegister registers an agent
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var agent trusera.Agent
+	if !readJSON(w, r, &agent) {
+		return
+	}
+	if agent.Name == "" {
+		writeError(w, http.StatusBadRequest, "invalid_agent", "name is required")
+		return
+	}
+	agent.ID = ""
+	writeJSON(w, http.StatusCreated, s.addAgentLocked(agent))
+}
+
+// handleListAgents returns a page of agents filtered by name and framework.
+// Cursors are offsets into the filtered list.
+func (s *Server) handleListAgents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var matched []trusera.Agent
+	for _, a := range s.agents {
+		if name := query.Get("name"); name != "" && a.Name != name {
+			continue
+		}
+		if framework := query.Get("framework"); framework != "" && a.Framework != framework {
+			continue
+		}
+		matched = append(matched, *a)
+	}
+
+	limit := defaultPageSize
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_limit", "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+	start := 0
+	if v := query.Get("cursor"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > len(matched) {
+			writeError(w, http.StatusBadRequest, "invalid_cursor", "unknown cursor")
+			return
+		}
+		start = n
+	}
+
+	end := min(start+limit, len(matched))
+	list := trusera.AgentList{Agents: append([]trusera.Agent{}, matched[start:end]...)}
+	if end < len(matched) {
+		list.NextCursor = strconv.Itoa(end)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// handleAgent gets, updates or deletes one agent
+func (s *Server) handleAgent(w http.ResponseWriter, r *http.Request, escapedID string) {
+	id, err := url.PathUnescape(escapedID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_agent_id", err.Error())
+		return
+	}
+	agent := s.findAgentLocked(id)
+	if agent == nil {
+		writeError(w, http.StatusNotFound, "agent_not_found", fmt.Sprintf("agent %s not found", id))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, agent)
+	case http.MethodPatch:
+		var update trusera.AgentUpdate
+		if !readJSON(w, r, &update) {
+			return
+		}
+		if update.Name != nil {
+			agent.Name = *update.Name
+		}
+		if update.Framework != nil {
+			agent.Framework = *update.Framework
+		}
+		if update.Labels != nil {
+			agent.Labels = update.Labels
+		}
+		if update.Status != "" {
+			agent.Status = update.Status
+		}
+		writeJSON(w, http.StatusOK, agent)
+	case http.MethodDelete:
+		for i, a := range s.agents {
+			if a == agent {
+				s.agents = append(s.agents[:i], s.agents[i+1:]...)
+				break
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", r.Method+" is not supported")
+	}
+}
+
+// addAgentLocked stores agent, filling in the fields the API sets
+func (s *Server) addAgentLocked(agent trusera.Agent) *trusera.Agent {
+	if agent.ID == "" {
+		s.nextAgent++
+		agent.ID = "agent-" + strconv.Itoa(s.nextAgent)
+	}
+	if agent.Status == "" {
+		agent.Status = trusera.AgentActive
+	}
+	now := time.Now().UTC()
+	if agent.CreatedAt.IsZero() {
+		agent.CreatedAt = now
+	}
+	if agent.LastSeenAt.IsZero() {
+		agent.LastSeenAt = now
+	}
+	s.agents = append(s.agents, &agent)
+	return &agent
+}
+
+// findAgentLocked returns the agent with id, or nil
+func (s *Server) findAgentLocked(id string) *trusera.Agent {
+	for _, a := range s.agents {
+		if a.ID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+// readJSON decodes the request body into v, answering 400 if it cannot
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", err.Error())
+		return false
+	}
+	return true
+}
+
+// writeJSON answers with status and v as JSON
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers with status and an error body the SDK reads as an APIError
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]string{"code": code, "message": message})
+}
@@ -0,0 +1,173 @@
+package truseratest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	trusera "github.com/Trusera/ai-bom/trusera-sdk-go"
+	"github.com/Trusera/ai-bom/trusera-sdk-go/aibom"
+)
+
+func TestServerEvents(t *testing.T) {
+	api := NewServer(t)
+	client := api.Client(trusera.WithAgentID("agent-1"))
+
+	client.Track(trusera.NewEvent(trusera.EventToolCall, "search").WithIdempotencyKey("key-1"))
+	client.Track(trusera.NewEvent(trusera.EventLLMInvoke, "gpt-4o"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	// A redelivered event is discarded
+	client.Track(trusera.NewEvent(trusera.EventToolCall, "search").WithIdempotencyKey("key-1"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	events := api.ReceivedEvents()
+	if len(events) != 2 || events[0].Name != "search" || events[1].Type != trusera.EventLLMInvoke {
+		t.Errorf("unexpected events %+v", events)
+	}
+}
+
+func TestServerWaitForEvents(t *testing.T) {
+	api := NewServer(t)
+	client := api.Client(trusera.WithBatchSize(1))
+
+	client.Track(trusera.NewEvent(trusera.EventAPICall, "fetch"))
+	if events := api.WaitForEvents(1); events[0].Name != "fetch" {
+		t.Errorf("unexpected events %+v", events)
+	}
+}
+
+func TestServerAPIKey(t *testing.T) {
+	api := NewServer(t, WithAPIKey("secret"))
+
+	wrong := trusera.NewClient("other", trusera.WithBaseURL(api.URL), trusera.WithFlushRetry(0, 0))
+	defer wrong.Close()
+	wrong.Track(trusera.NewEvent(trusera.EventToolCall, "search"))
+	var apiErr *trusera.APIError
+	if err := wrong.Flush(); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected a 401, got %v", err)
+	}
+
+	client := api.Client()
+	client.Track(trusera.NewEvent(trusera.EventToolCall, "search"))
+	if err := client.Flush(); err != nil {
+		t.Errorf("expected the server's key to be accepted: %v", err)
+	}
+}
+
+func TestServerFailNext(t *testing.T) {
+	api := NewServer(t)
+	client := api.Client(trusera.WithFlushRetry(1, time.Millisecond))
+
+	api.FailNext(1, http.StatusServiceUnavailable)
+	client.Track(trusera.NewEvent(trusera.EventToolCall, "search"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("expected the retry to succeed: %v", err)
+	}
+	if n := len(api.ReceivedEvents()); n != 1 {
+		t.Errorf("expected 1 event after the retry, got %d", n)
+	}
+}
+
+func TestServerAgents(t *testing.T) {
+	api := NewServer(t)
+	client := api.Client()
+	ctx := context.Background()
+
+	id, err := client.RegisterAgent("support-bot", "langchain")
+	if err != nil || id != "agent-1" {
+		t.Fatalf("register: %q, %v", id, err)
+	}
+	api.AddAgent(trusera.Agent{Name: "triage", Framework: "crewai"})
+	api.AddAgent(trusera.Agent{ID: "custom", Name: "billing", Framework: "langchain"})
+
+	list, err := client.ListAgents(ctx, trusera.ListAgentsOptions{Framework: "langchain", Limit: 1})
+	if err != nil || len(list.Agents) != 1 || list.Agents[0].ID != "agent-1" || list.NextCursor == "" {
+		t.Fatalf("unexpected first page %+v, %v", list, err)
+	}
+	list, err = client.ListAgents(ctx, trusera.ListAgentsOptions{Framework: "langchain", Limit: 1, Cursor: list.NextCursor})
+	if err != nil || len(list.Agents) != 1 || list.Agents[0].ID != "custom" || list.NextCursor != "" {
+		t.Fatalf("unexpected last page %+v, %v", list, err)
+	}
+
+	name := "support-agent"
+	agent, err := client.UpdateAgent(ctx, id, trusera.AgentUpdate{Name: &name, Status: trusera.AgentRetired})
+	if err != nil || agent.Name != name || agent.Status != trusera.AgentRetired || agent.Framework != "langchain" {
+		t.Errorf("unexpected update %+v, %v", agent, err)
+	}
+	if err := client.DeleteAgent(ctx, "custom"); err != nil {
+		t.Errorf("delete: %v", err)
+	}
+	if _, err := client.GetAgent(ctx, "custom"); !errors.Is(err, trusera.ErrAgentNotFound) {
+		t.Errorf("expected ErrAgentNotFound after delete, got %v", err)
+	}
+	if agents := api.Agents(); len(agents) != 2 {
+		t.Errorf("expected 2 agents left, got %+v", agents)
+	}
+}
+
+func TestServerBOMs(t *testing.T) {
+	api := NewServer(t)
+	client := api.Client(trusera.WithAgentID("agent-1"))
+
+	b := aibom.NewBuilder("agent-1")
+	b.Add(trusera.NewEvent(trusera.EventToolCall, "search"))
+	if err := client.UploadBOM(trusera.WithRunID(context.Background(), "run-7"), b.BOM()); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	if bom := api.LastBOM(); bom.AgentID != "agent-1" || len(bom.Tools) != 1 || bom.Tools[0].Name != "search" {
+		t.Errorf("unexpected BOM %+v", bom)
+	}
+	if uploads := api.BOMs(); len(uploads) != 1 || uploads[0].RunID != "run-7" {
+		t.Errorf("unexpected uploads %+v", uploads)
+	}
+}
+
+func TestServerDecisions(t *testing.T) {
+	api := NewServer(t)
+	client := api.Client()
+
+	reports := []trusera.DecisionReport{{Method: "DELETE", Hostname: "api.example.com", Decision: "deny", EnforcementAction: "blocked"}}
+	if err := client.ReportDecisions(context.Background(), reports); err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	if got := api.ReceivedDecisions(); len(got) != 1 || got[0].EnforcementAction != "blocked" {
+		t.Errorf("unexpected decisions %+v", got)
+	}
+}
+
+func TestServerPolicies(t *testing.T) {
+	api := NewServer(t)
+	api.SetPolicy("deploys", `forbid ( principal, action == Action::"deploy", resource );`)
+	api.SetPolicy("deletes", `forbid ( principal, action == Action::"delete", resource );`)
+	api.SetPolicy("deploys", "")
+
+	resp, err := http.Get(api.URL + "/v1/policies/cedar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Policies []struct {
+			Name    string `json:"name"`
+			Cedar   string `json:"cedar_dsl"`
+			Enabled bool   `json:"enabled"`
+		} `json:"policies"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Policies) != 1 || body.Policies[0].Name != "deletes" || !body.Policies[0].Enabled {
+		t.Fatalf("unexpected policies %+v", body.Policies)
+	}
+	if _, err := trusera.ParseCedarPolicy(body.Policies[0].Cedar); err != nil {
+		t.Errorf("served policy does not parse: %v", err)
+	}
+}
@@ -0,0 +1,274 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config describes an S3-compatible bucket for segment uploads
+type S3Config struct {
+	Bucket string
+	Region string
+	// Prefix is prepended to segment names, e.g. "agents/research/"
+	Prefix string
+
+	// Credentials default to the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+	// AWS_SESSION_TOKEN environment variables
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Endpoint overrides the AWS endpoint for S3-compatible stores such as
+	// MinIO or GCS interoperability ("https://storage.googleapis.com").
+	// Objects are then addressed path-style.
+	Endpoint string
+
+	// HTTPClient defaults to a client with a 60s timeout
+	HTTPClient *http.Client
+}
+
+// s3Uploader puts segments into S3 with SigV4-signed requests
+type s3Uploader struct {
+	cfg S3Config
+	now func() time.Time
+}
+
+// NewS3Uploader returns a SegmentUploader that writes segments to an S3
+// bucket, or to any store speaking the S3 API
+func NewS3Uploader(cfg S3Config) (SegmentUploader, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("S3 bucket is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_REGION")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.AccessKeyID == "" {
+		cfg.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		cfg.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		cfg.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("S3 credentials are required")
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 60 * time.Second}
+	}
+	return &s3Uploader{cfg: cfg, now: time.Now}, nil
+}
+
+func (u *s3Uploader) Upload(ctx context.Context, name string, data []byte) error {
+	key := u.cfg.Prefix + name
+
+	var target string
+	if u.cfg.Endpoint != "" {
+		target = strings.TrimSuffix(u.cfg.Endpoint, "/") + "/" + u.cfg.Bucket + "/" + key
+	} else {
+		target = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", u.cfg.Bucket, u.cfg.Region, key)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	payloadHash := hexSHA256(data)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if u.cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", u.cfg.SessionToken)
+	}
+	signV4(req, payloadHash, u.cfg.AccessKeyID, u.cfg.SecretAccessKey, u.cfg.Region, "s3", u.now())
+
+	return doUpload(u.cfg.HTTPClient, req)
+}
+
+// GCSConfig describes a Google Cloud Storage bucket for segment uploads
+type GCSConfig struct {
+	Bucket string
+	// Prefix is prepended to segment names, e.g. "agents/research/"
+	Prefix string
+
+	// Token returns an OAuth2 access token with storage write scope, e.g. from
+	// the metadata server or golang.org/x/oauth2
+	Token func(ctx context.Context) (string, error)
+
+	// Endpoint defaults to "https://storage.googleapis.com"
+	Endpoint string
+
+	// HTTPClient defaults to a client with a 60s timeout
+	HTTPClient *http.Client
+}
+
+// gcsUploader writes segments with the GCS JSON API media upload
+type gcsUploader struct {
+	cfg GCSConfig
+}
+
+// NewGCSUploader returns a SegmentUploader that writes segments to a GCS
+// bucket. HMAC keys can be used instead through NewS3Uploader with the GCS
+// interoperability endpoint.
+func NewGCSUploader(cfg GCSConfig) (SegmentUploader, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("GCS bucket is required")
+	}
+	if cfg.Token == nil {
+		return nil, errors.New("GCS token source is required")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://storage.googleapis.com"
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 60 * time.Second}
+	}
+	return &gcsUploader{cfg: cfg}, nil
+}
+
+func (u *gcsUploader) Upload(ctx context.Context, name string, data []byte) error {
+	token, err := u.cfg.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get GCS token: %w", err)
+	}
+
+	target := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+		strings.TrimSuffix(u.cfg.Endpoint, "/"), url.PathEscape(u.cfg.Bucket), url.QueryEscape(u.cfg.Prefix+name))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return doUpload(u.cfg.HTTPClient, req)
+}
+
+// doUpload sends an upload request and checks for success
+func doUpload(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("upload returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// signV4 adds an AWS Signature Version 4 Authorization header to req, signing
+// the host, X-Amz-* and Content-Type headers
+func signV4(req *http.Request, payloadHash, accessKey, secretKey, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, vv := range req.Header {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, "x-amz-") || lk == "content-type" {
+			headers[lk] = strings.TrimSpace(strings.Join(vv, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsCanonicalPath(req.URL.EscapedPath()),
+		awsCanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+// awsCanonicalPath re-encodes an escaped path the way SigV4 expects for S3
+func awsCanonicalPath(escapedPath string) string {
+	p, err := url.PathUnescape(escapedPath)
+	if err != nil {
+		p = escapedPath
+	}
+	if p == "" {
+		return "/"
+	}
+	return awsURIEscape(p, true)
+}
+
+// awsCanonicalQuery sorts and encodes query parameters for SigV4
+func awsCanonicalQuery(q url.Values) string {
+	var pairs []string
+	for k, vv := range q {
+		for _, v := range vv {
+			pairs = append(pairs, awsURIEscape(k, false)+"="+awsURIEscape(v, false))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// awsURIEscape percent-encodes everything except unreserved characters and,
+// when keepSlash is set, slashes
+func awsURIEscape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c == '/' && keepSlash) || c == '-' || c == '_' || c == '.' || c == '~' ||
+			('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
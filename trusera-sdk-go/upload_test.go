@@ -0,0 +1,133 @@
+package trusera
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSignV4KnownVector(t *testing.T) {
+	// get-vanilla from the AWS Signature Version 4 test suite
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	signV4(req, hexSHA256(nil), "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "service", now)
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("unexpected authorization\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestS3UploaderPutsSignedObject(t *testing.T) {
+	var mu sync.Mutex
+	var method, path, auth, sha, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		method, path, body = r.Method, r.URL.Path, string(data)
+		auth = r.Header.Get("Authorization")
+		sha = r.Header.Get("X-Amz-Content-Sha256")
+	}))
+	defer server.Close()
+
+	uploader, err := NewS3Uploader(S3Config{
+		Bucket:          "agent-logs",
+		Region:          "eu-west-1",
+		Prefix:          "research/",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		Endpoint:        server.URL,
+	})
+	if err != nil {
+		t.Fatalf("failed to create uploader: %v", err)
+	}
+
+	if err := uploader.Upload(context.Background(), "events-1.jsonl", []byte("{}\n")); err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if method != http.MethodPut || path != "/agent-logs/research/events-1.jsonl" {
+		t.Errorf("unexpected request %s %s", method, path)
+	}
+	if body != "{}\n" {
+		t.Errorf("unexpected body %q", body)
+	}
+	if sha != hexSHA256([]byte("{}\n")) {
+		t.Errorf("unexpected payload hash %s", sha)
+	}
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(auth, "/eu-west-1/s3/aws4_request") {
+		t.Errorf("unexpected authorization %s", auth)
+	}
+}
+
+func TestS3UploaderErrors(t *testing.T) {
+	if _, err := NewS3Uploader(S3Config{}); err == nil {
+		t.Error("expected error without bucket")
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	if _, err := NewS3Uploader(S3Config{Bucket: "b"}); err == nil {
+		t.Error("expected error without credentials")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	uploader, _ := NewS3Uploader(S3Config{Bucket: "b", AccessKeyID: "a", SecretAccessKey: "s", Endpoint: server.URL})
+	err := uploader.Upload(context.Background(), "x.jsonl", nil)
+	if err == nil || !strings.Contains(err.Error(), "403") || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("expected status error, got %v", err)
+	}
+}
+
+func TestGCSUploaderPostsMedia(t *testing.T) {
+	var mu sync.Mutex
+	var path, name, uploadType, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		path = r.URL.Path
+		name = r.URL.Query().Get("name")
+		uploadType = r.URL.Query().Get("uploadType")
+		auth = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	uploader, err := NewGCSUploader(GCSConfig{
+		Bucket:   "agent-logs",
+		Prefix:   "research/",
+		Endpoint: server.URL,
+		Token:    func(context.Context) (string, error) { return "ya29.token", nil },
+	})
+	if err != nil {
+		t.Fatalf("failed to create uploader: %v", err)
+	}
+
+	if err := uploader.Upload(context.Background(), "events-1.jsonl", []byte("{}\n")); err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if path != "/upload/storage/v1/b/agent-logs/o" || name != "research/events-1.jsonl" || uploadType != "media" {
+		t.Errorf("unexpected request %s name=%s uploadType=%s", path, name, uploadType)
+	}
+	if auth != "Bearer ya29.token" {
+		t.Errorf("unexpected authorization %s", auth)
+	}
+}
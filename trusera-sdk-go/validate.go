@@ -0,0 +1,107 @@
+package trusera
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidEvent is wrapped by errors returned from NewEventValidator
+var ErrInvalidEvent = errors.New("invalid event")
+
+// EventValidator checks an event before it is queued
+type EventValidator func(Event) error
+
+// EventLimits configures NewEventValidator. Zero values disable a check.
+type EventLimits struct {
+	// MaxPayloadBytes bounds the JSON size of Payload and Metadata combined
+	MaxPayloadBytes int
+	// MaxClockSkew bounds how far in the future a timestamp may be
+	MaxClockSkew time.Duration
+	// MaxAge bounds how far in the past a timestamp may be
+	MaxAge time.Duration
+}
+
+// NewEventValidator returns a validator requiring an ID, type, name and an
+// RFC 3339 timestamp, and enforcing limits. Errors wrap ErrInvalidEvent.
+func NewEventValidator(limits EventLimits) EventValidator {
+	return func(e Event) error {
+		switch {
+		case e.ID == "":
+			return invalidEvent(e, "id is required")
+		case e.Type == "":
+			return invalidEvent(e, "type is required")
+		case e.Name == "":
+			return invalidEvent(e, "name is required")
+		case e.DurationMs < 0:
+			return invalidEvent(e, "duration is negative")
+		case e.ParentID != "" && e.ParentID == e.ID:
+			return invalidEvent(e, "event is its own parent")
+		}
+
+		ts, err := time.Parse(time.RFC3339, e.Timestamp)
+		if err != nil {
+			return invalidEvent(e, fmt.Sprintf("timestamp %q is not RFC 3339", e.Timestamp))
+		}
+		now := time.Now()
+		if limits.MaxClockSkew > 0 && ts.After(now.Add(limits.MaxClockSkew)) {
+			return invalidEvent(e, fmt.Sprintf("timestamp %s is in the future", e.Timestamp))
+		}
+		if limits.MaxAge > 0 && ts.Before(now.Add(-limits.MaxAge)) {
+			return invalidEvent(e, fmt.Sprintf("timestamp %s is older than %s", e.Timestamp, limits.MaxAge))
+		}
+
+		if limits.MaxPayloadBytes > 0 {
+			payload, err := json.Marshal(e.Payload)
+			if err != nil {
+				return invalidEvent(e, fmt.Sprintf("payload is not JSON: %v", err))
+			}
+			metadata, err := json.Marshal(e.Metadata)
+			if err != nil {
+				return invalidEvent(e, fmt.Sprintf("metadata is not JSON: %v", err))
+			}
+			if size := len(payload) + len(metadata); size > limits.MaxPayloadBytes {
+				return invalidEvent(e, fmt.Sprintf("payload is %d bytes, limit is %d", size, limits.MaxPayloadBytes))
+			}
+		}
+		return nil
+	}
+}
+
+func invalidEvent(e Event, reason string) error {
+	if e.ID == "" {
+		return fmt.Errorf("%w: %s", ErrInvalidEvent, reason)
+	}
+	return fmt.Errorf("%w %s: %s", ErrInvalidEvent, e.ID, reason)
+}
+
+// WithEventValidator checks events in Track and TrackBatch before they are
+// queued, so malformed events are caught where they are created rather than
+// failing the whole batch at flush time. Rejected events are not queued;
+// onReject, if not nil, is called with each one and the validation error.
+func WithEventValidator(v EventValidator, onReject func(Event, error)) Option {
+	return func(c *Client) {
+		c.validator = v
+		c.onReject = onReject
+	}
+}
+
+// validate filters out events rejected by the validator
+func (c *Client) validate(events []Event) []Event {
+	if c.validator == nil {
+		return events
+	}
+
+	valid := events[:0:0]
+	for _, e := range events {
+		if err := c.validator(e); err != nil {
+			if c.onReject != nil {
+				c.onReject(e, err)
+			}
+			continue
+		}
+		valid = append(valid, e)
+	}
+	return valid
+}
@@ -0,0 +1,104 @@
+package trusera
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewEventValidator(t *testing.T) {
+	validate := NewEventValidator(EventLimits{
+		MaxPayloadBytes: 64,
+		MaxClockSkew:    time.Minute,
+		MaxAge:          time.Hour,
+	})
+
+	tests := []struct {
+		name   string
+		event  func() Event
+		reason string
+	}{
+		{"valid", func() Event { return NewEvent(EventToolCall, "search") }, ""},
+		{"missing name", func() Event { return NewEvent(EventToolCall, "") }, "name is required"},
+		{"missing type", func() Event { return NewEvent("", "search") }, "type is required"},
+		{"missing id", func() Event {
+			e := NewEvent(EventToolCall, "search")
+			e.ID = ""
+			return e
+		}, "id is required"},
+		{"negative duration", func() Event {
+			e := NewEvent(EventToolCall, "search")
+			e.DurationMs = -1
+			return e
+		}, "duration is negative"},
+		{"own parent", func() Event {
+			e := NewEvent(EventToolCall, "search")
+			return e.WithParent(e.ID)
+		}, "its own parent"},
+		{"bad timestamp", func() Event {
+			e := NewEvent(EventToolCall, "search")
+			e.Timestamp = "yesterday"
+			return e
+		}, "not RFC 3339"},
+		{"future timestamp", func() Event {
+			e := NewEvent(EventToolCall, "search")
+			e.Timestamp = time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+			return e
+		}, "in the future"},
+		{"stale timestamp", func() Event {
+			e := NewEvent(EventToolCall, "search")
+			e.Timestamp = time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+			return e
+		}, "older than"},
+		{"large payload", func() Event {
+			return NewEvent(EventToolCall, "search").WithPayload("query", strings.Repeat("x", 100))
+		}, "limit is 64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate(tt.event())
+			if tt.reason == "" {
+				if err != nil {
+					t.Errorf("expected valid event, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidEvent) || !strings.Contains(err.Error(), tt.reason) {
+				t.Errorf("expected error containing %q, got %v", tt.reason, err)
+			}
+		})
+	}
+}
+
+func TestTrackRejectsInvalidEvents(t *testing.T) {
+	var mu sync.Mutex
+	var rejected []string
+
+	client := NewClient("test-key",
+		WithFlushRetry(0, 0),
+		WithEventValidator(NewEventValidator(EventLimits{}), func(e Event, err error) {
+			mu.Lock()
+			rejected = append(rejected, err.Error())
+			mu.Unlock()
+		}),
+	)
+	defer client.Close()
+
+	client.Track(NewEvent(EventToolCall, ""))
+	client.TrackBatch([]Event{NewEvent(EventToolCall, "search"), NewEvent("", "fetch")})
+
+	client.mu.Lock()
+	if len(client.events) != 1 || client.events[0].Name != "search" {
+		t.Errorf("expected only the valid event queued, got %+v", client.events)
+	}
+	client.mu.Unlock()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(rejected) != 2 {
+		t.Errorf("expected 2 rejections, got %v", rejected)
+	}
+}
@@ -0,0 +1,260 @@
+package trusera
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers set on decision webhook deliveries
+const (
+	HeaderWebhookDelivery  = "X-Trusera-Delivery"
+	HeaderWebhookTimestamp = "X-Trusera-Timestamp"
+	HeaderWebhookSignature = "X-Trusera-Signature"
+)
+
+const (
+	defaultWebhookRetries = 3
+	defaultWebhookBackoff = 500 * time.Millisecond
+)
+
+// WebhookFilter selects the decisions delivered to a webhook
+type WebhookFilter func(DecisionEvent) bool
+
+// WebhookOnActions matches decisions with any of the given enforcement
+// actions, e.g. WebhookOnActions("blocked")
+func WebhookOnActions(actions ...string) WebhookFilter {
+	return func(ev DecisionEvent) bool {
+		for _, a := range actions {
+			if ev.EnforcementAction == a {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// WebhookOption configures a decision webhook
+type WebhookOption func(*decisionWebhook)
+
+// WithWebhookSecret signs deliveries with HMAC-SHA256. The signature covers
+// the timestamp header, a dot and the body, and is sent as "sha256=<hex>" in
+// the X-Trusera-Signature header. Receivers verify it with VerifyWebhookSignature.
+func WithWebhookSecret(secret []byte) WebhookOption {
+	return func(w *decisionWebhook) {
+		w.secret = secret
+	}
+}
+
+// WithWebhookRetry sets how many times failed deliveries are retried
+// (default 3) and the initial backoff, which doubles per attempt (default 500ms)
+func WithWebhookRetry(max int, backoff time.Duration) WebhookOption {
+	return func(w *decisionWebhook) {
+		w.retry = retryPolicy{max: max, backoff: backoff}
+	}
+}
+
+// WithWebhookClient sets the client used for deliveries (default 10s timeout)
+func WithWebhookClient(client *http.Client) WebhookOption {
+	return func(w *decisionWebhook) {
+		w.client = client
+	}
+}
+
+// WithWebhookHeader adds a header to every delivery
+func WithWebhookHeader(key, value string) WebhookOption {
+	return func(w *decisionWebhook) {
+		w.header.Add(key, value)
+	}
+}
+
+// WithDecisionWebhook POSTs a JSON payload to url for every decision matched
+// by filter, so violations reach security tooling without polling logs. A nil
+// filter matches every denied request. Deliveries run asynchronously like
+// decision hooks and are retried on connection errors and 5xx responses.
+// May be given several times.
+func WithDecisionWebhook(url string, filter WebhookFilter, opts ...WebhookOption) StandaloneOption {
+	return func(si *StandaloneInterceptor) {
+		w := &decisionWebhook{
+			url:         url,
+			filter:      filter,
+			client:      &http.Client{Timeout: 10 * time.Second},
+			header:      make(http.Header),
+			retry:       retryPolicy{max: defaultWebhookRetries, backoff: defaultWebhookBackoff},
+			interceptor: si,
+		}
+		for _, opt := range opts {
+			opt(w)
+		}
+
+		si.hooks.onBlock = append(si.hooks.onBlock, w.deliver)
+		si.hooks.onWarn = append(si.hooks.onWarn, w.deliver)
+		si.hooks.onAllow = append(si.hooks.onAllow, w.deliver)
+	}
+}
+
+// decisionWebhook delivers matching decisions to an HTTP endpoint
+type decisionWebhook struct {
+	url    string
+	filter WebhookFilter
+	secret []byte
+	client *http.Client
+	header http.Header
+	retry  retryPolicy
+
+	// interceptor provides the logger for failed deliveries
+	interceptor *StandaloneInterceptor
+}
+
+// webhookPayload is the JSON body of a delivery
+type webhookPayload struct {
+	Event             string   `json:"event"`
+	Timestamp         string   `json:"timestamp"`
+	Method            string   `json:"method"`
+	URL               string   `json:"url"`
+	Hostname          string   `json:"hostname"`
+	Path              string   `json:"path"`
+	Status            int      `json:"status,omitempty"`
+	DurationMs        int64    `json:"duration_ms"`
+	Decision          string   `json:"decision"`
+	EnforcementAction string   `json:"enforcement_action"`
+	Reasons           []string `json:"reasons,omitempty"`
+	Matched           []string `json:"matched,omitempty"`
+	TraceID           string   `json:"trace_id,omitempty"`
+	SpanID            string   `json:"span_id,omitempty"`
+	RunID             string   `json:"run_id,omitempty"`
+	SessionID         string   `json:"session_id,omitempty"`
+}
+
+// deliver sends ev when it matches the filter
+func (w *decisionWebhook) deliver(ev DecisionEvent) {
+	if w.filter == nil {
+		if ev.Decision != "Deny" {
+			return
+		}
+	} else if !w.filter(ev) {
+		return
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		Event:             "decision",
+		Timestamp:         ev.Timestamp.UTC().Format(time.RFC3339),
+		Method:            ev.Method,
+		URL:               ev.URL,
+		Hostname:          ev.Hostname,
+		Path:              ev.Path,
+		Status:            ev.Status,
+		DurationMs:        ev.Duration.Milliseconds(),
+		Decision:          ev.Decision,
+		EnforcementAction: ev.EnforcementAction,
+		Reasons:           ev.Reasons,
+		Matched:           compactMatched(ev.Matched),
+		TraceID:           ev.TraceID,
+		SpanID:            ev.SpanID,
+		RunID:             ev.RunID,
+		SessionID:         ev.SessionID,
+	})
+	if err != nil {
+		return
+	}
+
+	if err := w.send(context.Background(), body); err != nil {
+		w.interceptor.logger.Warn("decision webhook delivery failed", "url", w.url, "error", err)
+	}
+}
+
+// send posts body, retrying transient failures
+func (w *decisionWebhook) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, vv := range w.header {
+		req.Header[k] = vv
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookDelivery, generateID())
+
+	// Retries keep the delivery ID but are signed afresh
+	sign := func(r *http.Request) {
+		if len(w.secret) == 0 {
+			return
+		}
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		r.Header.Set(HeaderWebhookTimestamp, ts)
+		r.Header.Set(HeaderWebhookSignature, "sha256="+webhookSignature(w.secret, ts, body))
+	}
+
+	sign(req)
+	resp, err := w.client.Do(req)
+	attempts := 1
+	for w.retry.retryable(req, resp, err, attempts) {
+		discardResponse(resp)
+		if !sleepContext(ctx, w.retry.delay(attempts)) {
+			break
+		}
+
+		retryReq, rewindErr := rewindRequest(req)
+		if rewindErr != nil {
+			break
+		}
+		sign(retryReq)
+		attempts++
+		resp, err = w.client.Do(retryReq)
+	}
+
+	if err != nil {
+		return err
+	}
+	discardResponse(resp)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d after %d attempts", resp.StatusCode, attempts)
+	}
+	return nil
+}
+
+// webhookSignature computes the hex HMAC-SHA256 of "<timestamp>.<body>"
+func webhookSignature(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature checks the signature headers of a decision webhook
+// delivery against body. Deliveries timestamped more than tolerance from now
+// are rejected to prevent replays; a zero tolerance skips that check.
+func VerifyWebhookSignature(secret, body []byte, header http.Header, tolerance time.Duration) error {
+	ts := header.Get(HeaderWebhookTimestamp)
+	sig, ok := strings.CutPrefix(header.Get(HeaderWebhookSignature), "sha256=")
+	if ts == "" || !ok {
+		return errors.New("missing webhook signature")
+	}
+
+	if tolerance > 0 {
+		unix, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return errors.New("invalid webhook timestamp")
+		}
+		if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+			return errors.New("webhook timestamp outside tolerance")
+		}
+	}
+
+	expected, _ := hex.DecodeString(webhookSignature(secret, ts, body))
+	got, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(expected, got) {
+		return errors.New("webhook signature mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package trusera
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver records deliveries, failing the first failures requests
+type webhookReceiver struct {
+	mu         sync.Mutex
+	failures   int
+	attempts   int
+	deliveries []webhookPayload
+	headers    []http.Header
+	bodies     [][]byte
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.attempts++
+	if r.attempts <= r.failures {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var p webhookPayload
+	json.Unmarshal(body, &p)
+	r.deliveries = append(r.deliveries, p)
+	r.headers = append(r.headers, req.Header.Clone())
+	r.bodies = append(r.bodies, body)
+}
+
+func TestDecisionWebhookDeliversSignedBlocks(t *testing.T) {
+	receiver := &webhookReceiver{failures: 1}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	policyPath := writeTestPolicy(t, t.TempDir(), `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "blocked.example.com";
+};
+`)
+
+	secret := []byte("s3cret")
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementBlock),
+		WithDecisionWebhook(server.URL, WebhookOnActions("blocked"),
+			WithWebhookSecret(secret),
+			WithWebhookRetry(2, time.Millisecond),
+			WithWebhookHeader("X-Team", "security"),
+		),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	resp, err := client.Get("https://api.example.com/ok")
+	if err != nil {
+		t.Fatalf("allowed request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if _, err := client.Get("https://blocked.example.com/exfil"); err == nil {
+		t.Fatal("expected blocked request")
+	}
+
+	if err := si.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+
+	if receiver.attempts != 2 {
+		t.Errorf("expected 1 retry after the 503, got %d attempts", receiver.attempts)
+	}
+	if len(receiver.deliveries) != 1 {
+		t.Fatalf("expected 1 delivery, got %d", len(receiver.deliveries))
+	}
+
+	p := receiver.deliveries[0]
+	if p.Event != "decision" || p.Hostname != "blocked.example.com" || p.EnforcementAction != "blocked" || p.Decision != "Deny" {
+		t.Errorf("unexpected payload %+v", p)
+	}
+
+	h := receiver.headers[0]
+	if h.Get("X-Team") != "security" || h.Get(HeaderWebhookDelivery) == "" {
+		t.Errorf("unexpected headers %v", h)
+	}
+	if err := VerifyWebhookSignature(secret, receiver.bodies[0], h, time.Minute); err != nil {
+		t.Errorf("signature did not verify: %v", err)
+	}
+	if err := VerifyWebhookSignature([]byte("wrong"), receiver.bodies[0], h, time.Minute); err == nil {
+		t.Error("expected mismatch with the wrong secret")
+	}
+}
+
+func TestDecisionWebhookDefaultFilter(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	policyPath := writeTestPolicy(t, t.TempDir(), `
+forbid ( principal, action == Action::"deploy", resource )
+when {
+    resource.hostname == "denied.example.com";
+};
+`)
+
+	si, err := NewStandaloneInterceptor(
+		WithPolicyFile(policyPath),
+		WithEnforcement(EnforcementWarn),
+		WithDecisionWebhook(server.URL, nil),
+	)
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+
+	client := si.WrapClient(&http.Client{Transport: stubTransport{}})
+	for _, host := range []string{"api.example.com", "denied.example.com"} {
+		resp, err := client.Get("https://" + host + "/")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	si.Close()
+
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+
+	if len(receiver.deliveries) != 1 || receiver.deliveries[0].EnforcementAction != "warned" {
+		t.Errorf("expected only the warned decision, got %+v", receiver.deliveries)
+	}
+	if receiver.headers[0].Get(HeaderWebhookSignature) != "" {
+		t.Error("expected unsigned delivery without a secret")
+	}
+}
+
+func TestVerifyWebhookSignatureTolerance(t *testing.T) {
+	secret := []byte("s3cret")
+	body := []byte(`{"event":"decision"}`)
+
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	header := http.Header{}
+	header.Set(HeaderWebhookTimestamp, old)
+	header.Set(HeaderWebhookSignature, "sha256="+webhookSignature(secret, old, body))
+
+	if err := VerifyWebhookSignature(secret, body, header, 5*time.Minute); err == nil {
+		t.Error("expected stale delivery to be rejected")
+	}
+	if err := VerifyWebhookSignature(secret, body, header, 0); err != nil {
+		t.Errorf("expected signature to verify without tolerance: %v", err)
+	}
+	if err := VerifyWebhookSignature(secret, body, http.Header{}, 0); err == nil {
+		t.Error("expected error for missing signature")
+	}
+}